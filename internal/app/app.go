@@ -1,12 +1,16 @@
 package app
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
+	"os"
 	"tush00nka/bbbab_messenger/internal/config"
 	"tush00nka/bbbab_messenger/internal/handler"
 	"tush00nka/bbbab_messenger/internal/model"
+	"tush00nka/bbbab_messenger/internal/pkg/linkpreview"
+	"tush00nka/bbbab_messenger/internal/pkg/push"
 	"tush00nka/bbbab_messenger/internal/pkg/sms"
 	"tush00nka/bbbab_messenger/internal/pkg/tg"
 	"tush00nka/bbbab_messenger/internal/repository"
@@ -47,6 +51,11 @@ func Run(cfg *config.Config) {
 		log.Fatal(err)
 	}
 
+	// Полнотекстовый поиск по сообщениям
+	if err := repository.MigrateMessageSearch(db, cfg.SearchTextConfig); err != nil {
+		log.Fatal("Failed to migrate message search", err)
+	}
+
 	s3, err := service.NewS3Service(cfg)
 	if err != nil {
 		log.Fatal("Failed to create S3 service", err)
@@ -77,11 +86,9 @@ func Run(cfg *config.Config) {
 	userService := service.NewUserService(userRepo)
 	userHandler := handler.NewUserHandler(userService, s3, smsRepo, sms, tgBot)
 
-	db.Set("gorm:table_options", "CREATE TABLE chat_users (chat_id bigint, user_id bigint, PRIMARY KEY(chat_id, user_id))").AutoMigrate(&model.ChatUser{})
-
 	// Chat
-	chatRepo := repository.NewChatRepository(db)
-	chatService := service.NewChatService(chatRepo)
+	chatRepo := repository.NewChatRepository(db, repository.ChatRepositoryOptions{SearchConfig: cfg.SearchTextConfig})
+	chatService := service.NewChatService(chatRepo, service.ChatServiceOptions{RateLimiter: cacheRepo, Users: userService})
 	chatCacheService := service.NewChatCacheService(cacheRepo, chatRepo)
 
 	// WS Hub
@@ -101,7 +108,84 @@ func Run(cfg *config.Config) {
 	// Создаем логгер
 	logger := &simpleLogger{}
 
-	chatHandler := handler.NewChatHandler(chatService, chatCacheService, s3, hub, wsUpgrader, logger)
+	// Превью ссылок: страницы загружаются с защитой от запросов во внутреннюю сеть
+	linkPreviewService := service.NewLinkPreviewService(
+		linkpreview.NewHTTPFetcher(linkpreview.Options{}), cacheRepo, chatRepo)
+
+	// Push-уведомления участникам, у которых чат не открыт
+	pushProviders, err := newPushProviders(cfg)
+	if err != nil {
+		log.Fatal("Failed to init push providers", err)
+	}
+	notificationService := service.NewNotificationService(
+		repository.NewDeviceTokenRepository(db), chatService,
+		service.NotificationServiceOptions{Providers: pushProviders, Presence: chatCacheService})
+
+	chatHandler := handler.NewChatHandler(chatService, chatCacheService, s3, linkPreviewService, notificationService, hub, wsUpgrader, logger)
+
+	// Диспетчер отложенных сообщений
+	go chatHandler.RunScheduledDispatcher(context.Background())
+
+	// Удаление исчезающих сообщений
+	go chatHandler.RunMessageReaper(context.Background())
+
 	server := NewServer(userHandler, chatHandler)
 	server.Run(cfg.ServerPort)
 }
+
+// newPushProviders создает провайдеры push-уведомлений для платформ, у которых заданы ключи
+func newPushProviders(cfg *config.Config) (map[string]push.Provider, error) {
+	providers := make(map[string]push.Provider)
+
+	if cfg.PushFake {
+		fake := push.NewFakeProvider()
+		for _, platform := range []string{model.PushPlatformFCM, model.PushPlatformAPNs, model.PushPlatformWebPush} {
+			providers[platform] = fake
+		}
+		return providers, nil
+	}
+
+	if cfg.FCMCredentialsFile != "" {
+		credentials, err := os.ReadFile(cfg.FCMCredentialsFile)
+		if err != nil {
+			return nil, err
+		}
+		fcm, err := push.NewFCMProvider(push.FCMOptions{CredentialsJSON: credentials})
+		if err != nil {
+			return nil, err
+		}
+		providers[model.PushPlatformFCM] = fcm
+	}
+
+	if cfg.APNsKeyFile != "" {
+		key, err := os.ReadFile(cfg.APNsKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		apns, err := push.NewAPNsProvider(push.APNsOptions{
+			KeyPEM:  key,
+			KeyID:   cfg.APNsKeyID,
+			TeamID:  cfg.APNsTeamID,
+			Topic:   cfg.APNsTopic,
+			Sandbox: cfg.APNsSandbox,
+		})
+		if err != nil {
+			return nil, err
+		}
+		providers[model.PushPlatformAPNs] = apns
+	}
+
+	if cfg.VAPIDPrivateKey != "" {
+		webPush, err := push.NewWebPushProvider(push.WebPushOptions{
+			VAPIDPublicKey:  cfg.VAPIDPublicKey,
+			VAPIDPrivateKey: cfg.VAPIDPrivateKey,
+			Subject:         cfg.VAPIDSubject,
+		})
+		if err != nil {
+			return nil, err
+		}
+		providers[model.PushPlatformWebPush] = webPush
+	}
+
+	return providers, nil
+}
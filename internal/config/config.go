@@ -28,6 +28,25 @@ type Config struct {
 	S3UseSSL          bool   `mapstructure:"S3_USE_SSL"`
 
 	TGBotAPI string `mapstructure:"TG_BOT_API"`
+
+	// SearchTextConfig конфигурация полнотекстового поиска Postgres: russian, english или simple
+	SearchTextConfig string `mapstructure:"SEARCH_TEXT_CONFIG"`
+
+	// Push-уведомления: платформа включается, если заданы ее ключи
+	// PushFake принимать уведомления всех платформ без отправки, для локальной разработки
+	PushFake bool `mapstructure:"PUSH_FAKE"`
+
+	FCMCredentialsFile string `mapstructure:"FCM_CREDENTIALS_FILE"`
+
+	APNsKeyFile string `mapstructure:"APNS_KEY_FILE"`
+	APNsKeyID   string `mapstructure:"APNS_KEY_ID"`
+	APNsTeamID  string `mapstructure:"APNS_TEAM_ID"`
+	APNsTopic   string `mapstructure:"APNS_TOPIC"`
+	APNsSandbox bool   `mapstructure:"APNS_SANDBOX"`
+
+	VAPIDPublicKey  string `mapstructure:"VAPID_PUBLIC_KEY"`
+	VAPIDPrivateKey string `mapstructure:"VAPID_PRIVATE_KEY"`
+	VAPIDSubject    string `mapstructure:"VAPID_SUBJECT"`
 }
 
 func Load() (*Config, error) {
@@ -80,6 +99,14 @@ func Load() (*Config, error) {
 		return nil, fmt.Errorf("REDIS_PASSWORD is required")
 	}
 
+	switch cfg.SearchTextConfig {
+	case "":
+		cfg.SearchTextConfig = "russian"
+	case "russian", "english", "simple":
+	default:
+		return nil, fmt.Errorf("SEARCH_TEXT_CONFIG must be one of russian, english, simple")
+	}
+
 	// if cfg.TGBotAPI == "" {
 	// 	return nil, fmt.Errorf("TG_BOT_API is required")
 	// }
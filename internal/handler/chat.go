@@ -3,8 +3,8 @@ package handler
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"html"
 	"log"
 	"net/http"
 	"slices"
@@ -37,8 +37,12 @@ const (
 type SendMessageRequest struct {
 	ReceiverID uint   `json:"receiver_id" binding:"required"`
 	ChatID     uint   `json:"chat_id"`
-	Message    string `json:"message" binding:"required,min=1,max=5000"`
-	Type       string `json:"type" binding:"oneof=text image file" default:"text"`
+	Message    string `json:"message" binding:"max=5000"`
+	Type       string `json:"type" binding:"oneof=text image file poll" default:"text"`
+	// Entities разметка текста: смещения и длины в кодовых единицах UTF-16
+	Entities model.MessageEntities `json:"entities,omitempty"`
+	// Poll опрос для сообщений типа poll; текст сообщения по умолчанию — вопрос опроса
+	Poll *model.Poll `json:"poll,omitempty"`
 }
 
 // CreateChatRequest запрос на создание чата
@@ -78,11 +82,42 @@ type GetChatMessagesResponse struct {
 
 // ListChatsResponse ответ со списком чатов
 type ListChatsResponse struct {
-	ID          uint           `json:"id"`
-	Name        string         `json:"name"`
-	LastMessage *model.Message `json:"lastMessage,omitempty"`
-	CreatedAt   time.Time      `json:"createdAt"`
-	UpdatedAt   time.Time      `json:"updatedAt"`
+	ID          uint   `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	AvatarURL   string `json:"avatarUrl,omitempty"`
+	IsGroup     bool   `json:"isGroup,omitempty"`
+	IsChannel   bool   `json:"isChannel,omitempty"`
+	// SubscriberCount количество подписчиков (только для каналов)
+	SubscriberCount int64 `json:"subscriberCount,omitempty"`
+	// MemberCount количество участников
+	MemberCount int64 `json:"memberCount"`
+	// Participants участники личного чата, включая текущего пользователя
+	Participants []model.User   `json:"participants,omitempty"`
+	LastMessage  *model.Message `json:"lastMessage,omitempty"`
+	// LastActivityAt время последнего сообщения или создания чата, по нему сортируется список
+	LastActivityAt time.Time `json:"lastActivityAt"`
+	// UnreadCount число непрочитанных сообщений текущего пользователя
+	UnreadCount int64 `json:"unreadCount"`
+	// UnreadMentions число непрочитанных упоминаний текущего пользователя
+	UnreadMentions int64 `json:"unreadMentions,omitempty"`
+	// PinnedPosition место закрепленного чата вверху списка, начиная с 1
+	PinnedPosition int  `json:"pinnedPosition,omitempty"`
+	Archived       bool `json:"archived,omitempty"`
+	// Notifications действующие настройки уведомлений текущего пользователя
+	Notifications model.ChatNotificationSettings `json:"notifications"`
+	// Draft черновик текущего пользователя в этом чате
+	Draft     *model.ChatDraft `json:"draft,omitempty"`
+	CreatedAt time.Time        `json:"createdAt"`
+	UpdatedAt time.Time        `json:"updatedAt"`
+}
+
+// ChatListPageResponse страница списка чатов
+type ChatListPageResponse struct {
+	Data       []ListChatsResponse `json:"data"`
+	Pagination PaginationInfo      `json:"pagination"`
+	// SyncedAt момент запроса; передается в since при следующей синхронизации
+	SyncedAt time.Time `json:"syncedAt"`
 }
 
 // StatusResponse ответ со статусом
@@ -95,6 +130,8 @@ type ChatHandler struct {
 	chatService      service.ChatService
 	chatCacheService *service.ChatCacheService
 	s3Service        *service.S3Service
+	linkPreviews     *service.LinkPreviewService
+	notifications    *service.NotificationService
 	hub              *ws.Hub
 	wsUpgrader       *websocket.Upgrader
 	logger           Logger
@@ -112,6 +149,8 @@ func NewChatHandler(
 	chatService service.ChatService,
 	chatCacheService *service.ChatCacheService,
 	s3Service *service.S3Service,
+	linkPreviews *service.LinkPreviewService,
+	notifications *service.NotificationService,
 	hub *ws.Hub,
 	wsUpgrader *websocket.Upgrader,
 	logger Logger,
@@ -124,6 +163,8 @@ func NewChatHandler(
 		chatService:      chatService,
 		chatCacheService: chatCacheService,
 		s3Service:        s3Service,
+		linkPreviews:     linkPreviews,
+		notifications:    notifications,
 		hub:              hub,
 		wsUpgrader:       wsUpgrader,
 		logger:           logger,
@@ -139,25 +180,85 @@ func (h *ChatHandler) RegisterRoutes(router *mux.Router) {
 	router.HandleFunc("/chat/message/{id:[0-9]+}", authMiddleware(h.deleteMessage)).Methods("DELETE", "OPTIONS") //new one
 	router.HandleFunc("/chat/create", authMiddleware(h.createChat)).Methods("POST", "OPTIONS")
 	router.HandleFunc("/chat/list", authMiddleware(h.listChats)).Methods("GET", "OPTIONS")
+	router.HandleFunc("/chat/list/page", authMiddleware(h.listChatsPage)).Methods("GET", "OPTIONS")
 	router.HandleFunc("/chat/{id:[0-9]+}/ws", h.wsChat).Methods("GET", "OPTIONS")
 	router.HandleFunc("/chat/{id:[0-9]+}/messages", authMiddleware(h.getMessages)).Methods("GET", "OPTIONS")
+	router.HandleFunc("/chat/{chat_id:[0-9]+}/search", authMiddleware(h.searchMessages)).Methods("GET", "OPTIONS")
+	router.HandleFunc("/search", authMiddleware(h.globalSearch)).Methods("GET", "OPTIONS")
+	router.HandleFunc("/chat/{chat_id:[0-9]+}/messages/delete", authMiddleware(h.deleteMessages)).Methods("POST", "OPTIONS")
+	router.HandleFunc("/chat/{chat_id:[0-9]+}/scheduled", authMiddleware(h.scheduleMessage)).Methods("POST", "OPTIONS")
+	router.HandleFunc("/chat/{chat_id:[0-9]+}/scheduled", authMiddleware(h.listScheduledMessages)).Methods("GET", "OPTIONS")
+	router.HandleFunc("/chat/scheduled/{id:[0-9]+}", authMiddleware(h.updateScheduledMessage)).Methods("PUT", "OPTIONS")
+	router.HandleFunc("/chat/scheduled/{id:[0-9]+}", authMiddleware(h.cancelScheduledMessage)).Methods("DELETE", "OPTIONS")
+	router.HandleFunc("/chat/forward", authMiddleware(h.forwardMessages)).Methods("POST", "OPTIONS")
+	router.HandleFunc("/chat/{chat_id:[0-9]+}/draft", authMiddleware(h.saveDraft)).Methods("PUT", "OPTIONS")
+	router.HandleFunc("/chat/{chat_id:[0-9]+}/draft", authMiddleware(h.getDraft)).Methods("GET", "OPTIONS")
+	router.HandleFunc("/me/chat-organization", authMiddleware(h.getChatOrganization)).Methods("GET", "OPTIONS")
+	router.HandleFunc("/me/pinned-chats", authMiddleware(h.setPinnedChats)).Methods("PUT", "OPTIONS")
+	router.HandleFunc("/chat/{chat_id:[0-9]+}/archive", authMiddleware(h.archiveChat)).Methods("POST", "OPTIONS")
+	router.HandleFunc("/chat/{chat_id:[0-9]+}/archive", authMiddleware(h.unarchiveChat)).Methods("DELETE", "OPTIONS")
+	router.HandleFunc("/me/folders", authMiddleware(h.createChatFolder)).Methods("POST", "OPTIONS")
+	router.HandleFunc("/me/folders/{folder_id:[0-9]+}", authMiddleware(h.updateChatFolder)).Methods("PUT", "OPTIONS")
+	router.HandleFunc("/me/folders/{folder_id:[0-9]+}", authMiddleware(h.deleteChatFolder)).Methods("DELETE", "OPTIONS")
+	router.HandleFunc("/chat/{chat_id:[0-9]+}/notifications", authMiddleware(h.setChatNotifications)).Methods("PUT", "OPTIONS")
+	router.HandleFunc("/me/unread", authMiddleware(h.getUnreadSummary)).Methods("GET", "OPTIONS")
+	router.HandleFunc("/me/devices", authMiddleware(h.registerDevice)).Methods("POST", "OPTIONS")
+	router.HandleFunc("/me/devices", authMiddleware(h.unregisterDevice)).Methods("DELETE", "OPTIONS")
+	router.HandleFunc("/me/saved", authMiddleware(h.saveMessage)).Methods("POST", "OPTIONS")
+	router.HandleFunc("/me/saved", authMiddleware(h.getSavedMessages)).Methods("GET", "OPTIONS")
+	router.HandleFunc("/me/saved/{message_id:[0-9]+}", authMiddleware(h.deleteSavedMessage)).Methods("DELETE", "OPTIONS")
+	router.HandleFunc("/me/mentions", authMiddleware(h.getMentions)).Methods("GET", "OPTIONS")
+	router.HandleFunc("/chat/{chat_id:[0-9]+}/mentions/read", authMiddleware(h.readMentions)).Methods("POST", "OPTIONS")
+	router.HandleFunc("/chat/poll/{message_id:[0-9]+}", authMiddleware(h.getPoll)).Methods("GET", "OPTIONS")
+	router.HandleFunc("/chat/poll/{message_id:[0-9]+}/vote", authMiddleware(h.votePoll)).Methods("POST", "OPTIONS")
+	router.HandleFunc("/chat/poll/{message_id:[0-9]+}/close", authMiddleware(h.closePoll)).Methods("POST", "OPTIONS")
+	router.HandleFunc("/chat/{id:[0-9]+}/pins", authMiddleware(h.listPins)).Methods("GET", "OPTIONS")
+	router.HandleFunc("/chat/{chat_id:[0-9]+}/pins/{message_id:[0-9]+}", authMiddleware(h.pinMessage)).Methods("POST", "OPTIONS")
+	router.HandleFunc("/chat/{chat_id:[0-9]+}/pins/{message_id:[0-9]+}", authMiddleware(h.unpinMessage)).Methods("DELETE", "OPTIONS")
 	router.HandleFunc("/chat/join/{chat_id:[0-9]+}/{user_id:[0-9]+}", authMiddleware(h.UserJoined)).Methods("POST", "OPTIONS")
 	router.HandleFunc("/chat/leave/{chat_id:[0-9]+}/{user_id:[0-9]+}", authMiddleware(h.UserLeft)).Methods("POST", "OPTIONS")
 	router.HandleFunc("/chat/{chat_id:[0-9]+}/add/{user_id:[0-9]+}", authMiddleware(h.UserAdd)).Methods("POST", "OPTIONS")
 	router.HandleFunc("/chat/{chat_id:[0-9]+}/remove/{user_id:[0-9]+}", authMiddleware(h.UserRemove)).Methods("POST", "OPTIONS")
+	router.HandleFunc("/chat/{chat_id:[0-9]+}/leave", authMiddleware(h.leaveChat)).Methods("POST", "OPTIONS")
+	router.HandleFunc("/chat/{chat_id:[0-9]+}/clear", authMiddleware(h.clearHistory)).Methods("POST", "OPTIONS")
+	router.HandleFunc("/chat/{chat_id:[0-9]+}", authMiddleware(h.deleteChat)).Methods("DELETE", "OPTIONS")
 	router.HandleFunc("/chat/{chat_id:[0-9]+}/rename", authMiddleware(h.RenameChat)).Methods("POST", "OPTIONS")
 	router.HandleFunc("/chat/group/create", authMiddleware(h.CreateGroup)).Methods("POST", "OPTIONS")
+	router.HandleFunc("/chat/channel/create", authMiddleware(h.createChannel)).Methods("POST", "OPTIONS")
+	router.HandleFunc("/chat/{chat_id:[0-9]+}/subscribe", authMiddleware(h.subscribeToChannel)).Methods("POST", "OPTIONS")
+	router.HandleFunc("/chat/{chat_id:[0-9]+}/unsubscribe", authMiddleware(h.unsubscribeFromChannel)).Methods("POST", "OPTIONS")
+	router.HandleFunc("/chat/{chat_id:[0-9]+}/info", authMiddleware(h.updateChatInfo)).Methods("PUT", "OPTIONS")
+	router.HandleFunc("/chat/{chat_id:[0-9]+}/slowmode", authMiddleware(h.setSlowMode)).Methods("PUT", "OPTIONS")
+	router.HandleFunc("/chat/{chat_id:[0-9]+}/timer", authMiddleware(h.setMessageTimer)).Methods("PUT", "OPTIONS")
+	router.HandleFunc("/chat/{chat_id:[0-9]+}/avatar", authMiddleware(h.uploadChatAvatar)).Methods("POST", "OPTIONS")
+	router.HandleFunc("/chat/{chat_id:[0-9]+}/avatar", authMiddleware(h.deleteChatAvatar)).Methods("DELETE", "OPTIONS")
+	router.HandleFunc("/chat/{chat_id:[0-9]+}/invites", authMiddleware(h.createInvite)).Methods("POST", "OPTIONS")
+	router.HandleFunc("/chat/{chat_id:[0-9]+}/invites", authMiddleware(h.listInvites)).Methods("GET", "OPTIONS")
+	router.HandleFunc("/chat/{chat_id:[0-9]+}/invites/{invite_id:[0-9]+}", authMiddleware(h.revokeInvite)).Methods("DELETE", "OPTIONS")
+	router.HandleFunc("/chat/join/{token:[A-Za-z0-9_-]+}", authMiddleware(h.joinByInvite)).Methods("POST", "OPTIONS")
+	router.HandleFunc("/chat/{chat_id:[0-9]+}/join-by-request", authMiddleware(h.setJoinByRequest)).Methods("PUT", "OPTIONS")
+	router.HandleFunc("/chat/{chat_id:[0-9]+}/join-requests", authMiddleware(h.requestToJoin)).Methods("POST", "OPTIONS")
+	router.HandleFunc("/chat/{chat_id:[0-9]+}/join-requests", authMiddleware(h.listJoinRequests)).Methods("GET", "OPTIONS")
+	router.HandleFunc("/chat/{chat_id:[0-9]+}/join-requests/{request_id:[0-9]+}/approve", authMiddleware(h.approveJoinRequest)).Methods("POST", "OPTIONS")
+	router.HandleFunc("/chat/{chat_id:[0-9]+}/join-requests/{request_id:[0-9]+}/reject", authMiddleware(h.rejectJoinRequest)).Methods("POST", "OPTIONS")
+	router.HandleFunc("/chat/{chat_id:[0-9]+}/bans", authMiddleware(h.banUser)).Methods("POST", "OPTIONS")
+	router.HandleFunc("/chat/{chat_id:[0-9]+}/bans", authMiddleware(h.listBans)).Methods("GET", "OPTIONS")
+	router.HandleFunc("/chat/{chat_id:[0-9]+}/bans/{user_id:[0-9]+}", authMiddleware(h.unbanUser)).Methods("DELETE", "OPTIONS")
+	router.HandleFunc("/chat/{chat_id:[0-9]+}/kick/{user_id:[0-9]+}", authMiddleware(h.kickMember)).Methods("POST", "OPTIONS")
+	router.HandleFunc("/chat/{chat_id:[0-9]+}/members/{user_id:[0-9]+}/role", authMiddleware(h.changeMemberRole)).Methods("PUT", "OPTIONS")
+	router.HandleFunc("/chat/{chat_id:[0-9]+}/audit", authMiddleware(h.getAuditLog)).Methods("GET", "OPTIONS")
 }
 
 // DeleteMessage удаляет сообщение
 // @Summary Delete message
-// @Description Delete a specific message by ID
+// @Description Delete a specific message by ID. scope=everyone (default) removes it for all members: the sender can do it within 48 hours, group admins at any time. scope=me hides it only for the current user
 // @ID delete-message
 // @Tags chat
 // @Accept json
 // @Produce json
 // @Param Authorization header string true "Bearer токен" default(Bearer )
 // @Param id path int true "Message ID"
+// @Param scope query string false "Deletion scope" Enums(everyone, me) default(everyone)
 // @Success 200 {object} StatusResponse
 // @Failure 400 {object} httputils.ErrorResponse
 // @Failure 401 {object} httputils.ErrorResponse
@@ -180,6 +281,12 @@ func (h *ChatHandler) deleteMessage(w http.ResponseWriter, r *http.Request) {
 	}
 	msgID := uint(msgID64)
 
+	forEveryone, ok := parseDeleteScope(r.URL.Query().Get("scope"))
+	if !ok {
+		httputils.ResponseError(w, http.StatusBadRequest, "scope must be 'everyone' or 'me'")
+		return
+	}
+
 	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
 
@@ -194,63 +301,33 @@ func (h *ChatHandler) deleteMessage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Логика прав:
-	// 1) сообщение может удалять только его отправитель
-	if msg.SenderID != claims.UserID {
-		httputils.ResponseError(w, http.StatusForbidden, "cannot delete messages of other users")
+	if !h.deleteMessagesInChat(ctx, w, claims.UserID, msg.ChatID, []uint{msgID}, forEveryone) {
 		return
 	}
 
-	// 2) и только участник чата
-	isMember, err := h.chatService.IsUserInChat(ctx, msg.ChatID, claims.UserID)
-	if err != nil {
-		h.logger.Error("failed to check membership", "error", err)
-		httputils.ResponseError(w, http.StatusInternalServerError, "failed to validate membership")
-		return
-	}
-	if !isMember {
-		httputils.ResponseError(w, http.StatusForbidden, "user is not a member of this chat")
-		return
-	}
+	httputils.ResponseJSON(w, http.StatusOK, StatusResponse{Status: "message deleted"})
+}
 
-	// Удаляем из БД
-	if err := h.chatService.DeleteMessage(ctx, msgID); err != nil {
-		h.logger.Error("failed to delete message", "error", err)
-		httputils.ResponseError(w, http.StatusInternalServerError, "failed to delete message")
+// broadcastMessageDeleted уведомляет WS-клиентов чата об удалении сообщения
+func (h *ChatHandler) broadcastMessageDeleted(chatID, messageID uint) {
+	if h.hub == nil {
 		return
 	}
 
-	// Асинхронно чистим Redis
-	if h.chatCacheService != nil {
-		go func(chatID, messageID uint) {
-			ctxCache, cancelCache := context.WithTimeout(context.Background(), 3*time.Second)
-			defer cancelCache()
-
-			if err := h.chatCacheService.DeleteMessage(ctxCache, chatID, messageID); err != nil {
-				h.logger.Warn("failed to delete message from cache", "error", err)
-			}
-		}(msg.ChatID, msg.ID)
+	ev := ws.OutEvent{
+		Type:      ws.EventTypeMessageDeleted,
+		ChatID:    chatID,
+		MessageID: messageID,
+		Timestamp: time.Now(),
 	}
 
-	// Уведомляем всех WS-клиентов чата
-	if h.hub != nil {
-		ev := ws.OutEvent{
-			Type:      ws.EventTypeMessageDeleted,
-			ChatID:    msg.ChatID,
-			MessageID: msg.ID,
-			Timestamp: time.Now(),
-		}
-
-		if room, ok := h.hub.GetRoomSafe(msg.ChatID); ok {
-			if data, err := json.Marshal(ev); err == nil {
-				room.Broadcast(data)
-			} else {
-				h.logger.Warn("failed to marshal message_deleted event", "error", err)
-			}
+	if room, ok := h.hub.GetRoomSafe(chatID); ok {
+		if data, err := json.Marshal(ev); err == nil {
+			room.Broadcast(data)
+		} else {
+			h.logger.Warn("failed to marshal message_deleted event", "error", err)
 		}
 	}
-
-	httputils.ResponseJSON(w, http.StatusOK, StatusResponse{Status: "message deleted"})
 }
 
 // authMiddleware middleware для аутентификации
@@ -298,6 +375,21 @@ func getClaimsFromContext(r *http.Request) (*auth.Claims, error) {
 	return claims, nil
 }
 
+// requireChatAdmin проверяет права администратора чата и отвечает ошибкой, если их нет
+func (h *ChatHandler) requireChatAdmin(ctx context.Context, w http.ResponseWriter, chatID, userID uint) bool {
+	isAdmin, err := h.chatService.IsChatAdmin(ctx, chatID, userID)
+	if err != nil {
+		h.logger.Error("failed to check admin rights", "error", err)
+		httputils.ResponseError(w, http.StatusInternalServerError, "failed to validate permissions")
+		return false
+	}
+	if !isAdmin {
+		httputils.ResponseError(w, http.StatusForbidden, "only chat admins can perform this action")
+		return false
+	}
+	return true
+}
+
 func (h *ChatHandler) sendMessage(w http.ResponseWriter, r *http.Request) {
 	claims, err := getClaimsFromContext(r)
 	if err != nil {
@@ -317,8 +409,11 @@ func (h *ChatHandler) sendMessage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	req.Message = strings.TrimSpace(req.Message)
-	if req.Message == "" || len(req.Message) > MaxMessageLength {
+	if strings.TrimSpace(req.Message) == "" && req.Poll != nil {
+		req.Message = strings.TrimSpace(req.Poll.Question)
+		req.Entities = nil
+	}
+	if strings.TrimSpace(req.Message) == "" || len(req.Message) > MaxMessageLength {
 		httputils.ResponseError(w, http.StatusBadRequest,
 			fmt.Sprintf("message must be 1-%d characters", MaxMessageLength))
 		return
@@ -353,17 +448,35 @@ func (h *ChatHandler) sendMessage(w http.ResponseWriter, r *http.Request) {
 	msg := model.Message{
 		ChatID:    chat.ID,
 		SenderID:  claims.UserID,
-		Message:   html.EscapeString(req.Message),
+		Message:   req.Message,
+		Entities:  req.Entities,
 		Type:      req.Type,
 		Timestamp: time.Now(),
+		Poll:      req.Poll,
 	}
 
 	if err := h.processMessage(ctx, chat, &msg); err != nil {
+		if errors.Is(err, service.ErrChannelReadOnly) {
+			httputils.ResponseError(w, http.StatusForbidden, err.Error())
+			return
+		}
+		if errors.Is(err, service.ErrInvalidPoll) || errors.Is(err, service.ErrPollGroupOnly) ||
+			errors.Is(err, service.ErrInvalidEntities) {
+			httputils.ResponseError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		var slowModeErr *service.SlowModeError
+		if errors.As(err, &slowModeErr) {
+			responseSlowMode(w, slowModeErr)
+			return
+		}
 		h.logger.Error("failed to process message", "error", err)
 		httputils.ResponseError(w, http.StatusInternalServerError, "failed to send message")
 		return
 	}
 
+	h.clearDraft(msg.SenderID, msg.ChatID)
+
 	// Теперь msg содержит правильный ID / CreatedAt / Timestamp
 	httputils.ResponseJSON(w, http.StatusCreated, msg)
 }
@@ -413,6 +526,13 @@ func (h *ChatHandler) processMessage(ctx context.Context, chat *model.Chat, msg
 		return err
 	}
 
+	h.publishMessage(chat, *msg)
+
+	return nil
+}
+
+// publishMessage кеширует сохраненное сообщение и рассылает его участникам чата
+func (h *ChatHandler) publishMessage(chat *model.Chat, msg model.Message) {
 	// Кешируем в Redis (асинхронно, но кладём уже объект с корректным ID)
 	if h.chatCacheService != nil {
 		go func(m model.Message) {
@@ -422,15 +542,18 @@ func (h *ChatHandler) processMessage(ctx context.Context, chat *model.Chat, msg
 			if err := h.chatCacheService.SendMessage(ctxCache, chat, m); err != nil {
 				h.logger.Warn("failed to cache message", "error", err)
 			}
-		}(*msg)
+		}(msg)
 	}
 
 	// Отправляем через WebSocket
 	if h.hub != nil {
-		h.hub.BroadcastMessage(chat.ID, *msg)
+		h.hub.BroadcastMessage(chat.ID, msg)
 	}
 
-	return nil
+	h.notifyMentions(msg)
+	h.attachLinkPreview(msg)
+	h.unarchiveForMessage(msg)
+	h.sendPushNotifications(msg)
 }
 
 // GetMessages возвращает сообщения чата
@@ -500,7 +623,7 @@ func (h *ChatHandler) getMessages(w http.ResponseWriter, r *http.Request) {
 
 	// Получаем сообщения
 	messages, hasNext, hasPrevious, totalCount, err := h.chatService.GetChatMessages(
-		ctx, uint(chatID), cursor, limit, direction)
+		ctx, uint(chatID), claims.UserID, cursor, limit, direction)
 	if err != nil {
 		h.logger.Error("failed to get messages", "error", err)
 		httputils.ResponseError(w, http.StatusInternalServerError, "failed to get messages")
@@ -640,18 +763,37 @@ func (h *ChatHandler) RenameChat(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = h.chatService.UpdateGroupInfo(ctx, uint(chatID), newName, "")
+	chat, err := h.chatService.GetChatByID(ctx, uint(chatID))
+	if err != nil || chat == nil {
+		httputils.ResponseError(w, http.StatusNotFound, "chat not found")
+		return
+	}
+
+	// Описание сохраняем, меняется только название
+	err = h.chatService.UpdateGroupInfo(ctx, uint(chatID), newName, chat.Description)
 	if err != nil {
 		httputils.ResponseError(w, http.StatusInternalServerError, "failed to rename chat")
 		return
 	}
 
+	h.recordAudit(model.ChatAuditLog{
+		ChatID:  chat.ID,
+		ActorID: claims.UserID,
+		Action:  model.AuditChatRenamed,
+		Before:  chat.Name,
+		After:   strings.TrimSpace(newName),
+	})
+
+	chat.Name = strings.TrimSpace(newName)
+	h.fillChatAvatarURL(ctx, chat)
+	h.broadcastChatUpdated(chat, claims.UserID)
+
 	w.WriteHeader(http.StatusOK)
 }
 
-// UserAdd отмечает пользователя как подключенного
+// UserAdd добавляет пользователя в группу
 // @Summary Add User to Chat
-// @Description Add User to Chat
+// @Description Add a user to a group chat. Only group admins can add members; banned users cannot be added.
 // @ID user-add
 // @Tags chat
 // @Param Authorization header string true "Bearer токен" default(Bearer )
@@ -661,17 +803,13 @@ func (h *ChatHandler) RenameChat(w http.ResponseWriter, r *http.Request) {
 // @Failure 400 {object} httputils.ErrorResponse
 // @Failure 401 {object} httputils.ErrorResponse
 // @Failure 403 {object} httputils.ErrorResponse
+// @Failure 409 {object} httputils.ErrorResponse
 // @Failure 500 {object} httputils.ErrorResponse
 // @Router /chat/{chat_id}/add/{user_id} [post]
 func (h *ChatHandler) UserAdd(w http.ResponseWriter, r *http.Request) {
-	tokenStr := extractTokenFromHeader(r)
-	if tokenStr == "" {
-		httputils.ResponseError(w, http.StatusUnauthorized, "missing auth token")
-		return
-	}
-	claims, err := auth.ValidateToken(tokenStr)
+	claims, err := getClaimsFromContext(r)
 	if err != nil {
-		httputils.ResponseError(w, http.StatusUnauthorized, "invalid token")
+		httputils.ResponseError(w, http.StatusUnauthorized, "unauthorized")
 		return
 	}
 
@@ -686,69 +824,64 @@ func (h *ChatHandler) UserAdd(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(r.Context(), PresenceTimeout)
 	defer cancel()
 
-	if ok, _ := h.chatService.IsUserInChat(ctx, uint(chatID), claims.UserID); !ok {
-		httputils.ResponseError(w, http.StatusForbidden, "adder is not in chat")
+	if err := h.chatService.AddMember(ctx, uint(chatID), claims.UserID, uint(userID)); err != nil {
+		switch {
+		case errors.Is(err, service.ErrCannotAddMembers),
+			errors.Is(err, service.ErrUserBanned):
+			httputils.ResponseError(w, http.StatusForbidden, err.Error())
+		case errors.Is(err, service.ErrAlreadyMember):
+			httputils.ResponseError(w, http.StatusConflict, err.Error())
+		default:
+			httputils.ResponseError(w, http.StatusBadRequest, fmt.Sprintf("failed to add users to chat: %v", err))
+		}
 		return
 	}
 
-	err = h.chatService.AddUsersToChat(ctx, uint(chatID), uint(userID))
-	if err != nil {
-		httputils.ResponseError(w, http.StatusForbidden, fmt.Sprintf("failed to add users to chat: %v", err))
-		return
-	}
+	targetID := uint(userID)
+	h.recordAudit(model.ChatAuditLog{
+		ChatID:       uint(chatID),
+		ActorID:      claims.UserID,
+		Action:       model.AuditUserAdded,
+		TargetUserID: &targetID,
+	})
 
 	w.WriteHeader(http.StatusOK)
 }
 
-// UserRemove отмечает пользователя как подключенного
+// UserRemove удаляет пользователя из группы. Удаление себя — выход из группы,
+// удаление другого участника — исключение с проверкой прав, как в kickMember.
 // @Summary Remove User from Chat
-// @Description Removes User from Chat
+// @Description Remove a user from a group chat. Removing yourself leaves the group (ownership passes on if you are the owner). Removing another member requires admin rights: the owner cannot be removed and only the owner can remove admins.
 // @ID user-remove
 // @Tags chat
 // @Param Authorization header string true "Bearer токен" default(Bearer )
 // @Param chat_id path int true "Chat ID"
 // @Param user_id path int true "User ID"
-// @Success 200
+// @Success 200 {object} StatusResponse
 // @Failure 400 {object} httputils.ErrorResponse
 // @Failure 401 {object} httputils.ErrorResponse
 // @Failure 403 {object} httputils.ErrorResponse
 // @Failure 500 {object} httputils.ErrorResponse
 // @Router /chat/{chat_id}/remove/{user_id} [post]
 func (h *ChatHandler) UserRemove(w http.ResponseWriter, r *http.Request) {
-	tokenStr := extractTokenFromHeader(r)
-	if tokenStr == "" {
-		httputils.ResponseError(w, http.StatusUnauthorized, "missing auth token")
-		return
-	}
-	claims, err := auth.ValidateToken(tokenStr)
+	claims, err := getClaimsFromContext(r)
 	if err != nil {
-		httputils.ResponseError(w, http.StatusUnauthorized, "invalid token")
+		httputils.ResponseError(w, http.StatusUnauthorized, "unauthorized")
 		return
 	}
 
-	vars := mux.Vars(r)
-	chatID, err1 := strconv.ParseUint(vars["chat_id"], 10, 64)
-	userID, err2 := strconv.ParseUint(vars["user_id"], 10, 64)
-	if err1 != nil || err2 != nil {
+	userID, err := parsePathID(r, "user_id")
+	if err != nil {
 		httputils.ResponseError(w, http.StatusBadRequest, "invalid chat or user id")
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(r.Context(), PresenceTimeout)
-	defer cancel()
-
-	if ok, _ := h.chatService.IsUserInChat(ctx, uint(chatID), claims.UserID); !ok {
-		httputils.ResponseError(w, http.StatusForbidden, "remover is not in chat")
-		return
-	}
-
-	err = h.chatService.RemoveUserFromChat(ctx, uint(chatID), uint(userID))
-	if err != nil {
-		httputils.ResponseError(w, http.StatusForbidden, fmt.Sprintf("failed to remove user from chat: %v", err))
+	if userID == claims.UserID {
+		h.leaveChat(w, r)
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
+	h.removeMember(w, r, model.AuditUserRemoved)
 }
 
 // UserJoined отмечает пользователя как подключенного
@@ -893,7 +1026,8 @@ func (h *ChatHandler) CreateGroup(w http.ResponseWriter, r *http.Request) {
 	req.UserIDs = append(req.UserIDs, claims.UserID)
 
 	// Создаем групповой чат
-	chat, err := h.chatService.CreateGroupChat(ctx, req.Name, req.UserIDs)
+	// Создатель группы становится ее владельцем
+	chat, err := h.chatService.CreateGroupChat(ctx, req.Name, req.UserIDs, claims.UserID)
 	if err != nil {
 		h.logger.Error("failed to create group chat", "error", err)
 		httputils.ResponseError(w, http.StatusInternalServerError, "failed to create group chat")
@@ -936,6 +1070,17 @@ func (h *ChatHandler) wsChat(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
 	defer cancel()
 
+	ban, err := h.chatService.GetActiveBan(ctx, chatID, claims.UserID)
+	if err != nil {
+		h.logger.Error("failed to check chat ban", "error", err)
+		httputils.ResponseError(w, http.StatusInternalServerError, "failed to validate membership")
+		return
+	}
+	if ban != nil {
+		httputils.ResponseError(w, http.StatusForbidden, service.ErrUserBanned.Error())
+		return
+	}
+
 	ok, err := h.chatService.IsUserInChat(ctx, chatID, claims.UserID)
 	if err != nil {
 		h.logger.Error("failed to validate membership", "error", err)
@@ -947,6 +1092,12 @@ func (h *ChatHandler) wsChat(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	chat, err := h.chatService.GetChatMeta(ctx, chatID)
+	if err != nil || chat == nil {
+		httputils.ResponseError(w, http.StatusNotFound, "chat not found")
+		return
+	}
+
 	// Настройка WebSocket Upgrader с поддержкой query параметров
 	upgrader := websocket.Upgrader{
 		ReadBufferSize:  1024,
@@ -973,6 +1124,17 @@ func (h *ChatHandler) wsChat(w http.ResponseWriter, r *http.Request) {
 	client.SetRateLimit(10) // 10 сообщений в секунду
 
 	room := h.hub.GetRoom(chatID)
+	if chat.IsChannel {
+		room = h.hub.GetChannelRoom(chatID)
+
+		// Подписчики канала только читают
+		isAdmin, err := h.chatService.IsChatAdmin(ctx, chatID, claims.UserID)
+		if err != nil {
+			h.logger.Warn("failed to check channel admin rights", "error", err)
+		}
+		client.ReadOnly = !isAdmin
+	}
+
 	if !room.RegisterClient(client) {
 		clientCancel()
 		conn.Close()
@@ -1061,7 +1223,8 @@ func (h *ChatHandler) sendChatHistory(client *ws.Client, chatID uint) {
 
 	// Если в кеше пусто, пробуем БД
 	if len(messages) == 0 {
-		dbMessages, err := h.chatService.GetRecentMessages(ctx, chatID, 50)
+		// Кеш общий для всех участников, поэтому в него кладем историю без учета скрытых сообщений
+		dbMessages, err := h.chatService.GetRecentMessages(ctx, chatID, 0, 50)
 		if err == nil && len(dbMessages) > 0 {
 			messages = dbMessages
 
@@ -1078,6 +1241,13 @@ func (h *ChatHandler) sendChatHistory(client *ws.Client, chatID uint) {
 		}
 	}
 
+	messages = h.filterHiddenMessages(ctx, client.UserID, chatID, messages)
+
+	// Результаты опросов в кеше устаревают, поэтому подгружаем их заново для каждого клиента
+	if err := h.chatService.AttachPolls(ctx, messages, client.UserID); err != nil {
+		h.logger.Warn("failed to load poll results", "error", err)
+	}
+
 	if len(messages) > 0 {
 		client.SendJSON(ws.OutEvent{
 			Type:     "history",
@@ -1115,9 +1285,9 @@ func (h *ChatHandler) handleIncomingMessage(c *ws.Client, ev ws.InEvent) {
 
 // handleChatMessage обрабатывает текстовые сообщения
 func (h *ChatHandler) handleChatMessage(c *ws.Client, ev ws.InEvent) {
-	txt := strings.TrimSpace(ev.Message)
+	txt := ev.Message
 
-	if len(txt) == 0 {
+	if strings.TrimSpace(txt) == "" {
 		c.SendJSON(ws.OutEvent{Type: "error", Message: "message cannot be empty"})
 		return
 	}
@@ -1129,6 +1299,11 @@ func (h *ChatHandler) handleChatMessage(c *ws.Client, ev ws.InEvent) {
 		return
 	}
 
+	if c.ReadOnly {
+		c.SendJSON(ws.OutEvent{Type: "error", Message: service.ErrChannelReadOnly.Error()})
+		return
+	}
+
 	if !c.CheckRateLimit() {
 		c.SendJSON(ws.OutEvent{
 			Type:    "error",
@@ -1137,12 +1312,12 @@ func (h *ChatHandler) handleChatMessage(c *ws.Client, ev ws.InEvent) {
 		return
 	}
 
-	txt = html.EscapeString(txt)
-
+	// Текст хранится как есть, экранирование выполняется клиентом при отображении
 	msg := model.Message{
 		ChatID:    c.ChatID,
 		SenderID:  c.UserID,
 		Message:   txt,
+		Entities:  ev.Entities,
 		Timestamp: time.Now(),
 	}
 
@@ -1158,15 +1333,24 @@ func (h *ChatHandler) processWebSocketMessage(c *ws.Client, msg *model.Message)
 	chat.ID = msg.ChatID
 
 	if err := h.chatService.SendMessageToChat(ctx, chat, msg); err != nil {
-		h.logger.Error("failed to save message", "error", err)
+		errEvent := ws.OutEvent{Type: "error", Message: "failed to save message"}
+
+		var slowModeErr *service.SlowModeError
+		switch {
+		case errors.Is(err, service.ErrChannelReadOnly),
+			errors.Is(err, service.ErrInvalidEntities):
+			errEvent.Message = err.Error()
+		case errors.As(err, &slowModeErr):
+			errEvent.Message = err.Error()
+			errEvent.Meta = map[string]any{"retry_after": slowModeErr.RetryAfterSeconds()}
+		default:
+			h.logger.Error("failed to save message", "error", err)
+		}
 
 		select {
 		case <-ctx.Done():
 		default:
-			c.SendJSON(ws.OutEvent{
-				Type:    "error",
-				Message: "failed to save message",
-			})
+			c.SendJSON(errEvent)
 		}
 		return
 	}
@@ -1189,6 +1373,12 @@ func (h *ChatHandler) processWebSocketMessage(c *ws.Client, msg *model.Message)
 		h.hub.BroadcastMessage(msg.ChatID, *msg)
 	}
 
+	h.notifyMentions(*msg)
+	h.attachLinkPreview(*msg)
+	h.unarchiveForMessage(*msg)
+	h.sendPushNotifications(*msg)
+	h.clearDraft(msg.SenderID, msg.ChatID)
+
 	select {
 	case <-ctx.Done():
 	default:
@@ -1202,6 +1392,10 @@ func (h *ChatHandler) processWebSocketMessage(c *ws.Client, msg *model.Message)
 
 // handleTypingIndicator обрабатывает индикатор набора текста
 func (h *ChatHandler) handleTypingIndicator(c *ws.Client, ev ws.InEvent) {
+	if c.ReadOnly {
+		return
+	}
+
 	isTyping := strings.ToLower(strings.TrimSpace(ev.Message)) == "true"
 	if h.hub != nil {
 		h.hub.BroadcastTypingIndicator(c.ChatID, c.UserID, isTyping)
@@ -1222,6 +1416,11 @@ func (h *ChatHandler) handleReadReceipt(c *ws.Client, ev ws.InEvent) {
 	if err := h.chatService.MarkMessageAsRead(ctx, uint(messageID), c.UserID); err != nil {
 		h.logger.Warn("failed to mark message as read", "error", err)
 	}
+
+	// Прочитанное сообщение закрывает и упоминания до него включительно
+	if err := h.chatService.MarkMentionsRead(ctx, c.UserID, c.ChatID, uint(messageID)); err != nil {
+		h.logger.Warn("failed to mark mentions as read", "error", err)
+	}
 }
 
 // GetChatInfo возвращает информацию о чате
@@ -1264,7 +1463,7 @@ func (h *ChatHandler) getChatInfo(w http.ResponseWriter, r *http.Request) {
 
 	// Получаем информацию о чате
 	chat, err := h.chatService.GetChatByID(ctx, uint(chatID))
-	if err != nil {
+	if err != nil || chat == nil {
 		httputils.ResponseError(w, http.StatusNotFound, "chat not found")
 		return
 	}
@@ -1273,18 +1472,31 @@ func (h *ChatHandler) getChatInfo(w http.ResponseWriter, r *http.Request) {
 		chat.Users[i].EnsureDisplayName()
 	}
 
+	h.fillChatAvatarURL(ctx, chat)
+
+	if pin, err := h.chatService.GetLatestPin(ctx, chat.ID); err != nil {
+		h.logger.Warn("failed to get pinned message", "error", err)
+	} else {
+		chat.PinnedMessage = pin
+	}
+
 	httputils.ResponseJSON(w, http.StatusOK, chat)
 }
 
 // ListChats возвращает список чатов пользователя
 // @Summary List user chats
-// @Description Get list of chats for current user
+// @Description Get all of the current user's chats as an array: pinned chats first in their order, then the rest by last activity. Archived chats are listed separately. Each chat comes with last message, unread counters, notification settings, member count and draft. With since only chats changed after that moment are returned. Use /chat/list/page to load the list page by page.
 // @ID list-chats
 // @Tags chat
 // @Accept json
 // @Produce json
 // @Param Authorization header string true "Bearer токен" default(Bearer )
+// @Param since query string false "Only chats changed after this time (RFC3339)"
+// @Param archived query bool false "List archived chats instead of the main list"
+// @Param folder_id query int false "List chats of this folder (archived ones included unless the folder excludes them)"
 // @Success 200 {object} []ListChatsResponse
+// @Failure 400 {object} httputils.ErrorResponse
+// @Failure 401 {object} httputils.ErrorResponse
 // @Failure 500 {object} httputils.ErrorResponse
 // @Router /chat/list [get]
 func (h *ChatHandler) listChats(w http.ResponseWriter, r *http.Request) {
@@ -1294,34 +1506,186 @@ func (h *ChatHandler) listChats(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	params, err := parseChatListParams(r)
+	if err != nil {
+		httputils.ResponseError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
 
-	chats, err := h.chatService.GetChatsForUser(ctx, claims.UserID)
+	// Прежний формат ответа — массив всех чатов, поэтому страницы собираются целиком
+	params.Cursor = ""
+	params.Limit = service.MaxChatListLimit
+	var items []model.ChatListItem
+	for {
+		page, nextCursor, err := h.chatService.ListChats(ctx, claims.UserID, params)
+		if err != nil {
+			h.responseChatListError(w, err)
+			return
+		}
+
+		items = append(items, page...)
+		if nextCursor == "" {
+			break
+		}
+		params.Cursor = nextCursor
+	}
+
+	httputils.ResponseJSON(w, http.StatusOK, h.buildChatListResponses(ctx, claims.UserID, items))
+}
+
+// ListChatsPage возвращает страницу списка чатов пользователя
+// @Summary List user chats page by page
+// @Description Get a page of the current user's chats: pinned chats first in their order, then the rest by last activity. Archived chats are listed separately. Each chat comes with last message, unread counters, notification settings, member count and draft. With since only chats changed after that moment are returned (new, edited or deleted messages, reads, chat settings, drafts); chats the user left or that were deleted are reported by WebSocket events instead.
+// @ID list-chats-page
+// @Tags chat
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer токен" default(Bearer )
+// @Param since query string false "Only chats changed after this time (RFC3339), e.g. syncedAt of the first page of the previous sync"
+// @Param archived query bool false "List archived chats instead of the main list"
+// @Param folder_id query int false "List chats of this folder (archived ones included unless the folder excludes them)"
+// @Param cursor query string false "Cursor from the previous page"
+// @Param limit query int false "Limit" minimum(1) maximum(100) default(50)
+// @Success 200 {object} ChatListPageResponse
+// @Failure 400 {object} httputils.ErrorResponse
+// @Failure 401 {object} httputils.ErrorResponse
+// @Failure 500 {object} httputils.ErrorResponse
+// @Router /chat/list/page [get]
+func (h *ChatHandler) listChatsPage(w http.ResponseWriter, r *http.Request) {
+	claims, err := getClaimsFromContext(r)
 	if err != nil {
+		httputils.ResponseError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	params, err := parseChatListParams(r)
+	if err != nil {
+		httputils.ResponseError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	// Фиксируем момент до запроса, чтобы изменения во время выборки попали в следующую синхронизацию
+	syncedAt := time.Now().UTC()
+
+	items, nextCursor, err := h.chatService.ListChats(ctx, claims.UserID, params)
+	if err != nil {
+		h.responseChatListError(w, err)
+		return
+	}
+
+	responses := h.buildChatListResponses(ctx, claims.UserID, items)
+	page := ChatListPageResponse{
+		Data: responses,
+		Pagination: PaginationInfo{
+			HasNext:     nextCursor != "",
+			HasPrevious: params.Cursor != "",
+			Limit:       len(responses),
+		},
+		SyncedAt: syncedAt,
+	}
+	if nextCursor != "" {
+		page.Pagination.NextCursor = &nextCursor
+	}
+
+	httputils.ResponseJSON(w, http.StatusOK, page)
+}
+
+// parseChatListParams разбирает параметры запроса списка чатов
+func parseChatListParams(r *http.Request) (service.ChatListParams, error) {
+	queryParams := r.URL.Query()
+	params := service.ChatListParams{Cursor: queryParams.Get("cursor")}
+
+	if sinceStr := queryParams.Get("since"); sinceStr != "" {
+		parsed, err := time.Parse(time.RFC3339, sinceStr)
+		if err != nil {
+			return params, errors.New("invalid since, expected RFC3339")
+		}
+		params.Since = &parsed
+	}
+
+	params.Archived, _ = strconv.ParseBool(queryParams.Get("archived"))
+
+	if folderStr := queryParams.Get("folder_id"); folderStr != "" {
+		parsed, err := strconv.ParseUint(folderStr, 10, 64)
+		if err != nil {
+			return params, errors.New("invalid folder id")
+		}
+		params.FolderID = uint(parsed)
+	}
+
+	if limitStr := queryParams.Get("limit"); limitStr != "" {
+		if parsedLimit, err := strconv.Atoi(limitStr); err == nil {
+			params.Limit = parsedLimit
+		}
+	}
+
+	return params, nil
+}
+
+// responseChatListError отвечает ошибкой загрузки списка чатов
+func (h *ChatHandler) responseChatListError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidChatListCursor):
+		httputils.ResponseError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, service.ErrChatFolderNotFound):
+		httputils.ResponseError(w, http.StatusNotFound, err.Error())
+	default:
 		h.logger.Error("failed to get chat list", "error", err)
 		httputils.ResponseError(w, http.StatusInternalServerError, "failed to get chat list")
-		return
 	}
+}
+
+// buildChatListResponses дополняет чаты списка упоминаниями, черновиками и ссылками на аватары
+func (h *ChatHandler) buildChatListResponses(ctx context.Context, userID uint, items []model.ChatListItem) []ListChatsResponse {
+	mentionCounts, err := h.chatService.GetUnreadMentionCounts(ctx, userID)
+	if err != nil {
+		h.logger.Warn("failed to count unread mentions", "error", err)
+	}
+
+	drafts, err := h.chatService.GetDraftsForUser(ctx, userID)
+	if err != nil {
+		h.logger.Warn("failed to get drafts", "error", err)
+	}
+
+	responses := make([]ListChatsResponse, 0, len(items))
+	for _, item := range items {
+		chat := item.Chat
+		h.fillChatAvatarURL(ctx, &chat)
 
-	var responses []ListChatsResponse
-	for _, chat := range *chats {
 		response := ListChatsResponse{
-			ID:        chat.ID,
-			Name:      chat.Name,
-			CreatedAt: chat.CreatedAt,
-			UpdatedAt: chat.UpdatedAt,
+			ID:             chat.ID,
+			Name:           chat.Name,
+			Description:    chat.Description,
+			AvatarURL:      chat.AvatarURL,
+			IsGroup:        chat.IsGroup,
+			IsChannel:      chat.IsChannel,
+			MemberCount:    item.MemberCount,
+			Participants:   item.Participants,
+			LastMessage:    item.LastMessage,
+			LastActivityAt: item.LastActivityAt,
+			UnreadCount:    item.UnreadCount,
+			UnreadMentions: mentionCounts[chat.ID],
+			PinnedPosition: item.PinnedPosition,
+			Archived:       item.Archived,
+			Notifications:  item.Notifications,
+			Draft:          drafts[chat.ID],
+			CreatedAt:      chat.CreatedAt,
+			UpdatedAt:      chat.UpdatedAt,
 		}
-
-		// Добавляем последнее сообщение, если есть
-		if len(chat.Messages) > 0 {
-			response.LastMessage = &chat.Messages[len(chat.Messages)-1]
+		if chat.IsChannel {
+			response.SubscriberCount = item.MemberCount
 		}
 
 		responses = append(responses, response)
 	}
 
-	httputils.ResponseJSON(w, http.StatusOK, responses)
+	return responses
 }
 
 // defaultLogger простой логгер по умолчанию
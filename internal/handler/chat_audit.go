@@ -0,0 +1,208 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+	"tush00nka/bbbab_messenger/internal/model"
+	"tush00nka/bbbab_messenger/internal/pkg/httputils"
+	"tush00nka/bbbab_messenger/internal/service"
+	"tush00nka/bbbab_messenger/internal/ws"
+)
+
+// ChangeRoleRequest запрос на изменение роли участника
+type ChangeRoleRequest struct {
+	Role string `json:"role" binding:"required,oneof=owner admin member"`
+}
+
+// AuditLogResponse страница журнала модерации
+type AuditLogResponse struct {
+	Data       []model.ChatAuditLog `json:"data"`
+	Pagination PaginationInfo       `json:"pagination"`
+}
+
+// GetAuditLog возвращает журнал модерации группы
+// @Summary Get chat audit log
+// @Description Get administrative actions of a group chat, newest first (admins only)
+// @ID get-chat-audit-log
+// @Tags chat
+// @Produce json
+// @Param Authorization header string true "Bearer токен" default(Bearer )
+// @Param chat_id path int true "Chat ID"
+// @Param cursor query int false "ID of the last entry from the previous page"
+// @Param limit query int false "Limit" minimum(1) maximum(100) default(50)
+// @Success 200 {object} AuditLogResponse
+// @Failure 400 {object} httputils.ErrorResponse
+// @Failure 401 {object} httputils.ErrorResponse
+// @Failure 403 {object} httputils.ErrorResponse
+// @Failure 500 {object} httputils.ErrorResponse
+// @Router /chat/{chat_id}/audit [get]
+func (h *ChatHandler) getAuditLog(w http.ResponseWriter, r *http.Request) {
+	claims, err := getClaimsFromContext(r)
+	if err != nil {
+		httputils.ResponseError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	chatID, err := parsePathID(r, "chat_id")
+	if err != nil {
+		httputils.ResponseError(w, http.StatusBadRequest, "invalid chat id")
+		return
+	}
+
+	queryParams := r.URL.Query()
+
+	var beforeID uint
+	if cursor := queryParams.Get("cursor"); cursor != "" {
+		parsed, err := strconv.ParseUint(cursor, 10, 64)
+		if err != nil {
+			httputils.ResponseError(w, http.StatusBadRequest, "invalid cursor")
+			return
+		}
+		beforeID = uint(parsed)
+	}
+
+	limit := 0
+	if limitStr := queryParams.Get("limit"); limitStr != "" {
+		if parsedLimit, err := strconv.Atoi(limitStr); err == nil {
+			limit = parsedLimit
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	if !h.requireChatAdmin(ctx, w, chatID, claims.UserID) {
+		return
+	}
+
+	entries, hasNext, err := h.chatService.GetAuditLog(ctx, chatID, beforeID, limit)
+	if err != nil {
+		h.logger.Error("failed to get audit log", "error", err)
+		httputils.ResponseError(w, http.StatusInternalServerError, "failed to get audit log")
+		return
+	}
+
+	var nextCursor *string
+	if hasNext && len(entries) > 0 {
+		cursor := strconv.FormatUint(uint64(entries[len(entries)-1].ID), 10)
+		nextCursor = &cursor
+	}
+
+	httputils.ResponseJSON(w, http.StatusOK, AuditLogResponse{
+		Data: entries,
+		Pagination: PaginationInfo{
+			NextCursor:  nextCursor,
+			HasNext:     hasNext,
+			HasPrevious: beforeID > 0,
+			Limit:       len(entries),
+		},
+	})
+}
+
+// ChangeMemberRole меняет роль участника группы
+// @Summary Change member role
+// @Description Promote or demote a group member (owner only). Setting role "owner" transfers ownership.
+// @ID change-member-role
+// @Tags chat
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer токен" default(Bearer )
+// @Param chat_id path int true "Chat ID"
+// @Param user_id path int true "User ID"
+// @Param roleData body ChangeRoleRequest true "New role"
+// @Success 200 {object} StatusResponse
+// @Failure 400 {object} httputils.ErrorResponse
+// @Failure 401 {object} httputils.ErrorResponse
+// @Failure 403 {object} httputils.ErrorResponse
+// @Failure 500 {object} httputils.ErrorResponse
+// @Router /chat/{chat_id}/members/{user_id}/role [put]
+func (h *ChatHandler) changeMemberRole(w http.ResponseWriter, r *http.Request) {
+	claims, err := getClaimsFromContext(r)
+	if err != nil {
+		httputils.ResponseError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	chatID, err := parsePathID(r, "chat_id")
+	if err != nil {
+		httputils.ResponseError(w, http.StatusBadRequest, "invalid chat id")
+		return
+	}
+
+	userID, err := parsePathID(r, "user_id")
+	if err != nil {
+		httputils.ResponseError(w, http.StatusBadRequest, "invalid user id")
+		return
+	}
+
+	var req ChangeRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputils.ResponseError(w, http.StatusBadRequest, "invalid request format")
+		return
+	}
+	defer r.Body.Close()
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	oldRole, err := h.chatService.ChangeMemberRole(ctx, chatID, claims.UserID, userID, req.Role)
+	if err != nil {
+		if errors.Is(err, service.ErrCannotModerate) {
+			httputils.ResponseError(w, http.StatusForbidden, "only the chat owner can change roles")
+			return
+		}
+		httputils.ResponseError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if oldRole != req.Role {
+		h.recordAudit(model.ChatAuditLog{
+			ChatID:       chatID,
+			ActorID:      claims.UserID,
+			Action:       model.AuditRoleChanged,
+			TargetUserID: &userID,
+			Before:       oldRole,
+			After:        req.Role,
+		})
+
+		if h.hub != nil {
+			h.hub.BroadcastEvent(chatID, ws.OutEvent{
+				Type:   ws.EventTypeRoleChanged,
+				UserID: userID,
+				Message: map[string]any{
+					"role":     req.Role,
+					"previous": oldRole,
+					"by":       claims.UserID,
+				},
+			})
+		}
+	}
+
+	httputils.ResponseJSON(w, http.StatusOK, StatusResponse{Status: "role changed"})
+}
+
+// recordAudit асинхронно сохраняет запись журнала модерации.
+// Ошибка записи журнала не должна отменять уже выполненное действие, поэтому только логируется.
+func (h *ChatHandler) recordAudit(entry model.ChatAuditLog) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+
+		if err := h.chatService.RecordAudit(ctx, &entry); err != nil {
+			h.logger.Warn("failed to record audit entry", "action", entry.Action, "error", err)
+		}
+	}()
+}
+
+// auditJSON сериализует составное значение для полей Before/After журнала
+func auditJSON(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
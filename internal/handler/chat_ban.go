@@ -0,0 +1,337 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+	"tush00nka/bbbab_messenger/internal/model"
+	"tush00nka/bbbab_messenger/internal/pkg/httputils"
+	"tush00nka/bbbab_messenger/internal/service"
+	"tush00nka/bbbab_messenger/internal/ws"
+)
+
+// BanUserRequest запрос на блокировку участника
+type BanUserRequest struct {
+	UserID   uint   `json:"user_id" binding:"required"`
+	Reason   string `json:"reason" binding:"max=500"`
+	Duration int64  `json:"duration"` // длительность в секундах, 0 — бессрочно
+}
+
+// KickMemberRequest запрос на исключение участника
+type KickMemberRequest struct {
+	Reason string `json:"reason" binding:"max=500"`
+}
+
+// BanUser блокирует участника группы
+// @Summary Ban chat member
+// @Description Ban a user from a group chat with an optional reason and duration (admins only).
+// @Description The user is removed from the chat and disconnected from its websocket room.
+// @ID ban-chat-member
+// @Tags chat
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer токен" default(Bearer )
+// @Param chat_id path int true "Chat ID"
+// @Param banData body BanUserRequest true "Ban settings"
+// @Success 201 {object} model.ChatBan
+// @Failure 400 {object} httputils.ErrorResponse
+// @Failure 401 {object} httputils.ErrorResponse
+// @Failure 403 {object} httputils.ErrorResponse
+// @Failure 500 {object} httputils.ErrorResponse
+// @Router /chat/{chat_id}/bans [post]
+func (h *ChatHandler) banUser(w http.ResponseWriter, r *http.Request) {
+	claims, err := getClaimsFromContext(r)
+	if err != nil {
+		httputils.ResponseError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	chatID, err := parsePathID(r, "chat_id")
+	if err != nil {
+		httputils.ResponseError(w, http.StatusBadRequest, "invalid chat id")
+		return
+	}
+
+	var req BanUserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputils.ResponseError(w, http.StatusBadRequest, "invalid request format")
+		return
+	}
+	defer r.Body.Close()
+
+	if req.UserID == 0 {
+		httputils.ResponseError(w, http.StatusBadRequest, "user_id is required")
+		return
+	}
+	// Проверяем до перевода в time.Duration: большое значение при умножении переполнится
+	if req.Duration < 0 || req.Duration > int64(service.MaxBanDuration/time.Second) {
+		httputils.ResponseError(w, http.StatusBadRequest,
+			fmt.Sprintf("duration must be between 0 and %d seconds", int64(service.MaxBanDuration/time.Second)))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	if !h.requireChatAdmin(ctx, w, chatID, claims.UserID) {
+		return
+	}
+
+	ban, err := h.chatService.BanUser(ctx, chatID, claims.UserID, req.UserID, req.Reason, time.Duration(req.Duration)*time.Second)
+	if err != nil {
+		if errors.Is(err, service.ErrCannotModerate) {
+			httputils.ResponseError(w, http.StatusForbidden, err.Error())
+			return
+		}
+		httputils.ResponseError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	h.disconnectRemovedMember(chatID, req.UserID, ws.OutEvent{
+		Type:   ws.EventTypeUserBanned,
+		UserID: req.UserID,
+		Meta: map[string]any{
+			"reason":     ban.Reason,
+			"expires_at": ban.ExpiresAt,
+			"by":         claims.UserID,
+		},
+	})
+
+	h.recordAudit(model.ChatAuditLog{
+		ChatID:       chatID,
+		ActorID:      claims.UserID,
+		Action:       model.AuditUserBanned,
+		TargetUserID: &ban.UserID,
+		After:        auditJSON(map[string]any{"reason": ban.Reason, "expires_at": ban.ExpiresAt}),
+	})
+
+	httputils.ResponseJSON(w, http.StatusCreated, ban)
+}
+
+// ListBans возвращает действующие блокировки группы
+// @Summary List chat bans
+// @Description List active bans of a group chat (admins only)
+// @ID list-chat-bans
+// @Tags chat
+// @Produce json
+// @Param Authorization header string true "Bearer токен" default(Bearer )
+// @Param chat_id path int true "Chat ID"
+// @Success 200 {array} model.ChatBan
+// @Failure 400 {object} httputils.ErrorResponse
+// @Failure 401 {object} httputils.ErrorResponse
+// @Failure 403 {object} httputils.ErrorResponse
+// @Failure 500 {object} httputils.ErrorResponse
+// @Router /chat/{chat_id}/bans [get]
+func (h *ChatHandler) listBans(w http.ResponseWriter, r *http.Request) {
+	claims, err := getClaimsFromContext(r)
+	if err != nil {
+		httputils.ResponseError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	chatID, err := parsePathID(r, "chat_id")
+	if err != nil {
+		httputils.ResponseError(w, http.StatusBadRequest, "invalid chat id")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	if !h.requireChatAdmin(ctx, w, chatID, claims.UserID) {
+		return
+	}
+
+	bans, err := h.chatService.GetChatBans(ctx, chatID)
+	if err != nil {
+		h.logger.Error("failed to get chat bans", "error", err)
+		httputils.ResponseError(w, http.StatusInternalServerError, "failed to get chat bans")
+		return
+	}
+
+	httputils.ResponseJSON(w, http.StatusOK, bans)
+}
+
+// UnbanUser снимает блокировку участника
+// @Summary Unban chat member
+// @Description Lift a ban so the user can be added to the group again (admins only)
+// @ID unban-chat-member
+// @Tags chat
+// @Produce json
+// @Param Authorization header string true "Bearer токен" default(Bearer )
+// @Param chat_id path int true "Chat ID"
+// @Param user_id path int true "User ID"
+// @Success 200 {object} StatusResponse
+// @Failure 400 {object} httputils.ErrorResponse
+// @Failure 401 {object} httputils.ErrorResponse
+// @Failure 403 {object} httputils.ErrorResponse
+// @Failure 404 {object} httputils.ErrorResponse
+// @Failure 500 {object} httputils.ErrorResponse
+// @Router /chat/{chat_id}/bans/{user_id} [delete]
+func (h *ChatHandler) unbanUser(w http.ResponseWriter, r *http.Request) {
+	claims, err := getClaimsFromContext(r)
+	if err != nil {
+		httputils.ResponseError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	chatID, err := parsePathID(r, "chat_id")
+	if err != nil {
+		httputils.ResponseError(w, http.StatusBadRequest, "invalid chat id")
+		return
+	}
+
+	userID, err := parsePathID(r, "user_id")
+	if err != nil {
+		httputils.ResponseError(w, http.StatusBadRequest, "invalid user id")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	if !h.requireChatAdmin(ctx, w, chatID, claims.UserID) {
+		return
+	}
+
+	if err := h.chatService.UnbanUser(ctx, chatID, userID); err != nil {
+		if errors.Is(err, service.ErrBanNotFound) {
+			httputils.ResponseError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		h.logger.Error("failed to unban user", "error", err)
+		httputils.ResponseError(w, http.StatusInternalServerError, "failed to unban user")
+		return
+	}
+
+	h.recordAudit(model.ChatAuditLog{
+		ChatID:       chatID,
+		ActorID:      claims.UserID,
+		Action:       model.AuditUserUnbanned,
+		TargetUserID: &userID,
+	})
+
+	httputils.ResponseJSON(w, http.StatusOK, StatusResponse{Status: "unbanned"})
+}
+
+// KickMember исключает участника группы
+// @Summary Kick chat member
+// @Description Remove a member from a group chat with an optional reason without banning (admins only)
+// @ID kick-chat-member
+// @Tags chat
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer токен" default(Bearer )
+// @Param chat_id path int true "Chat ID"
+// @Param user_id path int true "User ID"
+// @Param kickData body KickMemberRequest false "Kick reason"
+// @Success 200 {object} StatusResponse
+// @Failure 400 {object} httputils.ErrorResponse
+// @Failure 401 {object} httputils.ErrorResponse
+// @Failure 403 {object} httputils.ErrorResponse
+// @Failure 500 {object} httputils.ErrorResponse
+// @Router /chat/{chat_id}/kick/{user_id} [post]
+func (h *ChatHandler) kickMember(w http.ResponseWriter, r *http.Request) {
+	h.removeMember(w, r, model.AuditUserKicked)
+}
+
+// removeMember исключает участника из чата; auditAction различает исключение
+// через /kick и удаление через /remove в журнале модерации
+func (h *ChatHandler) removeMember(w http.ResponseWriter, r *http.Request, auditAction string) {
+	claims, err := getClaimsFromContext(r)
+	if err != nil {
+		httputils.ResponseError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	chatID, err := parsePathID(r, "chat_id")
+	if err != nil {
+		httputils.ResponseError(w, http.StatusBadRequest, "invalid chat id")
+		return
+	}
+
+	userID, err := parsePathID(r, "user_id")
+	if err != nil {
+		httputils.ResponseError(w, http.StatusBadRequest, "invalid user id")
+		return
+	}
+
+	// Причина необязательна, пустое тело допустимо
+	var req KickMemberRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		httputils.ResponseError(w, http.StatusBadRequest, "invalid request format")
+		return
+	}
+	defer r.Body.Close()
+
+	if len(req.Reason) > 500 {
+		httputils.ResponseError(w, http.StatusBadRequest, "reason too long (max 500 characters)")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	if !h.requireChatAdmin(ctx, w, chatID, claims.UserID) {
+		return
+	}
+
+	if err := h.chatService.KickMember(ctx, chatID, claims.UserID, userID); err != nil {
+		if errors.Is(err, service.ErrCannotModerate) {
+			httputils.ResponseError(w, http.StatusForbidden, err.Error())
+			return
+		}
+		httputils.ResponseError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	h.disconnectRemovedMember(chatID, userID, ws.OutEvent{
+		Type:   ws.EventTypeUserKicked,
+		UserID: userID,
+		Meta: map[string]any{
+			"reason": req.Reason,
+			"by":     claims.UserID,
+		},
+	})
+
+	h.recordAudit(model.ChatAuditLog{
+		ChatID:       chatID,
+		ActorID:      claims.UserID,
+		Action:       auditAction,
+		TargetUserID: &userID,
+		After:        req.Reason,
+	})
+
+	httputils.ResponseJSON(w, http.StatusOK, StatusResponse{Status: "kicked"})
+}
+
+// disconnectRemovedMember отключает исключенного участника от комнаты чата,
+// уведомляет остальных участников и очищает его присутствие в кеше
+func (h *ChatHandler) disconnectRemovedMember(chatID, userID uint, ev ws.OutEvent) {
+	if h.hub != nil {
+		h.hub.DisconnectUser(chatID, userID, ev)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+
+		// Для каналов список подписчиков скрыт
+		if chat, err := h.chatService.GetChatMeta(ctx, chatID); err == nil && chat != nil && !chat.IsChannel {
+			h.hub.BroadcastEvent(chatID, ev)
+		}
+	}
+
+	if h.chatCacheService != nil {
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), PresenceTimeout)
+			defer cancel()
+
+			if err := h.chatCacheService.UserLeft(ctx, chatID, userID); err != nil {
+				h.logger.Warn("failed to clear user presence", "error", err)
+			}
+		}()
+	}
+}
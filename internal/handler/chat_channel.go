@@ -0,0 +1,170 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+	"tush00nka/bbbab_messenger/internal/pkg/httputils"
+	"tush00nka/bbbab_messenger/internal/service"
+)
+
+// CreateChannelRequest запрос на создание канала
+type CreateChannelRequest struct {
+	Name        string `json:"name" binding:"required,min=1,max=100"`
+	Description string `json:"description" binding:"max=1000"`
+}
+
+// CreateChannel создает канал
+// @Summary Create channel
+// @Description Create a broadcast channel: only admins can post, subscribers can only read
+// @ID create-channel
+// @Tags chat
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer токен" default(Bearer )
+// @Param channelData body CreateChannelRequest true "Channel data"
+// @Success 201 {object} model.Chat
+// @Failure 400 {object} httputils.ErrorResponse
+// @Failure 401 {object} httputils.ErrorResponse
+// @Failure 500 {object} httputils.ErrorResponse
+// @Router /chat/channel/create [post]
+func (h *ChatHandler) createChannel(w http.ResponseWriter, r *http.Request) {
+	claims, err := getClaimsFromContext(r)
+	if err != nil {
+		httputils.ResponseError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req CreateChannelRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputils.ResponseError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	defer r.Body.Close()
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		httputils.ResponseError(w, http.StatusBadRequest, "channel name is required")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	chat, err := h.chatService.CreateChannel(ctx, claims.UserID, req.Name, req.Description)
+	if err != nil {
+		h.logger.Error("failed to create channel", "error", err)
+		httputils.ResponseError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	httputils.ResponseJSON(w, http.StatusCreated, chat)
+}
+
+// SubscribeToChannel подписка на канал
+// @Summary Subscribe to channel
+// @Description Subscribe current user to a channel
+// @ID subscribe-channel
+// @Tags chat
+// @Produce json
+// @Param Authorization header string true "Bearer токен" default(Bearer )
+// @Param chat_id path int true "Chat ID"
+// @Success 200 {object} StatusResponse
+// @Failure 400 {object} httputils.ErrorResponse
+// @Failure 401 {object} httputils.ErrorResponse
+// @Failure 404 {object} httputils.ErrorResponse
+// @Failure 409 {object} httputils.ErrorResponse
+// @Failure 500 {object} httputils.ErrorResponse
+// @Router /chat/{chat_id}/subscribe [post]
+func (h *ChatHandler) subscribeToChannel(w http.ResponseWriter, r *http.Request) {
+	claims, err := getClaimsFromContext(r)
+	if err != nil {
+		httputils.ResponseError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	chatID, err := parsePathID(r, "chat_id")
+	if err != nil {
+		httputils.ResponseError(w, http.StatusBadRequest, "invalid chat id")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := h.chatService.SubscribeToChannel(ctx, chatID, claims.UserID); err != nil {
+		switch {
+		case errors.Is(err, service.ErrNotAChannel):
+			httputils.ResponseError(w, http.StatusNotFound, err.Error())
+		case errors.Is(err, service.ErrAlreadyMember):
+			httputils.ResponseError(w, http.StatusConflict, err.Error())
+		case errors.Is(err, service.ErrUserBanned):
+			httputils.ResponseError(w, http.StatusForbidden, err.Error())
+		default:
+			h.logger.Error("failed to subscribe to channel", "error", err)
+			httputils.ResponseError(w, http.StatusInternalServerError, "failed to subscribe to channel")
+		}
+		return
+	}
+
+	httputils.ResponseJSON(w, http.StatusOK, StatusResponse{Status: "subscribed"})
+}
+
+// UnsubscribeFromChannel отписка от канала
+// @Summary Unsubscribe from channel
+// @Description Unsubscribe current user from a channel
+// @ID unsubscribe-channel
+// @Tags chat
+// @Produce json
+// @Param Authorization header string true "Bearer токен" default(Bearer )
+// @Param chat_id path int true "Chat ID"
+// @Success 200 {object} StatusResponse
+// @Failure 400 {object} httputils.ErrorResponse
+// @Failure 401 {object} httputils.ErrorResponse
+// @Failure 404 {object} httputils.ErrorResponse
+// @Router /chat/{chat_id}/unsubscribe [post]
+func (h *ChatHandler) unsubscribeFromChannel(w http.ResponseWriter, r *http.Request) {
+	claims, err := getClaimsFromContext(r)
+	if err != nil {
+		httputils.ResponseError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	chatID, err := parsePathID(r, "chat_id")
+	if err != nil {
+		httputils.ResponseError(w, http.StatusBadRequest, "invalid chat id")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := h.chatService.UnsubscribeFromChannel(ctx, chatID, claims.UserID); err != nil {
+		if errors.Is(err, service.ErrNotAChannel) {
+			httputils.ResponseError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		httputils.ResponseError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	httputils.ResponseJSON(w, http.StatusOK, StatusResponse{Status: "unsubscribed"})
+}
+
+// broadcastUserJoined уведомляет участников о новом участнике.
+// Для каналов событие не рассылается: список подписчиков скрыт.
+func (h *ChatHandler) broadcastUserJoined(ctx context.Context, chatID, userID uint, meta any) {
+	if h.hub == nil {
+		return
+	}
+
+	chat, err := h.chatService.GetChatMeta(ctx, chatID)
+	if err != nil || chat == nil || chat.IsChannel {
+		return
+	}
+
+	h.hub.BroadcastUserJoined(chatID, userID, meta)
+}
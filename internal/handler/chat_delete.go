@@ -0,0 +1,231 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+	"tush00nka/bbbab_messenger/internal/model"
+	"tush00nka/bbbab_messenger/internal/pkg/httputils"
+	"tush00nka/bbbab_messenger/internal/service"
+	"tush00nka/bbbab_messenger/internal/ws"
+)
+
+// Области удаления сообщений
+const (
+	DeleteScopeEveryone = "everyone"
+	DeleteScopeMe       = "me"
+)
+
+// DeleteMessagesRequest запрос на удаление выбранных сообщений
+type DeleteMessagesRequest struct {
+	MessageIDs []uint `json:"message_ids" binding:"required,min=1,max=100"`
+	Scope      string `json:"scope" binding:"oneof=everyone me" default:"everyone"`
+}
+
+// DeleteMessagesResponse результат удаления выбранных сообщений
+type DeleteMessagesResponse struct {
+	MessageIDs []uint `json:"message_ids"`
+	Scope      string `json:"scope"`
+}
+
+// DeleteMessages удаляет выбранные сообщения чата
+// @Summary Delete selected messages
+// @Description Bulk delete messages of one chat. scope=everyone removes them for all members (own messages within 48 hours, any messages for group admins), scope=me hides them only for the current user. The operation is all-or-nothing
+// @ID delete-messages
+// @Tags chat
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer токен" default(Bearer )
+// @Param chat_id path int true "Chat ID"
+// @Param deleteData body DeleteMessagesRequest true "Messages to delete"
+// @Success 200 {object} DeleteMessagesResponse
+// @Failure 400 {object} httputils.ErrorResponse
+// @Failure 401 {object} httputils.ErrorResponse
+// @Failure 403 {object} httputils.ErrorResponse
+// @Failure 404 {object} httputils.ErrorResponse
+// @Failure 500 {object} httputils.ErrorResponse
+// @Router /chat/{chat_id}/messages/delete [post]
+func (h *ChatHandler) deleteMessages(w http.ResponseWriter, r *http.Request) {
+	claims, err := getClaimsFromContext(r)
+	if err != nil {
+		httputils.ResponseError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	chatID, err := parsePathID(r, "chat_id")
+	if err != nil {
+		httputils.ResponseError(w, http.StatusBadRequest, "invalid chat id")
+		return
+	}
+
+	var req DeleteMessagesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputils.ResponseError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	defer r.Body.Close()
+
+	forEveryone, ok := parseDeleteScope(req.Scope)
+	if !ok {
+		httputils.ResponseError(w, http.StatusBadRequest, "scope must be 'everyone' or 'me'")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	if !h.deleteMessagesInChat(ctx, w, claims.UserID, chatID, req.MessageIDs, forEveryone) {
+		return
+	}
+
+	scope := DeleteScopeMe
+	if forEveryone {
+		scope = DeleteScopeEveryone
+	}
+	httputils.ResponseJSON(w, http.StatusOK, DeleteMessagesResponse{
+		MessageIDs: req.MessageIDs,
+		Scope:      scope,
+	})
+}
+
+// parseDeleteScope разбирает область удаления; по умолчанию сообщение удаляется у всех
+func parseDeleteScope(scope string) (forEveryone bool, ok bool) {
+	switch scope {
+	case "", DeleteScopeEveryone:
+		return true, true
+	case DeleteScopeMe:
+		return false, true
+	default:
+		return false, false
+	}
+}
+
+// deleteMessagesInChat удаляет сообщения через сервис и уведомляет клиентов.
+// При ошибке сам пишет ответ и возвращает false.
+func (h *ChatHandler) deleteMessagesInChat(
+	ctx context.Context,
+	w http.ResponseWriter,
+	userID, chatID uint,
+	messageIDs []uint,
+	forEveryone bool,
+) bool {
+	messages, pinnedIDs, err := h.chatService.DeleteMessages(ctx, userID, chatID, messageIDs, forEveryone)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrNothingToDelete),
+			errors.Is(err, service.ErrTooManyToDelete):
+			httputils.ResponseError(w, http.StatusBadRequest, err.Error())
+		case errors.Is(err, service.ErrMessageNotInChat):
+			httputils.ResponseError(w, http.StatusNotFound, err.Error())
+		case errors.Is(err, service.ErrNotChatMember),
+			errors.Is(err, service.ErrDeleteNotAllowed),
+			errors.Is(err, service.ErrDeleteWindowExpired):
+			httputils.ResponseError(w, http.StatusForbidden, err.Error())
+		default:
+			h.logger.Error("failed to delete messages", "error", err)
+			httputils.ResponseError(w, http.StatusInternalServerError, "failed to delete message")
+		}
+		return false
+	}
+
+	if !forEveryone {
+		h.notifyMessagesHidden(userID, chatID, messages)
+		return true
+	}
+
+	// Закрепления удаляются вместе с сообщениями, но клиентов нужно уведомить отдельно
+	for _, id := range pinnedIDs {
+		h.broadcastMessageUnpinned(chatID, id, userID)
+	}
+
+	// В журнал модерации попадают только удаления в группах
+	if chat, err := h.chatService.GetChatMeta(ctx, chatID); err == nil && chat != nil && chat.IsGroup {
+		for _, msg := range messages {
+			h.recordAudit(model.ChatAuditLog{
+				ChatID:          chatID,
+				ActorID:         userID,
+				Action:          model.AuditMessageDeleted,
+				TargetUserID:    &msg.SenderID,
+				TargetMessageID: &msg.ID,
+				Before:          msg.Message,
+			})
+		}
+	}
+
+	// Асинхронно чистим Redis
+	if h.chatCacheService != nil {
+		go func(messages []model.Message) {
+			ctxCache, cancelCache := context.WithTimeout(context.Background(), 3*time.Second)
+			defer cancelCache()
+
+			for _, msg := range messages {
+				if err := h.chatCacheService.DeleteMessage(ctxCache, msg.ChatID, msg.ID); err != nil {
+					h.logger.Warn("failed to delete message from cache", "error", err)
+				}
+			}
+		}(messages)
+	}
+
+	// Уведомляем всех WS-клиентов чата
+	for _, msg := range messages {
+		h.broadcastMessageDeleted(msg.ChatID, msg.ID)
+	}
+
+	return true
+}
+
+// notifyMessagesHidden синхронизирует удаление «только у себя» между устройствами пользователя
+func (h *ChatHandler) notifyMessagesHidden(userID, chatID uint, messages []model.Message) {
+	if h.hub == nil {
+		return
+	}
+
+	ids := make([]uint, len(messages))
+	for i, msg := range messages {
+		ids[i] = msg.ID
+	}
+
+	h.hub.SendToUser(userID, ws.OutEvent{
+		Type:   ws.EventTypeMessagesHidden,
+		ChatID: chatID,
+		UserID: userID,
+		Meta:   map[string]any{"message_ids": ids},
+	})
+}
+
+// filterHiddenMessages убирает из общей истории чата сообщения, скрытые пользователем
+// по одному или очисткой истории
+func (h *ChatHandler) filterHiddenMessages(ctx context.Context, userID, chatID uint, messages []model.Message) []model.Message {
+	if len(messages) == 0 || userID == 0 {
+		return messages
+	}
+
+	clearedUpTo, err := h.chatService.GetHistoryClearedUpTo(ctx, chatID, userID)
+	if err != nil {
+		h.logger.Warn("failed to get history watermark", "error", err)
+	}
+
+	hiddenIDs, err := h.chatService.GetHiddenMessageIDs(ctx, userID, chatID)
+	if err != nil {
+		h.logger.Warn("failed to get hidden messages", "error", err)
+	}
+	if clearedUpTo == 0 && len(hiddenIDs) == 0 {
+		return messages
+	}
+
+	hidden := make(map[uint]bool, len(hiddenIDs))
+	for _, id := range hiddenIDs {
+		hidden[id] = true
+	}
+
+	visible := make([]model.Message, 0, len(messages))
+	for _, msg := range messages {
+		if msg.ID > clearedUpTo && !hidden[msg.ID] {
+			visible = append(visible, msg)
+		}
+	}
+
+	return visible
+}
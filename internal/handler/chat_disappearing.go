@@ -0,0 +1,218 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+	"tush00nka/bbbab_messenger/internal/model"
+	"tush00nka/bbbab_messenger/internal/pkg/httputils"
+)
+
+// Параметры удаления исчезающих сообщений
+const (
+	MessageReaperInterval = 5 * time.Second
+	MessageReaperBatch    = 100
+)
+
+// MessageTimerRequest запрос на настройку исчезающих сообщений
+type MessageTimerRequest struct {
+	Seconds int `json:"seconds" binding:"min=0"` // 0 — выключить
+}
+
+// SetMessageTimer настраивает время жизни новых сообщений чата
+// @Summary Set disappearing messages timer
+// @Description Set lifetime of new messages in seconds (0 disables). In groups only admins can change it. A system message is posted into the chat
+// @ID set-message-timer
+// @Tags chat
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer токен" default(Bearer )
+// @Param chat_id path int true "Chat ID"
+// @Param timerData body MessageTimerRequest true "Message lifetime"
+// @Success 200 {object} model.Chat
+// @Failure 400 {object} httputils.ErrorResponse
+// @Failure 401 {object} httputils.ErrorResponse
+// @Failure 403 {object} httputils.ErrorResponse
+// @Failure 404 {object} httputils.ErrorResponse
+// @Failure 500 {object} httputils.ErrorResponse
+// @Router /chat/{chat_id}/timer [put]
+func (h *ChatHandler) setMessageTimer(w http.ResponseWriter, r *http.Request) {
+	claims, err := getClaimsFromContext(r)
+	if err != nil {
+		httputils.ResponseError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	chatID, err := parsePathID(r, "chat_id")
+	if err != nil {
+		httputils.ResponseError(w, http.StatusBadRequest, "invalid chat id")
+		return
+	}
+
+	var req MessageTimerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputils.ResponseError(w, http.StatusBadRequest, "invalid request format")
+		return
+	}
+	defer r.Body.Close()
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	chat, err := h.chatService.GetChatByID(ctx, chatID)
+	if err != nil {
+		h.logger.Error("failed to get chat", "error", err)
+		httputils.ResponseError(w, http.StatusInternalServerError, "failed to get chat")
+		return
+	}
+	if chat == nil {
+		httputils.ResponseError(w, http.StatusNotFound, "chat not found")
+		return
+	}
+
+	// В личных чатах таймер может менять любой участник, в группах — только администраторы
+	if chat.IsGroup {
+		if !h.requireChatAdmin(ctx, w, chatID, claims.UserID) {
+			return
+		}
+	} else {
+		isMember, err := h.chatService.IsUserInChat(ctx, chatID, claims.UserID)
+		if err != nil || !isMember {
+			httputils.ResponseError(w, http.StatusForbidden, "user is not a member of this chat")
+			return
+		}
+	}
+
+	if chat.MessageTTLSeconds == req.Seconds {
+		h.fillChatAvatarURL(ctx, chat)
+		httputils.ResponseJSON(w, http.StatusOK, chat)
+		return
+	}
+
+	if err := h.chatService.SetMessageTTL(ctx, chatID, req.Seconds); err != nil {
+		httputils.ResponseError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if chat.IsGroup {
+		h.recordAudit(model.ChatAuditLog{
+			ChatID:  chatID,
+			ActorID: claims.UserID,
+			Action:  model.AuditMessageTTLChanged,
+			Before:  strconv.Itoa(chat.MessageTTLSeconds),
+			After:   strconv.Itoa(req.Seconds),
+		})
+	}
+
+	chat.MessageTTLSeconds = req.Seconds
+	h.fillChatAvatarURL(ctx, chat)
+	h.broadcastChatUpdated(chat, claims.UserID)
+
+	// Служебное сообщение видно всем участникам и само подчиняется новому таймеру
+	notice, err := h.chatService.SendSystemMessage(ctx, chatID, claims.UserID, messageTimerNotice(req.Seconds))
+	if err != nil {
+		h.logger.Warn("failed to post message timer notice", "error", err)
+	} else {
+		h.publishMessage(chat, *notice)
+	}
+
+	httputils.ResponseJSON(w, http.StatusOK, chat)
+}
+
+// messageTimerNotice текст служебного сообщения об изменении таймера
+func messageTimerNotice(seconds int) string {
+	if seconds == 0 {
+		return "Disappearing messages turned off"
+	}
+
+	return fmt.Sprintf("Disappearing messages turned on: new messages will be deleted after %s",
+		(time.Duration(seconds) * time.Second).String())
+}
+
+// RunMessageReaper периодически удаляет исчезающие сообщения с истекшим сроком жизни.
+// Блокирует вызывающую горутину до отмены ctx.
+func (h *ChatHandler) RunMessageReaper(ctx context.Context) {
+	ticker := time.NewTicker(MessageReaperInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			h.reapExpiredMessages(ctx)
+		}
+	}
+}
+
+// reapExpiredMessages удаляет пачки истекших сообщений, пока они не закончатся
+func (h *ChatHandler) reapExpiredMessages(ctx context.Context) {
+	for {
+		reapCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		expired, err := h.chatService.DeleteExpiredMessages(reapCtx, MessageReaperBatch)
+		if err != nil {
+			cancel()
+			h.logger.Error("failed to delete expired messages", "error", err)
+			return
+		}
+
+		h.cleanupDeletedMessages(reapCtx, expired)
+		cancel()
+
+		if len(expired) < MessageReaperBatch || ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// cleanupDeletedMessages убирает удаленные из БД сообщения из кеша Redis и хранилища
+// вложений и рассылает message_deleted
+func (h *ChatHandler) cleanupDeletedMessages(ctx context.Context, messages []model.Message) {
+	attachments := make(map[string]bool)
+
+	for _, msg := range messages {
+		if h.chatCacheService != nil {
+			if err := h.chatCacheService.DeleteMessage(ctx, msg.ChatID, msg.ID); err != nil {
+				h.logger.Warn("failed to delete message from cache", "error", err)
+			}
+		}
+
+		if msg.AttachmentURL != nil && *msg.AttachmentURL != "" {
+			attachments[*msg.AttachmentURL] = true
+		}
+
+		h.broadcastMessageDeleted(msg.ChatID, msg.ID)
+	}
+
+	urls := make([]string, 0, len(attachments))
+	for attachmentURL := range attachments {
+		urls = append(urls, attachmentURL)
+	}
+	h.deleteUnreferencedAttachments(ctx, urls)
+}
+
+// deleteUnreferencedAttachments удаляет из хранилища вложения, на которые больше не ссылаются сообщения
+func (h *ChatHandler) deleteUnreferencedAttachments(ctx context.Context, attachmentURLs []string) {
+	if h.s3Service == nil {
+		return
+	}
+
+	for _, attachmentURL := range attachmentURLs {
+		// Пересланные копии ссылаются на тот же объект
+		referenced, err := h.chatService.IsAttachmentReferenced(ctx, attachmentURL)
+		if err != nil {
+			h.logger.Warn("failed to check attachment references", "error", err)
+			continue
+		}
+		if referenced {
+			continue
+		}
+
+		if err := h.s3Service.DeleteMessageAttachment(ctx, attachmentURL); err != nil {
+			h.logger.Warn("failed to delete message attachment", "error", err)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+	"tush00nka/bbbab_messenger/internal/model"
+	"tush00nka/bbbab_messenger/internal/pkg/httputils"
+	"tush00nka/bbbab_messenger/internal/service"
+	"tush00nka/bbbab_messenger/internal/ws"
+)
+
+// SaveDraftRequest запрос на сохранение черновика
+type SaveDraftRequest struct {
+	Text string `json:"text"`
+	// Entities разметка текста: смещения и длины в кодовых единицах UTF-16
+	Entities  model.MessageEntities `json:"entities,omitempty"`
+	ReplyToID *uint                 `json:"reply_to_id,omitempty"`
+	// DeviceID идентификатор устройства-источника, возвращается в событии draft,
+	// чтобы клиент мог не применять собственное изменение повторно
+	DeviceID string `json:"device_id,omitempty"`
+}
+
+// SaveDraft сохраняет черновик сообщения в чате
+// @Summary Save chat draft
+// @Description Save the current user's message draft for the chat. Empty text without reply removes the draft. Other devices of the user receive a draft event.
+// @ID save-chat-draft
+// @Tags chat
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer токен" default(Bearer )
+// @Param chat_id path int true "Chat ID"
+// @Param request body SaveDraftRequest true "Draft"
+// @Success 200 {object} model.ChatDraft
+// @Failure 400 {object} httputils.ErrorResponse
+// @Failure 401 {object} httputils.ErrorResponse
+// @Failure 403 {object} httputils.ErrorResponse
+// @Failure 500 {object} httputils.ErrorResponse
+// @Router /chat/{chat_id}/draft [put]
+func (h *ChatHandler) saveDraft(w http.ResponseWriter, r *http.Request) {
+	claims, err := getClaimsFromContext(r)
+	if err != nil {
+		httputils.ResponseError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	chatID, err := parsePathID(r, "chat_id")
+	if err != nil {
+		httputils.ResponseError(w, http.StatusBadRequest, "invalid chat id")
+		return
+	}
+
+	var req SaveDraftRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputils.ResponseError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	draft, err := h.chatService.SaveDraft(ctx, &model.ChatDraft{
+		UserID:    claims.UserID,
+		ChatID:    chatID,
+		Text:      req.Text,
+		Entities:  req.Entities,
+		ReplyToID: req.ReplyToID,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrDraftTooLong),
+			errors.Is(err, service.ErrInvalidEntities):
+			httputils.ResponseError(w, http.StatusBadRequest, err.Error())
+		case errors.Is(err, service.ErrNotChatMember):
+			httputils.ResponseError(w, http.StatusForbidden, err.Error())
+		default:
+			h.logger.Error("failed to save draft", "error", err)
+			httputils.ResponseError(w, http.StatusInternalServerError, "failed to save draft")
+		}
+		return
+	}
+
+	h.broadcastDraft(draft, req.DeviceID)
+
+	httputils.ResponseJSON(w, http.StatusOK, draft)
+}
+
+// GetDraft возвращает черновик сообщения в чате
+// @Summary Get chat draft
+// @Description Get the current user's message draft for the chat; an empty draft is returned when there is none
+// @ID get-chat-draft
+// @Tags chat
+// @Produce json
+// @Param Authorization header string true "Bearer токен" default(Bearer )
+// @Param chat_id path int true "Chat ID"
+// @Success 200 {object} model.ChatDraft
+// @Failure 400 {object} httputils.ErrorResponse
+// @Failure 401 {object} httputils.ErrorResponse
+// @Failure 500 {object} httputils.ErrorResponse
+// @Router /chat/{chat_id}/draft [get]
+func (h *ChatHandler) getDraft(w http.ResponseWriter, r *http.Request) {
+	claims, err := getClaimsFromContext(r)
+	if err != nil {
+		httputils.ResponseError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	chatID, err := parsePathID(r, "chat_id")
+	if err != nil {
+		httputils.ResponseError(w, http.StatusBadRequest, "invalid chat id")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	draft, err := h.chatService.GetDraft(ctx, claims.UserID, chatID)
+	if err != nil {
+		h.logger.Error("failed to get draft", "error", err)
+		httputils.ResponseError(w, http.StatusInternalServerError, "failed to get draft")
+		return
+	}
+
+	httputils.ResponseJSON(w, http.StatusOK, draft)
+}
+
+// broadcastDraft рассылает событие draft во все соединения владельца черновика
+func (h *ChatHandler) broadcastDraft(draft *model.ChatDraft, deviceID string) {
+	if h.hub == nil || draft == nil {
+		return
+	}
+
+	ev := ws.OutEvent{
+		Type:    ws.EventTypeDraft,
+		ChatID:  draft.ChatID,
+		UserID:  draft.UserID,
+		Message: draft,
+	}
+	if deviceID != "" {
+		ev.Meta = map[string]any{"device_id": deviceID}
+	}
+
+	h.hub.SendToUser(draft.UserID, ev)
+}
+
+// clearDraft удаляет черновик после отправки сообщения и сообщает об этом остальным устройствам
+func (h *ChatHandler) clearDraft(userID, chatID uint) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		deleted, err := h.chatService.DeleteDraft(ctx, userID, chatID)
+		if err != nil {
+			h.logger.Warn("failed to delete draft", "error", err)
+			return
+		}
+		if deleted {
+			h.broadcastDraft(&model.ChatDraft{UserID: userID, ChatID: chatID}, "")
+		}
+	}()
+}
@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+	"tush00nka/bbbab_messenger/internal/model"
+	"tush00nka/bbbab_messenger/internal/pkg/httputils"
+	"tush00nka/bbbab_messenger/internal/service"
+)
+
+// ForwardMessagesRequest запрос на пересылку сообщений
+type ForwardMessagesRequest struct {
+	MessageIDs []uint `json:"message_ids" binding:"required"`
+	ChatIDs    []uint `json:"chat_ids" binding:"required"`
+}
+
+// ForwardMessages пересылает сообщения в другие чаты
+// @Summary Forward messages
+// @Description Copy messages (text and attachments) into other chats of the current user. Origin is kept unless the author hides it in privacy settings
+// @ID forward-messages
+// @Tags chat
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer токен" default(Bearer )
+// @Param forwardData body ForwardMessagesRequest true "Messages and target chats"
+// @Success 201 {array} model.Message
+// @Failure 400 {object} httputils.ErrorResponse
+// @Failure 401 {object} httputils.ErrorResponse
+// @Failure 403 {object} httputils.ErrorResponse
+// @Failure 404 {object} httputils.ErrorResponse
+// @Failure 429 {object} SlowModeErrorResponse
+// @Failure 500 {object} httputils.ErrorResponse
+// @Router /chat/forward [post]
+func (h *ChatHandler) forwardMessages(w http.ResponseWriter, r *http.Request) {
+	claims, err := getClaimsFromContext(r)
+	if err != nil {
+		httputils.ResponseError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req ForwardMessagesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputils.ResponseError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	defer r.Body.Close()
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	// Копии сохраняются все вместе, поэтому при ошибке рассылать нечего
+	messages, err := h.chatService.ForwardMessages(ctx, claims.UserID, req.MessageIDs, req.ChatIDs)
+	if err != nil {
+		var slowModeErr *service.SlowModeError
+		switch {
+		case errors.Is(err, service.ErrNothingToForward),
+			errors.Is(err, service.ErrTooManyForwarded),
+			errors.Is(err, service.ErrTooManyTargets),
+			errors.Is(err, service.ErrPollGroupOnly):
+			httputils.ResponseError(w, http.StatusBadRequest, err.Error())
+		case errors.Is(err, service.ErrForwardSourceDenied):
+			httputils.ResponseError(w, http.StatusNotFound, err.Error())
+		case errors.Is(err, service.ErrForwardTargetDenied),
+			errors.Is(err, service.ErrChannelReadOnly):
+			httputils.ResponseError(w, http.StatusForbidden, err.Error())
+		case errors.As(err, &slowModeErr):
+			responseSlowMode(w, slowModeErr)
+		default:
+			h.logger.Error("failed to forward messages", "error", err)
+			httputils.ResponseError(w, http.StatusInternalServerError, "failed to forward messages")
+		}
+		return
+	}
+
+	for _, msg := range messages {
+		chat := &model.Chat{}
+		chat.ID = msg.ChatID
+		h.publishMessage(chat, msg)
+	}
+
+	httputils.ResponseJSON(w, http.StatusCreated, messages)
+}
@@ -0,0 +1,334 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+	"tush00nka/bbbab_messenger/internal/model"
+	"tush00nka/bbbab_messenger/internal/pkg/httputils"
+	"tush00nka/bbbab_messenger/internal/ws"
+)
+
+// ChatAvatarURLExpiry время жизни ссылки на аватар чата
+const ChatAvatarURLExpiry = time.Hour
+
+// UpdateChatInfoRequest запрос на изменение информации о группе
+type UpdateChatInfoRequest struct {
+	Name        *string `json:"name,omitempty" binding:"omitempty,min=1,max=100"`
+	Description *string `json:"description,omitempty" binding:"omitempty,max=1000"`
+}
+
+// ChatAvatarResponse ответ с ссылкой на аватар чата
+type ChatAvatarResponse struct {
+	AvatarURL string `json:"avatar_url"`
+}
+
+// UpdateChatInfo изменяет название и описание группы
+// @Summary Update group info
+// @Description Update name and/or description of a group chat (admins only)
+// @ID update-chat-info
+// @Tags chat
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer токен" default(Bearer )
+// @Param chat_id path int true "Chat ID"
+// @Param chatData body UpdateChatInfoRequest true "Fields to update"
+// @Success 200 {object} model.Chat
+// @Failure 400 {object} httputils.ErrorResponse
+// @Failure 401 {object} httputils.ErrorResponse
+// @Failure 403 {object} httputils.ErrorResponse
+// @Failure 404 {object} httputils.ErrorResponse
+// @Failure 500 {object} httputils.ErrorResponse
+// @Router /chat/{chat_id}/info [put]
+func (h *ChatHandler) updateChatInfo(w http.ResponseWriter, r *http.Request) {
+	claims, err := getClaimsFromContext(r)
+	if err != nil {
+		httputils.ResponseError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	chatID, err := parsePathID(r, "chat_id")
+	if err != nil {
+		httputils.ResponseError(w, http.StatusBadRequest, "invalid chat id")
+		return
+	}
+
+	var req UpdateChatInfoRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputils.ResponseError(w, http.StatusBadRequest, "invalid request format")
+		return
+	}
+	defer r.Body.Close()
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	chat, ok := h.getGroupForAdmin(ctx, w, chatID, claims.UserID)
+	if !ok {
+		return
+	}
+
+	name, description := chat.Name, chat.Description
+	if req.Name != nil {
+		name = strings.TrimSpace(*req.Name)
+	}
+	if req.Description != nil {
+		description = strings.TrimSpace(*req.Description)
+	}
+
+	if err := h.chatService.UpdateGroupInfo(ctx, chatID, name, description); err != nil {
+		httputils.ResponseError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if name != chat.Name {
+		h.recordAudit(model.ChatAuditLog{
+			ChatID:  chatID,
+			ActorID: claims.UserID,
+			Action:  model.AuditChatRenamed,
+			Before:  chat.Name,
+			After:   name,
+		})
+	}
+	if description != chat.Description {
+		h.recordAudit(model.ChatAuditLog{
+			ChatID:  chatID,
+			ActorID: claims.UserID,
+			Action:  model.AuditDescriptionEdited,
+			Before:  chat.Description,
+			After:   description,
+		})
+	}
+
+	chat.Name = name
+	chat.Description = description
+	h.fillChatAvatarURL(ctx, chat)
+	h.broadcastChatUpdated(chat, claims.UserID)
+
+	httputils.ResponseJSON(w, http.StatusOK, chat)
+}
+
+// UploadChatAvatar загружает аватар группы
+// @Summary Upload group avatar
+// @Description Upload avatar of a group chat (admins only)
+// @ID upload-chat-avatar
+// @Tags chat
+// @Accept multipart/form-data
+// @Produce json
+// @Param Authorization header string true "Bearer токен" default(Bearer )
+// @Param chat_id path int true "Chat ID"
+// @Param avatar formData file true "Файл для загрузки"
+// @Success 200 {object} ChatAvatarResponse
+// @Failure 400 {object} httputils.ErrorResponse
+// @Failure 401 {object} httputils.ErrorResponse
+// @Failure 403 {object} httputils.ErrorResponse
+// @Failure 404 {object} httputils.ErrorResponse
+// @Failure 500 {object} httputils.ErrorResponse
+// @Router /chat/{chat_id}/avatar [post]
+func (h *ChatHandler) uploadChatAvatar(w http.ResponseWriter, r *http.Request) {
+	claims, err := getClaimsFromContext(r)
+	if err != nil {
+		httputils.ResponseError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	chatID, err := parsePathID(r, "chat_id")
+	if err != nil {
+		httputils.ResponseError(w, http.StatusBadRequest, "invalid chat id")
+		return
+	}
+
+	if h.s3Service == nil {
+		httputils.ResponseError(w, http.StatusInternalServerError, "file storage is not configured")
+		return
+	}
+
+	file, header, err := r.FormFile("avatar")
+	if err != nil {
+		httputils.ResponseError(w, http.StatusBadRequest, "failed to get file from request")
+		return
+	}
+	defer file.Close()
+
+	contentType, err := validateAvatarFile(header)
+	if err != nil {
+		httputils.ResponseError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx := r.Context()
+
+	chat, ok := h.getGroupForAdmin(ctx, w, chatID, claims.UserID)
+	if !ok {
+		return
+	}
+
+	filename := filepath.Base(header.Filename)
+	metadata, err := h.s3Service.UploadChatAvatar(ctx, file, filename, contentType, chatID)
+	if err != nil {
+		h.logger.Error("failed to upload chat avatar", "error", err)
+		httputils.ResponseError(w, http.StatusInternalServerError, "failed to upload chat avatar")
+		return
+	}
+
+	if err := h.chatService.UpdateChatAvatar(ctx, chatID, metadata.S3Key); err != nil {
+		h.logger.Error("failed to update chat avatar", "error", err)
+		httputils.ResponseError(w, http.StatusInternalServerError, "failed to update chat avatar")
+		return
+	}
+
+	// Старый аватар удаляем только после успешного сохранения нового
+	if chat.AvatarKey != "" {
+		if err := h.s3Service.DeleteChatAvatar(ctx, chat.AvatarKey); err != nil {
+			h.logger.Warn("failed to delete old chat avatar", "error", err)
+		}
+	}
+
+	h.recordAudit(model.ChatAuditLog{
+		ChatID:  chatID,
+		ActorID: claims.UserID,
+		Action:  model.AuditAvatarChanged,
+		Before:  chat.AvatarKey,
+		After:   metadata.S3Key,
+	})
+
+	chat.AvatarKey = metadata.S3Key
+	h.fillChatAvatarURL(ctx, chat)
+	h.broadcastChatUpdated(chat, claims.UserID)
+
+	httputils.ResponseJSON(w, http.StatusOK, ChatAvatarResponse{AvatarURL: chat.AvatarURL})
+}
+
+// DeleteChatAvatar удаляет аватар группы
+// @Summary Delete group avatar
+// @Description Remove avatar of a group chat (admins only)
+// @ID delete-chat-avatar
+// @Tags chat
+// @Produce json
+// @Param Authorization header string true "Bearer токен" default(Bearer )
+// @Param chat_id path int true "Chat ID"
+// @Success 200 {object} StatusResponse
+// @Failure 400 {object} httputils.ErrorResponse
+// @Failure 401 {object} httputils.ErrorResponse
+// @Failure 403 {object} httputils.ErrorResponse
+// @Failure 404 {object} httputils.ErrorResponse
+// @Failure 500 {object} httputils.ErrorResponse
+// @Router /chat/{chat_id}/avatar [delete]
+func (h *ChatHandler) deleteChatAvatar(w http.ResponseWriter, r *http.Request) {
+	claims, err := getClaimsFromContext(r)
+	if err != nil {
+		httputils.ResponseError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	chatID, err := parsePathID(r, "chat_id")
+	if err != nil {
+		httputils.ResponseError(w, http.StatusBadRequest, "invalid chat id")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	chat, ok := h.getGroupForAdmin(ctx, w, chatID, claims.UserID)
+	if !ok {
+		return
+	}
+
+	if chat.AvatarKey == "" {
+		httputils.ResponseJSON(w, http.StatusOK, StatusResponse{Status: "no avatar"})
+		return
+	}
+
+	if err := h.chatService.UpdateChatAvatar(ctx, chatID, ""); err != nil {
+		h.logger.Error("failed to clear chat avatar", "error", err)
+		httputils.ResponseError(w, http.StatusInternalServerError, "failed to delete chat avatar")
+		return
+	}
+
+	if h.s3Service != nil {
+		if err := h.s3Service.DeleteChatAvatar(ctx, chat.AvatarKey); err != nil {
+			h.logger.Warn("failed to delete chat avatar from storage", "error", err)
+		}
+	}
+
+	h.recordAudit(model.ChatAuditLog{
+		ChatID:  chatID,
+		ActorID: claims.UserID,
+		Action:  model.AuditAvatarChanged,
+		Before:  chat.AvatarKey,
+	})
+
+	chat.AvatarKey = ""
+	chat.AvatarURL = ""
+	h.broadcastChatUpdated(chat, claims.UserID)
+
+	httputils.ResponseJSON(w, http.StatusOK, StatusResponse{Status: "avatar deleted"})
+}
+
+// getGroupForAdmin загружает групповой чат и проверяет права администратора
+func (h *ChatHandler) getGroupForAdmin(ctx context.Context, w http.ResponseWriter, chatID, userID uint) (*model.Chat, bool) {
+	if !h.requireChatAdmin(ctx, w, chatID, userID) {
+		return nil, false
+	}
+
+	chat, err := h.chatService.GetChatByID(ctx, chatID)
+	if err != nil {
+		h.logger.Error("failed to get chat", "error", err)
+		httputils.ResponseError(w, http.StatusInternalServerError, "failed to get chat")
+		return nil, false
+	}
+	if chat == nil {
+		httputils.ResponseError(w, http.StatusNotFound, "chat not found")
+		return nil, false
+	}
+	if !chat.IsGroup {
+		httputils.ResponseError(w, http.StatusBadRequest, "only group chats can be edited")
+		return nil, false
+	}
+
+	return chat, true
+}
+
+// fillChatAvatarURL выставляет временную ссылку на аватар чата
+func (h *ChatHandler) fillChatAvatarURL(ctx context.Context, chat *model.Chat) {
+	if chat == nil || chat.AvatarKey == "" || h.s3Service == nil {
+		return
+	}
+
+	metadata := &model.FileMetadata{
+		S3Key:    chat.AvatarKey,
+		S3Bucket: h.s3Service.Config.S3BucketName,
+	}
+
+	url, err := h.s3Service.GeneratePresignedURL(ctx, metadata, ChatAvatarURLExpiry)
+	if err != nil {
+		h.logger.Warn("failed to generate chat avatar url", "error", err)
+		return
+	}
+
+	chat.AvatarURL = url
+}
+
+// broadcastChatUpdated рассылает участникам обновленную информацию о чате
+func (h *ChatHandler) broadcastChatUpdated(chat *model.Chat, actorID uint) {
+	if h.hub == nil || chat == nil {
+		return
+	}
+
+	h.hub.BroadcastEvent(chat.ID, ws.OutEvent{
+		Type:   ws.EventTypeChatUpdated,
+		UserID: actorID,
+		Message: map[string]any{
+			"id":                  chat.ID,
+			"name":                chat.Name,
+			"description":         chat.Description,
+			"avatar_url":          chat.AvatarURL,
+			"slow_mode_seconds":   chat.SlowModeSeconds,
+			"message_ttl_seconds": chat.MessageTTLSeconds,
+		},
+	})
+}
@@ -0,0 +1,634 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+	"tush00nka/bbbab_messenger/internal/model"
+	"tush00nka/bbbab_messenger/internal/pkg/httputils"
+	"tush00nka/bbbab_messenger/internal/service"
+	"tush00nka/bbbab_messenger/internal/ws"
+
+	"github.com/gorilla/mux"
+)
+
+// CreateInviteRequest запрос на создание пригласительной ссылки
+type CreateInviteRequest struct {
+	ExpiresIn        int64 `json:"expires_in"` // время жизни в секундах, 0 — бессрочно
+	MaxUses          int   `json:"max_uses"`   // 0 — без ограничений
+	RequiresApproval bool  `json:"requires_approval"`
+}
+
+// JoinChatResponse ответ на вступление в чат по ссылке
+type JoinChatResponse struct {
+	ChatID        uint   `json:"chat_id"`
+	Status        string `json:"status"`
+	JoinRequestID uint   `json:"join_request_id,omitempty"`
+}
+
+// JoinRequestRequest запрос на вступление в группу
+type JoinRequestRequest struct {
+	Message string `json:"message" binding:"max=500"`
+}
+
+// JoinByRequestRequest запрос на настройку вступления по заявке без ссылки
+type JoinByRequestRequest struct {
+	Enabled bool `json:"enabled"`
+}
+
+// JoinRequestCooldownResponse ответ на повторную заявку вскоре после отклонения
+type JoinRequestCooldownResponse struct {
+	Message    string `json:"message"`
+	RetryAfter int    `json:"retry_after"` // секунды до возможности подать новую заявку
+}
+
+// CreateInvite создает пригласительную ссылку
+// @Summary Create invite link
+// @Description Create an invite link for a group chat (admins only)
+// @ID create-invite
+// @Tags chat
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer токен" default(Bearer )
+// @Param chat_id path int true "Chat ID"
+// @Param inviteData body CreateInviteRequest true "Invite settings"
+// @Success 201 {object} model.ChatInvite
+// @Failure 400 {object} httputils.ErrorResponse
+// @Failure 401 {object} httputils.ErrorResponse
+// @Failure 403 {object} httputils.ErrorResponse
+// @Failure 500 {object} httputils.ErrorResponse
+// @Router /chat/{chat_id}/invites [post]
+func (h *ChatHandler) createInvite(w http.ResponseWriter, r *http.Request) {
+	claims, err := getClaimsFromContext(r)
+	if err != nil {
+		httputils.ResponseError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	chatID, err := parsePathID(r, "chat_id")
+	if err != nil {
+		httputils.ResponseError(w, http.StatusBadRequest, "invalid chat id")
+		return
+	}
+
+	var req CreateInviteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputils.ResponseError(w, http.StatusBadRequest, "invalid request format")
+		return
+	}
+	defer r.Body.Close()
+
+	if req.MaxUses < 0 {
+		httputils.ResponseError(w, http.StatusBadRequest, "max_uses cannot be negative")
+		return
+	}
+	// Проверяем до перевода в time.Duration: большое значение при умножении переполнится
+	if req.ExpiresIn < 0 || req.ExpiresIn > int64(service.MaxInviteTTL/time.Second) {
+		httputils.ResponseError(w, http.StatusBadRequest,
+			fmt.Sprintf("expires_in must be between 0 and %d seconds", int64(service.MaxInviteTTL/time.Second)))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	if !h.requireChatAdmin(ctx, w, chatID, claims.UserID) {
+		return
+	}
+
+	invite, err := h.chatService.CreateInvite(ctx, chatID, claims.UserID,
+		time.Duration(req.ExpiresIn)*time.Second, req.MaxUses, req.RequiresApproval)
+	if err != nil {
+		h.logger.Error("failed to create invite", "error", err)
+		httputils.ResponseError(w, http.StatusBadRequest, "failed to create invite: "+err.Error())
+		return
+	}
+
+	h.recordAudit(model.ChatAuditLog{
+		ChatID:  chatID,
+		ActorID: claims.UserID,
+		Action:  model.AuditInviteCreated,
+		After:   invite.Token,
+	})
+
+	httputils.ResponseJSON(w, http.StatusCreated, invite)
+}
+
+// ListInvites возвращает пригласительные ссылки чата
+// @Summary List invite links
+// @Description List invite links of a group chat (admins only)
+// @ID list-invites
+// @Tags chat
+// @Produce json
+// @Param Authorization header string true "Bearer токен" default(Bearer )
+// @Param chat_id path int true "Chat ID"
+// @Success 200 {object} []model.ChatInvite
+// @Failure 400 {object} httputils.ErrorResponse
+// @Failure 401 {object} httputils.ErrorResponse
+// @Failure 403 {object} httputils.ErrorResponse
+// @Failure 500 {object} httputils.ErrorResponse
+// @Router /chat/{chat_id}/invites [get]
+func (h *ChatHandler) listInvites(w http.ResponseWriter, r *http.Request) {
+	claims, err := getClaimsFromContext(r)
+	if err != nil {
+		httputils.ResponseError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	chatID, err := parsePathID(r, "chat_id")
+	if err != nil {
+		httputils.ResponseError(w, http.StatusBadRequest, "invalid chat id")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	if !h.requireChatAdmin(ctx, w, chatID, claims.UserID) {
+		return
+	}
+
+	invites, err := h.chatService.GetChatInvites(ctx, chatID)
+	if err != nil {
+		h.logger.Error("failed to get invites", "error", err)
+		httputils.ResponseError(w, http.StatusInternalServerError, "failed to get invites")
+		return
+	}
+
+	httputils.ResponseJSON(w, http.StatusOK, invites)
+}
+
+// RevokeInvite отзывает пригласительную ссылку
+// @Summary Revoke invite link
+// @Description Revoke an invite link of a group chat (admins only)
+// @ID revoke-invite
+// @Tags chat
+// @Produce json
+// @Param Authorization header string true "Bearer токен" default(Bearer )
+// @Param chat_id path int true "Chat ID"
+// @Param invite_id path int true "Invite ID"
+// @Success 200 {object} StatusResponse
+// @Failure 400 {object} httputils.ErrorResponse
+// @Failure 401 {object} httputils.ErrorResponse
+// @Failure 403 {object} httputils.ErrorResponse
+// @Failure 404 {object} httputils.ErrorResponse
+// @Failure 500 {object} httputils.ErrorResponse
+// @Router /chat/{chat_id}/invites/{invite_id} [delete]
+func (h *ChatHandler) revokeInvite(w http.ResponseWriter, r *http.Request) {
+	claims, err := getClaimsFromContext(r)
+	if err != nil {
+		httputils.ResponseError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	chatID, err1 := parsePathID(r, "chat_id")
+	inviteID, err2 := parsePathID(r, "invite_id")
+	if err1 != nil || err2 != nil {
+		httputils.ResponseError(w, http.StatusBadRequest, "invalid chat or invite id")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	if !h.requireChatAdmin(ctx, w, chatID, claims.UserID) {
+		return
+	}
+
+	if err := h.chatService.RevokeInvite(ctx, chatID, inviteID); err != nil {
+		if errors.Is(err, service.ErrInviteNotFound) {
+			httputils.ResponseError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		h.logger.Error("failed to revoke invite", "error", err)
+		httputils.ResponseError(w, http.StatusInternalServerError, "failed to revoke invite")
+		return
+	}
+
+	h.recordAudit(model.ChatAuditLog{
+		ChatID:  chatID,
+		ActorID: claims.UserID,
+		Action:  model.AuditInviteRevoked,
+		Before:  strconv.FormatUint(uint64(inviteID), 10),
+	})
+
+	httputils.ResponseJSON(w, http.StatusOK, StatusResponse{Status: "invite revoked"})
+}
+
+// JoinByInvite вступление в чат по пригласительной ссылке
+// @Summary Join chat by invite
+// @Description Join a group chat using an invite link token
+// @ID join-by-invite
+// @Tags chat
+// @Produce json
+// @Param Authorization header string true "Bearer токен" default(Bearer )
+// @Param token path string true "Invite token"
+// @Success 200 {object} JoinChatResponse
+// @Success 202 {object} JoinChatResponse
+// @Failure 401 {object} httputils.ErrorResponse
+// @Failure 403 {object} httputils.ErrorResponse
+// @Failure 404 {object} httputils.ErrorResponse
+// @Failure 409 {object} httputils.ErrorResponse
+// @Failure 410 {object} httputils.ErrorResponse
+// @Failure 429 {object} JoinRequestCooldownResponse
+// @Failure 500 {object} httputils.ErrorResponse
+// @Router /chat/join/{token} [post]
+func (h *ChatHandler) joinByInvite(w http.ResponseWriter, r *http.Request) {
+	claims, err := getClaimsFromContext(r)
+	if err != nil {
+		httputils.ResponseError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	token := mux.Vars(r)["token"]
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	invite, joinRequest, notifyAdmins, err := h.chatService.JoinChatByInvite(ctx, token, claims.UserID)
+	var cooldownErr *service.JoinRequestCooldownError
+	switch {
+	case errors.Is(err, service.ErrInviteNotFound):
+		httputils.ResponseError(w, http.StatusNotFound, err.Error())
+		return
+	case errors.Is(err, service.ErrInviteInvalid):
+		httputils.ResponseError(w, http.StatusGone, err.Error())
+		return
+	case errors.Is(err, service.ErrAlreadyMember):
+		httputils.ResponseError(w, http.StatusConflict, err.Error())
+		return
+	case errors.Is(err, service.ErrUserBanned):
+		httputils.ResponseError(w, http.StatusForbidden, err.Error())
+		return
+	case errors.As(err, &cooldownErr):
+		responseJoinRequestCooldown(w, cooldownErr)
+		return
+	case err != nil:
+		h.logger.Error("failed to join chat by invite", "error", err)
+		httputils.ResponseError(w, http.StatusInternalServerError, "failed to join chat")
+		return
+	}
+
+	if joinRequest != nil {
+		if notifyAdmins {
+			h.notifyAdminsAboutJoinRequest(joinRequest)
+		}
+		httputils.ResponseJSON(w, http.StatusAccepted, JoinChatResponse{
+			ChatID:        invite.ChatID,
+			Status:        "approval required",
+			JoinRequestID: joinRequest.ID,
+		})
+		return
+	}
+
+	h.broadcastUserJoined(ctx, invite.ChatID, claims.UserID, map[string]any{
+		"via":       "invite",
+		"invite_id": invite.ID,
+	})
+
+	httputils.ResponseJSON(w, http.StatusOK, JoinChatResponse{ChatID: invite.ChatID, Status: "joined"})
+}
+
+// RequestToJoin подает заявку на вступление в группу
+// @Summary Request to join chat
+// @Description Ask group admins for access. Only groups with join_by_request enabled accept requests, others respond 404.
+// @Description After a rejection a new request can be filed only when the cooldown expires.
+// @ID request-to-join
+// @Tags chat
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer токен" default(Bearer )
+// @Param chat_id path int true "Chat ID"
+// @Param requestData body JoinRequestRequest false "Optional message for admins"
+// @Success 202 {object} model.ChatJoinRequest
+// @Failure 400 {object} httputils.ErrorResponse
+// @Failure 401 {object} httputils.ErrorResponse
+// @Failure 403 {object} httputils.ErrorResponse
+// @Failure 404 {object} httputils.ErrorResponse
+// @Failure 409 {object} httputils.ErrorResponse
+// @Failure 429 {object} JoinRequestCooldownResponse
+// @Failure 500 {object} httputils.ErrorResponse
+// @Router /chat/{chat_id}/join-requests [post]
+func (h *ChatHandler) requestToJoin(w http.ResponseWriter, r *http.Request) {
+	claims, err := getClaimsFromContext(r)
+	if err != nil {
+		httputils.ResponseError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	chatID, err := parsePathID(r, "chat_id")
+	if err != nil {
+		httputils.ResponseError(w, http.StatusBadRequest, "invalid chat id")
+		return
+	}
+
+	var req JoinRequestRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			httputils.ResponseError(w, http.StatusBadRequest, "invalid request format")
+			return
+		}
+	}
+	defer r.Body.Close()
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	joinRequest, notifyAdmins, err := h.chatService.RequestToJoin(ctx, chatID, claims.UserID, req.Message)
+	var cooldownErr *service.JoinRequestCooldownError
+	switch {
+	case errors.Is(err, service.ErrGroupNotFound):
+		httputils.ResponseError(w, http.StatusNotFound, err.Error())
+		return
+	case errors.Is(err, service.ErrAlreadyMember):
+		httputils.ResponseError(w, http.StatusConflict, err.Error())
+		return
+	case errors.Is(err, service.ErrUserBanned):
+		httputils.ResponseError(w, http.StatusForbidden, err.Error())
+		return
+	case errors.As(err, &cooldownErr):
+		responseJoinRequestCooldown(w, cooldownErr)
+		return
+	case err != nil:
+		httputils.ResponseError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if notifyAdmins {
+		h.notifyAdminsAboutJoinRequest(joinRequest)
+	}
+
+	httputils.ResponseJSON(w, http.StatusAccepted, joinRequest)
+}
+
+// SetJoinByRequest настраивает вступление в группу по заявке без ссылки
+// @Summary Set join by request
+// @Description Allow or forbid requests to join the group without an invite link (admins only)
+// @ID set-join-by-request
+// @Tags chat
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer токен" default(Bearer )
+// @Param chat_id path int true "Chat ID"
+// @Param joinByRequestData body JoinByRequestRequest true "Join by request setting"
+// @Success 200 {object} model.Chat
+// @Failure 400 {object} httputils.ErrorResponse
+// @Failure 401 {object} httputils.ErrorResponse
+// @Failure 403 {object} httputils.ErrorResponse
+// @Failure 404 {object} httputils.ErrorResponse
+// @Failure 500 {object} httputils.ErrorResponse
+// @Router /chat/{chat_id}/join-by-request [put]
+func (h *ChatHandler) setJoinByRequest(w http.ResponseWriter, r *http.Request) {
+	claims, err := getClaimsFromContext(r)
+	if err != nil {
+		httputils.ResponseError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	chatID, err := parsePathID(r, "chat_id")
+	if err != nil {
+		httputils.ResponseError(w, http.StatusBadRequest, "invalid chat id")
+		return
+	}
+
+	var req JoinByRequestRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputils.ResponseError(w, http.StatusBadRequest, "invalid request format")
+		return
+	}
+	defer r.Body.Close()
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	chat, ok := h.getGroupForAdmin(ctx, w, chatID, claims.UserID)
+	if !ok {
+		return
+	}
+
+	if err := h.chatService.SetJoinByRequest(ctx, chatID, req.Enabled); err != nil {
+		httputils.ResponseError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if chat.JoinByRequest != req.Enabled {
+		h.recordAudit(model.ChatAuditLog{
+			ChatID:  chatID,
+			ActorID: claims.UserID,
+			Action:  model.AuditJoinByRequest,
+			Before:  strconv.FormatBool(chat.JoinByRequest),
+			After:   strconv.FormatBool(req.Enabled),
+		})
+	}
+
+	chat.JoinByRequest = req.Enabled
+	h.fillChatAvatarURL(ctx, chat)
+	h.broadcastChatUpdated(chat, claims.UserID)
+
+	httputils.ResponseJSON(w, http.StatusOK, chat)
+}
+
+// ListJoinRequests возвращает заявки на вступление
+// @Summary List join requests
+// @Description List join requests of a group chat (admins only)
+// @ID list-join-requests
+// @Tags chat
+// @Produce json
+// @Param Authorization header string true "Bearer токен" default(Bearer )
+// @Param chat_id path int true "Chat ID"
+// @Param status query string false "Status filter" Enums(pending, approved, rejected) default(pending)
+// @Success 200 {object} []model.ChatJoinRequest
+// @Failure 400 {object} httputils.ErrorResponse
+// @Failure 401 {object} httputils.ErrorResponse
+// @Failure 403 {object} httputils.ErrorResponse
+// @Failure 500 {object} httputils.ErrorResponse
+// @Router /chat/{chat_id}/join-requests [get]
+func (h *ChatHandler) listJoinRequests(w http.ResponseWriter, r *http.Request) {
+	claims, err := getClaimsFromContext(r)
+	if err != nil {
+		httputils.ResponseError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	chatID, err := parsePathID(r, "chat_id")
+	if err != nil {
+		httputils.ResponseError(w, http.StatusBadRequest, "invalid chat id")
+		return
+	}
+
+	status := r.URL.Query().Get("status")
+	if status == "" {
+		status = model.JoinRequestPending
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	if !h.requireChatAdmin(ctx, w, chatID, claims.UserID) {
+		return
+	}
+
+	requests, err := h.chatService.GetJoinRequests(ctx, chatID, status)
+	if err != nil {
+		httputils.ResponseError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	httputils.ResponseJSON(w, http.StatusOK, requests)
+}
+
+// ApproveJoinRequest одобряет заявку на вступление
+// @Summary Approve join request
+// @Description Approve a pending join request and add the user to the chat (admins only)
+// @ID approve-join-request
+// @Tags chat
+// @Produce json
+// @Param Authorization header string true "Bearer токен" default(Bearer )
+// @Param chat_id path int true "Chat ID"
+// @Param request_id path int true "Join request ID"
+// @Success 200 {object} model.ChatJoinRequest
+// @Failure 400 {object} httputils.ErrorResponse
+// @Failure 401 {object} httputils.ErrorResponse
+// @Failure 403 {object} httputils.ErrorResponse
+// @Failure 404 {object} httputils.ErrorResponse
+// @Failure 409 {object} httputils.ErrorResponse
+// @Failure 500 {object} httputils.ErrorResponse
+// @Router /chat/{chat_id}/join-requests/{request_id}/approve [post]
+func (h *ChatHandler) approveJoinRequest(w http.ResponseWriter, r *http.Request) {
+	h.resolveJoinRequest(w, r, true)
+}
+
+// RejectJoinRequest отклоняет заявку на вступление
+// @Summary Reject join request
+// @Description Reject a pending join request (admins only)
+// @ID reject-join-request
+// @Tags chat
+// @Produce json
+// @Param Authorization header string true "Bearer токен" default(Bearer )
+// @Param chat_id path int true "Chat ID"
+// @Param request_id path int true "Join request ID"
+// @Success 200 {object} model.ChatJoinRequest
+// @Failure 400 {object} httputils.ErrorResponse
+// @Failure 401 {object} httputils.ErrorResponse
+// @Failure 403 {object} httputils.ErrorResponse
+// @Failure 404 {object} httputils.ErrorResponse
+// @Failure 409 {object} httputils.ErrorResponse
+// @Failure 500 {object} httputils.ErrorResponse
+// @Router /chat/{chat_id}/join-requests/{request_id}/reject [post]
+func (h *ChatHandler) rejectJoinRequest(w http.ResponseWriter, r *http.Request) {
+	h.resolveJoinRequest(w, r, false)
+}
+
+// resolveJoinRequest общая логика одобрения/отклонения заявки
+func (h *ChatHandler) resolveJoinRequest(w http.ResponseWriter, r *http.Request, approve bool) {
+	claims, err := getClaimsFromContext(r)
+	if err != nil {
+		httputils.ResponseError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	chatID, err1 := parsePathID(r, "chat_id")
+	requestID, err2 := parsePathID(r, "request_id")
+	if err1 != nil || err2 != nil {
+		httputils.ResponseError(w, http.StatusBadRequest, "invalid chat or request id")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	if !h.requireChatAdmin(ctx, w, chatID, claims.UserID) {
+		return
+	}
+
+	var joinRequest *model.ChatJoinRequest
+	if approve {
+		joinRequest, err = h.chatService.ApproveJoinRequest(ctx, chatID, requestID, claims.UserID)
+	} else {
+		joinRequest, err = h.chatService.RejectJoinRequest(ctx, chatID, requestID, claims.UserID)
+	}
+	switch {
+	case errors.Is(err, service.ErrJoinRequestNotFound):
+		httputils.ResponseError(w, http.StatusNotFound, err.Error())
+		return
+	case errors.Is(err, service.ErrJoinRequestResolved):
+		httputils.ResponseError(w, http.StatusConflict, err.Error())
+		return
+	case errors.Is(err, service.ErrUserBanned):
+		httputils.ResponseError(w, http.StatusConflict, err.Error())
+		return
+	case err != nil:
+		h.logger.Error("failed to resolve join request", "error", err)
+		httputils.ResponseError(w, http.StatusInternalServerError, "failed to resolve join request")
+		return
+	}
+
+	if h.hub != nil {
+		h.hub.SendToUser(joinRequest.UserID, ws.OutEvent{
+			Type:    ws.EventTypeJoinRequestResolved,
+			ChatID:  chatID,
+			UserID:  joinRequest.UserID,
+			Message: joinRequest,
+		})
+	}
+
+	action := model.AuditJoinRejected
+	if approve {
+		action = model.AuditJoinApproved
+	}
+	h.recordAudit(model.ChatAuditLog{
+		ChatID:       chatID,
+		ActorID:      claims.UserID,
+		Action:       action,
+		TargetUserID: &joinRequest.UserID,
+	})
+
+	if approve {
+		h.broadcastUserJoined(ctx, chatID, joinRequest.UserID, map[string]any{
+			"via":             "join_request",
+			"join_request_id": joinRequest.ID,
+		})
+	}
+
+	httputils.ResponseJSON(w, http.StatusOK, joinRequest)
+}
+
+// responseJoinRequestCooldown отвечает 429 с временем до возможности подать новую заявку
+func responseJoinRequestCooldown(w http.ResponseWriter, err *service.JoinRequestCooldownError) {
+	w.Header().Set("Retry-After", strconv.Itoa(err.RetryAfterSeconds()))
+	httputils.ResponseJSON(w, http.StatusTooManyRequests, JoinRequestCooldownResponse{
+		Message:    err.Error(),
+		RetryAfter: err.RetryAfterSeconds(),
+	})
+}
+
+// notifyAdminsAboutJoinRequest асинхронно уведомляет администраторов о новой заявке
+func (h *ChatHandler) notifyAdminsAboutJoinRequest(joinRequest *model.ChatJoinRequest) {
+	if h.hub == nil {
+		return
+	}
+
+	go func(req model.ChatJoinRequest) {
+		ctx, cancel := context.WithTimeout(context.Background(), PresenceTimeout)
+		defer cancel()
+
+		adminIDs, err := h.chatService.GetChatAdminIDs(ctx, req.ChatID)
+		if err != nil {
+			h.logger.Warn("failed to get chat admins", "error", err)
+			return
+		}
+
+		for _, adminID := range adminIDs {
+			h.hub.SendToUser(adminID, ws.OutEvent{
+				Type:    ws.EventTypeJoinRequest,
+				ChatID:  req.ChatID,
+				UserID:  req.UserID,
+				Message: req,
+			})
+		}
+	}(*joinRequest)
+}
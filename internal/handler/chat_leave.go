@@ -0,0 +1,254 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+	"tush00nka/bbbab_messenger/internal/model"
+	"tush00nka/bbbab_messenger/internal/pkg/httputils"
+	"tush00nka/bbbab_messenger/internal/service"
+	"tush00nka/bbbab_messenger/internal/ws"
+)
+
+// ClearHistoryResponse результат очистки истории
+type ClearHistoryResponse struct {
+	ChatID        uint `json:"chat_id"`
+	ClearedUpToID uint `json:"cleared_up_to_id"` // сообщения с ID не больше этого скрыты
+}
+
+// LeaveChat выход текущего пользователя из группы
+// @Summary Leave group chat
+// @Description Remove current user from a group. If the owner leaves, ownership passes to the oldest admin or member
+// @ID leave-chat
+// @Tags chat
+// @Produce json
+// @Param Authorization header string true "Bearer токен" default(Bearer )
+// @Param chat_id path int true "Chat ID"
+// @Success 200 {object} StatusResponse
+// @Failure 400 {object} httputils.ErrorResponse
+// @Failure 401 {object} httputils.ErrorResponse
+// @Failure 403 {object} httputils.ErrorResponse
+// @Failure 500 {object} httputils.ErrorResponse
+// @Router /chat/{chat_id}/leave [post]
+func (h *ChatHandler) leaveChat(w http.ResponseWriter, r *http.Request) {
+	claims, err := getClaimsFromContext(r)
+	if err != nil {
+		httputils.ResponseError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	chatID, err := parsePathID(r, "chat_id")
+	if err != nil {
+		httputils.ResponseError(w, http.StatusBadRequest, "invalid chat id")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	newOwnerID, err := h.chatService.LeaveChat(ctx, chatID, claims.UserID)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrCannotLeaveDirect):
+			httputils.ResponseError(w, http.StatusBadRequest, err.Error())
+		case errors.Is(err, service.ErrNotChatMember):
+			httputils.ResponseError(w, http.StatusForbidden, err.Error())
+		default:
+			h.logger.Error("failed to leave chat", "error", err)
+			httputils.ResponseError(w, http.StatusInternalServerError, "failed to leave chat")
+		}
+		return
+	}
+
+	h.recordAudit(model.ChatAuditLog{
+		ChatID:       chatID,
+		ActorID:      claims.UserID,
+		Action:       model.AuditUserLeft,
+		TargetUserID: &claims.UserID,
+	})
+
+	if newOwnerID != 0 {
+		h.recordAudit(model.ChatAuditLog{
+			ChatID:       chatID,
+			ActorID:      claims.UserID,
+			Action:       model.AuditRoleChanged,
+			TargetUserID: &newOwnerID,
+			After:        model.ChatRoleOwner,
+		})
+
+		if h.hub != nil {
+			h.hub.BroadcastEvent(chatID, ws.OutEvent{
+				Type:   ws.EventTypeRoleChanged,
+				UserID: newOwnerID,
+				Message: map[string]any{
+					"role": model.ChatRoleOwner,
+					"by":   claims.UserID,
+				},
+			})
+		}
+	}
+
+	h.disconnectRemovedMember(chatID, claims.UserID, ws.OutEvent{
+		Type:   ws.EventTypeUserLeft,
+		ChatID: chatID,
+		UserID: claims.UserID,
+		Meta:   map[string]any{"left_chat": true},
+	})
+
+	httputils.ResponseJSON(w, http.StatusOK, StatusResponse{Status: "left chat"})
+}
+
+// ClearHistory очищает историю чата только для текущего пользователя
+// @Summary Clear chat history for me
+// @Description Hide all current messages of the chat for the current user only. New messages stay visible
+// @ID clear-chat-history
+// @Tags chat
+// @Produce json
+// @Param Authorization header string true "Bearer токен" default(Bearer )
+// @Param chat_id path int true "Chat ID"
+// @Success 200 {object} ClearHistoryResponse
+// @Failure 400 {object} httputils.ErrorResponse
+// @Failure 401 {object} httputils.ErrorResponse
+// @Failure 403 {object} httputils.ErrorResponse
+// @Failure 500 {object} httputils.ErrorResponse
+// @Router /chat/{chat_id}/clear [post]
+func (h *ChatHandler) clearHistory(w http.ResponseWriter, r *http.Request) {
+	claims, err := getClaimsFromContext(r)
+	if err != nil {
+		httputils.ResponseError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	chatID, err := parsePathID(r, "chat_id")
+	if err != nil {
+		httputils.ResponseError(w, http.StatusBadRequest, "invalid chat id")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	clearedUpTo, ok := h.clearHistoryForUser(ctx, w, chatID, claims.UserID)
+	if !ok {
+		return
+	}
+
+	httputils.ResponseJSON(w, http.StatusOK, ClearHistoryResponse{
+		ChatID:        chatID,
+		ClearedUpToID: clearedUpTo,
+	})
+}
+
+// DeleteChat удаляет личный чат
+// @Summary Delete direct chat
+// @Description Delete a direct chat. With for_both=true the chat, its messages and attachments are removed for both members; otherwise the history is cleared only for the current user
+// @ID delete-chat
+// @Tags chat
+// @Produce json
+// @Param Authorization header string true "Bearer токен" default(Bearer )
+// @Param chat_id path int true "Chat ID"
+// @Param for_both query bool false "Delete for both members"
+// @Success 200 {object} StatusResponse
+// @Failure 400 {object} httputils.ErrorResponse
+// @Failure 401 {object} httputils.ErrorResponse
+// @Failure 403 {object} httputils.ErrorResponse
+// @Failure 500 {object} httputils.ErrorResponse
+// @Router /chat/{chat_id} [delete]
+func (h *ChatHandler) deleteChat(w http.ResponseWriter, r *http.Request) {
+	claims, err := getClaimsFromContext(r)
+	if err != nil {
+		httputils.ResponseError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	chatID, err := parsePathID(r, "chat_id")
+	if err != nil {
+		httputils.ResponseError(w, http.StatusBadRequest, "invalid chat id")
+		return
+	}
+
+	forBoth := false
+	if v := r.URL.Query().Get("for_both"); v != "" {
+		if forBoth, err = strconv.ParseBool(v); err != nil {
+			httputils.ResponseError(w, http.StatusBadRequest, "invalid for_both value")
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	if !forBoth {
+		if _, ok := h.clearHistoryForUser(ctx, w, chatID, claims.UserID); ok {
+			httputils.ResponseJSON(w, http.StatusOK, StatusResponse{Status: "chat deleted for me"})
+		}
+		return
+	}
+
+	memberIDs, attachments, err := h.chatService.DeleteDirectChat(ctx, chatID, claims.UserID)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrNotDirectChat):
+			httputils.ResponseError(w, http.StatusBadRequest, err.Error())
+		case errors.Is(err, service.ErrNotChatMember):
+			httputils.ResponseError(w, http.StatusForbidden, err.Error())
+		default:
+			h.logger.Error("failed to delete chat", "error", err)
+			httputils.ResponseError(w, http.StatusInternalServerError, "failed to delete chat")
+		}
+		return
+	}
+
+	// Кеш и вложения чистим в фоне: записи в БД уже удалены
+	go func() {
+		ctxCleanup, cancelCleanup := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancelCleanup()
+
+		if h.chatCacheService != nil {
+			if err := h.chatCacheService.ClearChat(ctxCleanup, chatID); err != nil {
+				h.logger.Warn("failed to clear chat cache", "error", err)
+			}
+		}
+		h.deleteUnreferencedAttachments(ctxCleanup, attachments)
+	}()
+
+	if h.hub != nil {
+		for _, memberID := range memberIDs {
+			h.hub.SendToUser(memberID, ws.OutEvent{
+				Type:   ws.EventTypeChatDeleted,
+				ChatID: chatID,
+				UserID: claims.UserID,
+			})
+		}
+	}
+
+	httputils.ResponseJSON(w, http.StatusOK, StatusResponse{Status: "chat deleted"})
+}
+
+// clearHistoryForUser очищает историю и синхронизирует другие устройства пользователя.
+// При ошибке сам пишет ответ и возвращает false.
+func (h *ChatHandler) clearHistoryForUser(ctx context.Context, w http.ResponseWriter, chatID, userID uint) (uint, bool) {
+	clearedUpTo, err := h.chatService.ClearHistory(ctx, chatID, userID)
+	if err != nil {
+		if errors.Is(err, service.ErrNotChatMember) {
+			httputils.ResponseError(w, http.StatusForbidden, err.Error())
+			return 0, false
+		}
+		h.logger.Error("failed to clear chat history", "error", err)
+		httputils.ResponseError(w, http.StatusInternalServerError, "failed to clear chat history")
+		return 0, false
+	}
+
+	if h.hub != nil {
+		h.hub.SendToUser(userID, ws.OutEvent{
+			Type:   ws.EventTypeHistoryCleared,
+			ChatID: chatID,
+			UserID: userID,
+			Meta:   map[string]any{"cleared_up_to_id": clearedUpTo},
+		})
+	}
+
+	return clearedUpTo, true
+}
@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"context"
+	"time"
+	"tush00nka/bbbab_messenger/internal/model"
+	"tush00nka/bbbab_messenger/internal/ws"
+)
+
+// LinkPreviewTimeout время на загрузку страницы и сохранение превью
+const LinkPreviewTimeout = 15 * time.Second
+
+// attachLinkPreview в фоне собирает превью ссылки из сообщения и, если оно есть,
+// обновляет сообщение в кеше и рассылает участникам message_updated
+func (h *ChatHandler) attachLinkPreview(msg model.Message) {
+	if h.linkPreviews == nil || msg.LinkPreview != nil {
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), LinkPreviewTimeout)
+		defer cancel()
+
+		preview, err := h.linkPreviews.GeneratePreview(ctx, &msg)
+		if err != nil {
+			h.logger.Warn("failed to generate link preview", "error", err)
+			return
+		}
+		if preview == nil {
+			return
+		}
+
+		if h.chatCacheService != nil {
+			if err := h.chatCacheService.UpdateMessage(ctx, msg); err != nil {
+				h.logger.Warn("failed to update cached message", "error", err)
+			}
+		}
+
+		if h.hub != nil {
+			h.hub.BroadcastEvent(msg.ChatID, ws.OutEvent{
+				Type:      ws.EventTypeMessageUpdated,
+				ChatID:    msg.ChatID,
+				MessageID: msg.ID,
+				Message:   msg,
+			})
+		}
+	}()
+}
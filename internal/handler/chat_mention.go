@@ -0,0 +1,171 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+	"tush00nka/bbbab_messenger/internal/model"
+	"tush00nka/bbbab_messenger/internal/pkg/httputils"
+	"tush00nka/bbbab_messenger/internal/ws"
+)
+
+// MentionsResponse страница ленты упоминаний
+type MentionsResponse struct {
+	Data       []model.MessageMention `json:"data"`
+	Pagination PaginationInfo         `json:"pagination"`
+}
+
+// GetMentions возвращает ленту упоминаний текущего пользователя
+// @Summary Get my mentions
+// @Description Get messages where the current user was mentioned, newest first
+// @ID get-my-mentions
+// @Tags chat
+// @Produce json
+// @Param Authorization header string true "Bearer токен" default(Bearer )
+// @Param chat_id query int false "Only mentions from this chat"
+// @Param unread query bool false "Only unread mentions"
+// @Param cursor query int false "ID of the last mention from the previous page"
+// @Param limit query int false "Limit" minimum(1) maximum(100) default(20)
+// @Success 200 {object} MentionsResponse
+// @Failure 400 {object} httputils.ErrorResponse
+// @Failure 401 {object} httputils.ErrorResponse
+// @Failure 500 {object} httputils.ErrorResponse
+// @Router /me/mentions [get]
+func (h *ChatHandler) getMentions(w http.ResponseWriter, r *http.Request) {
+	claims, err := getClaimsFromContext(r)
+	if err != nil {
+		httputils.ResponseError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	queryParams := r.URL.Query()
+
+	var chatID uint
+	if chatStr := queryParams.Get("chat_id"); chatStr != "" {
+		parsed, err := strconv.ParseUint(chatStr, 10, 64)
+		if err != nil {
+			httputils.ResponseError(w, http.StatusBadRequest, "invalid chat id")
+			return
+		}
+		chatID = uint(parsed)
+	}
+
+	var beforeID uint
+	if cursor := queryParams.Get("cursor"); cursor != "" {
+		parsed, err := strconv.ParseUint(cursor, 10, 64)
+		if err != nil {
+			httputils.ResponseError(w, http.StatusBadRequest, "invalid cursor")
+			return
+		}
+		beforeID = uint(parsed)
+	}
+
+	limit := 0
+	if limitStr := queryParams.Get("limit"); limitStr != "" {
+		if parsedLimit, err := strconv.Atoi(limitStr); err == nil {
+			limit = parsedLimit
+		}
+	}
+
+	unreadOnly, _ := strconv.ParseBool(queryParams.Get("unread"))
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	mentions, hasNext, err := h.chatService.GetMentions(ctx, claims.UserID, chatID, beforeID, limit, unreadOnly)
+	if err != nil {
+		h.logger.Error("failed to get mentions", "error", err)
+		httputils.ResponseError(w, http.StatusInternalServerError, "failed to get mentions")
+		return
+	}
+
+	var nextCursor *string
+	if hasNext && len(mentions) > 0 {
+		cursor := strconv.FormatUint(uint64(mentions[len(mentions)-1].ID), 10)
+		nextCursor = &cursor
+	}
+
+	httputils.ResponseJSON(w, http.StatusOK, MentionsResponse{
+		Data: mentions,
+		Pagination: PaginationInfo{
+			NextCursor:  nextCursor,
+			HasNext:     hasNext,
+			HasPrevious: beforeID > 0,
+			Limit:       len(mentions),
+		},
+	})
+}
+
+// ReadMentions отмечает прочитанными все упоминания в чате
+// @Summary Mark mentions as read
+// @Description Reset the unread mentions counter of the chat for the current user
+// @ID read-mentions
+// @Tags chat
+// @Produce json
+// @Param Authorization header string true "Bearer токен" default(Bearer )
+// @Param chat_id path int true "Chat ID"
+// @Success 200 {object} StatusResponse
+// @Failure 400 {object} httputils.ErrorResponse
+// @Failure 401 {object} httputils.ErrorResponse
+// @Failure 500 {object} httputils.ErrorResponse
+// @Router /chat/{chat_id}/mentions/read [post]
+func (h *ChatHandler) readMentions(w http.ResponseWriter, r *http.Request) {
+	claims, err := getClaimsFromContext(r)
+	if err != nil {
+		httputils.ResponseError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	chatID, err := parsePathID(r, "chat_id")
+	if err != nil {
+		httputils.ResponseError(w, http.StatusBadRequest, "invalid chat id")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := h.chatService.MarkMentionsRead(ctx, claims.UserID, chatID, 0); err != nil {
+		h.logger.Error("failed to mark mentions as read", "error", err)
+		httputils.ResponseError(w, http.StatusInternalServerError, "failed to mark mentions as read")
+		return
+	}
+
+	httputils.ResponseJSON(w, http.StatusOK, StatusResponse{Status: "mentions read"})
+}
+
+// notifyMentions отправляет упомянутым участникам событие mention во все их соединения,
+// в том числе открытые в других чатах. Если уведомления чата отключены, событие помечается silent.
+func (h *ChatHandler) notifyMentions(msg model.Message) {
+	if h.hub == nil || len(msg.Mentions) == 0 {
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		userIDs := make([]uint, len(msg.Mentions))
+		for i, mention := range msg.Mentions {
+			userIDs[i] = mention.UserID
+		}
+		notified := h.notifiedUsers(ctx, msg, userIDs)
+
+		for _, mention := range msg.Mentions {
+			count, err := h.chatService.GetUnreadMentionCount(ctx, mention.UserID, msg.ChatID)
+			if err != nil {
+				h.logger.Warn("failed to count unread mentions", "error", err)
+			}
+
+			h.hub.SendToUser(mention.UserID, ws.OutEvent{
+				Type:      ws.EventTypeMention,
+				ChatID:    msg.ChatID,
+				MessageID: msg.ID,
+				UserID:    msg.SenderID,
+				Message:   msg,
+				Meta:      map[string]any{"unread_mentions": count, "silent": !notified[mention.UserID]},
+			})
+		}
+	}()
+}
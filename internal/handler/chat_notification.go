@@ -0,0 +1,139 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+	"tush00nka/bbbab_messenger/internal/model"
+	"tush00nka/bbbab_messenger/internal/pkg/httputils"
+	"tush00nka/bbbab_messenger/internal/service"
+	"tush00nka/bbbab_messenger/internal/ws"
+)
+
+// SetChatNotificationsRequest запрос на изменение уведомлений чата
+type SetChatNotificationsRequest struct {
+	// Mode all — все уведомления, mentions — только упоминания и ответы (группы), none — без уведомлений
+	Mode string `json:"mode"`
+	// MuteFor срок действия режима в секундах; 0 — бессрочно
+	MuteFor int64 `json:"mute_for,omitempty"`
+}
+
+// SetChatNotifications меняет уведомления чата для текущего пользователя
+// @Summary Set chat notifications
+// @Description Mute the chat for a duration or forever, or switch a group to mentions only. After mute_for seconds notifications are back to all. Other devices receive a chat_notifications event.
+// @ID set-chat-notifications
+// @Tags chat
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer токен" default(Bearer )
+// @Param chat_id path int true "Chat ID"
+// @Param request body SetChatNotificationsRequest true "Notification settings"
+// @Success 200 {object} model.ChatNotificationSettings
+// @Failure 400 {object} httputils.ErrorResponse
+// @Failure 401 {object} httputils.ErrorResponse
+// @Failure 403 {object} httputils.ErrorResponse
+// @Failure 500 {object} httputils.ErrorResponse
+// @Router /chat/{chat_id}/notifications [put]
+func (h *ChatHandler) setChatNotifications(w http.ResponseWriter, r *http.Request) {
+	claims, err := getClaimsFromContext(r)
+	if err != nil {
+		httputils.ResponseError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	chatID, err := parsePathID(r, "chat_id")
+	if err != nil {
+		httputils.ResponseError(w, http.StatusBadRequest, "invalid chat id")
+		return
+	}
+
+	var req SetChatNotificationsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputils.ResponseError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.MuteFor < 0 || req.MuteFor > int64(service.MaxMuteDuration/time.Second) {
+		httputils.ResponseError(w, http.StatusBadRequest, service.ErrInvalidMuteDuration.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	settings, err := h.chatService.SetChatNotifications(ctx, claims.UserID, chatID, req.Mode, time.Duration(req.MuteFor)*time.Second)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidNotifyMode),
+			errors.Is(err, service.ErrInvalidMuteDuration),
+			errors.Is(err, service.ErrMentionsOnlyGroupOnly):
+			httputils.ResponseError(w, http.StatusBadRequest, err.Error())
+		case errors.Is(err, service.ErrNotChatMember):
+			httputils.ResponseError(w, http.StatusForbidden, err.Error())
+		default:
+			h.logger.Error("failed to set chat notifications", "error", err)
+			httputils.ResponseError(w, http.StatusInternalServerError, "failed to set chat notifications")
+		}
+		return
+	}
+
+	if h.hub != nil {
+		h.hub.SendToUser(claims.UserID, ws.OutEvent{
+			Type:    ws.EventTypeChatNotifications,
+			ChatID:  chatID,
+			UserID:  claims.UserID,
+			Message: settings,
+		})
+	}
+
+	httputils.ResponseJSON(w, http.StatusOK, settings)
+}
+
+// GetUnreadSummary возвращает общий счетчик непрочитанного
+// @Summary Get unread badge
+// @Description Get the total unread counter for the app badge. Chats with notifications off are skipped, mentions only chats count only mentions and replies to the user.
+// @ID get-unread-summary
+// @Tags chat
+// @Produce json
+// @Param Authorization header string true "Bearer токен" default(Bearer )
+// @Success 200 {object} model.UnreadSummary
+// @Failure 401 {object} httputils.ErrorResponse
+// @Failure 500 {object} httputils.ErrorResponse
+// @Router /me/unread [get]
+func (h *ChatHandler) getUnreadSummary(w http.ResponseWriter, r *http.Request) {
+	claims, err := getClaimsFromContext(r)
+	if err != nil {
+		httputils.ResponseError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	summary, err := h.chatService.GetUnreadSummary(ctx, claims.UserID)
+	if err != nil {
+		h.logger.Error("failed to get unread summary", "error", err)
+		httputils.ResponseError(w, http.StatusInternalServerError, "failed to get unread summary")
+		return
+	}
+
+	httputils.ResponseJSON(w, http.StatusOK, summary)
+}
+
+// notifiedUsers возвращает множество пользователей из userIDs, которых нужно уведомить о сообщении.
+// При ошибке уведомляются все.
+func (h *ChatHandler) notifiedUsers(ctx context.Context, msg model.Message, userIDs []uint) map[uint]bool {
+	notified := make(map[uint]bool, len(userIDs))
+
+	recipients, err := h.chatService.FilterNotificationRecipients(ctx, msg, userIDs)
+	if err != nil {
+		h.logger.Warn("failed to check notification settings", "error", err)
+		recipients = userIDs
+	}
+	for _, userID := range recipients {
+		notified[userID] = true
+	}
+
+	return notified
+}
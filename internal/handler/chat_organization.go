@@ -0,0 +1,398 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+	"tush00nka/bbbab_messenger/internal/model"
+	"tush00nka/bbbab_messenger/internal/pkg/httputils"
+	"tush00nka/bbbab_messenger/internal/service"
+	"tush00nka/bbbab_messenger/internal/ws"
+)
+
+// SetPinnedChatsRequest запрос на закрепление чатов
+type SetPinnedChatsRequest struct {
+	// ChatIDs закрепленные чаты в порядке отображения; пустой список открепляет все
+	ChatIDs []uint `json:"chat_ids"`
+}
+
+// ChatFolderRequest название и правила папки чатов
+type ChatFolderRequest struct {
+	Name string `json:"name"`
+	// Position место папки в списке, только при изменении
+	Position        int    `json:"position,omitempty"`
+	IncludeDirect   bool   `json:"include_direct"`
+	IncludeGroups   bool   `json:"include_groups"`
+	IncludeChannels bool   `json:"include_channels"`
+	IncludeChatIDs  []uint `json:"include_chat_ids,omitempty"`
+	ExcludeRead     bool   `json:"exclude_read"`
+	ExcludeArchived bool   `json:"exclude_archived"`
+	ExcludeChatIDs  []uint `json:"exclude_chat_ids,omitempty"`
+}
+
+// toModel переносит правила запроса в папку пользователя
+func (req ChatFolderRequest) toModel(userID, folderID uint) *model.ChatFolder {
+	return &model.ChatFolder{
+		ID:              folderID,
+		UserID:          userID,
+		Name:            req.Name,
+		Position:        req.Position,
+		IncludeDirect:   req.IncludeDirect,
+		IncludeGroups:   req.IncludeGroups,
+		IncludeChannels: req.IncludeChannels,
+		IncludeChatIDs:  req.IncludeChatIDs,
+		ExcludeRead:     req.ExcludeRead,
+		ExcludeArchived: req.ExcludeArchived,
+		ExcludeChatIDs:  req.ExcludeChatIDs,
+	}
+}
+
+// GetChatOrganization возвращает закрепленные и архивированные чаты и папки
+// @Summary Get chat list organization
+// @Description Get pinned chats (in order), archived chats and chat folders of the current user
+// @ID get-chat-organization
+// @Tags chat
+// @Produce json
+// @Param Authorization header string true "Bearer токен" default(Bearer )
+// @Success 200 {object} model.ChatOrganization
+// @Failure 401 {object} httputils.ErrorResponse
+// @Failure 500 {object} httputils.ErrorResponse
+// @Router /me/chat-organization [get]
+func (h *ChatHandler) getChatOrganization(w http.ResponseWriter, r *http.Request) {
+	claims, err := getClaimsFromContext(r)
+	if err != nil {
+		httputils.ResponseError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	org, err := h.chatService.GetChatOrganization(ctx, claims.UserID)
+	if err != nil {
+		h.logger.Error("failed to get chat organization", "error", err)
+		httputils.ResponseError(w, http.StatusInternalServerError, "failed to get chat organization")
+		return
+	}
+
+	httputils.ResponseJSON(w, http.StatusOK, org)
+}
+
+// SetPinnedChats закрепляет чаты вверху списка
+// @Summary Set pinned chats
+// @Description Replace the pinned chats of the current user; the order of chat_ids is the order in the list. Pinned chats leave the archive. Other devices receive a chat_organization event.
+// @ID set-pinned-chats
+// @Tags chat
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer токен" default(Bearer )
+// @Param request body SetPinnedChatsRequest true "Pinned chats"
+// @Success 200 {object} model.ChatOrganization
+// @Failure 400 {object} httputils.ErrorResponse
+// @Failure 401 {object} httputils.ErrorResponse
+// @Failure 403 {object} httputils.ErrorResponse
+// @Failure 500 {object} httputils.ErrorResponse
+// @Router /me/pinned-chats [put]
+func (h *ChatHandler) setPinnedChats(w http.ResponseWriter, r *http.Request) {
+	claims, err := getClaimsFromContext(r)
+	if err != nil {
+		httputils.ResponseError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req SetPinnedChatsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputils.ResponseError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := h.chatService.SetPinnedChats(ctx, claims.UserID, req.ChatIDs); err != nil {
+		switch {
+		case errors.Is(err, service.ErrTooManyPinnedChats):
+			httputils.ResponseError(w, http.StatusBadRequest, err.Error())
+		case errors.Is(err, service.ErrNotChatMember):
+			httputils.ResponseError(w, http.StatusForbidden, err.Error())
+		default:
+			h.logger.Error("failed to pin chats", "error", err)
+			httputils.ResponseError(w, http.StatusInternalServerError, "failed to pin chats")
+		}
+		return
+	}
+
+	h.respondChatOrganization(ctx, w, claims.UserID)
+}
+
+// ArchiveChat переносит чат в архив
+// @Summary Archive chat
+// @Description Move the chat to the current user's archive. The chat is unpinned and returns to the main list on a new message unless its notifications are muted. Other devices receive a chat_organization event.
+// @ID archive-chat
+// @Tags chat
+// @Produce json
+// @Param Authorization header string true "Bearer токен" default(Bearer )
+// @Param chat_id path int true "Chat ID"
+// @Success 200 {object} model.ChatOrganization
+// @Failure 400 {object} httputils.ErrorResponse
+// @Failure 401 {object} httputils.ErrorResponse
+// @Failure 403 {object} httputils.ErrorResponse
+// @Failure 500 {object} httputils.ErrorResponse
+// @Router /chat/{chat_id}/archive [post]
+func (h *ChatHandler) archiveChat(w http.ResponseWriter, r *http.Request) {
+	h.setChatArchived(w, r, true)
+}
+
+// UnarchiveChat возвращает чат из архива
+// @Summary Unarchive chat
+// @Description Return the chat from the current user's archive to the main list. Other devices receive a chat_organization event.
+// @ID unarchive-chat
+// @Tags chat
+// @Produce json
+// @Param Authorization header string true "Bearer токен" default(Bearer )
+// @Param chat_id path int true "Chat ID"
+// @Success 200 {object} model.ChatOrganization
+// @Failure 400 {object} httputils.ErrorResponse
+// @Failure 401 {object} httputils.ErrorResponse
+// @Failure 403 {object} httputils.ErrorResponse
+// @Failure 500 {object} httputils.ErrorResponse
+// @Router /chat/{chat_id}/archive [delete]
+func (h *ChatHandler) unarchiveChat(w http.ResponseWriter, r *http.Request) {
+	h.setChatArchived(w, r, false)
+}
+
+func (h *ChatHandler) setChatArchived(w http.ResponseWriter, r *http.Request, archived bool) {
+	claims, err := getClaimsFromContext(r)
+	if err != nil {
+		httputils.ResponseError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	chatID, err := parsePathID(r, "chat_id")
+	if err != nil {
+		httputils.ResponseError(w, http.StatusBadRequest, "invalid chat id")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := h.chatService.SetChatArchived(ctx, claims.UserID, chatID, archived); err != nil {
+		if errors.Is(err, service.ErrNotChatMember) {
+			httputils.ResponseError(w, http.StatusForbidden, err.Error())
+			return
+		}
+		h.logger.Error("failed to update chat archive", "error", err)
+		httputils.ResponseError(w, http.StatusInternalServerError, "failed to update chat archive")
+		return
+	}
+
+	h.respondChatOrganization(ctx, w, claims.UserID)
+}
+
+// CreateChatFolder создает папку чатов
+// @Summary Create chat folder
+// @Description Create a chat folder. A chat belongs to the folder if it matches any include rule and no exclude rule. Other devices receive a chat_organization event.
+// @ID create-chat-folder
+// @Tags chat
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer токен" default(Bearer )
+// @Param request body ChatFolderRequest true "Folder"
+// @Success 201 {object} model.ChatFolder
+// @Failure 400 {object} httputils.ErrorResponse
+// @Failure 401 {object} httputils.ErrorResponse
+// @Failure 500 {object} httputils.ErrorResponse
+// @Router /me/folders [post]
+func (h *ChatHandler) createChatFolder(w http.ResponseWriter, r *http.Request) {
+	claims, err := getClaimsFromContext(r)
+	if err != nil {
+		httputils.ResponseError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req ChatFolderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputils.ResponseError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	folder, err := h.chatService.CreateChatFolder(ctx, req.toModel(claims.UserID, 0))
+	if err != nil {
+		h.responseChatFolderError(w, "failed to create chat folder", err)
+		return
+	}
+
+	h.broadcastChatOrganization(ctx, claims.UserID)
+
+	httputils.ResponseJSON(w, http.StatusCreated, folder)
+}
+
+// UpdateChatFolder изменяет папку чатов
+// @Summary Update chat folder
+// @Description Replace the name, position and rules of a chat folder. Other devices receive a chat_organization event.
+// @ID update-chat-folder
+// @Tags chat
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer токен" default(Bearer )
+// @Param folder_id path int true "Folder ID"
+// @Param request body ChatFolderRequest true "Folder"
+// @Success 200 {object} model.ChatFolder
+// @Failure 400 {object} httputils.ErrorResponse
+// @Failure 401 {object} httputils.ErrorResponse
+// @Failure 404 {object} httputils.ErrorResponse
+// @Failure 500 {object} httputils.ErrorResponse
+// @Router /me/folders/{folder_id} [put]
+func (h *ChatHandler) updateChatFolder(w http.ResponseWriter, r *http.Request) {
+	claims, err := getClaimsFromContext(r)
+	if err != nil {
+		httputils.ResponseError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	folderID, err := parsePathID(r, "folder_id")
+	if err != nil {
+		httputils.ResponseError(w, http.StatusBadRequest, "invalid folder id")
+		return
+	}
+
+	var req ChatFolderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputils.ResponseError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	folder, err := h.chatService.UpdateChatFolder(ctx, req.toModel(claims.UserID, folderID))
+	if err != nil {
+		h.responseChatFolderError(w, "failed to update chat folder", err)
+		return
+	}
+
+	h.broadcastChatOrganization(ctx, claims.UserID)
+
+	httputils.ResponseJSON(w, http.StatusOK, folder)
+}
+
+// DeleteChatFolder удаляет папку чатов
+// @Summary Delete chat folder
+// @Description Delete a chat folder; its chats stay in the chat list. Other devices receive a chat_organization event.
+// @ID delete-chat-folder
+// @Tags chat
+// @Produce json
+// @Param Authorization header string true "Bearer токен" default(Bearer )
+// @Param folder_id path int true "Folder ID"
+// @Success 200 {object} StatusResponse
+// @Failure 400 {object} httputils.ErrorResponse
+// @Failure 401 {object} httputils.ErrorResponse
+// @Failure 404 {object} httputils.ErrorResponse
+// @Failure 500 {object} httputils.ErrorResponse
+// @Router /me/folders/{folder_id} [delete]
+func (h *ChatHandler) deleteChatFolder(w http.ResponseWriter, r *http.Request) {
+	claims, err := getClaimsFromContext(r)
+	if err != nil {
+		httputils.ResponseError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	folderID, err := parsePathID(r, "folder_id")
+	if err != nil {
+		httputils.ResponseError(w, http.StatusBadRequest, "invalid folder id")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := h.chatService.DeleteChatFolder(ctx, claims.UserID, folderID); err != nil {
+		h.responseChatFolderError(w, "failed to delete chat folder", err)
+		return
+	}
+
+	h.broadcastChatOrganization(ctx, claims.UserID)
+
+	httputils.ResponseJSON(w, http.StatusOK, StatusResponse{Status: "folder deleted"})
+}
+
+// responseChatFolderError отвечает ошибкой операции с папкой
+func (h *ChatHandler) responseChatFolderError(w http.ResponseWriter, message string, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidChatFolder),
+		errors.Is(err, service.ErrTooManyChatFolders):
+		httputils.ResponseError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, service.ErrChatFolderNotFound):
+		httputils.ResponseError(w, http.StatusNotFound, err.Error())
+	default:
+		h.logger.Error(message, "error", err)
+		httputils.ResponseError(w, http.StatusInternalServerError, message)
+	}
+}
+
+// respondChatOrganization отвечает актуальными настройками списка чатов и рассылает их
+// остальным устройствам пользователя
+func (h *ChatHandler) respondChatOrganization(ctx context.Context, w http.ResponseWriter, userID uint) {
+	org, err := h.chatService.GetChatOrganization(ctx, userID)
+	if err != nil {
+		h.logger.Error("failed to get chat organization", "error", err)
+		httputils.ResponseError(w, http.StatusInternalServerError, "failed to get chat organization")
+		return
+	}
+
+	h.sendChatOrganization(userID, org)
+
+	httputils.ResponseJSON(w, http.StatusOK, org)
+}
+
+// broadcastChatOrganization рассылает актуальные настройки списка чатов всем устройствам пользователя
+func (h *ChatHandler) broadcastChatOrganization(ctx context.Context, userID uint) {
+	if h.hub == nil {
+		return
+	}
+
+	org, err := h.chatService.GetChatOrganization(ctx, userID)
+	if err != nil {
+		h.logger.Warn("failed to get chat organization", "error", err)
+		return
+	}
+
+	h.sendChatOrganization(userID, org)
+}
+
+func (h *ChatHandler) sendChatOrganization(userID uint, org *model.ChatOrganization) {
+	if h.hub == nil {
+		return
+	}
+
+	h.hub.SendToUser(userID, ws.OutEvent{
+		Type:    ws.EventTypeChatOrganization,
+		UserID:  userID,
+		Message: org,
+	})
+}
+
+// unarchiveForMessage возвращает чат из архива у получателей нового сообщения
+// и сообщает об этом их устройствам
+func (h *ChatHandler) unarchiveForMessage(msg model.Message) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		userIDs, err := h.chatService.UnarchiveForNewMessage(ctx, msg.ChatID, msg.SenderID)
+		if err != nil {
+			h.logger.Warn("failed to unarchive chat", "error", err)
+			return
+		}
+
+		for _, userID := range userIDs {
+			h.broadcastChatOrganization(ctx, userID)
+		}
+	}()
+}
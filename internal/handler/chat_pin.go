@@ -0,0 +1,201 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+	"tush00nka/bbbab_messenger/internal/model"
+	"tush00nka/bbbab_messenger/internal/pkg/auth"
+	"tush00nka/bbbab_messenger/internal/pkg/httputils"
+	"tush00nka/bbbab_messenger/internal/service"
+	"tush00nka/bbbab_messenger/internal/ws"
+)
+
+// PinMessage закрепляет сообщение
+// @Summary Pin message
+// @Description Pin a message in a chat (admins in groups, any participant in direct chats)
+// @ID pin-message
+// @Tags chat
+// @Produce json
+// @Param Authorization header string true "Bearer токен" default(Bearer )
+// @Param chat_id path int true "Chat ID"
+// @Param message_id path int true "Message ID"
+// @Success 201 {object} model.PinnedMessage
+// @Failure 400 {object} httputils.ErrorResponse
+// @Failure 401 {object} httputils.ErrorResponse
+// @Failure 403 {object} httputils.ErrorResponse
+// @Failure 404 {object} httputils.ErrorResponse
+// @Failure 409 {object} httputils.ErrorResponse
+// @Failure 500 {object} httputils.ErrorResponse
+// @Router /chat/{chat_id}/pins/{message_id} [post]
+func (h *ChatHandler) pinMessage(w http.ResponseWriter, r *http.Request) {
+	claims, chatID, messageID, ok := parsePinRequest(w, r)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	pin, err := h.chatService.PinMessage(ctx, chatID, messageID, claims.UserID)
+	if err != nil {
+		h.responsePinError(w, err)
+		return
+	}
+
+	h.recordPinAudit(ctx, chatID, messageID, claims.UserID, model.AuditMessagePinned)
+
+	if h.hub != nil {
+		h.hub.BroadcastEvent(chatID, ws.OutEvent{
+			Type:      ws.EventTypeMessagePinned,
+			UserID:    claims.UserID,
+			MessageID: messageID,
+			Message:   pin,
+		})
+	}
+
+	httputils.ResponseJSON(w, http.StatusCreated, pin)
+}
+
+// UnpinMessage открепляет сообщение
+// @Summary Unpin message
+// @Description Unpin a message in a chat (admins in groups, any participant in direct chats)
+// @ID unpin-message
+// @Tags chat
+// @Produce json
+// @Param Authorization header string true "Bearer токен" default(Bearer )
+// @Param chat_id path int true "Chat ID"
+// @Param message_id path int true "Message ID"
+// @Success 200 {object} StatusResponse
+// @Failure 400 {object} httputils.ErrorResponse
+// @Failure 401 {object} httputils.ErrorResponse
+// @Failure 403 {object} httputils.ErrorResponse
+// @Failure 404 {object} httputils.ErrorResponse
+// @Failure 500 {object} httputils.ErrorResponse
+// @Router /chat/{chat_id}/pins/{message_id} [delete]
+func (h *ChatHandler) unpinMessage(w http.ResponseWriter, r *http.Request) {
+	claims, chatID, messageID, ok := parsePinRequest(w, r)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := h.chatService.UnpinMessage(ctx, chatID, messageID, claims.UserID); err != nil {
+		h.responsePinError(w, err)
+		return
+	}
+
+	h.recordPinAudit(ctx, chatID, messageID, claims.UserID, model.AuditMessageUnpinned)
+	h.broadcastMessageUnpinned(chatID, messageID, claims.UserID)
+
+	httputils.ResponseJSON(w, http.StatusOK, StatusResponse{Status: "message unpinned"})
+}
+
+// ListPins возвращает закрепленные сообщения чата
+// @Summary List pinned messages
+// @Description Get pinned messages of a chat, most recently pinned first
+// @ID list-pins
+// @Tags chat
+// @Produce json
+// @Param Authorization header string true "Bearer токен" default(Bearer )
+// @Param id path int true "Chat ID"
+// @Success 200 {array} model.PinnedMessage
+// @Failure 400 {object} httputils.ErrorResponse
+// @Failure 401 {object} httputils.ErrorResponse
+// @Failure 403 {object} httputils.ErrorResponse
+// @Failure 500 {object} httputils.ErrorResponse
+// @Router /chat/{id}/pins [get]
+func (h *ChatHandler) listPins(w http.ResponseWriter, r *http.Request) {
+	claims, err := getClaimsFromContext(r)
+	if err != nil {
+		httputils.ResponseError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	chatID, err := parsePathID(r, "id")
+	if err != nil {
+		httputils.ResponseError(w, http.StatusBadRequest, "invalid chat id")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	isMember, err := h.chatService.IsUserInChat(ctx, chatID, claims.UserID)
+	if err != nil || !isMember {
+		httputils.ResponseError(w, http.StatusForbidden, "user is not a member of this chat")
+		return
+	}
+
+	pins, err := h.chatService.GetPinnedMessages(ctx, chatID)
+	if err != nil {
+		h.logger.Error("failed to get pinned messages", "error", err)
+		httputils.ResponseError(w, http.StatusInternalServerError, "failed to get pinned messages")
+		return
+	}
+
+	httputils.ResponseJSON(w, http.StatusOK, pins)
+}
+
+// parsePinRequest извлекает пользователя, чат и сообщение из запроса закрепления
+func parsePinRequest(w http.ResponseWriter, r *http.Request) (*auth.Claims, uint, uint, bool) {
+	claims, err := getClaimsFromContext(r)
+	if err != nil {
+		httputils.ResponseError(w, http.StatusUnauthorized, "unauthorized")
+		return nil, 0, 0, false
+	}
+
+	chatID, err1 := parsePathID(r, "chat_id")
+	messageID, err2 := parsePathID(r, "message_id")
+	if err1 != nil || err2 != nil {
+		httputils.ResponseError(w, http.StatusBadRequest, "invalid chat or message id")
+		return nil, 0, 0, false
+	}
+
+	return claims, chatID, messageID, true
+}
+
+// responsePinError преобразует ошибку закрепления в HTTP-ответ
+func (h *ChatHandler) responsePinError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrPinNotAllowed):
+		httputils.ResponseError(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, service.ErrMessageNotInChat), errors.Is(err, service.ErrMessageNotPinned):
+		httputils.ResponseError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, service.ErrMessageAlreadyPinned):
+		httputils.ResponseError(w, http.StatusConflict, err.Error())
+	default:
+		httputils.ResponseError(w, http.StatusBadRequest, err.Error())
+	}
+}
+
+// recordPinAudit записывает закрепление в журнал модерации групп
+func (h *ChatHandler) recordPinAudit(ctx context.Context, chatID, messageID, actorID uint, action string) {
+	chat, err := h.chatService.GetChatMeta(ctx, chatID)
+	if err != nil || chat == nil || !chat.IsGroup {
+		return
+	}
+
+	h.recordAudit(model.ChatAuditLog{
+		ChatID:          chatID,
+		ActorID:         actorID,
+		Action:          action,
+		TargetMessageID: &messageID,
+	})
+}
+
+// broadcastMessageUnpinned уведомляет участников об откреплении сообщения
+func (h *ChatHandler) broadcastMessageUnpinned(chatID, messageID, actorID uint) {
+	if h.hub == nil {
+		return
+	}
+
+	h.hub.BroadcastEvent(chatID, ws.OutEvent{
+		Type:      ws.EventTypeMessageUnpinned,
+		UserID:    actorID,
+		MessageID: messageID,
+	})
+}
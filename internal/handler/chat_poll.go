@@ -0,0 +1,186 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+	"tush00nka/bbbab_messenger/internal/model"
+	"tush00nka/bbbab_messenger/internal/pkg/httputils"
+	"tush00nka/bbbab_messenger/internal/service"
+	"tush00nka/bbbab_messenger/internal/ws"
+)
+
+// VotePollRequest запрос на голосование в опросе
+type VotePollRequest struct {
+	// OptionIDs выбранные варианты; пустой список отзывает голос
+	OptionIDs []uint `json:"option_ids"`
+}
+
+// GetPoll возвращает опрос сообщения с результатами
+// @Summary Get poll
+// @Description Get poll of a message with aggregated results and options chosen by the current user
+// @ID get-poll
+// @Tags chat
+// @Produce json
+// @Param Authorization header string true "Bearer токен" default(Bearer )
+// @Param message_id path int true "Poll message ID"
+// @Success 200 {object} model.Poll
+// @Failure 400 {object} httputils.ErrorResponse
+// @Failure 401 {object} httputils.ErrorResponse
+// @Failure 404 {object} httputils.ErrorResponse
+// @Failure 500 {object} httputils.ErrorResponse
+// @Router /chat/poll/{message_id} [get]
+func (h *ChatHandler) getPoll(w http.ResponseWriter, r *http.Request) {
+	claims, err := getClaimsFromContext(r)
+	if err != nil {
+		httputils.ResponseError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	messageID, err := parsePathID(r, "message_id")
+	if err != nil {
+		httputils.ResponseError(w, http.StatusBadRequest, "invalid message id")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	poll, err := h.chatService.GetPoll(ctx, messageID, claims.UserID)
+	if err != nil {
+		h.responsePollError(w, err, "failed to get poll")
+		return
+	}
+
+	httputils.ResponseJSON(w, http.StatusOK, poll)
+}
+
+// VotePoll голосует в опросе
+// @Summary Vote in poll
+// @Description Replace the current user's vote in a poll. An empty option_ids list retracts the vote. Single-choice polls accept at most one option
+// @ID vote-poll
+// @Tags chat
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer токен" default(Bearer )
+// @Param message_id path int true "Poll message ID"
+// @Param voteData body VotePollRequest true "Chosen options"
+// @Success 200 {object} model.Poll
+// @Failure 400 {object} httputils.ErrorResponse
+// @Failure 401 {object} httputils.ErrorResponse
+// @Failure 404 {object} httputils.ErrorResponse
+// @Failure 409 {object} httputils.ErrorResponse
+// @Failure 500 {object} httputils.ErrorResponse
+// @Router /chat/poll/{message_id}/vote [post]
+func (h *ChatHandler) votePoll(w http.ResponseWriter, r *http.Request) {
+	claims, err := getClaimsFromContext(r)
+	if err != nil {
+		httputils.ResponseError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	messageID, err := parsePathID(r, "message_id")
+	if err != nil {
+		httputils.ResponseError(w, http.StatusBadRequest, "invalid message id")
+		return
+	}
+
+	var req VotePollRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputils.ResponseError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	defer r.Body.Close()
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	poll, err := h.chatService.VotePoll(ctx, messageID, claims.UserID, req.OptionIDs)
+	if err != nil {
+		h.responsePollError(w, err, "failed to vote")
+		return
+	}
+
+	h.broadcastPollUpdated(*poll)
+
+	httputils.ResponseJSON(w, http.StatusOK, poll)
+}
+
+// ClosePoll досрочно завершает опрос
+// @Summary Close poll
+// @Description Stop accepting votes in a poll (poll author or chat admins)
+// @ID close-poll
+// @Tags chat
+// @Produce json
+// @Param Authorization header string true "Bearer токен" default(Bearer )
+// @Param message_id path int true "Poll message ID"
+// @Success 200 {object} model.Poll
+// @Failure 400 {object} httputils.ErrorResponse
+// @Failure 401 {object} httputils.ErrorResponse
+// @Failure 403 {object} httputils.ErrorResponse
+// @Failure 404 {object} httputils.ErrorResponse
+// @Failure 409 {object} httputils.ErrorResponse
+// @Failure 500 {object} httputils.ErrorResponse
+// @Router /chat/poll/{message_id}/close [post]
+func (h *ChatHandler) closePoll(w http.ResponseWriter, r *http.Request) {
+	claims, err := getClaimsFromContext(r)
+	if err != nil {
+		httputils.ResponseError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	messageID, err := parsePathID(r, "message_id")
+	if err != nil {
+		httputils.ResponseError(w, http.StatusBadRequest, "invalid message id")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	poll, err := h.chatService.ClosePoll(ctx, messageID, claims.UserID)
+	if err != nil {
+		h.responsePollError(w, err, "failed to close poll")
+		return
+	}
+
+	h.broadcastPollUpdated(*poll)
+
+	httputils.ResponseJSON(w, http.StatusOK, poll)
+}
+
+// broadcastPollUpdated рассылает участникам чата новые результаты опроса.
+// Выбор конкретного пользователя в событие не попадает.
+func (h *ChatHandler) broadcastPollUpdated(poll model.Poll) {
+	if h.hub == nil {
+		return
+	}
+
+	poll.ChosenOptionIDs = nil
+	h.hub.BroadcastEvent(poll.ChatID, ws.OutEvent{
+		Type:      ws.EventTypePollUpdated,
+		ChatID:    poll.ChatID,
+		MessageID: poll.MessageID,
+		Message:   poll,
+	})
+}
+
+// responsePollError преобразует ошибки опросов в HTTP-ответ
+func (h *ChatHandler) responsePollError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, service.ErrPollNotFound):
+		httputils.ResponseError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, service.ErrPollCloseNotAllowed):
+		httputils.ResponseError(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, service.ErrPollClosed):
+		httputils.ResponseError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, service.ErrPollSingleChoice),
+		errors.Is(err, service.ErrPollInvalidOption):
+		httputils.ResponseError(w, http.StatusBadRequest, err.Error())
+	default:
+		h.logger.Error(fallback, "error", err)
+		httputils.ResponseError(w, http.StatusInternalServerError, fallback)
+	}
+}
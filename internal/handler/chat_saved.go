@@ -0,0 +1,200 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+	"tush00nka/bbbab_messenger/internal/model"
+	"tush00nka/bbbab_messenger/internal/pkg/httputils"
+	"tush00nka/bbbab_messenger/internal/service"
+)
+
+// SaveMessageRequest запрос на добавление сообщения в закладки
+type SaveMessageRequest struct {
+	MessageID uint     `json:"message_id"`
+	Note      string   `json:"note,omitempty"`
+	Tags      []string `json:"tags,omitempty"`
+}
+
+// SavedMessagesResponse страница закладок
+type SavedMessagesResponse struct {
+	Data       []model.SavedMessage `json:"data"`
+	Pagination PaginationInfo       `json:"pagination"`
+}
+
+// SaveMessage добавляет сообщение в закладки
+// @Summary Save message
+// @Description Bookmark a message from any chat the current user is a member of. Saving an already saved message replaces its note and tags.
+// @ID save-message
+// @Tags chat
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer токен" default(Bearer )
+// @Param request body SaveMessageRequest true "Message to save"
+// @Success 200 {object} model.SavedMessage
+// @Failure 400 {object} httputils.ErrorResponse
+// @Failure 401 {object} httputils.ErrorResponse
+// @Failure 404 {object} httputils.ErrorResponse
+// @Failure 500 {object} httputils.ErrorResponse
+// @Router /me/saved [post]
+func (h *ChatHandler) saveMessage(w http.ResponseWriter, r *http.Request) {
+	claims, err := getClaimsFromContext(r)
+	if err != nil {
+		httputils.ResponseError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req SaveMessageRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputils.ResponseError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.MessageID == 0 {
+		httputils.ResponseError(w, http.StatusBadRequest, "message_id is required")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	saved, err := h.chatService.SaveMessage(ctx, claims.UserID, req.MessageID, req.Note, req.Tags)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrSavedNoteTooLong),
+			errors.Is(err, service.ErrInvalidSavedTags):
+			httputils.ResponseError(w, http.StatusBadRequest, err.Error())
+		case errors.Is(err, service.ErrSavedSourceDenied):
+			httputils.ResponseError(w, http.StatusNotFound, err.Error())
+		default:
+			h.logger.Error("failed to save message", "error", err)
+			httputils.ResponseError(w, http.StatusInternalServerError, "failed to save message")
+		}
+		return
+	}
+
+	httputils.ResponseJSON(w, http.StatusOK, saved)
+}
+
+// GetSavedMessages возвращает закладки текущего пользователя
+// @Summary Get saved messages
+// @Description Get bookmarked messages of the current user, newest first, with the original message, its sender and chat. Entries whose original was deleted have original_deleted_at set and no message.
+// @ID get-saved-messages
+// @Tags chat
+// @Produce json
+// @Param Authorization header string true "Bearer токен" default(Bearer )
+// @Param tag query string false "Only entries with this tag"
+// @Param cursor query int false "ID of the last entry from the previous page"
+// @Param limit query int false "Limit" minimum(1) maximum(100) default(20)
+// @Success 200 {object} SavedMessagesResponse
+// @Failure 400 {object} httputils.ErrorResponse
+// @Failure 401 {object} httputils.ErrorResponse
+// @Failure 500 {object} httputils.ErrorResponse
+// @Router /me/saved [get]
+func (h *ChatHandler) getSavedMessages(w http.ResponseWriter, r *http.Request) {
+	claims, err := getClaimsFromContext(r)
+	if err != nil {
+		httputils.ResponseError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	queryParams := r.URL.Query()
+
+	var beforeID uint
+	if cursor := queryParams.Get("cursor"); cursor != "" {
+		parsed, err := strconv.ParseUint(cursor, 10, 64)
+		if err != nil {
+			httputils.ResponseError(w, http.StatusBadRequest, "invalid cursor")
+			return
+		}
+		beforeID = uint(parsed)
+	}
+
+	limit := 0
+	if limitStr := queryParams.Get("limit"); limitStr != "" {
+		if parsedLimit, err := strconv.Atoi(limitStr); err == nil {
+			limit = parsedLimit
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	saved, hasNext, err := h.chatService.GetSavedMessages(ctx, claims.UserID, beforeID, queryParams.Get("tag"), limit)
+	if err != nil {
+		h.logger.Error("failed to get saved messages", "error", err)
+		httputils.ResponseError(w, http.StatusInternalServerError, "failed to get saved messages")
+		return
+	}
+
+	// Чаты общие для нескольких закладок, ссылку на аватар генерируем один раз
+	filled := make(map[*model.Chat]bool)
+	for i := range saved {
+		if chat := saved[i].Chat; chat != nil && !filled[chat] {
+			h.fillChatAvatarURL(ctx, chat)
+			filled[chat] = true
+		}
+	}
+
+	var nextCursor *string
+	if hasNext && len(saved) > 0 {
+		cursor := strconv.FormatUint(uint64(saved[len(saved)-1].ID), 10)
+		nextCursor = &cursor
+	}
+
+	httputils.ResponseJSON(w, http.StatusOK, SavedMessagesResponse{
+		Data: saved,
+		Pagination: PaginationInfo{
+			NextCursor:  nextCursor,
+			HasNext:     hasNext,
+			HasPrevious: beforeID > 0,
+			Limit:       len(saved),
+		},
+	})
+}
+
+// DeleteSavedMessage убирает сообщение из закладок
+// @Summary Remove saved message
+// @Description Remove a message from the current user's bookmarks
+// @ID delete-saved-message
+// @Tags chat
+// @Produce json
+// @Param Authorization header string true "Bearer токен" default(Bearer )
+// @Param message_id path int true "Message ID"
+// @Success 200 {object} StatusResponse
+// @Failure 400 {object} httputils.ErrorResponse
+// @Failure 401 {object} httputils.ErrorResponse
+// @Failure 404 {object} httputils.ErrorResponse
+// @Failure 500 {object} httputils.ErrorResponse
+// @Router /me/saved/{message_id} [delete]
+func (h *ChatHandler) deleteSavedMessage(w http.ResponseWriter, r *http.Request) {
+	claims, err := getClaimsFromContext(r)
+	if err != nil {
+		httputils.ResponseError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	messageID, err := parsePathID(r, "message_id")
+	if err != nil {
+		httputils.ResponseError(w, http.StatusBadRequest, "invalid message id")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	deleted, err := h.chatService.DeleteSavedMessage(ctx, claims.UserID, messageID)
+	if err != nil {
+		h.logger.Error("failed to delete saved message", "error", err)
+		httputils.ResponseError(w, http.StatusInternalServerError, "failed to delete saved message")
+		return
+	}
+	if !deleted {
+		httputils.ResponseError(w, http.StatusNotFound, "saved message not found")
+		return
+	}
+
+	httputils.ResponseJSON(w, http.StatusOK, StatusResponse{Status: "saved message removed"})
+}
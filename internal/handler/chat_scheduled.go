@@ -0,0 +1,377 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"tush00nka/bbbab_messenger/internal/model"
+	"tush00nka/bbbab_messenger/internal/pkg/httputils"
+	"tush00nka/bbbab_messenger/internal/service"
+)
+
+// Параметры диспетчера отложенных сообщений
+const (
+	ScheduledDispatchInterval = 5 * time.Second
+	ScheduledDispatchBatch    = 50
+	// Сообщение, взятое в отправку и не завершенное за это время, забирается повторно
+	ScheduledStaleAfter = 5 * time.Minute
+)
+
+// ScheduleMessageRequest запрос на отложенную отправку сообщения
+type ScheduleMessageRequest struct {
+	Message       string                `json:"message" binding:"required,min=1,max=5000"`
+	Entities      model.MessageEntities `json:"entities,omitempty"`
+	Type          string                `json:"type" binding:"oneof=text image file" default:"text"`
+	AttachmentURL *string               `json:"attachment_url,omitempty"`
+	ReplyToID     *uint                 `json:"reply_to_id,omitempty"`
+	SendAt        time.Time             `json:"send_at" binding:"required"`
+}
+
+// UpdateScheduledMessageRequest запрос на изменение отложенного сообщения
+type UpdateScheduledMessageRequest struct {
+	Message *string `json:"message,omitempty"`
+	// Entities разметка нового текста, учитывается только вместе с message
+	Entities model.MessageEntities `json:"entities,omitempty"`
+	SendAt   *time.Time            `json:"send_at,omitempty"`
+}
+
+// ScheduleMessage создает отложенное сообщение
+// @Summary Schedule message
+// @Description Schedule a message to be sent to the chat at send_at (RFC 3339)
+// @ID schedule-message
+// @Tags chat
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer токен" default(Bearer )
+// @Param chat_id path int true "Chat ID"
+// @Param scheduleData body ScheduleMessageRequest true "Scheduled message"
+// @Success 201 {object} model.ScheduledMessage
+// @Failure 400 {object} httputils.ErrorResponse
+// @Failure 401 {object} httputils.ErrorResponse
+// @Failure 403 {object} httputils.ErrorResponse
+// @Failure 409 {object} httputils.ErrorResponse
+// @Failure 500 {object} httputils.ErrorResponse
+// @Router /chat/{chat_id}/scheduled [post]
+func (h *ChatHandler) scheduleMessage(w http.ResponseWriter, r *http.Request) {
+	claims, err := getClaimsFromContext(r)
+	if err != nil {
+		httputils.ResponseError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	chatID, err := parsePathID(r, "chat_id")
+	if err != nil {
+		httputils.ResponseError(w, http.StatusBadRequest, "invalid chat id")
+		return
+	}
+
+	var req ScheduleMessageRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputils.ResponseError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	defer r.Body.Close()
+
+	if strings.TrimSpace(req.Message) == "" || len(req.Message) > MaxMessageLength {
+		httputils.ResponseError(w, http.StatusBadRequest,
+			fmt.Sprintf("message must be 1-%d characters", MaxMessageLength))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	isMember, err := h.chatService.IsUserInChat(ctx, chatID, claims.UserID)
+	if err != nil || !isMember {
+		httputils.ResponseError(w, http.StatusForbidden, "user is not a member of this chat")
+		return
+	}
+
+	scheduled := &model.ScheduledMessage{
+		ChatID:        chatID,
+		SenderID:      claims.UserID,
+		Message:       req.Message,
+		Entities:      req.Entities,
+		Type:          req.Type,
+		AttachmentURL: req.AttachmentURL,
+		ReplyToID:     req.ReplyToID,
+		SendAt:        req.SendAt,
+	}
+	if err := h.chatService.ScheduleMessage(ctx, scheduled); err != nil {
+		h.responseScheduledError(w, err, "failed to schedule message")
+		return
+	}
+
+	httputils.ResponseJSON(w, http.StatusCreated, scheduled)
+}
+
+// ListScheduledMessages возвращает отложенные сообщения пользователя в чате
+// @Summary List scheduled messages
+// @Description Get pending scheduled messages of the current user in the chat, nearest first
+// @ID list-scheduled-messages
+// @Tags chat
+// @Produce json
+// @Param Authorization header string true "Bearer токен" default(Bearer )
+// @Param chat_id path int true "Chat ID"
+// @Success 200 {array} model.ScheduledMessage
+// @Failure 400 {object} httputils.ErrorResponse
+// @Failure 401 {object} httputils.ErrorResponse
+// @Failure 403 {object} httputils.ErrorResponse
+// @Failure 500 {object} httputils.ErrorResponse
+// @Router /chat/{chat_id}/scheduled [get]
+func (h *ChatHandler) listScheduledMessages(w http.ResponseWriter, r *http.Request) {
+	claims, err := getClaimsFromContext(r)
+	if err != nil {
+		httputils.ResponseError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	chatID, err := parsePathID(r, "chat_id")
+	if err != nil {
+		httputils.ResponseError(w, http.StatusBadRequest, "invalid chat id")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	isMember, err := h.chatService.IsUserInChat(ctx, chatID, claims.UserID)
+	if err != nil || !isMember {
+		httputils.ResponseError(w, http.StatusForbidden, "user is not a member of this chat")
+		return
+	}
+
+	messages, err := h.chatService.GetScheduledMessages(ctx, chatID, claims.UserID)
+	if err != nil {
+		h.logger.Error("failed to get scheduled messages", "error", err)
+		httputils.ResponseError(w, http.StatusInternalServerError, "failed to get scheduled messages")
+		return
+	}
+
+	httputils.ResponseJSON(w, http.StatusOK, messages)
+}
+
+// UpdateScheduledMessage изменяет текст или время отправки отложенного сообщения
+// @Summary Update scheduled message
+// @Description Change text and/or send time of a pending scheduled message
+// @ID update-scheduled-message
+// @Tags chat
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer токен" default(Bearer )
+// @Param id path int true "Scheduled message ID"
+// @Param updateData body UpdateScheduledMessageRequest true "Fields to update"
+// @Success 200 {object} model.ScheduledMessage
+// @Failure 400 {object} httputils.ErrorResponse
+// @Failure 401 {object} httputils.ErrorResponse
+// @Failure 404 {object} httputils.ErrorResponse
+// @Failure 409 {object} httputils.ErrorResponse
+// @Failure 500 {object} httputils.ErrorResponse
+// @Router /chat/scheduled/{id} [put]
+func (h *ChatHandler) updateScheduledMessage(w http.ResponseWriter, r *http.Request) {
+	claims, err := getClaimsFromContext(r)
+	if err != nil {
+		httputils.ResponseError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	id, err := parsePathID(r, "id")
+	if err != nil {
+		httputils.ResponseError(w, http.StatusBadRequest, "invalid scheduled message id")
+		return
+	}
+
+	var req UpdateScheduledMessageRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputils.ResponseError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	defer r.Body.Close()
+
+	if req.Message != nil {
+		if strings.TrimSpace(*req.Message) == "" || len(*req.Message) > MaxMessageLength {
+			httputils.ResponseError(w, http.StatusBadRequest,
+				fmt.Sprintf("message must be 1-%d characters", MaxMessageLength))
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	scheduled, err := h.chatService.UpdateScheduledMessage(ctx, claims.UserID, id, req.Message, req.Entities, req.SendAt)
+	if err != nil {
+		h.responseScheduledError(w, err, "failed to update scheduled message")
+		return
+	}
+
+	httputils.ResponseJSON(w, http.StatusOK, scheduled)
+}
+
+// CancelScheduledMessage отменяет отложенное сообщение
+// @Summary Cancel scheduled message
+// @Description Cancel a pending scheduled message
+// @ID cancel-scheduled-message
+// @Tags chat
+// @Produce json
+// @Param Authorization header string true "Bearer токен" default(Bearer )
+// @Param id path int true "Scheduled message ID"
+// @Success 200 {object} model.ScheduledMessage
+// @Failure 400 {object} httputils.ErrorResponse
+// @Failure 401 {object} httputils.ErrorResponse
+// @Failure 404 {object} httputils.ErrorResponse
+// @Failure 409 {object} httputils.ErrorResponse
+// @Failure 500 {object} httputils.ErrorResponse
+// @Router /chat/scheduled/{id} [delete]
+func (h *ChatHandler) cancelScheduledMessage(w http.ResponseWriter, r *http.Request) {
+	claims, err := getClaimsFromContext(r)
+	if err != nil {
+		httputils.ResponseError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	id, err := parsePathID(r, "id")
+	if err != nil {
+		httputils.ResponseError(w, http.StatusBadRequest, "invalid scheduled message id")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	scheduled, err := h.chatService.CancelScheduledMessage(ctx, claims.UserID, id)
+	if err != nil {
+		h.responseScheduledError(w, err, "failed to cancel scheduled message")
+		return
+	}
+
+	httputils.ResponseJSON(w, http.StatusOK, scheduled)
+}
+
+// responseScheduledError отображает ошибки сервиса отложенных сообщений в HTTP-статусы
+func (h *ChatHandler) responseScheduledError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, service.ErrScheduledNotFound):
+		httputils.ResponseError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, service.ErrScheduledNotEditable),
+		errors.Is(err, service.ErrTooManyScheduled):
+		httputils.ResponseError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, service.ErrScheduledInPast),
+		errors.Is(err, service.ErrScheduledTooFar),
+		errors.Is(err, service.ErrScheduledEmptyMessage),
+		errors.Is(err, service.ErrInvalidPoll),
+		errors.Is(err, service.ErrInvalidEntities):
+		httputils.ResponseError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, service.ErrChannelReadOnly):
+		httputils.ResponseError(w, http.StatusForbidden, err.Error())
+	default:
+		h.logger.Error(fallback, "error", err)
+		httputils.ResponseError(w, http.StatusInternalServerError, fallback)
+	}
+}
+
+// RunScheduledDispatcher периодически отправляет отложенные сообщения, время которых наступило.
+// Блокирует вызывающую горутину до отмены ctx. Безопасен при запуске на нескольких экземплярах:
+// сообщения забираются из БД через SELECT ... FOR UPDATE SKIP LOCKED.
+func (h *ChatHandler) RunScheduledDispatcher(ctx context.Context) {
+	ticker := time.NewTicker(ScheduledDispatchInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			h.dispatchScheduledMessages(ctx)
+		}
+	}
+}
+
+// dispatchScheduledMessages отправляет пачки наступивших сообщений, пока очередь не опустеет
+func (h *ChatHandler) dispatchScheduledMessages(ctx context.Context) {
+	for {
+		claimCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		due, err := h.chatService.ClaimDueScheduledMessages(claimCtx, ScheduledDispatchBatch, ScheduledStaleAfter)
+		cancel()
+		if err != nil {
+			h.logger.Error("failed to claim scheduled messages", "error", err)
+			return
+		}
+
+		for _, scheduled := range due {
+			h.deliverScheduledMessage(ctx, scheduled)
+		}
+
+		if len(due) < ScheduledDispatchBatch || ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// deliverScheduledMessage отправляет одно отложенное сообщение тем же путем, что и sendMessage
+func (h *ChatHandler) deliverScheduledMessage(parent context.Context, scheduled model.ScheduledMessage) {
+	ctx, cancel := context.WithTimeout(parent, 5*time.Second)
+	defer cancel()
+
+	messageID, sendErr := h.sendScheduledMessage(ctx, scheduled)
+
+	var slowModeErr *service.SlowModeError
+	if errors.As(sendErr, &slowModeErr) {
+		// Медленный режим: пробуем снова, когда освободится слот
+		retryAt := time.Now().Add(slowModeErr.RetryAfter)
+		if err := h.chatService.RescheduleScheduledMessage(ctx, scheduled.ID, retryAt, sendErr); err != nil {
+			h.logger.Error("failed to reschedule message", "error", err, "scheduled_id", scheduled.ID)
+		}
+		return
+	}
+
+	var sentID *uint
+	if sendErr != nil {
+		h.logger.Warn("failed to send scheduled message", "error", sendErr, "scheduled_id", scheduled.ID)
+	} else {
+		sentID = &messageID
+	}
+
+	if err := h.chatService.CompleteScheduledMessage(ctx, scheduled.ID, sentID, sendErr); err != nil {
+		h.logger.Error("failed to complete scheduled message", "error", err, "scheduled_id", scheduled.ID)
+	}
+}
+
+// sendScheduledMessage проверяет, что отправитель все еще в чате, и отправляет сообщение
+func (h *ChatHandler) sendScheduledMessage(ctx context.Context, scheduled model.ScheduledMessage) (uint, error) {
+	isMember, err := h.chatService.IsUserInChat(ctx, scheduled.ChatID, scheduled.SenderID)
+	if err != nil {
+		return 0, err
+	}
+	if !isMember {
+		return 0, errors.New("sender is no longer a member of this chat")
+	}
+
+	chat, err := h.chatService.GetChatMeta(ctx, scheduled.ChatID)
+	if err != nil {
+		return 0, err
+	}
+	if chat == nil {
+		return 0, errors.New("chat not found")
+	}
+
+	msg := model.Message{
+		ChatID:        scheduled.ChatID,
+		SenderID:      scheduled.SenderID,
+		Message:       scheduled.Message,
+		Entities:      scheduled.Entities,
+		Type:          scheduled.Type,
+		AttachmentURL: scheduled.AttachmentURL,
+		ReplyToID:     scheduled.ReplyToID,
+		Timestamp:     time.Now(),
+	}
+	if err := h.processMessage(ctx, chat, &msg); err != nil {
+		return 0, err
+	}
+
+	return msg.ID, nil
+}
@@ -0,0 +1,201 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+	"tush00nka/bbbab_messenger/internal/model"
+	"tush00nka/bbbab_messenger/internal/pkg/httputils"
+	"tush00nka/bbbab_messenger/internal/service"
+)
+
+// SearchMessagesResponse страница результатов поиска
+type SearchMessagesResponse struct {
+	Data       []model.MessageSearchResult `json:"data"`
+	Pagination PaginationInfo              `json:"pagination"`
+}
+
+// SearchMessages ищет сообщения в чате
+// @Summary Search chat messages
+// @Description Full-text search over chat messages with word forms, ranked by relevance. Words are combined with AND, "quoted text" is matched as a phrase, word* matches by prefix. Each result has a snippet with highlighted matches (UTF-16 offsets).
+// @ID search-chat-messages
+// @Tags chat
+// @Produce json
+// @Param Authorization header string true "Bearer токен" default(Bearer )
+// @Param chat_id path int true "Chat ID"
+// @Param q query string true "Search query"
+// @Param cursor query string false "Cursor from the previous page"
+// @Param limit query int false "Limit" minimum(1) maximum(100) default(20)
+// @Success 200 {object} SearchMessagesResponse
+// @Failure 400 {object} httputils.ErrorResponse
+// @Failure 401 {object} httputils.ErrorResponse
+// @Failure 403 {object} httputils.ErrorResponse
+// @Failure 500 {object} httputils.ErrorResponse
+// @Router /chat/{chat_id}/search [get]
+func (h *ChatHandler) searchMessages(w http.ResponseWriter, r *http.Request) {
+	claims, err := getClaimsFromContext(r)
+	if err != nil {
+		httputils.ResponseError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	chatID, err := parsePathID(r, "chat_id")
+	if err != nil {
+		httputils.ResponseError(w, http.StatusBadRequest, "invalid chat id")
+		return
+	}
+
+	queryParams := r.URL.Query()
+	cursor := queryParams.Get("cursor")
+
+	limit := 0
+	if limitStr := queryParams.Get("limit"); limitStr != "" {
+		if parsedLimit, err := strconv.Atoi(limitStr); err == nil {
+			limit = parsedLimit
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	results, nextCursor, err := h.chatService.SearchMessages(ctx, chatID, claims.UserID, queryParams.Get("q"), cursor, limit)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrEmptySearchQuery),
+			errors.Is(err, service.ErrSearchQueryTooLong),
+			errors.Is(err, service.ErrInvalidSearchCursor):
+			httputils.ResponseError(w, http.StatusBadRequest, err.Error())
+		case errors.Is(err, service.ErrNotChatMember):
+			httputils.ResponseError(w, http.StatusForbidden, err.Error())
+		default:
+			h.logger.Error("failed to search messages", "error", err)
+			httputils.ResponseError(w, http.StatusInternalServerError, "failed to search messages")
+		}
+		return
+	}
+
+	response := SearchMessagesResponse{
+		Data: results,
+		Pagination: PaginationInfo{
+			HasNext:     nextCursor != "",
+			HasPrevious: cursor != "",
+			Limit:       len(results),
+		},
+	}
+	if nextCursor != "" {
+		response.Pagination.NextCursor = &nextCursor
+	}
+
+	httputils.ResponseJSON(w, http.StatusOK, response)
+}
+
+// GlobalSearchResponse результаты поиска по всем чатам, сгруппированные по разделам
+type GlobalSearchResponse struct {
+	Chats      []model.Chat                `json:"chats"`
+	People     []*model.User               `json:"people"`
+	Messages   []model.MessageSearchResult `json:"messages"`
+	Pagination PaginationInfo              `json:"pagination"`
+}
+
+// GlobalSearch ищет по всем чатам текущего пользователя
+// @Summary Global search
+// @Description Search across all chats of the current user: chats by name, people by username and messages by text. Pagination applies to messages; chats and people are returned on the first page only and only when no message filter is set.
+// @ID global-search
+// @Tags chat
+// @Produce json
+// @Param Authorization header string true "Bearer токен" default(Bearer )
+// @Param q query string true "Search query"
+// @Param chat_id query int false "Only messages from this chat"
+// @Param sender_id query int false "Only messages from this sender"
+// @Param type query string false "Only messages of this type" Enums(text, image, file, system, poll)
+// @Param from query string false "Messages sent at or after this time (RFC3339)"
+// @Param to query string false "Messages sent before this time (RFC3339)"
+// @Param cursor query string false "Cursor from the previous page"
+// @Param limit query int false "Limit" minimum(1) maximum(100) default(20)
+// @Success 200 {object} GlobalSearchResponse
+// @Failure 400 {object} httputils.ErrorResponse
+// @Failure 401 {object} httputils.ErrorResponse
+// @Failure 500 {object} httputils.ErrorResponse
+// @Router /search [get]
+func (h *ChatHandler) globalSearch(w http.ResponseWriter, r *http.Request) {
+	claims, err := getClaimsFromContext(r)
+	if err != nil {
+		httputils.ResponseError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	queryParams := r.URL.Query()
+	params := service.GlobalSearchParams{
+		Query:  queryParams.Get("q"),
+		Type:   queryParams.Get("type"),
+		Cursor: queryParams.Get("cursor"),
+	}
+
+	for name, target := range map[string]*uint{"chat_id": &params.ChatID, "sender_id": &params.SenderID} {
+		if value := queryParams.Get(name); value != "" {
+			parsed, err := strconv.ParseUint(value, 10, 64)
+			if err != nil {
+				httputils.ResponseError(w, http.StatusBadRequest, "invalid "+name)
+				return
+			}
+			*target = uint(parsed)
+		}
+	}
+
+	for name, target := range map[string]**time.Time{"from": &params.From, "to": &params.To} {
+		if value := queryParams.Get(name); value != "" {
+			parsed, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				httputils.ResponseError(w, http.StatusBadRequest, "invalid "+name+", expected RFC3339")
+				return
+			}
+			*target = &parsed
+		}
+	}
+
+	if limitStr := queryParams.Get("limit"); limitStr != "" {
+		if parsedLimit, err := strconv.Atoi(limitStr); err == nil {
+			params.Limit = parsedLimit
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	result, err := h.chatService.GlobalSearch(ctx, claims.UserID, params)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrEmptySearchQuery),
+			errors.Is(err, service.ErrSearchQueryTooLong),
+			errors.Is(err, service.ErrInvalidSearchCursor),
+			errors.Is(err, service.ErrInvalidSearchFilter):
+			httputils.ResponseError(w, http.StatusBadRequest, err.Error())
+		default:
+			h.logger.Error("failed to search", "error", err)
+			httputils.ResponseError(w, http.StatusInternalServerError, "failed to search")
+		}
+		return
+	}
+
+	for i := range result.Chats {
+		h.fillChatAvatarURL(ctx, &result.Chats[i])
+	}
+
+	response := GlobalSearchResponse{
+		Chats:    result.Chats,
+		People:   result.People,
+		Messages: result.Messages,
+		Pagination: PaginationInfo{
+			HasNext:     result.NextCursor != "",
+			HasPrevious: params.Cursor != "",
+			Limit:       len(result.Messages),
+		},
+	}
+	if result.NextCursor != "" {
+		response.Pagination.NextCursor = &result.NextCursor
+	}
+
+	httputils.ResponseJSON(w, http.StatusOK, response)
+}
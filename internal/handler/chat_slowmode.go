@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+	"tush00nka/bbbab_messenger/internal/model"
+	"tush00nka/bbbab_messenger/internal/pkg/httputils"
+	"tush00nka/bbbab_messenger/internal/service"
+)
+
+// SlowModeRequest запрос на настройку медленного режима
+type SlowModeRequest struct {
+	Seconds int `json:"seconds" binding:"min=0,max=3600"` // 0 — выключить
+}
+
+// SlowModeErrorResponse ответ при срабатывании медленного режима
+type SlowModeErrorResponse struct {
+	Message    string `json:"message"`
+	RetryAfter int    `json:"retry_after"` // секунды до следующей отправки
+}
+
+// SetSlowMode настраивает медленный режим группы
+// @Summary Set chat slow mode
+// @Description Set minimal interval between messages of one member in a group chat (admins only, admins are not limited)
+// @ID set-slow-mode
+// @Tags chat
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer токен" default(Bearer )
+// @Param chat_id path int true "Chat ID"
+// @Param slowModeData body SlowModeRequest true "Slow mode interval"
+// @Success 200 {object} model.Chat
+// @Failure 400 {object} httputils.ErrorResponse
+// @Failure 401 {object} httputils.ErrorResponse
+// @Failure 403 {object} httputils.ErrorResponse
+// @Failure 404 {object} httputils.ErrorResponse
+// @Failure 500 {object} httputils.ErrorResponse
+// @Router /chat/{chat_id}/slowmode [put]
+func (h *ChatHandler) setSlowMode(w http.ResponseWriter, r *http.Request) {
+	claims, err := getClaimsFromContext(r)
+	if err != nil {
+		httputils.ResponseError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	chatID, err := parsePathID(r, "chat_id")
+	if err != nil {
+		httputils.ResponseError(w, http.StatusBadRequest, "invalid chat id")
+		return
+	}
+
+	var req SlowModeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputils.ResponseError(w, http.StatusBadRequest, "invalid request format")
+		return
+	}
+	defer r.Body.Close()
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	chat, ok := h.getGroupForAdmin(ctx, w, chatID, claims.UserID)
+	if !ok {
+		return
+	}
+
+	if err := h.chatService.SetSlowMode(ctx, chatID, req.Seconds); err != nil {
+		httputils.ResponseError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if chat.SlowModeSeconds != req.Seconds {
+		h.recordAudit(model.ChatAuditLog{
+			ChatID:  chatID,
+			ActorID: claims.UserID,
+			Action:  model.AuditSlowModeChanged,
+			Before:  strconv.Itoa(chat.SlowModeSeconds),
+			After:   strconv.Itoa(req.Seconds),
+		})
+	}
+
+	chat.SlowModeSeconds = req.Seconds
+	h.fillChatAvatarURL(ctx, chat)
+	h.broadcastChatUpdated(chat, claims.UserID)
+
+	httputils.ResponseJSON(w, http.StatusOK, chat)
+}
+
+// responseSlowMode отвечает 429 с временем ожидания до следующей отправки
+func responseSlowMode(w http.ResponseWriter, err *service.SlowModeError) {
+	w.Header().Set("Retry-After", strconv.Itoa(err.RetryAfterSeconds()))
+	httputils.ResponseJSON(w, http.StatusTooManyRequests, SlowModeErrorResponse{
+		Message:    err.Error(),
+		RetryAfter: err.RetryAfterSeconds(),
+	})
+}
@@ -0,0 +1,145 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+	"tush00nka/bbbab_messenger/internal/model"
+	"tush00nka/bbbab_messenger/internal/pkg/httputils"
+	"tush00nka/bbbab_messenger/internal/service"
+)
+
+// PushDeliveryTimeout время на доставку уведомлений об одном сообщении, включая повторы
+const PushDeliveryTimeout = 2 * time.Minute
+
+// RegisterDeviceRequest запрос на регистрацию устройства для push-уведомлений
+type RegisterDeviceRequest struct {
+	// Platform fcm, apns или webpush
+	Platform string `json:"platform"`
+	// Token токен устройства; для webpush — подписка из PushManager.subscribe() в JSON
+	Token string `json:"token"`
+}
+
+// UnregisterDeviceRequest запрос на удаление устройства
+type UnregisterDeviceRequest struct {
+	Token string `json:"token"`
+}
+
+// RegisterDevice регистрирует устройство для push-уведомлений
+// @Summary Register push device
+// @Description Register a device token of the current user. Push notifications about new messages are sent to devices of members who do not have the chat open, according to their chat notification settings. A token registered by another user moves to the current one.
+// @ID register-push-device
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer токен" default(Bearer )
+// @Param request body RegisterDeviceRequest true "Device"
+// @Success 201 {object} model.DeviceToken
+// @Failure 400 {object} httputils.ErrorResponse
+// @Failure 401 {object} httputils.ErrorResponse
+// @Failure 500 {object} httputils.ErrorResponse
+// @Router /me/devices [post]
+func (h *ChatHandler) registerDevice(w http.ResponseWriter, r *http.Request) {
+	claims, err := getClaimsFromContext(r)
+	if err != nil {
+		httputils.ResponseError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	if h.notifications == nil {
+		httputils.ResponseError(w, http.StatusBadRequest, service.ErrUnsupportedPushPlatform.Error())
+		return
+	}
+
+	var req RegisterDeviceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputils.ResponseError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	device, err := h.notifications.RegisterDevice(ctx, claims.UserID, req.Platform, req.Token)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrUnsupportedPushPlatform),
+			errors.Is(err, service.ErrInvalidDeviceToken):
+			httputils.ResponseError(w, http.StatusBadRequest, err.Error())
+		default:
+			h.logger.Error("failed to register device", "error", err)
+			httputils.ResponseError(w, http.StatusInternalServerError, "failed to register device")
+		}
+		return
+	}
+
+	httputils.ResponseJSON(w, http.StatusCreated, device)
+}
+
+// UnregisterDevice удаляет устройство из рассылки push-уведомлений
+// @Summary Unregister push device
+// @Description Stop push notifications to the device, e.g. on logout
+// @ID unregister-push-device
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer токен" default(Bearer )
+// @Param request body UnregisterDeviceRequest true "Device"
+// @Success 200 {object} StatusResponse
+// @Failure 400 {object} httputils.ErrorResponse
+// @Failure 401 {object} httputils.ErrorResponse
+// @Failure 404 {object} httputils.ErrorResponse
+// @Failure 500 {object} httputils.ErrorResponse
+// @Router /me/devices [delete]
+func (h *ChatHandler) unregisterDevice(w http.ResponseWriter, r *http.Request) {
+	claims, err := getClaimsFromContext(r)
+	if err != nil {
+		httputils.ResponseError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req UnregisterDeviceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Token == "" {
+		httputils.ResponseError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if h.notifications == nil {
+		httputils.ResponseError(w, http.StatusNotFound, "device not found")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	found, err := h.notifications.UnregisterDevice(ctx, claims.UserID, req.Token)
+	if err != nil {
+		h.logger.Error("failed to unregister device", "error", err)
+		httputils.ResponseError(w, http.StatusInternalServerError, "failed to unregister device")
+		return
+	}
+	if !found {
+		httputils.ResponseError(w, http.StatusNotFound, "device not found")
+		return
+	}
+
+	httputils.ResponseJSON(w, http.StatusOK, StatusResponse{Status: "device unregistered"})
+}
+
+// sendPushNotifications асинхронно уведомляет участников без открытого чата о новом сообщении
+func (h *ChatHandler) sendPushNotifications(msg model.Message) {
+	if h.notifications == nil {
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), PushDeliveryTimeout)
+		defer cancel()
+
+		if err := h.notifications.NotifyNewMessage(ctx, msg); err != nil {
+			h.logger.Warn("failed to send push notifications", "error", err)
+		}
+	}()
+}
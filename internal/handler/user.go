@@ -40,6 +40,7 @@ func (c *UserHandler) RegisterRoutes(router *mux.Router) {
 	router.HandleFunc("/user/{id}/avatar", c.UploadProfilePicture).Methods("POST", "OPTIONS")
 	router.HandleFunc("/user/{id}/avatar", c.GetProfilePicture).Methods("GET", "OPTIONS")
 	router.HandleFunc("/me", c.getCurrentUser).Methods("GET", "OPTIONS")
+	router.HandleFunc("/me/privacy", c.updatePrivacy).Methods("PUT", "OPTIONS")
 	router.HandleFunc("/search/{prompt}", c.searchUser).Methods("GET", "OPTIONS")
 
 	// router.HandleFunc("/sms", c.sendSMS).Methods("POST", "OPTIONS")
@@ -335,6 +336,59 @@ func (h *UserHandler) getCurrentUser(w http.ResponseWriter, r *http.Request) {
 	httputils.ResponseJSON(w, http.StatusOK, user)
 }
 
+type UpdatePrivacyRequest struct {
+	HideForwardOrigin *bool `json:"hide_forward_origin"`
+}
+
+// @Summary Update privacy settings
+// @Description Update privacy settings of current user
+// @ID update-privacy
+// @Tags user
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer токен" default(Bearer )
+// @Param privacyData body UpdatePrivacyRequest true "Privacy settings"
+// @Success 200 {object} model.User
+// @Failure 400 {object} httputils.ErrorResponse
+// @Failure 401 {object} httputils.ErrorResponse
+// @Failure 500 {object} httputils.ErrorResponse
+// @Router /me/privacy [put]
+func (h *UserHandler) updatePrivacy(w http.ResponseWriter, r *http.Request) {
+	tokenStr := extractTokenFromHeader(r)
+	if tokenStr == "" {
+		httputils.ResponseError(w, http.StatusUnauthorized, "missing auth token")
+		return
+	}
+	claims, err := auth.ValidateToken(tokenStr)
+	if err != nil {
+		httputils.ResponseError(w, http.StatusUnauthorized, "invalid token")
+		return
+	}
+
+	var req UpdatePrivacyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputils.ResponseError(w, http.StatusBadRequest, "invalid request format")
+		return
+	}
+	defer r.Body.Close()
+
+	if req.HideForwardOrigin != nil {
+		if err := h.userService.UpdatePrivacy(claims.UserID, *req.HideForwardOrigin); err != nil {
+			httputils.ResponseError(w, http.StatusInternalServerError, "failed to update privacy settings")
+			return
+		}
+	}
+
+	user, err := h.userService.GetUserByID(claims.UserID)
+	if err != nil {
+		httputils.ResponseError(w, http.StatusNotFound, "No such user")
+		return
+	}
+
+	user.SanitizePassword()
+	httputils.ResponseJSON(w, http.StatusOK, user)
+}
+
 // @Summary Search users
 // @Description Search users by username
 // @ID search-user
@@ -412,20 +466,9 @@ func (h *UserHandler) UploadProfilePicture(w http.ResponseWriter, r *http.Reques
 	}
 	defer file.Close()
 
-	if header.Size > 5*1024*1024 {
-		httputils.ResponseError(w, http.StatusBadRequest, "file too large. max size is 5MB")
-		return
-	}
-
-	contentType := header.Header.Get("Content-Type")
-	allowedTypes := map[string]bool{
-		"image/jpeg": true,
-		"image/jpg":  true,
-		"image/png":  true,
-		"image/gif":  true,
-	}
-	if !allowedTypes[contentType] {
-		httputils.ResponseError(w, http.StatusBadRequest, "Invalid file type. Only JPEG, PNG and GIF are allowed")
+	contentType, err := validateAvatarFile(header)
+	if err != nil {
+		httputils.ResponseError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
@@ -1,8 +1,13 @@
 package handler
 
 import (
+	"errors"
+	"mime/multipart"
 	"net/http"
+	"strconv"
 	"tush00nka/bbbab_messenger/internal/pkg/httputils"
+
+	"github.com/gorilla/mux"
 )
 
 type PongResponse struct {
@@ -20,3 +25,39 @@ type PongResponse struct {
 func Ping(w http.ResponseWriter, r *http.Request) {
 	httputils.ResponseJSON(w, 200, PongResponse{Message: "Pong"})
 }
+
+// parsePathID извлекает положительный числовой идентификатор из пути запроса
+func parsePathID(r *http.Request, key string) (uint, error) {
+	id, err := strconv.ParseUint(mux.Vars(r)[key], 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	if id == 0 {
+		return 0, errors.New("id cannot be zero")
+	}
+	return uint(id), nil
+}
+
+// MaxAvatarSize максимальный размер загружаемого аватара
+const MaxAvatarSize = 5 * 1024 * 1024
+
+var allowedAvatarTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/jpg":  true,
+	"image/png":  true,
+	"image/gif":  true,
+}
+
+// validateAvatarFile проверяет размер и тип аватара, возвращает его Content-Type
+func validateAvatarFile(header *multipart.FileHeader) (string, error) {
+	if header.Size > MaxAvatarSize {
+		return "", errors.New("file too large. max size is 5MB")
+	}
+
+	contentType := header.Header.Get("Content-Type")
+	if !allowedAvatarTypes[contentType] {
+		return "", errors.New("Invalid file type. Only JPEG, PNG and GIF are allowed")
+	}
+
+	return contentType, nil
+}
@@ -6,24 +6,68 @@ import (
 	"gorm.io/gorm"
 )
 
+// Роли участников чата
+const (
+	ChatRoleOwner  = "owner"
+	ChatRoleAdmin  = "admin"
+	ChatRoleMember = "member"
+)
+
 type Chat struct {
 	gorm.Model
-	Name     string `json:"name"` // опционально — имя группового чата
-	Users    []User `gorm:"many2many:chat_users;"`
-	Messages []Message
-	IsGroup  bool
+	Name        string `json:"name"` // опционально — имя группового чата
+	Description string `gorm:"type:text" json:"description"`
+	AvatarKey   string `json:"avatar_key"`
+	Users       []User `gorm:"many2many:chat_users;"`
+	Messages    []Message
+	IsGroup     bool
+	// IsChannel канал: публикуют только администраторы, подписчики только читают.
+	// У каналов IsGroup тоже выставлен, чтобы на них распространялись групповые функции.
+	IsChannel bool `gorm:"default:false;index" json:"is_channel"`
+	// SlowModeSeconds минимальный интервал между сообщениями одного участника, 0 — выключен
+	SlowModeSeconds int `gorm:"default:0" json:"slow_mode_seconds"`
+	// MessageTTLSeconds время жизни новых сообщений (исчезающие сообщения), 0 — выключено
+	MessageTTLSeconds int `gorm:"default:0" json:"message_ttl_seconds"`
+	// JoinByRequest разрешает подавать заявки на вступление без пригласительной ссылки
+	JoinByRequest bool `gorm:"default:false" json:"join_by_request"`
+
+	// AvatarURL временная ссылка на аватар, не хранится в БД
+	AvatarURL string `gorm:"-" json:"avatar_url,omitempty"`
+	// SubscriberCount количество подписчиков канала, не хранится в БД
+	SubscriberCount int64 `gorm:"-" json:"subscriber_count,omitempty"`
+	// PinnedMessage последнее закрепленное сообщение, не хранится в БД
+	PinnedMessage *PinnedMessage `gorm:"-" json:"pinned_message,omitempty"`
 }
 
 // ChatUser - промежуточная таблица для связи many-to-many
 type ChatUser struct {
 	ChatID    uint           `gorm:"primaryKey"`
 	UserID    uint           `gorm:"primaryKey"`
+	Role      string         `gorm:"type:varchar(20);default:'member'" json:"role"`
 	CreatedAt time.Time      `gorm:"autoCreateTime"`
 	UpdatedAt time.Time      `gorm:"autoUpdateTime"`
 	DeletedAt gorm.DeletedAt `gorm:"index"`
+
+	// HistoryClearedUpToID сообщения с ID не больше этого скрыты от участника («очистить историю у себя»)
+	HistoryClearedUpToID uint `gorm:"default:0" json:"history_cleared_up_to_id"`
+
+	// Организация списка чатов участника
+	// PinnedPosition место закрепленного чата вверху списка, начиная с 1; 0 — не закреплен
+	PinnedPosition int `gorm:"default:0" json:"pinned_position"`
+	// ArchivedAt момент архивации, nil — чат в основном списке
+	ArchivedAt *time.Time `json:"archived_at,omitempty"`
+
+	// Уведомления участника, см. ChatNotificationSettings
+	NotifyMode string     `gorm:"type:varchar(16);not null;default:'all'" json:"notify_mode"`
+	MutedUntil *time.Time `json:"muted_until,omitempty"`
 }
 
 // TableName задает имя таблицы
 func (ChatUser) TableName() string {
 	return "chat_users"
 }
+
+// IsAdminRole проверяет, дает ли роль права администратора
+func IsAdminRole(role string) bool {
+	return role == ChatRoleOwner || role == ChatRoleAdmin
+}
@@ -0,0 +1,47 @@
+package model
+
+import "time"
+
+// Действия журнала модерации
+const (
+	AuditUserAdded         = "user_added"
+	AuditUserRemoved       = "user_removed"
+	AuditUserLeft          = "user_left"
+	AuditUserKicked        = "user_kicked"
+	AuditUserBanned        = "user_banned"
+	AuditUserUnbanned      = "user_unbanned"
+	AuditRoleChanged       = "role_changed"
+	AuditChatRenamed       = "chat_renamed"
+	AuditDescriptionEdited = "description_changed"
+	AuditAvatarChanged     = "avatar_changed"
+	AuditSlowModeChanged   = "slow_mode_changed"
+	AuditMessageTTLChanged = "message_ttl_changed"
+	AuditJoinByRequest     = "join_by_request_changed"
+	AuditMessageDeleted    = "message_deleted"
+	AuditMessagePinned     = "message_pinned"
+	AuditMessageUnpinned   = "message_unpinned"
+	AuditInviteCreated     = "invite_created"
+	AuditInviteRevoked     = "invite_revoked"
+	AuditJoinApproved      = "join_request_approved"
+	AuditJoinRejected      = "join_request_rejected"
+)
+
+// ChatAuditLog запись журнала административных действий в чате
+type ChatAuditLog struct {
+	ID              uint      `gorm:"primarykey;index:idx_chat_audit_chat_id,priority:2" json:"id"`
+	ChatID          uint      `gorm:"index:idx_chat_audit_chat_id,priority:1;not null" json:"chat_id"`
+	ActorID         uint      `gorm:"not null" json:"actor_id"`
+	Action          string    `gorm:"type:varchar(50);not null" json:"action"`
+	TargetUserID    *uint     `json:"target_user_id,omitempty"`
+	TargetMessageID *uint     `json:"target_message_id,omitempty"`
+	Before          string    `gorm:"type:text" json:"before,omitempty"`
+	After           string    `gorm:"type:text" json:"after,omitempty"`
+	CreatedAt       time.Time `gorm:"autoCreateTime" json:"created_at"`
+
+	Actor User `gorm:"foreignKey:ActorID" json:"actor"`
+}
+
+// TableName задает имя таблицы
+func (ChatAuditLog) TableName() string {
+	return "chat_audit_log"
+}
@@ -0,0 +1,24 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ChatBan блокировка пользователя в групповом чате
+type ChatBan struct {
+	gorm.Model
+	ChatID     uint       `gorm:"uniqueIndex:idx_chat_ban_user;not null" json:"chat_id"`
+	UserID     uint       `gorm:"uniqueIndex:idx_chat_ban_user;not null" json:"user_id"`
+	BannedByID uint       `gorm:"not null" json:"banned_by_id"`
+	Reason     string     `gorm:"type:varchar(500)" json:"reason,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"` // nil — бессрочно
+
+	User User `gorm:"foreignKey:UserID" json:"user"`
+}
+
+// IsActive проверяет, действует ли блокировка
+func (b *ChatBan) IsActive(now time.Time) bool {
+	return b.ExpiresAt == nil || now.Before(*b.ExpiresAt)
+}
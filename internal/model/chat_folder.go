@@ -0,0 +1,33 @@
+package model
+
+import "time"
+
+// ChatFolder пользовательская папка чатов. Чат попадает в папку, если подходит
+// под одно из правил включения и ни под одно правило исключения.
+type ChatFolder struct {
+	ID       uint   `gorm:"primarykey" json:"id"`
+	UserID   uint   `gorm:"not null;index" json:"user_id"`
+	Name     string `gorm:"type:varchar(64);not null" json:"name"`
+	Position int    `gorm:"not null;default:0" json:"position"`
+
+	// Правила включения
+	IncludeDirect   bool   `gorm:"default:false" json:"include_direct"`
+	IncludeGroups   bool   `gorm:"default:false" json:"include_groups"`
+	IncludeChannels bool   `gorm:"default:false" json:"include_channels"`
+	IncludeChatIDs  []uint `gorm:"type:jsonb;serializer:json" json:"include_chat_ids,omitempty"`
+
+	// Правила исключения
+	ExcludeRead     bool   `gorm:"default:false" json:"exclude_read"`
+	ExcludeArchived bool   `gorm:"default:false" json:"exclude_archived"`
+	ExcludeChatIDs  []uint `gorm:"type:jsonb;serializer:json" json:"exclude_chat_ids,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ChatOrganization настройки списка чатов пользователя, общие для всех его устройств
+type ChatOrganization struct {
+	PinnedChatIDs   []uint       `json:"pinned_chat_ids"`
+	ArchivedChatIDs []uint       `json:"archived_chat_ids"`
+	Folders         []ChatFolder `json:"folders"`
+}
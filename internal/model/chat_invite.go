@@ -0,0 +1,55 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ChatInvite пригласительная ссылка в групповой чат
+type ChatInvite struct {
+	gorm.Model
+	ChatID           uint       `gorm:"index;not null" json:"chat_id"`
+	Token            string     `gorm:"type:varchar(64);uniqueIndex;not null" json:"token"`
+	CreatedByID      uint       `gorm:"not null" json:"created_by_id"`
+	ExpiresAt        *time.Time `json:"expires_at,omitempty"`
+	MaxUses          int        `gorm:"default:0" json:"max_uses"` // 0 — без ограничений
+	UseCount         int        `gorm:"default:0" json:"use_count"`
+	RequiresApproval bool       `gorm:"default:false" json:"requires_approval"`
+	RevokedAt        *time.Time `json:"revoked_at,omitempty"`
+}
+
+// IsUsable проверяет, можно ли вступить в чат по ссылке
+func (i *ChatInvite) IsUsable(now time.Time) bool {
+	if i.RevokedAt != nil {
+		return false
+	}
+	if i.ExpiresAt != nil && !now.Before(*i.ExpiresAt) {
+		return false
+	}
+	if i.MaxUses > 0 && i.UseCount >= i.MaxUses {
+		return false
+	}
+	return true
+}
+
+// Статусы заявок на вступление
+const (
+	JoinRequestPending  = "pending"
+	JoinRequestApproved = "approved"
+	JoinRequestRejected = "rejected"
+)
+
+// ChatJoinRequest заявка на вступление в групповой чат
+type ChatJoinRequest struct {
+	gorm.Model
+	ChatID       uint       `gorm:"index;not null" json:"chat_id"`
+	UserID       uint       `gorm:"index;not null" json:"user_id"`
+	InviteID     *uint      `json:"invite_id,omitempty"`
+	Message      string     `gorm:"type:varchar(500)" json:"message,omitempty"`
+	Status       string     `gorm:"type:varchar(20);default:'pending';index" json:"status"`
+	ReviewedByID *uint      `json:"reviewed_by_id,omitempty"`
+	ReviewedAt   *time.Time `json:"reviewed_at,omitempty"`
+
+	User User `gorm:"foreignKey:UserID" json:"user"`
+}
@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+// ChatListItem элемент списка чатов с данными, которые показывает клиент
+type ChatListItem struct {
+	Chat           Chat
+	LastMessage    *Message  // последнее видимое пользователю сообщение
+	LastActivityAt time.Time // время последнего сообщения или создания чата
+	UnreadCount    int64
+	MemberCount    int64
+	PinnedPosition int // 0 — не закреплен
+	Archived       bool
+	Notifications  ChatNotificationSettings
+	// Participants участники личных чатов; у групп и каналов только MemberCount
+	Participants []User
+}
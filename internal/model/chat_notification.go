@@ -0,0 +1,47 @@
+package model
+
+import "time"
+
+// Режимы уведомлений участника чата
+const (
+	NotifyAll      = "all"      // обо всех сообщениях
+	NotifyMentions = "mentions" // только об упоминаниях и ответах, только в группах
+	NotifyNone     = "none"     // без уведомлений
+)
+
+// ChatNotificationSettings настройки уведомлений участника чата
+type ChatNotificationSettings struct {
+	Mode string `json:"mode"`
+	// MutedUntil момент, после которого уведомления снова включаются; nil — бессрочно
+	MutedUntil *time.Time `json:"muted_until,omitempty"`
+}
+
+// Effective возвращает настройки, действующие на момент now: с истекшим сроком — все уведомления
+func (s ChatNotificationSettings) Effective(now time.Time) ChatNotificationSettings {
+	if s.Mode == "" || s.Mode == NotifyAll || (s.MutedUntil != nil && !s.MutedUntil.After(now)) {
+		return ChatNotificationSettings{Mode: NotifyAll}
+	}
+
+	return s
+}
+
+// Allows сообщает, уведомлять ли участника о сообщении на момент now;
+// mentioned — участник упомянут в сообщении или ему ответили
+func (s ChatNotificationSettings) Allows(mentioned bool, now time.Time) bool {
+	switch s.Effective(now).Mode {
+	case NotifyAll:
+		return true
+	case NotifyMentions:
+		return mentioned
+	default:
+		return false
+	}
+}
+
+// UnreadSummary общий счетчик непрочитанного пользователя для значка приложения.
+// Чаты без уведомлений не учитываются, в чатах «только упоминания» считаются
+// только упоминания и ответы пользователю.
+type UnreadSummary struct {
+	Messages int64 `json:"messages"`
+	Chats    int64 `json:"chats"`
+}
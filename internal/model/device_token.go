@@ -0,0 +1,21 @@
+package model
+
+import "time"
+
+// Платформы push-уведомлений
+const (
+	PushPlatformFCM     = "fcm"     // Firebase Cloud Messaging
+	PushPlatformAPNs    = "apns"    // Apple Push Notification service
+	PushPlatformWebPush = "webpush" // Web Push, токен — подписка браузера в JSON
+)
+
+// DeviceToken токен устройства для push-уведомлений. Токен уникален:
+// при входе в другой аккаунт на том же устройстве он переходит к новому пользователю.
+type DeviceToken struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	UserID    uint      `gorm:"not null;index" json:"user_id"`
+	Platform  string    `gorm:"type:varchar(16);not null" json:"platform"`
+	Token     string    `gorm:"type:text;not null;uniqueIndex" json:"token"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -0,0 +1,13 @@
+package model
+
+import "time"
+
+// ChatDraft черновик сообщения пользователя в чате, общий для всех его устройств
+type ChatDraft struct {
+	UserID    uint            `gorm:"primaryKey" json:"user_id"`
+	ChatID    uint            `gorm:"primaryKey" json:"chat_id"`
+	Text      string          `gorm:"type:text;not null" json:"text"`
+	Entities  MessageEntities `gorm:"type:jsonb" json:"entities,omitempty"`
+	ReplyToID *uint           `json:"reply_to_id,omitempty"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
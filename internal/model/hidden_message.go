@@ -0,0 +1,11 @@
+package model
+
+import "time"
+
+// HiddenMessage сообщение, удаленное пользователем только у себя
+type HiddenMessage struct {
+	UserID    uint      `gorm:"primaryKey;index:idx_hidden_user_chat,priority:1" json:"user_id"`
+	MessageID uint      `gorm:"primaryKey" json:"message_id"`
+	ChatID    uint      `gorm:"not null;index:idx_hidden_user_chat,priority:2" json:"chat_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,13 @@
+package model
+
+import "time"
+
+// LinkPreview превью первой ссылки сообщения, собранное сервером по OpenGraph-разметке
+type LinkPreview struct {
+	URL         string    `json:"url"`
+	Title       string    `json:"title,omitempty"`
+	Description string    `json:"description,omitempty"`
+	ImageURL    string    `json:"image_url,omitempty"`
+	SiteName    string    `json:"site_name,omitempty"`
+	FetchedAt   time.Time `json:"fetched_at"`
+}
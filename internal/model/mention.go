@@ -0,0 +1,16 @@
+package model
+
+import "time"
+
+// MessageMention упоминание участника чата в сообщении
+type MessageMention struct {
+	ID        uint       `gorm:"primarykey" json:"id"`
+	MessageID uint       `gorm:"not null;uniqueIndex:idx_mention_message_user,priority:1" json:"message_id"`
+	ChatID    uint       `gorm:"not null;index:idx_mention_user_chat,priority:2" json:"chat_id"`
+	UserID    uint       `gorm:"not null;uniqueIndex:idx_mention_message_user,priority:2;index:idx_mention_user_chat,priority:1" json:"user_id"`
+	ReadAt    *time.Time `json:"read_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+
+	// Message сообщение с упоминанием, не хранится в таблице
+	Message *Message `gorm:"-" json:"message,omitempty"`
+}
@@ -6,6 +6,15 @@ import (
 	"gorm.io/gorm"
 )
 
+// Типы сообщений
+const (
+	MessageTypeText   = "text"
+	MessageTypeImage  = "image"
+	MessageTypeFile   = "file"
+	MessageTypeSystem = "system" // служебное уведомление о событии в чате
+	MessageTypePoll   = "poll"   // опрос, вопрос дублируется в тексте сообщения
+)
+
 type Message struct {
 	gorm.Model
 	ChatID   uint   `gorm:"index;not null" json:"chat_id"`
@@ -14,11 +23,26 @@ type Message struct {
 	Type     string `gorm:"type:varchar(20);default:'text'" json:"type"`
 	Status   string `gorm:"type:varchar(20);default:'sent'" json:"status"`
 
+	// Entities разметка текста; текст хранится без экранирования, оно выполняется при отображении
+	Entities MessageEntities `gorm:"type:jsonb" json:"entities,omitempty"`
+
 	Timestamp time.Time
 
+	// ExpiresAt момент удаления исчезающего сообщения, nil — хранится бессрочно
+	ExpiresAt *time.Time `gorm:"index" json:"expires_at,omitempty"`
+
 	// Вложения и ссылки
 	AttachmentURL *string `json:"attachment_url,omitempty"`
 	ReplyToID     *uint   `gorm:"index" json:"reply_to_id,omitempty"`
+	// LinkPreview появляется после отправки, когда сервер загрузит страницу по ссылке
+	LinkPreview *LinkPreview `gorm:"type:jsonb;serializer:json" json:"link_preview,omitempty"`
+
+	// Пересылка: источник сохраняется, если автор оригинала не скрыл его настройками приватности
+	IsForwarded            bool   `gorm:"default:false" json:"is_forwarded"`
+	ForwardedFromUserID    *uint  `json:"forwarded_from_user_id,omitempty"`
+	ForwardedFromChatID    *uint  `json:"forwarded_from_chat_id,omitempty"`
+	ForwardedFromMessageID *uint  `json:"forwarded_from_message_id,omitempty"`
+	ForwardedFromName      string `gorm:"type:varchar(255)" json:"forwarded_from_name,omitempty"`
 
 	// Статистика
 	IsEdited bool `gorm:"default:false" json:"is_edited"`
@@ -26,6 +50,10 @@ type Message struct {
 	// Связи
 	Sender  User     `gorm:"foreignKey:SenderID" json:"sender"`
 	ReplyTo *Message `gorm:"foreignKey:ReplyToID" json:"reply_to,omitempty"`
+	Poll    *Poll    `gorm:"foreignKey:MessageID" json:"poll,omitempty"`
+	// Mentions упоминания участников, сохраняются вместе с сообщением; в ответах не передаются,
+	// получатели указаны в разметке
+	Mentions []MessageMention `gorm:"foreignKey:MessageID" json:"-"`
 }
 
 // Таблица для отслеживания прочитанных сообщений
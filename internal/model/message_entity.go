@@ -0,0 +1,69 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// Типы разметки текста сообщения
+const (
+	EntityBold          = "bold"
+	EntityItalic        = "italic"
+	EntityUnderline     = "underline"
+	EntityStrikethrough = "strikethrough"
+	EntityCode          = "code"      // моноширинный фрагмент внутри строки
+	EntityPre           = "pre"       // блок кода
+	EntityURL           = "url"       // ссылка, записанная в тексте
+	EntityTextLink      = "text_link" // текст со ссылкой в поле URL
+	EntityMention       = "mention"   // упоминание @username
+)
+
+// MessageEntity фрагмент разметки текста сообщения.
+// Offset и Length считаются в кодовых единицах UTF-16, как в JavaScript-клиентах.
+type MessageEntity struct {
+	Type   string `json:"type"`
+	Offset int    `json:"offset"`
+	Length int    `json:"length"`
+	// URL адрес для text_link
+	URL string `json:"url,omitempty"`
+	// Language язык подсветки для pre
+	Language string `json:"language,omitempty"`
+	// UserID пользователь, на которого сервер разрешил упоминание @username
+	UserID uint `json:"user_id,omitempty"`
+}
+
+// MessageEntities разметка сообщения, хранится в колонке JSONB
+type MessageEntities []MessageEntity
+
+// Value реализует driver.Valuer
+func (e MessageEntities) Value() (driver.Value, error) {
+	if len(e) == 0 {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(e)
+	if err != nil {
+		return nil, err
+	}
+
+	return string(data), nil
+}
+
+// Scan реализует sql.Scanner
+func (e *MessageEntities) Scan(value any) error {
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		*e = nil
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("unsupported message entities type %T", value)
+	}
+
+	return json.Unmarshal(data, e)
+}
@@ -0,0 +1,14 @@
+package model
+
+import "time"
+
+// PinnedMessage закрепленное сообщение чата
+type PinnedMessage struct {
+	ID         uint      `gorm:"primarykey" json:"id"`
+	ChatID     uint      `gorm:"uniqueIndex:idx_pinned_chat_message;not null" json:"chat_id"`
+	MessageID  uint      `gorm:"uniqueIndex:idx_pinned_chat_message;index;not null" json:"message_id"`
+	PinnedByID uint      `gorm:"not null" json:"pinned_by_id"`
+	CreatedAt  time.Time `gorm:"autoCreateTime" json:"pinned_at"`
+
+	Message Message `gorm:"foreignKey:MessageID" json:"message"`
+}
@@ -0,0 +1,55 @@
+package model
+
+import "time"
+
+// Poll опрос, прикрепленный к сообщению типа MessageTypePoll
+type Poll struct {
+	ID             uint         `gorm:"primarykey" json:"id"`
+	MessageID      uint         `gorm:"uniqueIndex;not null" json:"message_id"`
+	ChatID         uint         `gorm:"index;not null" json:"chat_id"`
+	Question       string       `gorm:"type:varchar(300);not null" json:"question"`
+	MultipleChoice bool         `gorm:"default:false" json:"multiple_choice"`
+	Anonymous      *bool        `gorm:"not null;default:true" json:"anonymous"` // по умолчанию опрос анонимный
+	ClosesAt       *time.Time   `json:"closes_at,omitempty"`
+	ClosedAt       *time.Time   `json:"closed_at,omitempty"`
+	CreatedAt      time.Time    `json:"created_at"`
+	Options        []PollOption `gorm:"foreignKey:PollID" json:"options"`
+
+	// Результаты, не хранятся в БД
+	TotalVoters     int64  `gorm:"-" json:"total_voters"`
+	ChosenOptionIDs []uint `gorm:"-" json:"chosen_option_ids,omitempty"` // голоса запросившего пользователя
+}
+
+// IsAnonymous проверяет, скрыты ли голоса участников. Не заданный флаг означает анонимный опрос.
+func (p *Poll) IsAnonymous() bool {
+	return p.Anonymous == nil || *p.Anonymous
+}
+
+// IsClosed проверяет, завершено ли голосование
+func (p *Poll) IsClosed(now time.Time) bool {
+	if p.ClosedAt != nil {
+		return true
+	}
+	return p.ClosesAt != nil && !now.Before(*p.ClosesAt)
+}
+
+// PollOption вариант ответа
+type PollOption struct {
+	ID       uint   `gorm:"primarykey" json:"id"`
+	PollID   uint   `gorm:"index;not null" json:"poll_id"`
+	Position int    `gorm:"not null" json:"position"`
+	Text     string `gorm:"type:varchar(100);not null" json:"text"`
+
+	// Результаты, не хранятся в БД
+	Votes    int64  `gorm:"-" json:"votes"`
+	VoterIDs []uint `gorm:"-" json:"voter_ids,omitempty"` // только для открытых опросов
+}
+
+// PollVote голос пользователя за вариант ответа
+type PollVote struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	PollID    uint      `gorm:"not null;uniqueIndex:idx_poll_vote,priority:1;index:idx_poll_vote_user,priority:1" json:"poll_id"`
+	OptionID  uint      `gorm:"not null;uniqueIndex:idx_poll_vote,priority:3" json:"option_id"`
+	UserID    uint      `gorm:"not null;uniqueIndex:idx_poll_vote,priority:2;index:idx_poll_vote_user,priority:2" json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
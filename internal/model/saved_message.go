@@ -0,0 +1,21 @@
+package model
+
+import "time"
+
+// SavedMessage закладка пользователя на сообщение из любого доступного ему чата
+type SavedMessage struct {
+	ID        uint     `gorm:"primarykey" json:"id"`
+	UserID    uint     `gorm:"not null;uniqueIndex:idx_saved_user_message,priority:1" json:"user_id"`
+	MessageID uint     `gorm:"not null;uniqueIndex:idx_saved_user_message,priority:2;index" json:"message_id"`
+	ChatID    uint     `gorm:"not null;index" json:"chat_id"`
+	Note      string   `gorm:"type:varchar(1000)" json:"note,omitempty"`
+	Tags      []string `gorm:"type:jsonb;serializer:json;index:idx_saved_tags,type:gin" json:"tags,omitempty"`
+	// OriginalDeletedAt момент удаления оригинала; закладка остается как отметка об удаленном сообщении
+	OriginalDeletedAt *time.Time `json:"original_deleted_at,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+
+	// Оригинал с отправителем и чат, не хранятся в БД; у удаленных оригиналов отсутствуют
+	Message *Message `gorm:"-" json:"message,omitempty"`
+	Chat    *Chat    `gorm:"-" json:"chat,omitempty"`
+}
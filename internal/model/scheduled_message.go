@@ -0,0 +1,40 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Статусы отложенных сообщений
+const (
+	ScheduledPending    = "pending"
+	ScheduledProcessing = "processing"
+	ScheduledSent       = "sent"
+	ScheduledFailed     = "failed"
+	ScheduledCanceled   = "canceled"
+)
+
+// ScheduledMessage сообщение, которое будет отправлено в чат в указанное время
+type ScheduledMessage struct {
+	gorm.Model
+	ChatID        uint            `gorm:"index;not null" json:"chat_id"`
+	SenderID      uint            `gorm:"index;not null" json:"sender_id"`
+	Message       string          `gorm:"type:text;not null" json:"message"`
+	Type          string          `gorm:"type:varchar(20);default:'text'" json:"type"`
+	Entities      MessageEntities `gorm:"type:jsonb" json:"entities,omitempty"`
+	AttachmentURL *string         `json:"attachment_url,omitempty"`
+	ReplyToID     *uint           `json:"reply_to_id,omitempty"`
+	SendAt        time.Time       `gorm:"index:idx_scheduled_due,priority:2;not null" json:"send_at"`
+	Status        string          `gorm:"type:varchar(20);default:'pending';index:idx_scheduled_due,priority:1" json:"status"`
+
+	// Результат отправки
+	Attempts      int    `gorm:"default:0" json:"attempts"`
+	SentMessageID *uint  `json:"sent_message_id,omitempty"`
+	LastError     string `gorm:"type:varchar(500)" json:"last_error,omitempty"`
+}
+
+// IsEditable проверяет, можно ли изменить или отменить отправку
+func (m *ScheduledMessage) IsEditable() bool {
+	return m.Status == ScheduledPending
+}
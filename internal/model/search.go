@@ -0,0 +1,16 @@
+package model
+
+// TextRange фрагмент текста; смещение и длина в кодовых единицах UTF-16, как в разметке сообщений
+type TextRange struct {
+	Offset int `json:"offset"`
+	Length int `json:"length"`
+}
+
+// MessageSearchResult найденное сообщение с релевантностью и фрагментом текста
+type MessageSearchResult struct {
+	Message Message `json:"message"`
+	Rank    float32 `json:"rank"`
+	// Snippet фрагмент текста вокруг совпадений, Highlights — совпавшие слова внутри него
+	Snippet    string      `json:"snippet"`
+	Highlights []TextRange `json:"highlights,omitempty"`
+}
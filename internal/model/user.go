@@ -14,6 +14,10 @@ type User struct {
 	Phone             string `json:"phone"` // +79995552233
 	DisplayName       string `json:"display_name"`
 	ProfilePictureKey string `json:"profile_picture_key"`
+
+	// Настройки приватности
+	// HideForwardOrigin при пересылке сообщений пользователя не раскрывать ссылку на его профиль и чат
+	HideForwardOrigin bool `gorm:"default:false" json:"hide_forward_origin"`
 }
 
 func (u *User) SanitizePassword() {
@@ -0,0 +1,269 @@
+package linkpreview
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+)
+
+// Ограничения загрузки страниц
+const (
+	DefaultTimeout      = 5 * time.Second
+	DefaultMaxBodySize  = 512 * 1024
+	DefaultMaxRedirects = 3
+	DefaultUserAgent    = "bbbab-messenger-link-preview/1.0"
+
+	maxTitleLength       = 300
+	maxDescriptionLength = 1000
+)
+
+// ErrNoPreview на странице нет данных для превью
+var ErrNoPreview = errors.New("link preview: page has no preview data")
+
+// Preview данные OpenGraph страницы
+type Preview struct {
+	URL         string `json:"url"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	ImageURL    string `json:"image_url,omitempty"`
+	SiteName    string `json:"site_name,omitempty"`
+}
+
+// Fetcher загружает превью страницы по ссылке
+type Fetcher interface {
+	Fetch(ctx context.Context, rawURL string) (*Preview, error)
+}
+
+// HTTPFetcher загружает страницу по HTTP и разбирает OpenGraph-разметку
+type HTTPFetcher struct {
+	client      *http.Client
+	maxBodySize int64
+	userAgent   string
+}
+
+// Options настройки HTTPFetcher
+type Options struct {
+	Timeout      time.Duration
+	MaxBodySize  int64
+	MaxRedirects int
+	UserAgent    string
+	// AllowPrivateNetworks отключает защиту от SSRF. Только для тестов с локальным HTTP-сервером.
+	AllowPrivateNetworks bool
+}
+
+// NewHTTPFetcher создает HTTPFetcher. Запросы во внутренние сети блокируются
+// на уровне подключения, включая перенаправления.
+func NewHTTPFetcher(opts Options) *HTTPFetcher {
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTimeout
+	}
+	if opts.MaxBodySize <= 0 {
+		opts.MaxBodySize = DefaultMaxBodySize
+	}
+	if opts.MaxRedirects <= 0 {
+		opts.MaxRedirects = DefaultMaxRedirects
+	}
+	if opts.UserAgent == "" {
+		opts.UserAgent = DefaultUserAgent
+	}
+
+	dialer := &net.Dialer{Timeout: opts.Timeout}
+	if !opts.AllowPrivateNetworks {
+		dialer.Control = dialControl
+	}
+
+	transport := &http.Transport{
+		// Прокси из окружения не используется: иначе проверка адреса применялась бы к прокси
+		Proxy:                 nil,
+		DialContext:           dialer.DialContext,
+		TLSHandshakeTimeout:   opts.Timeout,
+		ResponseHeaderTimeout: opts.Timeout,
+		MaxIdleConns:          10,
+		IdleConnTimeout:       30 * time.Second,
+	}
+
+	maxRedirects := opts.MaxRedirects
+	client := &http.Client{
+		Transport: transport,
+		Timeout:   opts.Timeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxRedirects {
+				return errors.New("link preview: too many redirects")
+			}
+			return checkURL(req.URL)
+		},
+	}
+
+	return &HTTPFetcher{
+		client:      client,
+		maxBodySize: opts.MaxBodySize,
+		userAgent:   opts.UserAgent,
+	}
+}
+
+// Fetch загружает страницу и возвращает превью
+func (f *HTTPFetcher) Fetch(ctx context.Context, rawURL string) (*Preview, error) {
+	pageURL, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkURL(pageURL); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pageURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", f.userAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("link preview: unexpected status %d", resp.StatusCode)
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return nil, ErrNoPreview
+	}
+
+	// После перенаправлений относительные ссылки считаются от итогового адреса
+	preview := parseHTML(io.LimitReader(resp.Body, f.maxBodySize), resp.Request.URL)
+	if preview.Title == "" && preview.Description == "" && preview.ImageURL == "" {
+		return nil, ErrNoPreview
+	}
+	preview.URL = rawURL
+
+	return preview, nil
+}
+
+// parseHTML извлекает OpenGraph-теги из <head>; при их отсутствии используются <title> и description
+func parseHTML(r io.Reader, base *url.URL) *Preview {
+	var (
+		preview                      Preview
+		fallbackTitle, fallbackDescr string
+		inTitle                      bool
+	)
+
+	tokenizer := html.NewTokenizer(r)
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			return finishPreview(&preview, fallbackTitle, fallbackDescr, base)
+
+		case html.StartTagToken, html.SelfClosingTagToken:
+			token := tokenizer.Token()
+			switch token.Data {
+			case "body":
+				// Метаданные находятся в <head>, остальную страницу не читаем
+				return finishPreview(&preview, fallbackTitle, fallbackDescr, base)
+			case "title":
+				inTitle = fallbackTitle == ""
+			case "meta":
+				key, content := metaAttrs(token)
+				switch key {
+				case "og:title":
+					preview.Title = content
+				case "og:description":
+					preview.Description = content
+				case "og:image", "og:image:url", "og:image:secure_url":
+					if preview.ImageURL == "" {
+						preview.ImageURL = content
+					}
+				case "og:site_name":
+					preview.SiteName = content
+				case "description":
+					fallbackDescr = content
+				}
+			}
+
+		case html.TextToken:
+			if inTitle {
+				fallbackTitle += string(tokenizer.Text())
+			}
+
+		case html.EndTagToken:
+			if tokenizer.Token().Data == "title" {
+				inTitle = false
+			}
+		}
+	}
+}
+
+// metaAttrs возвращает имя (property или name) и содержимое тега <meta>
+func metaAttrs(token html.Token) (string, string) {
+	var key, content string
+	for _, attr := range token.Attr {
+		switch strings.ToLower(attr.Key) {
+		case "property", "name":
+			if key == "" {
+				key = strings.ToLower(strings.TrimSpace(attr.Val))
+			}
+		case "content":
+			content = attr.Val
+		}
+	}
+
+	return key, content
+}
+
+// finishPreview подставляет запасные значения, обрезает длинные строки и приводит ссылку на картинку к абсолютной
+func finishPreview(preview *Preview, fallbackTitle, fallbackDescr string, base *url.URL) *Preview {
+	if preview.Title == "" {
+		preview.Title = fallbackTitle
+	}
+	if preview.Description == "" {
+		preview.Description = fallbackDescr
+	}
+
+	preview.Title = truncate(strings.Join(strings.Fields(preview.Title), " "), maxTitleLength)
+	preview.Description = truncate(strings.Join(strings.Fields(preview.Description), " "), maxDescriptionLength)
+	preview.SiteName = truncate(strings.TrimSpace(preview.SiteName), maxTitleLength)
+
+	if preview.ImageURL != "" {
+		preview.ImageURL = resolveImageURL(base, strings.TrimSpace(preview.ImageURL))
+	}
+
+	return preview
+}
+
+// resolveImageURL возвращает абсолютную http(s)-ссылку на картинку или пустую строку
+func resolveImageURL(base *url.URL, raw string) string {
+	ref, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+
+	abs := base.ResolveReference(ref)
+	if checkURL(abs) != nil {
+		return ""
+	}
+
+	return abs.String()
+}
+
+// truncate обрезает строку до max символов
+func truncate(s string, max int) string {
+	if utf8.RuneCountInString(s) <= max {
+		return s
+	}
+
+	runes := []rune(s)
+	return strings.TrimSpace(string(runes[:max])) + "…"
+}
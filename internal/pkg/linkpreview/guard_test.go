@@ -0,0 +1,90 @@
+package linkpreview
+
+import (
+	"errors"
+	"net/netip"
+	"testing"
+)
+
+func TestIsPublicAddr(t *testing.T) {
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{"8.8.8.8", true},
+		{"2606:4700:4700::1111", true},
+		{"::ffff:8.8.8.8", true},
+
+		// loopback
+		{"127.0.0.1", false},
+		{"127.10.0.1", false},
+		{"::1", false},
+		// link-local, включая адрес метаданных облака
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		// RFC 1918 и unique local
+		{"10.0.0.1", false},
+		{"172.16.5.4", false},
+		{"172.31.255.255", false},
+		{"192.168.1.1", false},
+		{"fd00::1", false},
+		// IPv4-mapped IPv6 не должен обходить проверки IPv4
+		{"::ffff:127.0.0.1", false},
+		{"::ffff:10.0.0.1", false},
+		{"::ffff:169.254.169.254", false},
+		// прочие зарезервированные диапазоны
+		{"0.0.0.0", false},
+		{"::", false},
+		{"100.64.0.1", false},
+		{"255.255.255.255", false},
+		{"224.0.0.1", false},
+		{"64:ff9b::a00:1", false},
+		{"2001:db8::1", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			if got := IsPublicAddr(netip.MustParseAddr(tt.addr)); got != tt.want {
+				t.Errorf("IsPublicAddr(%s) = %v, want %v", tt.addr, got, tt.want)
+			}
+		})
+	}
+
+	if IsPublicAddr(netip.Addr{}) {
+		t.Error("IsPublicAddr(zero Addr) = true, want false")
+	}
+}
+
+func TestDialControl(t *testing.T) {
+	tests := []struct {
+		address   string
+		forbidden bool
+		wantErr   bool
+	}{
+		{"8.8.8.8:443", false, false},
+		{"[2606:4700:4700::1111]:443", false, false},
+		{"127.0.0.1:80", true, true},
+		{"[::1]:80", true, true},
+		{"169.254.169.254:80", true, true},
+		{"[fe80::1]:80", true, true},
+		{"10.1.2.3:8080", true, true},
+		{"192.168.0.10:80", true, true},
+		{"[::ffff:127.0.0.1]:80", true, true},
+		{"[::ffff:192.168.0.1]:80", true, true},
+		// На этапе подключения адрес уже разрешен, имя означает ошибку
+		{"localhost:80", false, true},
+		{"8.8.8.8", false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.address, func(t *testing.T) {
+			err := dialControl("tcp", tt.address, nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("dialControl(%q) error = %v, wantErr %v", tt.address, err, tt.wantErr)
+			}
+			if got := errors.Is(err, ErrForbiddenAddress); got != tt.forbidden {
+				t.Errorf("dialControl(%q) forbidden = %v, want %v (error %v)", tt.address, got, tt.forbidden, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,200 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// Адреса Apple Push Notification service
+const (
+	APNsProductionEndpoint = "https://api.push.apple.com"
+	APNsSandboxEndpoint    = "https://api.sandbox.push.apple.com"
+
+	// apnsTokenLifetime токен провайдера действует час, обновляем его заранее
+	apnsTokenLifetime = 50 * time.Minute
+)
+
+// APNsOptions настройки APNsProvider
+type APNsOptions struct {
+	// KeyPEM ключ .p8 из Apple Developer
+	KeyPEM []byte
+	KeyID  string
+	TeamID string
+	// Topic bundle ID приложения
+	Topic string
+	// Sandbox отправлять через окружение разработки
+	Sandbox bool
+	// Endpoint адрес APNs, подменяется в тестах
+	Endpoint   string
+	HTTPClient *http.Client
+}
+
+// APNsProvider отправляет уведомления напрямую через APNs по HTTP/2 с токеном провайдера
+type APNsProvider struct {
+	client   *http.Client
+	endpoint string
+	keyID    string
+	teamID   string
+	topic    string
+	key      any
+
+	mu       sync.Mutex
+	jwt      string
+	issuedAt time.Time
+}
+
+// NewAPNsProvider создает APNsProvider
+func NewAPNsProvider(opts APNsOptions) (*APNsProvider, error) {
+	if opts.KeyID == "" || opts.TeamID == "" || opts.Topic == "" {
+		return nil, errors.New("apns: key id, team id and topic are required")
+	}
+
+	key, err := jwt.ParseECPrivateKeyFromPEM(opts.KeyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("apns: invalid key: %w", err)
+	}
+
+	endpoint := opts.Endpoint
+	if endpoint == "" {
+		endpoint = APNsProductionEndpoint
+		if opts.Sandbox {
+			endpoint = APNsSandboxEndpoint
+		}
+	}
+
+	return &APNsProvider{
+		client:   newHTTPClient(opts.HTTPClient),
+		endpoint: strings.TrimRight(endpoint, "/"),
+		keyID:    opts.KeyID,
+		teamID:   opts.TeamID,
+		topic:    opts.Topic,
+		key:      key,
+	}, nil
+}
+
+// ValidateToken проверяет, что токен — шестнадцатеричная строка от APNs
+func (p *APNsProvider) ValidateToken(token string) error {
+	if len(token) < 64 || len(token) > 200 {
+		return errors.New("apns: unexpected token length")
+	}
+	if _, err := hex.DecodeString(token); err != nil {
+		return errors.New("apns: token must be hex encoded")
+	}
+
+	return nil
+}
+
+// Send отправляет уведомление на устройство
+func (p *APNsProvider) Send(ctx context.Context, token string, n Notification) error {
+	authToken, err := p.getToken()
+	if err != nil {
+		return err
+	}
+
+	aps := map[string]any{
+		"alert": map[string]string{"title": n.Title, "body": n.Body},
+		"sound": "default",
+	}
+	if n.Badge != nil {
+		aps["badge"] = *n.Badge
+	}
+	if n.ThreadID != "" {
+		aps["thread-id"] = n.ThreadID
+	}
+
+	payload := map[string]any{"aps": aps}
+	for k, v := range n.Data {
+		if k != "aps" {
+			payload[k] = v
+		}
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.endpoint+"/3/device/"+token, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "bearer "+authToken)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("apns-topic", p.topic)
+	req.Header.Set("apns-push-type", "alert")
+	req.Header.Set("apns-priority", "10")
+	if n.TTL > 0 {
+		req.Header.Set("apns-expiration", strconv.FormatInt(time.Now().Add(n.TTL).Unix(), 10))
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return &TemporaryError{Err: fmt.Errorf("apns: %w", err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	var result struct {
+		Reason string `json:"reason"`
+	}
+	_ = json.NewDecoder(io.LimitReader(resp.Body, 64*1024)).Decode(&result)
+
+	switch {
+	case resp.StatusCode == http.StatusGone,
+		result.Reason == "BadDeviceToken",
+		result.Reason == "DeviceTokenNotForTopic",
+		result.Reason == "Unregistered":
+		return ErrInvalidToken
+	case result.Reason == "ExpiredProviderToken", result.Reason == "InvalidProviderToken":
+		// Токен провайдера выпустим заново при повторе
+		p.mu.Lock()
+		p.jwt = ""
+		p.mu.Unlock()
+		return &TemporaryError{Err: fmt.Errorf("apns: %s", result.Reason)}
+	}
+
+	return statusError("apns", resp, result.Reason)
+}
+
+// getToken возвращает токен провайдера. APNs не разрешает обновлять его чаще раза в 20 минут,
+// поэтому токен переиспользуется для всех запросов.
+func (p *APNsProvider) getToken() (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.jwt != "" && time.Since(p.issuedAt) < apnsTokenLifetime {
+		return p.jwt, nil
+	}
+
+	now := time.Now()
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"iss": p.teamID,
+		"iat": now.Unix(),
+	})
+	token.Header["kid"] = p.keyID
+
+	signed, err := token.SignedString(p.key)
+	if err != nil {
+		return "", fmt.Errorf("apns: sign token: %w", err)
+	}
+
+	p.jwt = signed
+	p.issuedAt = now
+
+	return p.jwt, nil
+}
@@ -0,0 +1,77 @@
+package push
+
+import (
+	"context"
+	"sync"
+)
+
+// FakeDelivery уведомление, принятое FakeProvider
+type FakeDelivery struct {
+	Token        string
+	Notification Notification
+}
+
+// FakeProvider запоминает уведомления вместо отправки. Для тестов и локальной разработки.
+type FakeProvider struct {
+	mu       sync.Mutex
+	sent     []FakeDelivery
+	failures map[string]*fakeFailure
+}
+
+type fakeFailure struct {
+	err   error
+	times int
+}
+
+// NewFakeProvider создает FakeProvider
+func NewFakeProvider() *FakeProvider {
+	return &FakeProvider{failures: make(map[string]*fakeFailure)}
+}
+
+// Send запоминает уведомление или возвращает ошибку, заданную через FailToken
+func (p *FakeProvider) Send(ctx context.Context, token string, n Notification) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if failure, ok := p.failures[token]; ok {
+		if failure.times > 0 {
+			failure.times--
+			if failure.times == 0 {
+				delete(p.failures, token)
+			}
+		}
+		return failure.err
+	}
+
+	p.sent = append(p.sent, FakeDelivery{Token: token, Notification: n})
+	return nil
+}
+
+// FailToken задает ошибку для отправок на токен: times раз подряд, при times <= 0 — всегда
+func (p *FakeProvider) FailToken(token string, err error, times int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.failures[token] = &fakeFailure{err: err, times: times}
+}
+
+// Sent возвращает принятые уведомления в порядке отправки
+func (p *FakeProvider) Sent() []FakeDelivery {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return append([]FakeDelivery(nil), p.sent...)
+}
+
+// Reset забывает принятые уведомления и заданные ошибки
+func (p *FakeProvider) Reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.sent = nil
+	p.failures = make(map[string]*fakeFailure)
+}
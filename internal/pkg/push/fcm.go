@@ -0,0 +1,248 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// Адреса Firebase Cloud Messaging HTTP v1
+const (
+	DefaultFCMEndpoint = "https://fcm.googleapis.com"
+	fcmScope           = "https://www.googleapis.com/auth/firebase.messaging"
+	googleTokenURL     = "https://oauth2.googleapis.com/token"
+)
+
+// FCMOptions настройки FCMProvider
+type FCMOptions struct {
+	// CredentialsJSON ключ сервисного аккаунта Google в формате JSON
+	CredentialsJSON []byte
+	// ProjectID проект Firebase; по умолчанию берется из ключа
+	ProjectID string
+	// Endpoint адрес FCM, подменяется в тестах
+	Endpoint   string
+	HTTPClient *http.Client
+}
+
+// FCMProvider отправляет уведомления через Firebase Cloud Messaging (Android, iOS через Firebase)
+type FCMProvider struct {
+	client    *http.Client
+	endpoint  string
+	projectID string
+	email     string
+	keyID     string
+	tokenURL  string
+	key       any
+
+	mu          sync.Mutex
+	accessToken string
+	expiresAt   time.Time
+}
+
+// NewFCMProvider создает FCMProvider по ключу сервисного аккаунта
+func NewFCMProvider(opts FCMOptions) (*FCMProvider, error) {
+	var credentials struct {
+		ProjectID    string `json:"project_id"`
+		PrivateKeyID string `json:"private_key_id"`
+		PrivateKey   string `json:"private_key"`
+		ClientEmail  string `json:"client_email"`
+		TokenURI     string `json:"token_uri"`
+	}
+	if err := json.Unmarshal(opts.CredentialsJSON, &credentials); err != nil {
+		return nil, fmt.Errorf("fcm: invalid credentials: %w", err)
+	}
+	if credentials.ClientEmail == "" || credentials.PrivateKey == "" {
+		return nil, errors.New("fcm: credentials must contain client_email and private_key")
+	}
+
+	key, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(credentials.PrivateKey))
+	if err != nil {
+		return nil, fmt.Errorf("fcm: invalid private key: %w", err)
+	}
+
+	projectID := opts.ProjectID
+	if projectID == "" {
+		projectID = credentials.ProjectID
+	}
+	if projectID == "" {
+		return nil, errors.New("fcm: project id is required")
+	}
+
+	endpoint := opts.Endpoint
+	if endpoint == "" {
+		endpoint = DefaultFCMEndpoint
+	}
+	tokenURL := credentials.TokenURI
+	if tokenURL == "" {
+		tokenURL = googleTokenURL
+	}
+
+	return &FCMProvider{
+		client:    newHTTPClient(opts.HTTPClient),
+		endpoint:  strings.TrimRight(endpoint, "/"),
+		projectID: projectID,
+		email:     credentials.ClientEmail,
+		keyID:     credentials.PrivateKeyID,
+		tokenURL:  tokenURL,
+		key:       key,
+	}, nil
+}
+
+// Send отправляет уведомление на регистрационный токен FCM
+func (p *FCMProvider) Send(ctx context.Context, token string, n Notification) error {
+	accessToken, err := p.getAccessToken(ctx)
+	if err != nil {
+		return err
+	}
+
+	message := map[string]any{
+		"token":        token,
+		"notification": map[string]string{"title": n.Title, "body": n.Body},
+	}
+	if len(n.Data) > 0 {
+		message["data"] = n.Data
+	}
+
+	android := map[string]any{"priority": "high"}
+	if n.TTL > 0 {
+		android["ttl"] = strconv.FormatInt(int64(n.TTL/time.Second), 10) + "s"
+	}
+	if n.ThreadID != "" {
+		android["notification"] = map[string]string{"tag": n.ThreadID}
+	}
+	message["android"] = android
+
+	aps := map[string]any{"sound": "default"}
+	if n.Badge != nil {
+		aps["badge"] = *n.Badge
+	}
+	if n.ThreadID != "" {
+		aps["thread-id"] = n.ThreadID
+	}
+	message["apns"] = map[string]any{"payload": map[string]any{"aps": aps}}
+
+	body, err := json.Marshal(map[string]any{"message": message})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		p.endpoint+"/v1/projects/"+url.PathEscape(p.projectID)+"/messages:send", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return &TemporaryError{Err: fmt.Errorf("fcm: %w", err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	var result struct {
+		Error struct {
+			Status  string `json:"status"`
+			Message string `json:"message"`
+			Details []struct {
+				ErrorCode string `json:"errorCode"`
+			} `json:"details"`
+		} `json:"error"`
+	}
+	_ = json.NewDecoder(io.LimitReader(resp.Body, 64*1024)).Decode(&result)
+
+	for _, detail := range result.Error.Details {
+		if detail.ErrorCode == "UNREGISTERED" {
+			return ErrInvalidToken
+		}
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrInvalidToken
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		// Токен доступа отозван раньше срока: получим новый при повторе
+		p.mu.Lock()
+		p.accessToken = ""
+		p.mu.Unlock()
+		return &TemporaryError{Err: fmt.Errorf("fcm: unauthorized: %s", result.Error.Message)}
+	}
+
+	return statusError("fcm", resp, result.Error.Status)
+}
+
+// getAccessToken возвращает токен доступа OAuth2, обновляя его заранее до истечения
+func (p *FCMProvider) getAccessToken(ctx context.Context) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.accessToken != "" && time.Until(p.expiresAt) > time.Minute {
+		return p.accessToken, nil
+	}
+
+	now := time.Now()
+	assertion := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":   p.email,
+		"scope": fcmScope,
+		"aud":   p.tokenURL,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+	if p.keyID != "" {
+		assertion.Header["kid"] = p.keyID
+	}
+	signed, err := assertion.SignedString(p.key)
+	if err != nil {
+		return "", fmt.Errorf("fcm: sign assertion: %w", err)
+	}
+
+	form := url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {signed},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", &TemporaryError{Err: fmt.Errorf("fcm: get access token: %w", err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", statusError("fcm: get access token", resp, "")
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 64*1024)).Decode(&token); err != nil {
+		return "", &TemporaryError{Err: fmt.Errorf("fcm: decode access token: %w", err)}
+	}
+	if token.AccessToken == "" {
+		return "", &TemporaryError{Err: errors.New("fcm: empty access token")}
+	}
+
+	p.accessToken = token.AccessToken
+	p.expiresAt = now.Add(time.Duration(token.ExpiresIn) * time.Second)
+
+	return p.accessToken, nil
+}
@@ -0,0 +1,99 @@
+package push
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// DefaultTimeout время на один запрос к сервису доставки
+const DefaultTimeout = 10 * time.Second
+
+// Ошибки доставки, после которых повторять отправку бессмысленно
+var (
+	// ErrInvalidToken токен устройства недействителен или отозван, его нужно удалить
+	ErrInvalidToken = errors.New("push: device token is invalid or unregistered")
+	// ErrRejected сервис доставки отклонил уведомление
+	ErrRejected = errors.New("push: notification rejected")
+)
+
+// Notification уведомление для одного устройства
+type Notification struct {
+	Title string
+	Body  string
+	// Badge число на значке приложения; nil — не менять
+	Badge *int64
+	// ThreadID группирует уведомления, например, одного чата
+	ThreadID string
+	// Data данные для клиента, доставляются вместе с уведомлением
+	Data map[string]string
+	// TTL сколько сервис доставки хранит уведомление для выключенного устройства; 0 — по умолчанию сервиса
+	TTL time.Duration
+}
+
+// Provider отправляет уведомление на устройство через сервис доставки платформы.
+// Ошибки ErrInvalidToken и ErrRejected окончательные, остальные — временные.
+type Provider interface {
+	Send(ctx context.Context, token string, n Notification) error
+}
+
+// TokenValidator проверяет формат токена при регистрации устройства.
+// Реализуется провайдерами, у которых токен имеет проверяемую структуру.
+type TokenValidator interface {
+	ValidateToken(token string) error
+}
+
+// TemporaryError временная ошибка доставки; RetryAfter — рекомендованная сервисом пауза
+type TemporaryError struct {
+	Err        error
+	RetryAfter time.Duration
+}
+
+func (e *TemporaryError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *TemporaryError) Unwrap() error {
+	return e.Err
+}
+
+// statusError переводит неуспешный HTTP-ответ сервиса доставки в ошибку:
+// 429 и 5xx — временные, остальные — ErrRejected
+func statusError(service string, resp *http.Response, reason string) error {
+	err := fmt.Errorf("%s: status %d %s", service, resp.StatusCode, reason)
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError {
+		return &TemporaryError{Err: err, RetryAfter: retryAfter(resp)}
+	}
+
+	return fmt.Errorf("%w: %v", ErrRejected, err)
+}
+
+// retryAfter разбирает заголовок Retry-After в секундах или в формате даты
+func retryAfter(resp *http.Response) time.Duration {
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		if wait := time.Until(at); wait > 0 {
+			return wait
+		}
+	}
+
+	return 0
+}
+
+// newHTTPClient возвращает client или клиент с таймаутом по умолчанию
+func newHTTPClient(client *http.Client) *http.Client {
+	if client != nil {
+		return client
+	}
+
+	return &http.Client{Timeout: DefaultTimeout}
+}
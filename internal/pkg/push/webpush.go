@@ -0,0 +1,281 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// Параметры Web Push
+const (
+	// webPushRecordSize размер записи aes128gcm (RFC 8188); уведомление помещается в одну запись
+	webPushRecordSize = 4096
+	// webPushMaxPayload наибольший размер открытого текста, который принимают push-сервисы
+	webPushMaxPayload  = 3993
+	vapidTokenLifetime = 12 * time.Hour
+)
+
+// WebPushOptions настройки WebPushProvider
+type WebPushOptions struct {
+	// VAPIDPublicKey и VAPIDPrivateKey ключи сервера в base64url: точка P-256 без сжатия и скаляр
+	VAPIDPublicKey  string
+	VAPIDPrivateKey string
+	// Subject контакт для push-сервиса: mailto: или https:
+	Subject    string
+	HTTPClient *http.Client
+}
+
+// WebPushSubscription подписка браузера из PushManager.subscribe(); хранится как токен устройства в JSON
+type WebPushSubscription struct {
+	Endpoint string `json:"endpoint"`
+	Keys     struct {
+		P256DH string `json:"p256dh"`
+		Auth   string `json:"auth"`
+	} `json:"keys"`
+}
+
+// WebPushProvider отправляет уведомления в браузеры по протоколу Web Push
+// с шифрованием содержимого (RFC 8291) и авторизацией VAPID (RFC 8292)
+type WebPushProvider struct {
+	client    *http.Client
+	publicKey string
+	key       *ecdsa.PrivateKey
+	subject   string
+}
+
+// NewWebPushProvider создает WebPushProvider
+func NewWebPushProvider(opts WebPushOptions) (*WebPushProvider, error) {
+	if opts.Subject == "" {
+		return nil, errors.New("webpush: subject is required")
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(opts.VAPIDPrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("webpush: invalid private key: %w", err)
+	}
+	private, err := ecdh.P256().NewPrivateKey(raw)
+	if err != nil {
+		return nil, fmt.Errorf("webpush: invalid private key: %w", err)
+	}
+
+	public := private.PublicKey().Bytes()
+	if opts.VAPIDPublicKey != "" && opts.VAPIDPublicKey != base64.RawURLEncoding.EncodeToString(public) {
+		return nil, errors.New("webpush: public key does not match private key")
+	}
+
+	// Точка без сжатия: 0x04 || X || Y
+	key := &ecdsa.PrivateKey{
+		PublicKey: ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(public[1:33]),
+			Y:     new(big.Int).SetBytes(public[33:]),
+		},
+		D: new(big.Int).SetBytes(raw),
+	}
+
+	return &WebPushProvider{
+		client:    newHTTPClient(opts.HTTPClient),
+		publicKey: base64.RawURLEncoding.EncodeToString(public),
+		key:       key,
+		subject:   opts.Subject,
+	}, nil
+}
+
+// ValidateToken проверяет, что токен — подписка браузера с HTTPS-адресом и ключами
+func (p *WebPushProvider) ValidateToken(token string) error {
+	_, _, _, err := parseSubscription(token)
+	return err
+}
+
+// Send шифрует уведомление ключами подписки и отправляет его push-сервису браузера
+func (p *WebPushProvider) Send(ctx context.Context, token string, n Notification) error {
+	endpoint, userPublic, authSecret, err := parseSubscription(token)
+	if err != nil {
+		return ErrInvalidToken
+	}
+
+	payload, err := json.Marshal(struct {
+		Title string            `json:"title"`
+		Body  string            `json:"body"`
+		Badge *int64            `json:"badge,omitempty"`
+		Tag   string            `json:"tag,omitempty"`
+		Data  map[string]string `json:"data,omitempty"`
+	}{n.Title, n.Body, n.Badge, n.ThreadID, n.Data})
+	if err != nil {
+		return err
+	}
+	if len(payload) > webPushMaxPayload {
+		return fmt.Errorf("%w: webpush: payload too large", ErrRejected)
+	}
+
+	body, err := encryptWebPush(payload, userPublic, authSecret)
+	if err != nil {
+		return err
+	}
+
+	authorization, err := p.vapidAuthorization(endpoint)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.String(), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", authorization)
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("TTL", strconv.FormatInt(int64(n.TTL/time.Second), 10))
+	req.Header.Set("Urgency", "high")
+	if n.ThreadID != "" {
+		// Новое уведомление чата заменяет недоставленное предыдущее
+		req.Header.Set("Topic", base64.RawURLEncoding.EncodeToString([]byte(n.ThreadID)))
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return &TemporaryError{Err: fmt.Errorf("webpush: %w", err)}
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode == http.StatusNotFound, resp.StatusCode == http.StatusGone:
+		return ErrInvalidToken
+	}
+
+	return statusError("webpush", resp, "")
+}
+
+// vapidAuthorization возвращает заголовок авторизации VAPID для push-сервиса endpoint
+func (p *WebPushProvider) vapidAuthorization(endpoint *url.URL) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"aud": endpoint.Scheme + "://" + endpoint.Host,
+		"exp": time.Now().Add(vapidTokenLifetime).Unix(),
+		"sub": p.subject,
+	})
+
+	signed, err := token.SignedString(p.key)
+	if err != nil {
+		return "", fmt.Errorf("webpush: sign vapid token: %w", err)
+	}
+
+	return "vapid t=" + signed + ", k=" + p.publicKey, nil
+}
+
+// parseSubscription разбирает подписку браузера
+func parseSubscription(token string) (*url.URL, *ecdh.PublicKey, []byte, error) {
+	var subscription WebPushSubscription
+	if err := json.Unmarshal([]byte(token), &subscription); err != nil {
+		return nil, nil, nil, errors.New("webpush: token must be a subscription JSON")
+	}
+
+	endpoint, err := url.Parse(subscription.Endpoint)
+	if err != nil || endpoint.Scheme != "https" || endpoint.Host == "" {
+		return nil, nil, nil, errors.New("webpush: subscription endpoint must be an https URL")
+	}
+
+	rawPublic, err := decodeBase64URL(subscription.Keys.P256DH)
+	if err != nil {
+		return nil, nil, nil, errors.New("webpush: invalid p256dh key")
+	}
+	userPublic, err := ecdh.P256().NewPublicKey(rawPublic)
+	if err != nil {
+		return nil, nil, nil, errors.New("webpush: invalid p256dh key")
+	}
+
+	authSecret, err := decodeBase64URL(subscription.Keys.Auth)
+	if err != nil || len(authSecret) != 16 {
+		return nil, nil, nil, errors.New("webpush: invalid auth secret")
+	}
+
+	return endpoint, userPublic, authSecret, nil
+}
+
+// encryptWebPush шифрует содержимое для подписки по схеме aes128gcm (RFC 8291)
+func encryptWebPush(payload []byte, userPublic *ecdh.PublicKey, authSecret []byte) ([]byte, error) {
+	serverPrivate, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	serverPublic := serverPrivate.PublicKey().Bytes()
+
+	sharedSecret, err := serverPrivate.ECDH(userPublic)
+	if err != nil {
+		return nil, err
+	}
+
+	// Ключевой материал связывает общий секрет с секретом подписки и обоими открытыми ключами
+	keyInfo := append([]byte("WebPush: info\x00"), userPublic.Bytes()...)
+	keyInfo = append(keyInfo, serverPublic...)
+	ikm, err := hkdf.Key(sha256.New, sharedSecret, authSecret, string(keyInfo), 32)
+	if err != nil {
+		return nil, err
+	}
+
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	prk, err := hkdf.Extract(sha256.New, ikm, salt)
+	if err != nil {
+		return nil, err
+	}
+	cek, err := hkdf.Expand(sha256.New, prk, "Content-Encoding: aes128gcm\x00", 16)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := hkdf.Expand(sha256.New, prk, "Content-Encoding: nonce\x00", 12)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	// Единственная запись завершается разделителем 0x02
+	plaintext := append(append([]byte{}, payload...), 0x02)
+
+	header := make([]byte, 0, 16+4+1+len(serverPublic))
+	header = append(header, salt...)
+	header = binary.BigEndian.AppendUint32(header, webPushRecordSize)
+	header = append(header, byte(len(serverPublic)))
+	header = append(header, serverPublic...)
+
+	return gcm.Seal(header, nonce, plaintext, nil), nil
+}
+
+// decodeBase64URL декодирует base64url с выравниванием и без
+func decodeBase64URL(value string) ([]byte, error) {
+	if decoded, err := base64.RawURLEncoding.DecodeString(value); err == nil {
+		return decoded, nil
+	}
+
+	return base64.URLEncoding.DecodeString(value)
+}
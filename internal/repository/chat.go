@@ -2,13 +2,16 @@ package repository
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"slices"
 	"strings"
 	"time"
 	"tush00nka/bbbab_messenger/internal/model"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // ChatRepository интерфейс репозитория чатов
@@ -16,6 +19,7 @@ type ChatRepository interface {
 	// Основные операции с чатами
 	Create(ctx context.Context, chat *model.Chat) error
 	GetByID(ctx context.Context, chatID uint) (*model.Chat, error)
+	GetMeta(ctx context.Context, chatID uint) (*model.Chat, error)
 	Update(ctx context.Context, chat *model.Chat) error
 	Delete(ctx context.Context, chatID uint) error
 
@@ -25,33 +29,146 @@ type ChatRepository interface {
 	GetChatUsers(ctx context.Context, chatID uint) ([]model.User, error)
 	IsUserInChat(ctx context.Context, chatID, userID uint) (bool, error)
 	GetChatUsersCount(ctx context.Context, chatID uint) (int64, error)
+	GetUserRole(ctx context.Context, chatID, userID uint) (string, error)
+	SetUserRole(ctx context.Context, chatID, userID uint, role string) error
+	TransferOwnership(ctx context.Context, chatID, fromUserID, toUserID uint) error
+	GetChatAdminIDs(ctx context.Context, chatID uint) ([]uint, error)
 
 	// Операции с сообщениями
 	SendMessage(ctx context.Context, chat *model.Chat, message *model.Message) error
+	SendMessages(ctx context.Context, messages []model.Message) error
 	GetMessages(ctx context.Context, chatID uint) ([]model.Message, error)
-	GetRecentMessages(ctx context.Context, chatID uint, limit int) ([]model.Message, error)
+	GetRecentMessages(ctx context.Context, chatID, viewerID uint, limit int) ([]model.Message, error)
 	GetMessageByID(ctx context.Context, messageID uint) (*model.Message, error)
+	GetMessagesByIDs(ctx context.Context, messageIDs []uint) ([]model.Message, error)
+	UpdateMessageLinkPreview(ctx context.Context, messageID uint, preview *model.LinkPreview) error
 	MarkMessageAsRead(ctx context.Context, messageID, userID uint) error
 	DeleteMessage(ctx context.Context, messageID uint) error
+	DeleteMessages(ctx context.Context, messageIDs []uint) ([]uint, error)
+	HideMessages(ctx context.Context, userID, chatID uint, messageIDs []uint) error
+	GetHiddenMessageIDs(ctx context.Context, userID, chatID uint) ([]uint, error)
 
 	// Пагинация сообщений
-	GetChatMessages(ctx context.Context, chatID uint, cursor string, limit int, direction string) (
+	GetChatMessages(ctx context.Context, chatID, viewerID uint, cursor string, limit int, direction string) (
 		[]model.Message, bool, bool, *int64, error)
 
 	// Чаты пользователя
 	GetChatsForUser(ctx context.Context, userID uint) (*[]model.Chat, error)
+	ListChats(ctx context.Context, filter ChatListFilter) ([]model.ChatListItem, error)
 	GetDirectChatsForUser(ctx context.Context, userID uint) ([]model.Chat, error)
 	GetForUsers(ctx context.Context, user1ID, user2ID uint) (*model.Chat, error)
 
 	// Групповые чаты
-	CreateGroup(ctx context.Context, chat *model.Chat, userIDs []uint) error
+	CreateGroup(ctx context.Context, chat *model.Chat, userIDs []uint, ownerID uint) error
+	CreateChannel(ctx context.Context, chat *model.Chat, ownerID uint) error
 	UpdateGroupInfo(ctx context.Context, chatID uint, name, description string) error
+	UpdateAvatar(ctx context.Context, chatID uint, avatarKey string) error
+	UpdateSlowMode(ctx context.Context, chatID uint, seconds int) error
+	UpdateJoinByRequest(ctx context.Context, chatID uint, enabled bool) error
 	GetGroupChatsForUser(ctx context.Context, userID uint) ([]model.Chat, error)
 
 	// Статистика и поиск
 	GetChatStatistics(ctx context.Context, chatID uint) (*ChatStats, error)
-	SearchMessages(ctx context.Context, chatID uint, query string, limit int) ([]model.Message, error)
+	SearchMessages(ctx context.Context, filter MessageSearchFilter) ([]model.MessageSearchResult, error)
+	SearchChats(ctx context.Context, userID uint, query string, limit int) ([]model.Chat, error)
 	GetUnreadCount(ctx context.Context, userID, chatID uint) (int64, error)
+
+	// Пригласительные ссылки
+	CreateInvite(ctx context.Context, invite *model.ChatInvite) error
+	GetInviteByID(ctx context.Context, inviteID uint) (*model.ChatInvite, error)
+	GetInviteByToken(ctx context.Context, token string) (*model.ChatInvite, error)
+	GetChatInvites(ctx context.Context, chatID uint) ([]model.ChatInvite, error)
+	RevokeInvite(ctx context.Context, inviteID uint) error
+	JoinByInvite(ctx context.Context, inviteID, userID uint) (bool, error)
+
+	// Заявки на вступление
+	CreateJoinRequest(ctx context.Context, request *model.ChatJoinRequest) error
+	CreateJoinRequestByInvite(ctx context.Context, request *model.ChatJoinRequest) (bool, error)
+	GetJoinRequestByID(ctx context.Context, requestID uint) (*model.ChatJoinRequest, error)
+	GetPendingJoinRequest(ctx context.Context, chatID, userID uint) (*model.ChatJoinRequest, error)
+	GetJoinRequests(ctx context.Context, chatID uint, status string) ([]model.ChatJoinRequest, error)
+	ResolveJoinRequest(ctx context.Context, requestID, reviewerID uint, status string) (bool, error)
+	ApproveJoinRequest(ctx context.Context, requestID, reviewerID uint) (bool, error)
+	GetLastRejectedJoinRequest(ctx context.Context, chatID, userID uint) (*model.ChatJoinRequest, error)
+	CountPendingJoinRequestsSince(ctx context.Context, chatID uint, since time.Time) (int64, error)
+
+	// Блокировки
+	BanUser(ctx context.Context, ban *model.ChatBan) error
+	GetActiveBan(ctx context.Context, chatID, userID uint) (*model.ChatBan, error)
+	GetChatBans(ctx context.Context, chatID uint) ([]model.ChatBan, error)
+	UnbanUser(ctx context.Context, chatID, userID uint) (bool, error)
+
+	// Закрепленные сообщения
+	PinMessage(ctx context.Context, pin *model.PinnedMessage) error
+	UnpinMessage(ctx context.Context, chatID, messageID uint) (bool, error)
+	IsMessagePinned(ctx context.Context, chatID, messageID uint) (bool, error)
+	GetPinnedMessages(ctx context.Context, chatID uint) ([]model.PinnedMessage, error)
+	GetLatestPin(ctx context.Context, chatID uint) (*model.PinnedMessage, error)
+
+	// Журнал модерации
+	CreateAuditLog(ctx context.Context, entry *model.ChatAuditLog) error
+	GetAuditLog(ctx context.Context, chatID, beforeID uint, limit int) ([]model.ChatAuditLog, error)
+
+	// Выход из чата и очистка истории
+	GetOwnershipSuccessor(ctx context.Context, chatID, excludeUserID uint) (uint, error)
+	LeaveChat(ctx context.Context, chatID, userID, newOwnerID uint) error
+	ClearHistory(ctx context.Context, chatID, userID uint) (uint, error)
+	GetHistoryClearedUpTo(ctx context.Context, chatID, userID uint) (uint, error)
+	PurgeChat(ctx context.Context, chatID uint) ([]string, error)
+
+	// Организация списка чатов
+	SetPinnedChats(ctx context.Context, userID uint, chatIDs []uint) error
+	SetChatArchived(ctx context.Context, userID, chatID uint, archived bool) (bool, error)
+	UnarchiveForNewMessage(ctx context.Context, chatID, senderID uint) ([]uint, error)
+	GetChatOrganization(ctx context.Context, userID uint) (*model.ChatOrganization, error)
+	CreateChatFolder(ctx context.Context, folder *model.ChatFolder) error
+	UpdateChatFolder(ctx context.Context, folder *model.ChatFolder) error
+	DeleteChatFolder(ctx context.Context, userID, folderID uint) (bool, error)
+	GetChatFolder(ctx context.Context, userID, folderID uint) (*model.ChatFolder, error)
+	GetChatFolders(ctx context.Context, userID uint) ([]model.ChatFolder, error)
+
+	// Уведомления
+	SetChatNotifications(ctx context.Context, userID, chatID uint, settings model.ChatNotificationSettings) (bool, error)
+	GetChatNotificationSettings(ctx context.Context, chatID uint, userIDs []uint) (map[uint]model.ChatNotificationSettings, error)
+	GetUnreadSummary(ctx context.Context, userID uint) (*model.UnreadSummary, error)
+
+	// Закладки
+	SaveMessage(ctx context.Context, saved *model.SavedMessage) error
+	GetSavedMessage(ctx context.Context, userID, messageID uint) (*model.SavedMessage, error)
+	GetSavedMessages(ctx context.Context, userID, beforeID uint, tag string, limit int) ([]model.SavedMessage, error)
+	DeleteSavedMessage(ctx context.Context, userID, messageID uint) (bool, error)
+
+	// Черновики
+	SaveDraft(ctx context.Context, draft *model.ChatDraft) error
+	GetDraft(ctx context.Context, userID, chatID uint) (*model.ChatDraft, error)
+	GetDraftsForUser(ctx context.Context, userID uint) ([]model.ChatDraft, error)
+	DeleteDraft(ctx context.Context, userID, chatID uint) (bool, error)
+
+	// Упоминания
+	GetMentions(ctx context.Context, userID, chatID, beforeID uint, limit int, unreadOnly bool) ([]model.MessageMention, error)
+	GetUnreadMentionCounts(ctx context.Context, userID uint) (map[uint]int64, error)
+	MarkMentionsRead(ctx context.Context, userID, chatID, upToMessageID uint) error
+
+	// Опросы
+	GetPollByMessageID(ctx context.Context, messageID uint) (*model.Poll, error)
+	GetPollsByMessageIDs(ctx context.Context, messageIDs []uint) ([]model.Poll, error)
+	LoadPollResults(ctx context.Context, polls []model.Poll, viewerID uint) error
+	ReplacePollVotes(ctx context.Context, pollID, userID uint, optionIDs []uint) error
+	ClosePoll(ctx context.Context, pollID uint, closedAt time.Time) (bool, error)
+
+	// Исчезающие сообщения
+	UpdateMessageTTL(ctx context.Context, chatID uint, seconds int) error
+	DeleteExpiredMessages(ctx context.Context, now time.Time, limit int) ([]model.Message, error)
+	IsAttachmentReferenced(ctx context.Context, attachmentURL string) (bool, error)
+
+	// Отложенные сообщения
+	CreateScheduledMessage(ctx context.Context, message *model.ScheduledMessage) error
+	GetScheduledMessage(ctx context.Context, id uint) (*model.ScheduledMessage, error)
+	GetScheduledMessages(ctx context.Context, chatID, senderID uint) ([]model.ScheduledMessage, error)
+	UpdatePendingScheduledMessage(ctx context.Context, id uint, updates map[string]any) (bool, error)
+	ClaimDueScheduledMessages(ctx context.Context, now, staleBefore time.Time, limit int) ([]model.ScheduledMessage, error)
+	CompleteScheduledMessage(ctx context.Context, id uint, status string, sentMessageID *uint, lastError string) error
+	RescheduleScheduledMessage(ctx context.Context, id uint, sendAt time.Time, lastError string) error
 }
 
 // ChatStats статистика чата
@@ -64,12 +181,29 @@ type ChatStats struct {
 
 // chatRepository реализация ChatRepository
 type chatRepository struct {
-	db *gorm.DB
+	db           *gorm.DB
+	searchConfig string
+}
+
+// ChatRepositoryOptions опции репозитория чатов
+type ChatRepositoryOptions struct {
+	// SearchConfig конфигурация полнотекстового поиска, та же, что передана в MigrateMessageSearch;
+	// по умолчанию DefaultSearchConfig
+	SearchConfig string
 }
 
 // NewChatRepository создает новый экземпляр репозитория
-func NewChatRepository(db *gorm.DB) ChatRepository {
-	return &chatRepository{db: db}
+func NewChatRepository(db *gorm.DB, options ...ChatRepositoryOptions) ChatRepository {
+	var opts ChatRepositoryOptions
+	if len(options) > 0 {
+		opts = options[0]
+	}
+
+	if !IsValidSearchConfig(opts.SearchConfig) {
+		opts.SearchConfig = DefaultSearchConfig
+	}
+
+	return &chatRepository{db: db, searchConfig: opts.SearchConfig}
 }
 
 // Create создает новый чат
@@ -89,7 +223,6 @@ func (r *chatRepository) GetByID(ctx context.Context, chatID uint) (*model.Chat,
 
 	var chat model.Chat
 	err := r.db.WithContext(ctx).
-		Preload("Users").
 		Preload("Messages", func(db *gorm.DB) *gorm.DB {
 			return db.Order("messages.created_at DESC").Limit(20)
 		}).
@@ -98,6 +231,31 @@ func (r *chatRepository) GetByID(ctx context.Context, chatID uint) (*model.Chat,
 	if errors.Is(err, gorm.ErrRecordNotFound) {
 		return nil, nil
 	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Подписчиков канала может быть очень много, поэтому для каналов их не загружаем
+	if chat.IsChannel {
+		chat.SubscriberCount, err = r.GetChatUsersCount(ctx, chatID)
+		return &chat, err
+	}
+
+	chat.Users, err = r.GetChatUsers(ctx, chatID)
+	return &chat, err
+}
+
+// GetMeta возвращает чат без участников и сообщений
+func (r *chatRepository) GetMeta(ctx context.Context, chatID uint) (*model.Chat, error) {
+	if chatID == 0 {
+		return nil, errors.New("chatID cannot be zero")
+	}
+
+	var chat model.Chat
+	err := r.db.WithContext(ctx).First(&chat, chatID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
 
 	return &chat, err
 }
@@ -136,8 +294,9 @@ func (r *chatRepository) AddUser(ctx context.Context, chatID, userID uint) error
 	return r.db.WithContext(ctx).Create(&chatUser).Error
 }
 
-// Метод CreateGroup
-func (r *chatRepository) CreateGroup(ctx context.Context, chat *model.Chat, userIDs []uint) error {
+// CreateGroup создает групповой чат с участниками userIDs; ownerID получает роль владельца
+// в той же транзакции, поэтому группа без владельца не появится
+func (r *chatRepository) CreateGroup(ctx context.Context, chat *model.Chat, userIDs []uint, ownerID uint) error {
 	if chat == nil {
 		return errors.New("chat cannot be nil")
 	}
@@ -146,6 +305,10 @@ func (r *chatRepository) CreateGroup(ctx context.Context, chat *model.Chat, user
 		return errors.New("group must have at least 2 users")
 	}
 
+	if !slices.Contains(userIDs, ownerID) {
+		return errors.New("group owner must be among group users")
+	}
+
 	// Начинаем транзакцию
 	tx := r.db.WithContext(ctx).Begin()
 	if tx.Error != nil {
@@ -164,6 +327,10 @@ func (r *chatRepository) CreateGroup(ctx context.Context, chat *model.Chat, user
 		chatUser := model.ChatUser{
 			ChatID: chat.ID,
 			UserID: userID,
+			Role:   model.ChatRoleMember,
+		}
+		if userID == ownerID {
+			chatUser.Role = model.ChatRoleOwner
 		}
 
 		if err := tx.Create(&chatUser).Error; err != nil {
@@ -175,6 +342,31 @@ func (r *chatRepository) CreateGroup(ctx context.Context, chat *model.Chat, user
 	return tx.Commit().Error
 }
 
+// CreateChannel создает канал, единственным участником и владельцем которого становится ownerID
+func (r *chatRepository) CreateChannel(ctx context.Context, chat *model.Chat, ownerID uint) error {
+	if chat == nil {
+		return errors.New("chat cannot be nil")
+	}
+	if ownerID == 0 {
+		return errors.New("ownerID cannot be zero")
+	}
+
+	chat.IsGroup = true
+	chat.IsChannel = true
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(chat).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(&model.ChatUser{
+			ChatID: chat.ID,
+			UserID: ownerID,
+			Role:   model.ChatRoleOwner,
+		}).Error
+	})
+}
+
 // RemoveUser удаляет пользователя из чата
 func (r *chatRepository) RemoveUser(ctx context.Context, chatID, userID uint) error {
 	if chatID == 0 || userID == 0 {
@@ -237,6 +429,108 @@ func (r *chatRepository) IsUserInChat(ctx context.Context, chatID, userID uint)
 	return exists > 0, err
 }
 
+// GetUserRole возвращает роль пользователя в чате (пустая строка, если он не участник)
+func (r *chatRepository) GetUserRole(ctx context.Context, chatID, userID uint) (string, error) {
+	if chatID == 0 || userID == 0 {
+		return "", errors.New("chatID and userID cannot be zero")
+	}
+
+	var roles []string
+	err := r.db.WithContext(ctx).Table("chat_users").
+		Where("chat_id = ? AND user_id = ?", chatID, userID).
+		Limit(1).
+		Pluck("role", &roles).Error
+	if err != nil {
+		return "", err
+	}
+
+	if len(roles) == 0 {
+		return "", nil
+	}
+	if roles[0] == "" {
+		return model.ChatRoleMember, nil
+	}
+
+	return roles[0], nil
+}
+
+// SetUserRole меняет роль участника чата
+func (r *chatRepository) SetUserRole(ctx context.Context, chatID, userID uint, role string) error {
+	if chatID == 0 || userID == 0 {
+		return errors.New("chatID and userID cannot be zero")
+	}
+
+	result := r.db.WithContext(ctx).Table("chat_users").
+		Where("chat_id = ? AND user_id = ?", chatID, userID).
+		Update("role", role)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("user is not a member of this chat")
+	}
+
+	return nil
+}
+
+// TransferOwnership передает владение чатом от fromUserID участнику toUserID,
+// прежний владелец становится администратором. Строки обоих участников блокируются,
+// поэтому параллельные передачи не оставят чат с двумя владельцами или без владельца.
+func (r *chatRepository) TransferOwnership(ctx context.Context, chatID, fromUserID, toUserID uint) error {
+	if chatID == 0 || fromUserID == 0 || toUserID == 0 {
+		return errors.New("chatID, fromUserID and toUserID cannot be zero")
+	}
+	if fromUserID == toUserID {
+		return errors.New("cannot transfer ownership to the current owner")
+	}
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var members []model.ChatUser
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("chat_id = ? AND user_id IN ?", chatID, []uint{fromUserID, toUserID}).
+			Order("user_id").
+			Find(&members).Error
+		if err != nil {
+			return err
+		}
+
+		roles := make(map[uint]string, len(members))
+		for _, m := range members {
+			roles[m.UserID] = m.Role
+		}
+		if roles[fromUserID] != model.ChatRoleOwner {
+			return errors.New("user is not the chat owner")
+		}
+		if _, ok := roles[toUserID]; !ok {
+			return errors.New("user is not a member of this chat")
+		}
+
+		if err := tx.Table("chat_users").
+			Where("chat_id = ? AND user_id = ?", chatID, fromUserID).
+			Update("role", model.ChatRoleAdmin).Error; err != nil {
+			return err
+		}
+
+		return tx.Table("chat_users").
+			Where("chat_id = ? AND user_id = ?", chatID, toUserID).
+			Update("role", model.ChatRoleOwner).Error
+	})
+}
+
+// GetChatAdminIDs возвращает ID владельца и администраторов чата
+func (r *chatRepository) GetChatAdminIDs(ctx context.Context, chatID uint) ([]uint, error) {
+	if chatID == 0 {
+		return nil, errors.New("chatID cannot be zero")
+	}
+
+	var userIDs []uint
+	err := r.db.WithContext(ctx).Table("chat_users").
+		Where("chat_id = ? AND role IN ?", chatID, []string{model.ChatRoleOwner, model.ChatRoleAdmin}).
+		Pluck("user_id", &userIDs).Error
+
+	return userIDs, err
+}
+
 // SendMessage отправляет сообщение в чат
 func (r *chatRepository) SendMessage(ctx context.Context, chat *model.Chat, message *model.Message) error {
 	if chat == nil || chat.ID == 0 {
@@ -255,6 +549,25 @@ func (r *chatRepository) SendMessage(ctx context.Context, chat *model.Chat, mess
 	return r.db.WithContext(ctx).Create(message).Error
 }
 
+// SendMessages сохраняет сообщения в одной транзакции: либо все, либо ни одного.
+// Порядок сохранения совпадает с порядком в срезе, ID проставляются в его элементы.
+func (r *chatRepository) SendMessages(ctx context.Context, messages []model.Message) error {
+	for i := range messages {
+		if messages[i].ChatID == 0 || messages[i].SenderID == 0 {
+			return errors.New("message chatID and senderID cannot be zero")
+		}
+	}
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for i := range messages {
+			if err := tx.Create(&messages[i]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
 // GetMessages возвращает все сообщения чата
 func (r *chatRepository) GetMessages(ctx context.Context, chatID uint) ([]model.Message, error) {
 	if chatID == 0 {
@@ -270,8 +583,8 @@ func (r *chatRepository) GetMessages(ctx context.Context, chatID uint) ([]model.
 	return messages, err
 }
 
-// GetRecentMessages возвращает последние сообщения чата
-func (r *chatRepository) GetRecentMessages(ctx context.Context, chatID uint, limit int) ([]model.Message, error) {
+// GetRecentMessages возвращает последние сообщения чата, кроме скрытых viewerID
+func (r *chatRepository) GetRecentMessages(ctx context.Context, chatID, viewerID uint, limit int) ([]model.Message, error) {
 	if chatID == 0 {
 		return nil, errors.New("chatID cannot be zero")
 	}
@@ -285,6 +598,7 @@ func (r *chatRepository) GetRecentMessages(ctx context.Context, chatID uint, lim
 
 	var messages []model.Message
 	err := r.db.WithContext(ctx).
+		Scopes(visibleTo(viewerID)).
 		Where("chat_id = ?", chatID).
 		Order("created_at DESC").
 		Limit(limit).
@@ -313,6 +627,41 @@ func (r *chatRepository) GetMessageByID(ctx context.Context, messageID uint) (*m
 	return &message, err
 }
 
+// GetMessagesByIDs возвращает сообщения по списку ID вместе с отправителями и опросами
+func (r *chatRepository) GetMessagesByIDs(ctx context.Context, messageIDs []uint) ([]model.Message, error) {
+	if len(messageIDs) == 0 {
+		return []model.Message{}, nil
+	}
+
+	var messages []model.Message
+	err := r.db.WithContext(ctx).
+		Preload("Sender").
+		Preload("Poll.Options", func(db *gorm.DB) *gorm.DB {
+			return db.Order("position ASC")
+		}).
+		Where("id IN ?", messageIDs).
+		Find(&messages).Error
+
+	return messages, err
+}
+
+// UpdateMessageLinkPreview сохраняет превью ссылки сообщения
+func (r *chatRepository) UpdateMessageLinkPreview(ctx context.Context, messageID uint, preview *model.LinkPreview) error {
+	if messageID == 0 {
+		return errors.New("messageID cannot be zero")
+	}
+
+	data, err := json.Marshal(preview)
+	if err != nil {
+		return err
+	}
+
+	// updated_at не меняем: превью не является правкой сообщения
+	return r.db.WithContext(ctx).Model(&model.Message{}).
+		Where("id = ?", messageID).
+		UpdateColumn("link_preview", gorm.Expr("?::jsonb", string(data))).Error
+}
+
 // MarkMessageAsRead отмечает сообщение как прочитанное
 func (r *chatRepository) MarkMessageAsRead(ctx context.Context, messageID, userID uint) error {
 	if messageID == 0 || userID == 0 {
@@ -347,13 +696,23 @@ func (r *chatRepository) DeleteMessage(ctx context.Context, messageID uint) erro
 		return errors.New("messageID cannot be zero")
 	}
 
-	return r.db.WithContext(ctx).Delete(&model.Message{}, messageID).Error
+	// Закрепление удаляется вместе с сообщением, закладки на него остаются отметками об удалении
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("message_id = ?", messageID).Delete(&model.PinnedMessage{}).Error; err != nil {
+			return err
+		}
+		if err := tombstoneSavedMessages(tx, []uint{messageID}); err != nil {
+			return err
+		}
+
+		return tx.Delete(&model.Message{}, messageID).Error
+	})
 }
 
-// GetChatMessages возвращает сообщения чата с пагинацией
+// GetChatMessages возвращает сообщения чата с пагинацией, кроме скрытых viewerID
 func (r *chatRepository) GetChatMessages(
 	ctx context.Context,
-	chatID uint,
+	chatID, viewerID uint,
 	cursor string,
 	limit int,
 	direction string,
@@ -377,6 +736,7 @@ func (r *chatRepository) GetChatMessages(
 	// Считаем общее количество сообщений
 	var totalCount int64
 	err := r.db.WithContext(ctx).Model(&model.Message{}).
+		Scopes(visibleTo(viewerID)).
 		Where("chat_id = ?", chatID).
 		Count(&totalCount).Error
 	if err != nil {
@@ -386,6 +746,7 @@ func (r *chatRepository) GetChatMessages(
 	// Строим основной запрос
 	query := r.db.WithContext(ctx).
 		Model(&model.Message{}).
+		Scopes(visibleTo(viewerID)).
 		Where("chat_id = ?", chatID).
 		Preload("Sender")
 
@@ -473,13 +834,20 @@ func (r *chatRepository) GetChatsForUser(ctx context.Context, userID uint) (*[]m
 
 	// Загружаем пользователей для каждого чата
 	for i := range chats {
-		users, err := r.GetChatUsers(ctx, chats[i].ID)
-		if err == nil {
-			chats[i].Users = users
+		if chats[i].IsChannel {
+			count, err := r.GetChatUsersCount(ctx, chats[i].ID)
+			if err == nil {
+				chats[i].SubscriberCount = count
+			}
+		} else {
+			users, err := r.GetChatUsers(ctx, chats[i].ID)
+			if err == nil {
+				chats[i].Users = users
+			}
 		}
 
-		// Загружаем последние сообщения
-		messages, err := r.GetRecentMessages(ctx, chats[i].ID, 1)
+		// Загружаем последнее сообщение, видимое пользователю
+		messages, err := r.GetRecentMessages(ctx, chats[i].ID, userID, 1)
 		if err == nil && len(messages) > 0 {
 			chats[i].Messages = messages
 		}
@@ -559,6 +927,45 @@ func (r *chatRepository) UpdateGroupInfo(ctx context.Context, chatID uint, name,
 	`, name, description, chatID).Error
 }
 
+// UpdateAvatar обновляет ключ аватара чата
+func (r *chatRepository) UpdateAvatar(ctx context.Context, chatID uint, avatarKey string) error {
+	if chatID == 0 {
+		return errors.New("chatID cannot be zero")
+	}
+
+	return r.db.WithContext(ctx).Exec(`
+		UPDATE chats
+		SET avatar_key = ?, updated_at = NOW()
+		WHERE id = ?
+	`, avatarKey, chatID).Error
+}
+
+// UpdateSlowMode обновляет интервал медленного режима чата
+func (r *chatRepository) UpdateSlowMode(ctx context.Context, chatID uint, seconds int) error {
+	if chatID == 0 {
+		return errors.New("chatID cannot be zero")
+	}
+
+	return r.db.WithContext(ctx).Exec(`
+		UPDATE chats
+		SET slow_mode_seconds = ?, updated_at = NOW()
+		WHERE id = ?
+	`, seconds, chatID).Error
+}
+
+// UpdateJoinByRequest включает или выключает вступление по заявке без ссылки
+func (r *chatRepository) UpdateJoinByRequest(ctx context.Context, chatID uint, enabled bool) error {
+	if chatID == 0 {
+		return errors.New("chatID cannot be zero")
+	}
+
+	return r.db.WithContext(ctx).Exec(`
+		UPDATE chats
+		SET join_by_request = ?, updated_at = NOW()
+		WHERE id = ?
+	`, enabled, chatID).Error
+}
+
 // GetGroupChatsForUser возвращает групповые чаты пользователя
 func (r *chatRepository) GetGroupChatsForUser(ctx context.Context, userID uint) ([]model.Chat, error) {
 	if userID == 0 {
@@ -628,35 +1035,6 @@ func (r *chatRepository) GetChatStatistics(ctx context.Context, chatID uint) (*C
 	return &stats, nil
 }
 
-// SearchMessages ищет сообщения в чате
-func (r *chatRepository) SearchMessages(ctx context.Context, chatID uint, query string, limit int) ([]model.Message, error) {
-	if chatID == 0 {
-		return nil, errors.New("chatID cannot be zero")
-	}
-
-	query = strings.TrimSpace(query)
-	if query == "" {
-		return nil, errors.New("search query cannot be empty")
-	}
-
-	if limit <= 0 {
-		limit = 20
-	}
-	if limit > 100 {
-		limit = 100
-	}
-
-	var messages []model.Message
-	err := r.db.WithContext(ctx).
-		Where("chat_id = ? AND message ILIKE ?", chatID, "%"+query+"%").
-		Order("created_at DESC").
-		Limit(limit).
-		Preload("Sender").
-		Find(&messages).Error
-
-	return messages, err
-}
-
 // GetUnreadCount возвращает количество непрочитанных сообщений
 func (r *chatRepository) GetUnreadCount(ctx context.Context, userID, chatID uint) (int64, error) {
 	if userID == 0 {
@@ -715,11 +1093,11 @@ func (r *chatRepository) GetMessagesLegacy(chatID uint) ([]model.Message, error)
 
 func (r *chatRepository) GetChatMessagesLegacy(chatID uint, cursor string, limit int, direction string, ctx context.Context) (
 	[]model.Message, bool, bool, *int64, error) {
-	return r.GetChatMessages(ctx, chatID, cursor, limit, direction)
+	return r.GetChatMessages(ctx, chatID, 0, cursor, limit, direction)
 }
 
-func (r *chatRepository) CreateGroupLegacy(chat *model.Chat, userIDs []uint) error {
-	return r.CreateGroup(context.Background(), chat, userIDs)
+func (r *chatRepository) CreateGroupLegacy(chat *model.Chat, userIDs []uint, ownerID uint) error {
+	return r.CreateGroup(context.Background(), chat, userIDs, ownerID)
 }
 
 func (r *chatRepository) IsUserInChatLegacy(chatID, userID uint) (bool, error) {
@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"tush00nka/bbbab_messenger/internal/model"
+)
+
+// CreateAuditLog сохраняет запись журнала модерации
+func (r *chatRepository) CreateAuditLog(ctx context.Context, entry *model.ChatAuditLog) error {
+	if entry == nil {
+		return errors.New("audit entry cannot be nil")
+	}
+	if entry.ChatID == 0 || entry.ActorID == 0 {
+		return errors.New("chatID and actorID cannot be zero")
+	}
+
+	return r.db.WithContext(ctx).Create(entry).Error
+}
+
+// GetAuditLog возвращает записи журнала чата от новых к старым.
+// beforeID — курсор: возвращаются записи с ID меньше указанного (0 — с самой новой).
+func (r *chatRepository) GetAuditLog(ctx context.Context, chatID, beforeID uint, limit int) ([]model.ChatAuditLog, error) {
+	if chatID == 0 {
+		return nil, errors.New("chatID cannot be zero")
+	}
+
+	query := r.db.WithContext(ctx).
+		Where("chat_id = ?", chatID).
+		Preload("Actor")
+	if beforeID > 0 {
+		query = query.Where("id < ?", beforeID)
+	}
+
+	var entries []model.ChatAuditLog
+	err := query.Order("id DESC").Limit(limit).Find(&entries).Error
+
+	for i := range entries {
+		entries[i].Actor.EnsureDisplayName()
+	}
+
+	return entries, err
+}
@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"tush00nka/bbbab_messenger/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// BanUser блокирует пользователя в чате и удаляет его из участников.
+// Существующая блокировка заменяется новой.
+func (r *chatRepository) BanUser(ctx context.Context, ban *model.ChatBan) error {
+	if ban == nil {
+		return errors.New("ban cannot be nil")
+	}
+	if ban.ChatID == 0 || ban.UserID == 0 {
+		return errors.New("chatID and userID cannot be zero")
+	}
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().
+			Where("chat_id = ? AND user_id = ?", ban.ChatID, ban.UserID).
+			Delete(&model.ChatBan{}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Create(ban).Error; err != nil {
+			return err
+		}
+
+		return tx.Exec(`
+			DELETE FROM chat_users
+			WHERE chat_id = ? AND user_id = ?
+		`, ban.ChatID, ban.UserID).Error
+	})
+}
+
+// GetActiveBan возвращает действующую блокировку пользователя в чате
+func (r *chatRepository) GetActiveBan(ctx context.Context, chatID, userID uint) (*model.ChatBan, error) {
+	if chatID == 0 || userID == 0 {
+		return nil, errors.New("chatID and userID cannot be zero")
+	}
+
+	var ban model.ChatBan
+	err := r.db.WithContext(ctx).
+		Where("chat_id = ? AND user_id = ?", chatID, userID).
+		Where("expires_at IS NULL OR expires_at > NOW()").
+		First(&ban).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+
+	return &ban, err
+}
+
+// GetChatBans возвращает действующие блокировки чата, начиная с новых
+func (r *chatRepository) GetChatBans(ctx context.Context, chatID uint) ([]model.ChatBan, error) {
+	if chatID == 0 {
+		return nil, errors.New("chatID cannot be zero")
+	}
+
+	var bans []model.ChatBan
+	err := r.db.WithContext(ctx).
+		Where("chat_id = ?", chatID).
+		Where("expires_at IS NULL OR expires_at > NOW()").
+		Preload("User").
+		Order("created_at DESC").
+		Find(&bans).Error
+
+	for i := range bans {
+		bans[i].User.EnsureDisplayName()
+	}
+
+	return bans, err
+}
+
+// UnbanUser снимает блокировку. Возвращает false, если блокировки не было.
+func (r *chatRepository) UnbanUser(ctx context.Context, chatID, userID uint) (bool, error) {
+	if chatID == 0 || userID == 0 {
+		return false, errors.New("chatID and userID cannot be zero")
+	}
+
+	result := r.db.WithContext(ctx).Unscoped().
+		Where("chat_id = ? AND user_id = ?", chatID, userID).
+		Delete(&model.ChatBan{})
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected > 0, nil
+}
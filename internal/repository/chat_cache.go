@@ -19,6 +19,7 @@ type ChatCacheRepository interface {
 	GetMessageCount(ctx context.Context, chatID uint) (int64, error)
 	TrimMessages(ctx context.Context, chatID uint, maxSize int64) error
 	DeleteMessage(ctx context.Context, chatID, messageID uint) error
+	UpdateMessage(ctx context.Context, chatID uint, msg model.Message) error
 
 	// Операции с пользователями (присутствие)
 	AddUserToChat(ctx context.Context, chatID, userID uint) error
@@ -32,6 +33,13 @@ type ChatCacheRepository interface {
 	GetActiveChatsCount(ctx context.Context) (int64, error)
 	SetChatTTL(ctx context.Context, chatID uint, ttl time.Duration) error
 
+	// Ограничение частоты отправки
+	AcquireSendSlot(ctx context.Context, chatID, userID uint, interval time.Duration) (time.Duration, error)
+
+	// Превью ссылок
+	GetLinkPreview(ctx context.Context, url string) (*model.LinkPreview, bool, error)
+	SaveLinkPreview(ctx context.Context, url string, preview *model.LinkPreview, ttl time.Duration) error
+
 	// Статистика
 	IncrementMessageCounter(ctx context.Context, chatID uint) (int64, error)
 	GetChatStatistics(ctx context.Context, chatID uint) (map[string]interface{}, error)
@@ -62,6 +70,11 @@ func (r *chatCacheRepository) getCounterKey(chatID uint) string {
 	return fmt.Sprintf("chat:%d:msg_counter", chatID)
 }
 
+// getSendSlotKey возвращает ключ последней отправки участника в медленном режиме
+func (r *chatCacheRepository) getSendSlotKey(chatID, userID uint) string {
+	return fmt.Sprintf("chat:%d:slowmode:%d", chatID, userID)
+}
+
 // getUserChatsKey возвращает ключ для хранения чатов пользователя
 func (r *chatCacheRepository) getUserChatsKey(userID uint) string {
 	return fmt.Sprintf("user:%d:active_chats", userID)
@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+	"tush00nka/bbbab_messenger/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// UpdateMessageTTL обновляет время жизни новых сообщений чата
+func (r *chatRepository) UpdateMessageTTL(ctx context.Context, chatID uint, seconds int) error {
+	if chatID == 0 {
+		return errors.New("chatID cannot be zero")
+	}
+
+	return r.db.WithContext(ctx).Exec(`
+		UPDATE chats
+		SET message_ttl_seconds = ?, updated_at = NOW()
+		WHERE id = ?
+	`, seconds, chatID).Error
+}
+
+// DeleteExpiredMessages безвозвратно удаляет до limit сообщений с истекшим сроком жизни
+// и возвращает их. Строки блокируются через FOR UPDATE SKIP LOCKED, поэтому
+// несколько экземпляров сервиса не удаляют одни и те же сообщения.
+func (r *chatRepository) DeleteExpiredMessages(ctx context.Context, now time.Time, limit int) ([]model.Message, error) {
+	if limit <= 0 {
+		return []model.Message{}, nil
+	}
+
+	var messages []model.Message
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Unscoped().
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("expires_at IS NOT NULL AND expires_at <= ?", now).
+			Order("expires_at ASC, id ASC").
+			Limit(limit).
+			Find(&messages).Error
+		if err != nil || len(messages) == 0 {
+			return err
+		}
+
+		ids := make([]uint, len(messages))
+		for i := range messages {
+			ids[i] = messages[i].ID
+		}
+
+		if err := tx.Where("message_id IN ?", ids).Delete(&model.PinnedMessage{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("message_id IN ?", ids).Delete(&model.MessageRead{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("message_id IN ?", ids).Delete(&model.MessageMention{}).Error; err != nil {
+			return err
+		}
+		if err := deletePolls(tx, ids); err != nil {
+			return err
+		}
+		if err := tombstoneSavedMessages(tx, ids); err != nil {
+			return err
+		}
+		// Ответы на удаляемые сообщения остаются, но теряют ссылку
+		if err := tx.Model(&model.Message{}).Unscoped().
+			Where("reply_to_id IN ?", ids).
+			Update("reply_to_id", nil).Error; err != nil {
+			return err
+		}
+
+		return tx.Unscoped().Where("id IN ?", ids).Delete(&model.Message{}).Error
+	})
+
+	return messages, err
+}
+
+// IsAttachmentReferenced проверяет, ссылаются ли на вложение другие сообщения,
+// например пересланные копии или отложенные отправки
+func (r *chatRepository) IsAttachmentReferenced(ctx context.Context, attachmentURL string) (bool, error) {
+	if attachmentURL == "" {
+		return false, nil
+	}
+
+	var count int64
+	err := r.db.WithContext(ctx).Model(&model.Message{}).
+		Where("attachment_url = ?", attachmentURL).
+		Count(&count).Error
+	if err != nil || count > 0 {
+		return count > 0, err
+	}
+
+	err = r.db.WithContext(ctx).Model(&model.ScheduledMessage{}).
+		Where("attachment_url = ? AND status IN ?", attachmentURL,
+			[]string{model.ScheduledPending, model.ScheduledProcessing}).
+		Count(&count).Error
+
+	return count > 0, err
+}
@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"tush00nka/bbbab_messenger/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SaveDraft создает или заменяет черновик пользователя в чате
+func (r *chatRepository) SaveDraft(ctx context.Context, draft *model.ChatDraft) error {
+	if draft == nil || draft.UserID == 0 || draft.ChatID == 0 {
+		return errors.New("userID and chatID cannot be zero")
+	}
+
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}, {Name: "chat_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"text", "entities", "reply_to_id", "updated_at"}),
+		}).
+		Create(draft).Error
+}
+
+// GetDraft возвращает черновик пользователя в чате
+func (r *chatRepository) GetDraft(ctx context.Context, userID, chatID uint) (*model.ChatDraft, error) {
+	if userID == 0 || chatID == 0 {
+		return nil, errors.New("userID and chatID cannot be zero")
+	}
+
+	var draft model.ChatDraft
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND chat_id = ?", userID, chatID).
+		First(&draft).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+
+	return &draft, err
+}
+
+// GetDraftsForUser возвращает черновики пользователя по всем чатам
+func (r *chatRepository) GetDraftsForUser(ctx context.Context, userID uint) ([]model.ChatDraft, error) {
+	if userID == 0 {
+		return nil, errors.New("userID cannot be zero")
+	}
+
+	var drafts []model.ChatDraft
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Find(&drafts).Error
+
+	return drafts, err
+}
+
+// DeleteDraft удаляет черновик. Возвращает false, если черновика не было.
+func (r *chatRepository) DeleteDraft(ctx context.Context, userID, chatID uint) (bool, error) {
+	if userID == 0 || chatID == 0 {
+		return false, errors.New("userID and chatID cannot be zero")
+	}
+
+	result := r.db.WithContext(ctx).
+		Where("user_id = ? AND chat_id = ?", userID, chatID).
+		Delete(&model.ChatDraft{})
+
+	return result.RowsAffected > 0, result.Error
+}
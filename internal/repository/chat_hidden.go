@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"tush00nka/bbbab_messenger/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// HideMessages скрывает сообщения чата только для пользователя; повторное скрытие ничего не меняет
+func (r *chatRepository) HideMessages(ctx context.Context, userID, chatID uint, messageIDs []uint) error {
+	if userID == 0 || chatID == 0 {
+		return errors.New("userID and chatID cannot be zero")
+	}
+	if len(messageIDs) == 0 {
+		return nil
+	}
+
+	hidden := make([]model.HiddenMessage, len(messageIDs))
+	for i, id := range messageIDs {
+		hidden[i] = model.HiddenMessage{UserID: userID, MessageID: id, ChatID: chatID}
+	}
+
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&hidden).Error
+}
+
+// GetHiddenMessageIDs возвращает ID сообщений чата, скрытых пользователем
+func (r *chatRepository) GetHiddenMessageIDs(ctx context.Context, userID, chatID uint) ([]uint, error) {
+	if userID == 0 || chatID == 0 {
+		return nil, errors.New("userID and chatID cannot be zero")
+	}
+
+	var ids []uint
+	err := r.db.WithContext(ctx).Model(&model.HiddenMessage{}).
+		Where("user_id = ? AND chat_id = ?", userID, chatID).
+		Pluck("message_id", &ids).Error
+
+	return ids, err
+}
+
+// DeleteMessages удаляет сообщения у всех участников вместе с их закреплениями.
+// Возвращает ID сообщений, которые были закреплены.
+func (r *chatRepository) DeleteMessages(ctx context.Context, messageIDs []uint) ([]uint, error) {
+	if len(messageIDs) == 0 {
+		return []uint{}, nil
+	}
+
+	var pinnedIDs []uint
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.PinnedMessage{}).
+			Where("message_id IN ?", messageIDs).
+			Pluck("message_id", &pinnedIDs).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("message_id IN ?", messageIDs).Delete(&model.PinnedMessage{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("message_id IN ?", messageIDs).Delete(&model.HiddenMessage{}).Error; err != nil {
+			return err
+		}
+		if err := tombstoneSavedMessages(tx, messageIDs); err != nil {
+			return err
+		}
+
+		return tx.Where("id IN ?", messageIDs).Delete(&model.Message{}).Error
+	})
+
+	return pinnedIDs, err
+}
+
+// visibleTo исключает сообщения, скрытые пользователем по одному или очисткой истории;
+// userID == 0 — без фильтра
+func visibleTo(userID uint) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if userID == 0 {
+			return db
+		}
+
+		return db.
+			Where("NOT EXISTS (SELECT 1 FROM hidden_messages hm WHERE hm.message_id = messages.id AND hm.user_id = ?)", userID).
+			Where(`messages.id > COALESCE((
+				SELECT cu.history_cleared_up_to_id FROM chat_users cu
+				WHERE cu.chat_id = messages.chat_id AND cu.user_id = ?
+			), 0)`, userID)
+	}
+}
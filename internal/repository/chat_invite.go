@@ -0,0 +1,325 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+	"tush00nka/bbbab_messenger/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CreateInvite создает пригласительную ссылку
+func (r *chatRepository) CreateInvite(ctx context.Context, invite *model.ChatInvite) error {
+	if invite == nil {
+		return errors.New("invite cannot be nil")
+	}
+	if invite.ChatID == 0 {
+		return errors.New("chatID cannot be zero")
+	}
+	if invite.Token == "" {
+		return errors.New("invite token cannot be empty")
+	}
+
+	return r.db.WithContext(ctx).Create(invite).Error
+}
+
+// GetInviteByID возвращает пригласительную ссылку по ID
+func (r *chatRepository) GetInviteByID(ctx context.Context, inviteID uint) (*model.ChatInvite, error) {
+	if inviteID == 0 {
+		return nil, errors.New("inviteID cannot be zero")
+	}
+
+	var invite model.ChatInvite
+	err := r.db.WithContext(ctx).First(&invite, inviteID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+
+	return &invite, err
+}
+
+// GetInviteByToken возвращает пригласительную ссылку по токену
+func (r *chatRepository) GetInviteByToken(ctx context.Context, token string) (*model.ChatInvite, error) {
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return nil, errors.New("invite token cannot be empty")
+	}
+
+	var invite model.ChatInvite
+	err := r.db.WithContext(ctx).Where("token = ?", token).First(&invite).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+
+	return &invite, err
+}
+
+// GetChatInvites возвращает все ссылки чата, начиная с новых
+func (r *chatRepository) GetChatInvites(ctx context.Context, chatID uint) ([]model.ChatInvite, error) {
+	if chatID == 0 {
+		return nil, errors.New("chatID cannot be zero")
+	}
+
+	var invites []model.ChatInvite
+	err := r.db.WithContext(ctx).
+		Where("chat_id = ?", chatID).
+		Order("created_at DESC").
+		Find(&invites).Error
+
+	return invites, err
+}
+
+// RevokeInvite отзывает пригласительную ссылку
+func (r *chatRepository) RevokeInvite(ctx context.Context, inviteID uint) error {
+	if inviteID == 0 {
+		return errors.New("inviteID cannot be zero")
+	}
+
+	return r.db.WithContext(ctx).Exec(`
+		UPDATE chat_invites
+		SET revoked_at = NOW(), updated_at = NOW()
+		WHERE id = ? AND revoked_at IS NULL
+	`, inviteID).Error
+}
+
+// JoinByInvite расходует одно использование ссылки и добавляет пользователя в чат
+// в одной транзакции: при ошибке вступления счетчик не меняется.
+// Возвращает false, если ссылка отозвана, истекла или лимит исчерпан.
+func (r *chatRepository) JoinByInvite(ctx context.Context, inviteID, userID uint) (bool, error) {
+	if inviteID == 0 || userID == 0 {
+		return false, errors.New("inviteID and userID cannot be zero")
+	}
+
+	used := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		invite, err := useInvite(tx, inviteID)
+		if err != nil || invite == nil {
+			return err
+		}
+
+		chatUser := model.ChatUser{
+			ChatID: invite.ChatID,
+			UserID: userID,
+		}
+		if err := tx.Create(&chatUser).Error; err != nil {
+			return err
+		}
+
+		used = true
+		return nil
+	})
+
+	return used, err
+}
+
+// CreateJoinRequestByInvite расходует одно использование ссылки request.InviteID
+// и создает заявку в одной транзакции.
+// Возвращает false, если ссылка отозвана, истекла или лимит исчерпан.
+func (r *chatRepository) CreateJoinRequestByInvite(ctx context.Context, request *model.ChatJoinRequest) (bool, error) {
+	if request == nil {
+		return false, errors.New("join request cannot be nil")
+	}
+	if request.InviteID == nil || *request.InviteID == 0 {
+		return false, errors.New("inviteID cannot be zero")
+	}
+	if request.ChatID == 0 || request.UserID == 0 {
+		return false, errors.New("chatID and userID cannot be zero")
+	}
+
+	used := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		invite, err := useInvite(tx, *request.InviteID)
+		if err != nil || invite == nil {
+			return err
+		}
+		if invite.ChatID != request.ChatID {
+			return errors.New("invite belongs to another chat")
+		}
+
+		if err := tx.Create(request).Error; err != nil {
+			return err
+		}
+
+		used = true
+		return nil
+	})
+
+	return used, err
+}
+
+// useInvite атомарно увеличивает счетчик использований ссылки, чтобы параллельные
+// вступления не превысили лимит. Возвращает nil, если ссылкой воспользоваться нельзя.
+func useInvite(tx *gorm.DB, inviteID uint) (*model.ChatInvite, error) {
+	var invites []model.ChatInvite
+	err := tx.Raw(`
+		UPDATE chat_invites
+		SET use_count = use_count + 1, updated_at = NOW()
+		WHERE id = ?
+		  AND deleted_at IS NULL
+		  AND revoked_at IS NULL
+		  AND (expires_at IS NULL OR expires_at > NOW())
+		  AND (max_uses = 0 OR use_count < max_uses)
+		RETURNING *
+	`, inviteID).Scan(&invites).Error
+	if err != nil || len(invites) == 0 {
+		return nil, err
+	}
+
+	return &invites[0], nil
+}
+
+// CreateJoinRequest создает заявку на вступление
+func (r *chatRepository) CreateJoinRequest(ctx context.Context, request *model.ChatJoinRequest) error {
+	if request == nil {
+		return errors.New("join request cannot be nil")
+	}
+	if request.ChatID == 0 || request.UserID == 0 {
+		return errors.New("chatID and userID cannot be zero")
+	}
+
+	return r.db.WithContext(ctx).Create(request).Error
+}
+
+// GetJoinRequestByID возвращает заявку по ID
+func (r *chatRepository) GetJoinRequestByID(ctx context.Context, requestID uint) (*model.ChatJoinRequest, error) {
+	if requestID == 0 {
+		return nil, errors.New("requestID cannot be zero")
+	}
+
+	var request model.ChatJoinRequest
+	err := r.db.WithContext(ctx).Preload("User").First(&request, requestID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+
+	return &request, err
+}
+
+// GetPendingJoinRequest возвращает необработанную заявку пользователя в чат
+func (r *chatRepository) GetPendingJoinRequest(ctx context.Context, chatID, userID uint) (*model.ChatJoinRequest, error) {
+	if chatID == 0 || userID == 0 {
+		return nil, errors.New("chatID and userID cannot be zero")
+	}
+
+	var request model.ChatJoinRequest
+	err := r.db.WithContext(ctx).
+		Where("chat_id = ? AND user_id = ? AND status = ?", chatID, userID, model.JoinRequestPending).
+		First(&request).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+
+	return &request, err
+}
+
+// GetJoinRequests возвращает заявки чата; пустой status — все заявки
+func (r *chatRepository) GetJoinRequests(ctx context.Context, chatID uint, status string) ([]model.ChatJoinRequest, error) {
+	if chatID == 0 {
+		return nil, errors.New("chatID cannot be zero")
+	}
+
+	query := r.db.WithContext(ctx).
+		Where("chat_id = ?", chatID).
+		Preload("User")
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var requests []model.ChatJoinRequest
+	err := query.Order("created_at ASC").Find(&requests).Error
+
+	for i := range requests {
+		requests[i].User.EnsureDisplayName()
+	}
+
+	return requests, err
+}
+
+// ResolveJoinRequest переводит заявку из pending в итоговый статус.
+// Возвращает false, если заявка уже была обработана.
+func (r *chatRepository) ResolveJoinRequest(ctx context.Context, requestID, reviewerID uint, status string) (bool, error) {
+	if requestID == 0 || reviewerID == 0 {
+		return false, errors.New("requestID and reviewerID cannot be zero")
+	}
+
+	result := r.db.WithContext(ctx).Exec(`
+		UPDATE chat_join_requests
+		SET status = ?, reviewed_by_id = ?, reviewed_at = NOW(), updated_at = NOW()
+		WHERE id = ? AND status = ?
+	`, status, reviewerID, requestID, model.JoinRequestPending)
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+// ApproveJoinRequest одобряет заявку и добавляет ее автора в чат в одной транзакции.
+// Возвращает false, если заявка уже была обработана; уже состоящий в чате автор не добавляется повторно.
+func (r *chatRepository) ApproveJoinRequest(ctx context.Context, requestID, reviewerID uint) (bool, error) {
+	if requestID == 0 || reviewerID == 0 {
+		return false, errors.New("requestID and reviewerID cannot be zero")
+	}
+
+	approved := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var requests []model.ChatJoinRequest
+		err := tx.Raw(`
+			UPDATE chat_join_requests
+			SET status = ?, reviewed_by_id = ?, reviewed_at = NOW(), updated_at = NOW()
+			WHERE id = ? AND status = ?
+			RETURNING *
+		`, model.JoinRequestApproved, reviewerID, requestID, model.JoinRequestPending).Scan(&requests).Error
+		if err != nil || len(requests) == 0 {
+			return err
+		}
+
+		chatUser := model.ChatUser{
+			ChatID: requests[0].ChatID,
+			UserID: requests[0].UserID,
+		}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&chatUser).Error; err != nil {
+			return err
+		}
+
+		approved = true
+		return nil
+	})
+
+	return approved, err
+}
+
+// GetLastRejectedJoinRequest возвращает последнюю отклоненную заявку пользователя в чат
+func (r *chatRepository) GetLastRejectedJoinRequest(ctx context.Context, chatID, userID uint) (*model.ChatJoinRequest, error) {
+	if chatID == 0 || userID == 0 {
+		return nil, errors.New("chatID and userID cannot be zero")
+	}
+
+	var request model.ChatJoinRequest
+	err := r.db.WithContext(ctx).
+		Where("chat_id = ? AND user_id = ? AND status = ?", chatID, userID, model.JoinRequestRejected).
+		Order("reviewed_at DESC NULLS LAST, id DESC").
+		First(&request).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+
+	return &request, err
+}
+
+// CountPendingJoinRequestsSince считает необработанные заявки в чат, поданные не раньше since
+func (r *chatRepository) CountPendingJoinRequestsSince(ctx context.Context, chatID uint, since time.Time) (int64, error) {
+	if chatID == 0 {
+		return 0, errors.New("chatID cannot be zero")
+	}
+
+	var count int64
+	err := r.db.WithContext(ctx).Model(&model.ChatJoinRequest{}).
+		Where("chat_id = ? AND status = ? AND created_at >= ?", chatID, model.JoinRequestPending, since).
+		Count(&count).Error
+
+	return count, err
+}
@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"tush00nka/bbbab_messenger/internal/model"
+)
+
+func TestApproveJoinRequest(t *testing.T) {
+	db := openTestDB(t)
+	if err := Migrate(db); err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+	repo := NewChatRepository(db)
+	ctx := context.Background()
+
+	users := []model.User{{Username: "owner"}, {Username: "member"}, {Username: "applicant"}}
+	if err := db.Create(&users).Error; err != nil {
+		t.Fatal(err)
+	}
+	owner, applicant := users[0].ID, users[2].ID
+
+	chat := &model.Chat{Name: "group"}
+	if err := repo.CreateGroup(ctx, chat, []uint{owner, users[1].ID}, owner); err != nil {
+		t.Fatalf("CreateGroup() error = %v", err)
+	}
+
+	request := &model.ChatJoinRequest{ChatID: chat.ID, UserID: applicant, Status: model.JoinRequestPending}
+	if err := repo.CreateJoinRequest(ctx, request); err != nil {
+		t.Fatalf("CreateJoinRequest() error = %v", err)
+	}
+
+	ok, err := repo.ApproveJoinRequest(ctx, request.ID, owner)
+	if err != nil || !ok {
+		t.Fatalf("ApproveJoinRequest() = %v, %v, want true", ok, err)
+	}
+	if isMember, _ := repo.IsUserInChat(ctx, chat.ID, applicant); !isMember {
+		t.Error("approved applicant is not a chat member")
+	}
+
+	// Повторное одобрение не меняет заявку и не добавляет участника
+	ok, err = repo.ApproveJoinRequest(ctx, request.ID, owner)
+	if err != nil || ok {
+		t.Errorf("second ApproveJoinRequest() = %v, %v, want false", ok, err)
+	}
+
+	stored, err := repo.GetJoinRequestByID(ctx, request.ID)
+	if err != nil || stored == nil {
+		t.Fatalf("GetJoinRequestByID() = %v, %v", stored, err)
+	}
+	if stored.Status != model.JoinRequestApproved || stored.ReviewedByID == nil || *stored.ReviewedByID != owner {
+		t.Errorf("stored request = %s reviewed by %v, want approved by %d", stored.Status, stored.ReviewedByID, owner)
+	}
+}
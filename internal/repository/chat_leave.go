@@ -0,0 +1,173 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"tush00nka/bbbab_messenger/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// GetOwnershipSuccessor выбирает нового владельца вместо excludeUserID:
+// самого давнего администратора, а если их нет — самого давнего участника.
+// Возвращает 0, если других участников нет.
+func (r *chatRepository) GetOwnershipSuccessor(ctx context.Context, chatID, excludeUserID uint) (uint, error) {
+	if chatID == 0 {
+		return 0, errors.New("chatID cannot be zero")
+	}
+
+	var userIDs []uint
+	err := r.db.WithContext(ctx).Table("chat_users").
+		Where("chat_id = ? AND user_id <> ?", chatID, excludeUserID).
+		Order(gorm.Expr("CASE WHEN role = ? THEN 0 ELSE 1 END", model.ChatRoleAdmin)).
+		Order("created_at ASC, user_id ASC").
+		Limit(1).
+		Pluck("user_id", &userIDs).Error
+	if err != nil || len(userIDs) == 0 {
+		return 0, err
+	}
+
+	return userIDs[0], nil
+}
+
+// LeaveChat удаляет участника из чата; если newOwnerID не 0, в той же транзакции
+// передает ему права владельца
+func (r *chatRepository) LeaveChat(ctx context.Context, chatID, userID, newOwnerID uint) error {
+	if chatID == 0 || userID == 0 {
+		return errors.New("chatID and userID cannot be zero")
+	}
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if newOwnerID != 0 {
+			result := tx.Table("chat_users").
+				Where("chat_id = ? AND user_id = ?", chatID, newOwnerID).
+				Update("role", model.ChatRoleOwner)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return errors.New("new owner is not a member of this chat")
+			}
+		}
+
+		return tx.Exec(`
+			DELETE FROM chat_users
+			WHERE chat_id = ? AND user_id = ?
+		`, chatID, userID).Error
+	})
+}
+
+// ClearHistory скрывает от участника все текущие сообщения чата и возвращает ID последнего из них
+func (r *chatRepository) ClearHistory(ctx context.Context, chatID, userID uint) (uint, error) {
+	if chatID == 0 || userID == 0 {
+		return 0, errors.New("chatID and userID cannot be zero")
+	}
+
+	var lastID uint
+	err := r.db.WithContext(ctx).Unscoped().Model(&model.Message{}).
+		Where("chat_id = ?", chatID).
+		Select("COALESCE(MAX(id), 0)").
+		Scan(&lastID).Error
+	if err != nil {
+		return 0, err
+	}
+
+	result := r.db.WithContext(ctx).Table("chat_users").
+		Where("chat_id = ? AND user_id = ?", chatID, userID).
+		Update("history_cleared_up_to_id", lastID)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return 0, errors.New("user is not a member of this chat")
+	}
+
+	// Отдельно скрытые сообщения до отметки больше не нужны
+	err = r.db.WithContext(ctx).
+		Where("user_id = ? AND chat_id = ? AND message_id <= ?", userID, chatID, lastID).
+		Delete(&model.HiddenMessage{}).Error
+
+	return lastID, err
+}
+
+// GetHistoryClearedUpTo возвращает отметку очистки истории участника (0 — не очищалась)
+func (r *chatRepository) GetHistoryClearedUpTo(ctx context.Context, chatID, userID uint) (uint, error) {
+	if chatID == 0 || userID == 0 {
+		return 0, errors.New("chatID and userID cannot be zero")
+	}
+
+	var ids []uint
+	err := r.db.WithContext(ctx).Table("chat_users").
+		Where("chat_id = ? AND user_id = ?", chatID, userID).
+		Limit(1).
+		Pluck("history_cleared_up_to_id", &ids).Error
+	if err != nil || len(ids) == 0 {
+		return 0, err
+	}
+
+	return ids[0], nil
+}
+
+// PurgeChat безвозвратно удаляет чат со всеми сообщениями и связанными записями.
+// Возвращает ссылки на вложения удаленных сообщений.
+func (r *chatRepository) PurgeChat(ctx context.Context, chatID uint) ([]string, error) {
+	if chatID == 0 {
+		return nil, errors.New("chatID cannot be zero")
+	}
+
+	var attachments []string
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Model(&model.Message{}).
+			Where("chat_id = ? AND attachment_url IS NOT NULL AND attachment_url <> ''", chatID).
+			Distinct().
+			Pluck("attachment_url", &attachments).Error; err != nil {
+			return err
+		}
+
+		messageIDs := tx.Unscoped().Model(&model.Message{}).Select("id").Where("chat_id = ?", chatID)
+		if err := tx.Unscoped().Where("message_id IN (?)", messageIDs).Delete(&model.MessageRead{}).Error; err != nil {
+			return err
+		}
+
+		if err := deletePolls(tx, messageIDs); err != nil {
+			return err
+		}
+		if err := tombstoneSavedMessages(tx, messageIDs); err != nil {
+			return err
+		}
+
+		// Служебные записи чата
+		for _, related := range []any{
+			&model.PinnedMessage{},
+			&model.HiddenMessage{},
+			&model.MessageMention{},
+			&model.ChatDraft{},
+			&model.ScheduledMessage{},
+			&model.ChatAuditLog{},
+			&model.ChatBan{},
+			&model.ChatInvite{},
+			&model.ChatJoinRequest{},
+		} {
+			if err := tx.Unscoped().Where("chat_id = ?", chatID).Delete(related).Error; err != nil {
+				return err
+			}
+		}
+
+		// Ответы ссылаются на сообщения того же чата
+		if err := tx.Unscoped().Model(&model.Message{}).
+			Where("chat_id = ? AND reply_to_id IS NOT NULL", chatID).
+			Update("reply_to_id", nil).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("chat_id = ?", chatID).Delete(&model.Message{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec(`DELETE FROM chat_users WHERE chat_id = ?`, chatID).Error; err != nil {
+			return err
+		}
+
+		return tx.Unscoped().Delete(&model.Chat{}, chatID).Error
+	})
+
+	return attachments, err
+}
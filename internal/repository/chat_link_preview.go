@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+	"tush00nka/bbbab_messenger/internal/model"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// getLinkPreviewKey возвращает ключ превью ссылки; URL хешируется, чтобы ограничить длину ключа
+func (r *chatCacheRepository) getLinkPreviewKey(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return "link_preview:" + hex.EncodeToString(sum[:])
+}
+
+// GetLinkPreview возвращает превью ссылки из кеша.
+// found == true и preview == nil означает, что для ссылки превью нет (закешированная неудача).
+func (r *chatCacheRepository) GetLinkPreview(ctx context.Context, url string) (*model.LinkPreview, bool, error) {
+	data, err := r.rdb.Get(ctx, r.getLinkPreviewKey(url)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get link preview: %w", err)
+	}
+
+	var preview *model.LinkPreview
+	if err := json.Unmarshal(data, &preview); err != nil {
+		return nil, false, fmt.Errorf("failed to unmarshal link preview: %w", err)
+	}
+
+	return preview, true, nil
+}
+
+// SaveLinkPreview кеширует превью ссылки; nil сохраняет отсутствие превью
+func (r *chatCacheRepository) SaveLinkPreview(ctx context.Context, url string, preview *model.LinkPreview, ttl time.Duration) error {
+	data, err := json.Marshal(preview)
+	if err != nil {
+		return fmt.Errorf("failed to marshal link preview: %w", err)
+	}
+
+	if err := r.rdb.Set(ctx, r.getLinkPreviewKey(url), data, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to save link preview: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateMessage заменяет закешированное сообщение чата с тем же ID
+func (r *chatCacheRepository) UpdateMessage(ctx context.Context, chatID uint, msg model.Message) error {
+	if chatID == 0 || msg.ID == 0 {
+		return fmt.Errorf("chatID and messageID cannot be zero")
+	}
+
+	key := r.getMessageKey(chatID)
+	values, err := r.rdb.LRange(ctx, key, 0, -1).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return nil
+		}
+		return fmt.Errorf("failed to get messages from redis: %w", err)
+	}
+
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %w", err)
+	}
+
+	for i, v := range values {
+		var cached model.Message
+		if err := json.Unmarshal([]byte(v), &cached); err != nil || cached.ID != msg.ID {
+			continue
+		}
+
+		if err := r.rdb.LSet(ctx, key, int64(i), data).Err(); err != nil {
+			return fmt.Errorf("failed to update message in redis: %w", err)
+		}
+	}
+
+	return nil
+}
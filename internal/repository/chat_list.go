@@ -0,0 +1,271 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+	"time"
+	"tush00nka/bbbab_messenger/internal/model"
+)
+
+// ChatListFilter параметры страницы списка чатов
+type ChatListFilter struct {
+	UserID uint
+	// Since режим синхронизации: только чаты, изменившиеся после этого момента
+	Since *time.Time
+	// Archived true — только архив, false — без архива, nil — все чаты
+	Archived *bool
+	// Folder только чаты, подходящие под правила папки
+	Folder *model.ChatFolder
+	// After курсор: последний чат предыдущей страницы
+	After *ChatListCursor
+	Limit int
+}
+
+// ChatListCursor позиция в списке чатов: сначала закрепленные по порядку,
+// затем остальные по последней активности
+type ChatListCursor struct {
+	PinnedPosition int // 0 — не закреплен
+	LastActivityAt time.Time
+	ChatID         uint
+}
+
+// unpinnedRank место незакрепленных чатов при сортировке, после всех закрепленных
+const unpinnedRank = math.MaxInt32
+
+// ListChats возвращает страницу чатов пользователя от недавно активных к давним.
+// Сортировка, последнее сообщение и счетчики считаются одним запросом, метаданные чатов,
+// последние сообщения и участники личных чатов подгружаются пакетно.
+func (r *chatRepository) ListChats(ctx context.Context, filter ChatListFilter) ([]model.ChatListItem, error) {
+	if filter.UserID == 0 {
+		return nil, errors.New("userID cannot be zero")
+	}
+	if filter.Limit <= 0 {
+		return []model.ChatListItem{}, nil
+	}
+
+	var (
+		conditions []string
+		args       = []any{unpinnedRank, filter.UserID}
+	)
+
+	if filter.Since != nil {
+		since := *filter.Since
+		// Изменения самого чата, участия в нем, сообщений (новые, правки, удаления),
+		// прочтений, скрытий и черновика пользователя
+		conditions = append(conditions, `(
+			c.updated_at > ? OR cu.updated_at > ?
+			OR EXISTS (SELECT 1 FROM messages m WHERE m.chat_id = c.id AND (m.updated_at > ? OR m.deleted_at > ?))
+			OR EXISTS (SELECT 1 FROM message_reads mr JOIN messages m ON m.id = mr.message_id
+				WHERE mr.user_id = cu.user_id AND m.chat_id = c.id AND mr.read_at > ?)
+			OR EXISTS (SELECT 1 FROM hidden_messages hm WHERE hm.user_id = cu.user_id AND hm.chat_id = c.id AND hm.created_at > ?)
+			OR EXISTS (SELECT 1 FROM chat_drafts d WHERE d.user_id = cu.user_id AND d.chat_id = c.id AND d.updated_at > ?)
+		)`)
+		args = append(args, since, since, since, since, since, since, since)
+	}
+
+	if filter.Archived != nil {
+		if *filter.Archived {
+			conditions = append(conditions, "cu.archived_at IS NOT NULL")
+		} else {
+			conditions = append(conditions, "cu.archived_at IS NULL")
+		}
+	}
+
+	if filter.Folder != nil {
+		condition, folderArgs := chatFolderCondition(filter.Folder)
+		conditions = append(conditions, condition)
+		args = append(args, folderArgs...)
+	}
+
+	listWhere := ""
+	if len(conditions) > 0 {
+		listWhere = " AND " + strings.Join(conditions, " AND ")
+	}
+
+	pageWhere := ""
+	if filter.After != nil {
+		pageWhere = `WHERE list.pin_rank > ?
+			OR (list.pin_rank = ? AND (list.last_activity_at, list.chat_id) < (?, ?))`
+		pinRank := filter.After.PinnedPosition
+		if pinRank == 0 {
+			pinRank = unpinnedRank
+		}
+		args = append(args, pinRank, pinRank, filter.After.LastActivityAt, filter.After.ChatID)
+	}
+	args = append(args, filter.Limit)
+
+	var rows []struct {
+		ChatID         uint
+		LastMessageID  *uint
+		LastActivityAt time.Time
+		PinRank        int
+		Archived       bool
+		NotifyMode     string
+		MutedUntil     *time.Time
+		UnreadCount    int64
+		MemberCount    int64
+	}
+	err := r.db.WithContext(ctx).Raw(`
+		WITH list AS (
+			SELECT c.id AS chat_id, cu.user_id, cu.history_cleared_up_to_id,
+			       lm.id AS last_message_id,
+			       COALESCE(lm.created_at, c.created_at) AS last_activity_at,
+			       CASE WHEN cu.pinned_position > 0 THEN cu.pinned_position ELSE ? END AS pin_rank,
+			       cu.archived_at IS NOT NULL AS archived,
+			       cu.notify_mode, cu.muted_until
+			FROM chats c
+			INNER JOIN chat_users cu ON cu.chat_id = c.id AND cu.user_id = ?
+			LEFT JOIN LATERAL (
+				SELECT m.id, m.created_at
+				FROM messages m
+				WHERE m.chat_id = c.id
+				  AND m.deleted_at IS NULL
+				  AND m.id > cu.history_cleared_up_to_id
+				  AND NOT EXISTS (SELECT 1 FROM hidden_messages hm WHERE hm.message_id = m.id AND hm.user_id = cu.user_id)
+				ORDER BY m.id DESC
+				LIMIT 1
+			) lm ON TRUE
+			WHERE c.deleted_at IS NULL`+listWhere+`
+		), page AS (
+			SELECT * FROM list `+pageWhere+`
+			ORDER BY list.pin_rank ASC, list.last_activity_at DESC, list.chat_id DESC
+			LIMIT ?
+		)
+		SELECT page.chat_id, page.last_message_id, page.last_activity_at, page.pin_rank, page.archived,
+		       page.notify_mode, page.muted_until,
+		       (SELECT COUNT(*) `+unreadMessagesFrom("page.chat_id", "page.user_id", "page.history_cleared_up_to_id")+`) AS unread_count,
+		       (SELECT COUNT(*) FROM chat_users x WHERE x.chat_id = page.chat_id) AS member_count
+		FROM page
+		ORDER BY page.pin_rank ASC, page.last_activity_at DESC, page.chat_id DESC
+	`, args...).Scan(&rows).Error
+	if err != nil || len(rows) == 0 {
+		return []model.ChatListItem{}, err
+	}
+
+	chatIDs := make([]uint, len(rows))
+	var messageIDs []uint
+	for i, row := range rows {
+		chatIDs[i] = row.ChatID
+		if row.LastMessageID != nil {
+			messageIDs = append(messageIDs, *row.LastMessageID)
+		}
+	}
+
+	var chats []model.Chat
+	if err := r.db.WithContext(ctx).Where("id IN ?", chatIDs).Find(&chats).Error; err != nil {
+		return nil, err
+	}
+	chatsByID := make(map[uint]model.Chat, len(chats))
+	var directIDs []uint
+	for _, chat := range chats {
+		chatsByID[chat.ID] = chat
+		if !chat.IsGroup {
+			directIDs = append(directIDs, chat.ID)
+		}
+	}
+
+	messages, err := r.GetMessagesByIDs(ctx, messageIDs)
+	if err != nil {
+		return nil, err
+	}
+	messagesByID := make(map[uint]*model.Message, len(messages))
+	for i := range messages {
+		messagesByID[messages[i].ID] = &messages[i]
+	}
+
+	participants, err := r.getParticipants(ctx, directIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	items := make([]model.ChatListItem, 0, len(rows))
+	for _, row := range rows {
+		chat, ok := chatsByID[row.ChatID]
+		if !ok {
+			// Удален между запросами
+			continue
+		}
+
+		item := model.ChatListItem{
+			Chat:           chat,
+			LastActivityAt: row.LastActivityAt,
+			UnreadCount:    row.UnreadCount,
+			MemberCount:    row.MemberCount,
+			Archived:       row.Archived,
+			Notifications: model.ChatNotificationSettings{
+				Mode:       row.NotifyMode,
+				MutedUntil: row.MutedUntil,
+			}.Effective(now),
+			Participants: participants[row.ChatID],
+		}
+		if row.PinRank != unpinnedRank {
+			item.PinnedPosition = row.PinRank
+		}
+		if row.LastMessageID != nil {
+			item.LastMessage = messagesByID[*row.LastMessageID]
+		}
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+// getParticipants возвращает участников чатов по ID чата
+func (r *chatRepository) getParticipants(ctx context.Context, chatIDs []uint) (map[uint][]model.User, error) {
+	participants := make(map[uint][]model.User, len(chatIDs))
+	if len(chatIDs) == 0 {
+		return participants, nil
+	}
+
+	var members []struct {
+		ChatID uint
+		UserID uint
+	}
+	if err := r.db.WithContext(ctx).Table("chat_users").
+		Select("chat_id, user_id").
+		Where("chat_id IN ?", chatIDs).
+		Scan(&members).Error; err != nil {
+		return nil, err
+	}
+
+	userIDs := make([]uint, 0, len(members))
+	chatsByUser := make(map[uint][]uint, len(members))
+	for _, m := range members {
+		if _, ok := chatsByUser[m.UserID]; !ok {
+			userIDs = append(userIDs, m.UserID)
+		}
+		chatsByUser[m.UserID] = append(chatsByUser[m.UserID], m.ChatID)
+	}
+
+	var users []model.User
+	if err := r.db.WithContext(ctx).Where("id IN ?", userIDs).Order("username").Find(&users).Error; err != nil {
+		return nil, err
+	}
+
+	for _, user := range users {
+		user.SanitizePassword()
+		user.EnsureDisplayName()
+		for _, chatID := range chatsByUser[user.ID] {
+			participants[chatID] = append(participants[chatID], user)
+		}
+	}
+
+	return participants, nil
+}
+
+// unreadMessagesFrom возвращает FROM и WHERE для непрочитанных участником сообщений чата:
+// чужие, не удаленные, не скрытые им и новее отметки очистки истории.
+// Аргументы — SQL-выражения с ID чата, ID участника и отметкой очистки.
+func unreadMessagesFrom(chatID, userID, clearedUpToID string) string {
+	return `FROM messages m
+		LEFT JOIN message_reads mr ON mr.message_id = m.id AND mr.user_id = ` + userID + `
+		WHERE m.chat_id = ` + chatID + `
+		  AND m.deleted_at IS NULL
+		  AND m.sender_id <> ` + userID + `
+		  AND mr.message_id IS NULL
+		  AND m.id > ` + clearedUpToID + `
+		  AND NOT EXISTS (SELECT 1 FROM hidden_messages hm WHERE hm.message_id = m.id AND hm.user_id = ` + userID + `)`
+}
@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+	"tush00nka/bbbab_messenger/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// GetMentions возвращает упоминания пользователя от новых к старым.
+// chatID == 0 — по всем чатам, beforeID — курсор (ID упоминания), unreadOnly — только непрочитанные.
+func (r *chatRepository) GetMentions(
+	ctx context.Context,
+	userID, chatID, beforeID uint,
+	limit int,
+	unreadOnly bool,
+) ([]model.MessageMention, error) {
+	if userID == 0 {
+		return nil, errors.New("userID cannot be zero")
+	}
+
+	query := r.db.WithContext(ctx).Scopes(visibleMentions(userID))
+	if chatID != 0 {
+		query = query.Where("message_mentions.chat_id = ?", chatID)
+	}
+	if beforeID != 0 {
+		query = query.Where("message_mentions.id < ?", beforeID)
+	}
+	if unreadOnly {
+		query = query.Where("message_mentions.read_at IS NULL")
+	}
+
+	var mentions []model.MessageMention
+	err := query.
+		Select("message_mentions.*").
+		Order("message_mentions.id DESC").
+		Limit(limit).
+		Find(&mentions).Error
+
+	return mentions, err
+}
+
+// GetUnreadMentionCounts возвращает число непрочитанных упоминаний пользователя по чатам
+func (r *chatRepository) GetUnreadMentionCounts(ctx context.Context, userID uint) (map[uint]int64, error) {
+	if userID == 0 {
+		return nil, errors.New("userID cannot be zero")
+	}
+
+	var rows []struct {
+		ChatID uint
+		Count  int64
+	}
+	err := r.db.WithContext(ctx).
+		Scopes(visibleMentions(userID)).
+		Select("message_mentions.chat_id, COUNT(*) AS count").
+		Where("message_mentions.read_at IS NULL").
+		Group("message_mentions.chat_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[uint]int64, len(rows))
+	for _, row := range rows {
+		counts[row.ChatID] = row.Count
+	}
+
+	return counts, nil
+}
+
+// MarkMentionsRead отмечает прочитанными упоминания пользователя в чате
+// в сообщениях с ID не больше upToMessageID (0 — все)
+func (r *chatRepository) MarkMentionsRead(ctx context.Context, userID, chatID, upToMessageID uint) error {
+	if userID == 0 || chatID == 0 {
+		return errors.New("userID and chatID cannot be zero")
+	}
+
+	query := r.db.WithContext(ctx).Model(&model.MessageMention{}).
+		Where("user_id = ? AND chat_id = ? AND read_at IS NULL", userID, chatID)
+	if upToMessageID != 0 {
+		query = query.Where("message_id <= ?", upToMessageID)
+	}
+
+	return query.Update("read_at", time.Now()).Error
+}
+
+// visibleMentions ограничивает упоминания пользователя теми, что он видит:
+// сообщение не удалено и не скрыто, история не очищена, пользователь все еще в чате
+func visibleMentions(userID uint) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Model(&model.MessageMention{}).
+			Joins("JOIN messages m ON m.id = message_mentions.message_id AND m.deleted_at IS NULL").
+			Joins(`JOIN chat_users cu ON cu.chat_id = message_mentions.chat_id
+				AND cu.user_id = message_mentions.user_id AND cu.deleted_at IS NULL`).
+			Where("message_mentions.user_id = ?", userID).
+			Where("message_mentions.message_id > cu.history_cleared_up_to_id").
+			Where(`NOT EXISTS (SELECT 1 FROM hidden_messages hm
+				WHERE hm.message_id = message_mentions.message_id AND hm.user_id = message_mentions.user_id)`)
+	}
+}
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+	"tush00nka/bbbab_messenger/internal/model"
+)
+
+// SetChatNotifications сохраняет настройки уведомлений участника.
+// Возвращает false, если пользователь не состоит в чате.
+func (r *chatRepository) SetChatNotifications(ctx context.Context, userID, chatID uint, settings model.ChatNotificationSettings) (bool, error) {
+	if userID == 0 || chatID == 0 {
+		return false, errors.New("userID and chatID cannot be zero")
+	}
+
+	result := r.db.WithContext(ctx).Model(&model.ChatUser{}).
+		Where("chat_id = ? AND user_id = ?", chatID, userID).
+		Updates(map[string]any{"notify_mode": settings.Mode, "muted_until": settings.MutedUntil})
+
+	return result.RowsAffected > 0, result.Error
+}
+
+// GetChatNotificationSettings возвращает действующие настройки уведомлений участников чата.
+// Пользователи, не состоящие в чате, в результат не попадают.
+func (r *chatRepository) GetChatNotificationSettings(ctx context.Context, chatID uint, userIDs []uint) (map[uint]model.ChatNotificationSettings, error) {
+	if chatID == 0 {
+		return nil, errors.New("chatID cannot be zero")
+	}
+
+	settings := make(map[uint]model.ChatNotificationSettings, len(userIDs))
+	if len(userIDs) == 0 {
+		return settings, nil
+	}
+
+	var rows []struct {
+		UserID     uint
+		NotifyMode string
+		MutedUntil *time.Time
+	}
+	if err := r.db.WithContext(ctx).Model(&model.ChatUser{}).
+		Select("user_id, notify_mode, muted_until").
+		Where("chat_id = ? AND user_id IN ?", chatID, userIDs).
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	for _, row := range rows {
+		settings[row.UserID] = model.ChatNotificationSettings{
+			Mode:       row.NotifyMode,
+			MutedUntil: row.MutedUntil,
+		}.Effective(now)
+	}
+
+	return settings, nil
+}
+
+// GetUnreadSummary считает непрочитанное пользователя для значка приложения с учетом
+// настроек уведомлений каждого чата
+func (r *chatRepository) GetUnreadSummary(ctx context.Context, userID uint) (*model.UnreadSummary, error) {
+	if userID == 0 {
+		return nil, errors.New("userID cannot be zero")
+	}
+
+	summary := &model.UnreadSummary{}
+	err := r.db.WithContext(ctx).Raw(`
+		WITH member AS (
+			SELECT cu.chat_id, cu.user_id, cu.history_cleared_up_to_id, `+notifyModeSQL("cu")+` AS mode
+			FROM chat_users cu
+			INNER JOIN chats c ON c.id = cu.chat_id AND c.deleted_at IS NULL
+			WHERE cu.user_id = ? AND cu.deleted_at IS NULL
+		), counts AS (
+			SELECT (SELECT COUNT(*) `+unreadMessagesFrom("member.chat_id", "member.user_id", "member.history_cleared_up_to_id")+`
+				AND (member.mode = ?
+					OR EXISTS (SELECT 1 FROM message_mentions mm WHERE mm.message_id = m.id AND mm.user_id = member.user_id)
+					OR EXISTS (SELECT 1 FROM messages rm WHERE rm.id = m.reply_to_id AND rm.sender_id = member.user_id))
+			) AS unread
+			FROM member
+			WHERE member.mode <> ?
+		)
+		SELECT COALESCE(SUM(unread), 0) AS messages, COUNT(*) FILTER (WHERE unread > 0) AS chats
+		FROM counts
+	`, userID, model.NotifyAll, model.NotifyNone).Scan(summary).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return summary, nil
+}
+
+// notifyModeSQL возвращает SQL-выражение с действующим режимом уведомлений участника;
+// alias — псевдоним таблицы chat_users
+func notifyModeSQL(alias string) string {
+	return `CASE WHEN ` + alias + `.muted_until IS NOT NULL AND ` + alias + `.muted_until <= NOW()
+		THEN '` + model.NotifyAll + `' ELSE ` + alias + `.notify_mode END`
+}
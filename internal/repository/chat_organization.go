@@ -0,0 +1,217 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+	"tush00nka/bbbab_messenger/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// SetPinnedChats заменяет закрепленные чаты пользователя; порядок chatIDs — порядок в списке.
+// Закрепленные чаты возвращаются из архива.
+func (r *chatRepository) SetPinnedChats(ctx context.Context, userID uint, chatIDs []uint) error {
+	if userID == 0 {
+		return errors.New("userID cannot be zero")
+	}
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.ChatUser{}).
+			Where("user_id = ? AND pinned_position > 0", userID).
+			Update("pinned_position", 0).Error; err != nil {
+			return err
+		}
+
+		for i, chatID := range chatIDs {
+			if err := tx.Model(&model.ChatUser{}).
+				Where("chat_id = ? AND user_id = ?", chatID, userID).
+				Updates(map[string]any{"pinned_position": i + 1, "archived_at": nil}).Error; err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// SetChatArchived архивирует чат у пользователя или возвращает из архива.
+// Архивированный чат открепляется. Возвращает false, если пользователь не состоит в чате.
+func (r *chatRepository) SetChatArchived(ctx context.Context, userID, chatID uint, archived bool) (bool, error) {
+	if userID == 0 || chatID == 0 {
+		return false, errors.New("userID and chatID cannot be zero")
+	}
+
+	updates := map[string]any{"archived_at": nil}
+	if archived {
+		updates = map[string]any{"archived_at": time.Now(), "pinned_position": 0}
+	}
+
+	result := r.db.WithContext(ctx).Model(&model.ChatUser{}).
+		Where("chat_id = ? AND user_id = ?", chatID, userID).
+		Updates(updates)
+
+	return result.RowsAffected > 0, result.Error
+}
+
+// UnarchiveForNewMessage возвращает чат из архива у всех участников, кроме отправителя
+// и тех, у кого уведомления чата приглушены, и возвращает их ID
+func (r *chatRepository) UnarchiveForNewMessage(ctx context.Context, chatID, senderID uint) ([]uint, error) {
+	if chatID == 0 {
+		return nil, errors.New("chatID cannot be zero")
+	}
+
+	var userIDs []uint
+	err := r.db.WithContext(ctx).Raw(`
+		UPDATE chat_users
+		SET archived_at = NULL, updated_at = NOW()
+		WHERE chat_id = ? AND user_id <> ? AND archived_at IS NOT NULL AND deleted_at IS NULL
+		  AND `+notifyModeSQL("chat_users")+` = ?
+		RETURNING user_id
+	`, chatID, senderID, model.NotifyAll).Scan(&userIDs).Error
+
+	return userIDs, err
+}
+
+// GetChatOrganization возвращает закрепленные и архивированные чаты пользователя и его папки
+func (r *chatRepository) GetChatOrganization(ctx context.Context, userID uint) (*model.ChatOrganization, error) {
+	if userID == 0 {
+		return nil, errors.New("userID cannot be zero")
+	}
+
+	org := &model.ChatOrganization{
+		PinnedChatIDs:   []uint{},
+		ArchivedChatIDs: []uint{},
+	}
+
+	if err := r.db.WithContext(ctx).Model(&model.ChatUser{}).
+		Where("user_id = ? AND pinned_position > 0", userID).
+		Order("pinned_position ASC").
+		Pluck("chat_id", &org.PinnedChatIDs).Error; err != nil {
+		return nil, err
+	}
+
+	if err := r.db.WithContext(ctx).Model(&model.ChatUser{}).
+		Where("user_id = ? AND archived_at IS NOT NULL", userID).
+		Order("archived_at DESC").
+		Pluck("chat_id", &org.ArchivedChatIDs).Error; err != nil {
+		return nil, err
+	}
+
+	folders, err := r.GetChatFolders(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	org.Folders = folders
+
+	return org, nil
+}
+
+// CreateChatFolder создает папку чатов
+func (r *chatRepository) CreateChatFolder(ctx context.Context, folder *model.ChatFolder) error {
+	if folder == nil || folder.UserID == 0 {
+		return errors.New("folder userID cannot be zero")
+	}
+
+	return r.db.WithContext(ctx).Create(folder).Error
+}
+
+// UpdateChatFolder сохраняет название, позицию и правила папки
+func (r *chatRepository) UpdateChatFolder(ctx context.Context, folder *model.ChatFolder) error {
+	if folder == nil || folder.ID == 0 || folder.UserID == 0 {
+		return errors.New("folder ID and userID cannot be zero")
+	}
+
+	return r.db.WithContext(ctx).
+		Where("user_id = ?", folder.UserID).
+		Select("*").
+		Omit("id", "user_id", "created_at").
+		Updates(folder).Error
+}
+
+// DeleteChatFolder удаляет папку пользователя. Возвращает false, если папки не было.
+func (r *chatRepository) DeleteChatFolder(ctx context.Context, userID, folderID uint) (bool, error) {
+	if userID == 0 || folderID == 0 {
+		return false, errors.New("userID and folderID cannot be zero")
+	}
+
+	result := r.db.WithContext(ctx).
+		Where("id = ? AND user_id = ?", folderID, userID).
+		Delete(&model.ChatFolder{})
+
+	return result.RowsAffected > 0, result.Error
+}
+
+// GetChatFolder возвращает папку пользователя
+func (r *chatRepository) GetChatFolder(ctx context.Context, userID, folderID uint) (*model.ChatFolder, error) {
+	if userID == 0 || folderID == 0 {
+		return nil, errors.New("userID and folderID cannot be zero")
+	}
+
+	var folder model.ChatFolder
+	err := r.db.WithContext(ctx).
+		Where("id = ? AND user_id = ?", folderID, userID).
+		First(&folder).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+
+	return &folder, err
+}
+
+// GetChatFolders возвращает папки пользователя по порядку
+func (r *chatRepository) GetChatFolders(ctx context.Context, userID uint) ([]model.ChatFolder, error) {
+	if userID == 0 {
+		return nil, errors.New("userID cannot be zero")
+	}
+
+	folders := []model.ChatFolder{}
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("position ASC, id ASC").
+		Find(&folders).Error
+
+	return folders, err
+}
+
+// chatFolderCondition переводит правила папки в условие для списка чатов
+// (алиасы c — chats, cu — chat_users текущего пользователя)
+func chatFolderCondition(folder *model.ChatFolder) (string, []any) {
+	var (
+		include []string
+		args    []any
+	)
+
+	if folder.IncludeDirect {
+		include = append(include, "NOT c.is_group")
+	}
+	if folder.IncludeGroups {
+		include = append(include, "(c.is_group AND NOT c.is_channel)")
+	}
+	if folder.IncludeChannels {
+		include = append(include, "c.is_channel")
+	}
+	if len(folder.IncludeChatIDs) > 0 {
+		include = append(include, "c.id IN ?")
+		args = append(args, folder.IncludeChatIDs)
+	}
+	if len(include) == 0 {
+		return "FALSE", nil
+	}
+
+	condition := "(" + strings.Join(include, " OR ") + ")"
+
+	if len(folder.ExcludeChatIDs) > 0 {
+		condition += " AND c.id NOT IN ?"
+		args = append(args, folder.ExcludeChatIDs)
+	}
+	if folder.ExcludeArchived {
+		condition += " AND cu.archived_at IS NULL"
+	}
+	if folder.ExcludeRead {
+		condition += " AND EXISTS (SELECT 1 " + unreadMessagesFrom("c.id", "cu.user_id", "cu.history_cleared_up_to_id") + ")"
+	}
+
+	return "(" + condition + ")", args
+}
@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"tush00nka/bbbab_messenger/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PinMessage закрепляет сообщение; повторное закрепление ничего не меняет
+func (r *chatRepository) PinMessage(ctx context.Context, pin *model.PinnedMessage) error {
+	if pin == nil {
+		return errors.New("pin cannot be nil")
+	}
+	if pin.ChatID == 0 || pin.MessageID == 0 {
+		return errors.New("chatID and messageID cannot be zero")
+	}
+
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(pin).Error
+}
+
+// UnpinMessage открепляет сообщение. Возвращает false, если оно не было закреплено.
+func (r *chatRepository) UnpinMessage(ctx context.Context, chatID, messageID uint) (bool, error) {
+	if chatID == 0 || messageID == 0 {
+		return false, errors.New("chatID and messageID cannot be zero")
+	}
+
+	result := r.db.WithContext(ctx).
+		Where("chat_id = ? AND message_id = ?", chatID, messageID).
+		Delete(&model.PinnedMessage{})
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+// IsMessagePinned проверяет, закреплено ли сообщение в чате
+func (r *chatRepository) IsMessagePinned(ctx context.Context, chatID, messageID uint) (bool, error) {
+	if chatID == 0 || messageID == 0 {
+		return false, errors.New("chatID and messageID cannot be zero")
+	}
+
+	var count int64
+	err := r.db.WithContext(ctx).Model(&model.PinnedMessage{}).
+		Where("chat_id = ? AND message_id = ?", chatID, messageID).
+		Count(&count).Error
+
+	return count > 0, err
+}
+
+// GetPinnedMessages возвращает закрепленные сообщения чата, начиная с последнего закрепленного
+func (r *chatRepository) GetPinnedMessages(ctx context.Context, chatID uint) ([]model.PinnedMessage, error) {
+	if chatID == 0 {
+		return nil, errors.New("chatID cannot be zero")
+	}
+
+	var pins []model.PinnedMessage
+	err := r.db.WithContext(ctx).
+		Where("chat_id = ?", chatID).
+		Preload("Message").
+		Preload("Message.Sender").
+		Order("created_at DESC, id DESC").
+		Find(&pins).Error
+
+	for i := range pins {
+		pins[i].Message.Sender.EnsureDisplayName()
+	}
+
+	return pins, err
+}
+
+// GetLatestPin возвращает последнее закрепленное сообщение чата
+func (r *chatRepository) GetLatestPin(ctx context.Context, chatID uint) (*model.PinnedMessage, error) {
+	if chatID == 0 {
+		return nil, errors.New("chatID cannot be zero")
+	}
+
+	var pin model.PinnedMessage
+	err := r.db.WithContext(ctx).
+		Where("chat_id = ?", chatID).
+		Preload("Message").
+		Preload("Message.Sender").
+		Order("created_at DESC, id DESC").
+		First(&pin).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	pin.Message.Sender.EnsureDisplayName()
+	return &pin, nil
+}
@@ -0,0 +1,201 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+	"tush00nka/bbbab_messenger/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// GetPollByMessageID возвращает опрос сообщения с вариантами ответа
+func (r *chatRepository) GetPollByMessageID(ctx context.Context, messageID uint) (*model.Poll, error) {
+	if messageID == 0 {
+		return nil, errors.New("messageID cannot be zero")
+	}
+
+	var poll model.Poll
+	err := r.db.WithContext(ctx).
+		Preload("Options", func(db *gorm.DB) *gorm.DB {
+			return db.Order("position ASC")
+		}).
+		Where("message_id = ?", messageID).
+		First(&poll).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+
+	return &poll, err
+}
+
+// GetPollsByMessageIDs возвращает опросы сообщений с вариантами ответа
+func (r *chatRepository) GetPollsByMessageIDs(ctx context.Context, messageIDs []uint) ([]model.Poll, error) {
+	if len(messageIDs) == 0 {
+		return []model.Poll{}, nil
+	}
+
+	var polls []model.Poll
+	err := r.db.WithContext(ctx).
+		Preload("Options", func(db *gorm.DB) *gorm.DB {
+			return db.Order("position ASC")
+		}).
+		Where("message_id IN ?", messageIDs).
+		Find(&polls).Error
+
+	return polls, err
+}
+
+// LoadPollResults подсчитывает голоса по вариантам и число проголосовавших.
+// Для открытых опросов заполняются списки проголосовавших, для viewerID — его выбор.
+func (r *chatRepository) LoadPollResults(ctx context.Context, polls []model.Poll, viewerID uint) error {
+	if len(polls) == 0 {
+		return nil
+	}
+
+	pollIDs := make([]uint, len(polls))
+	for i := range polls {
+		pollIDs[i] = polls[i].ID
+	}
+
+	var optionCounts []struct {
+		OptionID uint
+		Votes    int64
+	}
+	if err := r.db.WithContext(ctx).Model(&model.PollVote{}).
+		Select("option_id, COUNT(*) AS votes").
+		Where("poll_id IN ?", pollIDs).
+		Group("option_id").
+		Scan(&optionCounts).Error; err != nil {
+		return err
+	}
+
+	var voterCounts []struct {
+		PollID uint
+		Voters int64
+	}
+	if err := r.db.WithContext(ctx).Model(&model.PollVote{}).
+		Select("poll_id, COUNT(DISTINCT user_id) AS voters").
+		Where("poll_id IN ?", pollIDs).
+		Group("poll_id").
+		Scan(&voterCounts).Error; err != nil {
+		return err
+	}
+
+	// Поименные голоса нужны только открытым опросам и самому пользователю
+	var publicIDs []uint
+	for i := range polls {
+		if !polls[i].IsAnonymous() {
+			publicIDs = append(publicIDs, polls[i].ID)
+		}
+	}
+
+	var votes []model.PollVote
+	query := r.db.WithContext(ctx).Where("poll_id IN ?", pollIDs)
+	switch {
+	case len(publicIDs) > 0 && viewerID != 0:
+		query = query.Where("poll_id IN ? OR user_id = ?", publicIDs, viewerID)
+	case len(publicIDs) > 0:
+		query = query.Where("poll_id IN ?", publicIDs)
+	case viewerID != 0:
+		query = query.Where("user_id = ?", viewerID)
+	default:
+		query = nil
+	}
+	if query != nil {
+		if err := query.Order("created_at ASC, id ASC").Find(&votes).Error; err != nil {
+			return err
+		}
+	}
+
+	votesByOption := make(map[uint]int64, len(optionCounts))
+	for _, c := range optionCounts {
+		votesByOption[c.OptionID] = c.Votes
+	}
+	votersByPoll := make(map[uint]int64, len(voterCounts))
+	for _, c := range voterCounts {
+		votersByPoll[c.PollID] = c.Voters
+	}
+
+	for i := range polls {
+		poll := &polls[i]
+		poll.TotalVoters = votersByPoll[poll.ID]
+		poll.ChosenOptionIDs = nil
+
+		optionIndex := make(map[uint]int, len(poll.Options))
+		for j := range poll.Options {
+			poll.Options[j].Votes = votesByOption[poll.Options[j].ID]
+			poll.Options[j].VoterIDs = nil
+			optionIndex[poll.Options[j].ID] = j
+		}
+
+		for _, v := range votes {
+			if v.PollID != poll.ID {
+				continue
+			}
+			if v.UserID == viewerID {
+				poll.ChosenOptionIDs = append(poll.ChosenOptionIDs, v.OptionID)
+			}
+			if j, ok := optionIndex[v.OptionID]; ok && !poll.IsAnonymous() {
+				poll.Options[j].VoterIDs = append(poll.Options[j].VoterIDs, v.UserID)
+			}
+		}
+	}
+
+	return nil
+}
+
+// ReplacePollVotes заменяет голоса пользователя в опросе; пустой optionIDs отзывает голос
+func (r *chatRepository) ReplacePollVotes(ctx context.Context, pollID, userID uint, optionIDs []uint) error {
+	if pollID == 0 || userID == 0 {
+		return errors.New("pollID and userID cannot be zero")
+	}
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("poll_id = ? AND user_id = ?", pollID, userID).
+			Delete(&model.PollVote{}).Error; err != nil {
+			return err
+		}
+		if len(optionIDs) == 0 {
+			return nil
+		}
+
+		votes := make([]model.PollVote, len(optionIDs))
+		for i, optionID := range optionIDs {
+			votes[i] = model.PollVote{PollID: pollID, OptionID: optionID, UserID: userID}
+		}
+
+		return tx.Create(&votes).Error
+	})
+}
+
+// ClosePoll досрочно завершает опрос. Возвращает false, если он уже был завершен.
+func (r *chatRepository) ClosePoll(ctx context.Context, pollID uint, closedAt time.Time) (bool, error) {
+	if pollID == 0 {
+		return false, errors.New("pollID cannot be zero")
+	}
+
+	result := r.db.WithContext(ctx).Model(&model.Poll{}).
+		Where("id = ? AND closed_at IS NULL", pollID).
+		Update("closed_at", closedAt)
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+// deletePolls удаляет опросы сообщений вместе с вариантами и голосами.
+// messageIDs — список ID или подзапрос; вызывается внутри транзакции перед удалением сообщений.
+func deletePolls(tx *gorm.DB, messageIDs any) error {
+	pollIDs := tx.Model(&model.Poll{}).Select("id").Where("message_id IN (?)", messageIDs)
+
+	if err := tx.Where("poll_id IN (?)", pollIDs).Delete(&model.PollVote{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("poll_id IN (?)", pollIDs).Delete(&model.PollOption{}).Error; err != nil {
+		return err
+	}
+
+	return tx.Where("message_id IN (?)", messageIDs).Delete(&model.Poll{}).Error
+}
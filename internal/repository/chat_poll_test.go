@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"tush00nka/bbbab_messenger/internal/model"
+)
+
+func TestPollAnonymousFlagRoundTrip(t *testing.T) {
+	db := openTestDB(t)
+	if err := Migrate(db); err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+	repo := NewChatRepository(db)
+	ctx := context.Background()
+
+	user := &model.User{Username: "author"}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatal(err)
+	}
+	chat := &model.Chat{Name: "polls", IsGroup: true}
+	if err := db.Create(chat).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	for _, anonymous := range []bool{false, true} {
+		message := &model.Message{
+			SenderID: user.ID,
+			Message:  "question",
+			Type:     model.MessageTypePoll,
+			Poll: &model.Poll{
+				Question:  "question",
+				Anonymous: &anonymous,
+				Options:   []model.PollOption{{Position: 0, Text: "a"}, {Position: 1, Text: "b"}},
+			},
+		}
+		message.Poll.ChatID = chat.ID
+		if err := repo.SendMessage(ctx, chat, message); err != nil {
+			t.Fatalf("SendMessage() error = %v", err)
+		}
+
+		poll, err := repo.GetPollByMessageID(ctx, message.ID)
+		if err != nil || poll == nil {
+			t.Fatalf("GetPollByMessageID() = %v, %v", poll, err)
+		}
+		if poll.Anonymous == nil || *poll.Anonymous != anonymous {
+			t.Errorf("stored anonymous = %v, want %v", poll.Anonymous, anonymous)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"tush00nka/bbbab_messenger/internal/model"
+)
+
+func TestTransferOwnership(t *testing.T) {
+	db := openTestDB(t)
+	if err := Migrate(db); err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+	repo := NewChatRepository(db)
+	ctx := context.Background()
+
+	users := []model.User{{Username: "owner"}, {Username: "member"}, {Username: "outsider"}}
+	if err := db.Create(&users).Error; err != nil {
+		t.Fatal(err)
+	}
+	owner, member, outsider := users[0].ID, users[1].ID, users[2].ID
+
+	chat := &model.Chat{Name: "group"}
+	if err := repo.CreateGroup(ctx, chat, []uint{owner, member}, owner); err != nil {
+		t.Fatalf("CreateGroup() error = %v", err)
+	}
+
+	if err := repo.TransferOwnership(ctx, chat.ID, owner, outsider); err == nil {
+		t.Error("TransferOwnership() to a non-member succeeded")
+	}
+	if err := repo.TransferOwnership(ctx, chat.ID, member, owner); err == nil {
+		t.Error("TransferOwnership() from a non-owner succeeded")
+	}
+
+	if err := repo.TransferOwnership(ctx, chat.ID, owner, member); err != nil {
+		t.Fatalf("TransferOwnership() error = %v", err)
+	}
+
+	for userID, want := range map[uint]string{owner: model.ChatRoleAdmin, member: model.ChatRoleOwner} {
+		role, err := repo.GetUserRole(ctx, chat.ID, userID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if role != want {
+			t.Errorf("user %d role = %q, want %q", userID, role, want)
+		}
+	}
+}
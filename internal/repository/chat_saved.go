@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+	"tush00nka/bbbab_messenger/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SaveMessage добавляет сообщение в закладки пользователя; повторное сохранение
+// обновляет заметку и теги
+func (r *chatRepository) SaveMessage(ctx context.Context, saved *model.SavedMessage) error {
+	if saved == nil || saved.UserID == 0 || saved.MessageID == 0 {
+		return errors.New("userID and messageID cannot be zero")
+	}
+
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}, {Name: "message_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"note", "tags", "updated_at"}),
+		}).
+		Create(saved).Error
+}
+
+// GetSavedMessage возвращает закладку пользователя на сообщение
+func (r *chatRepository) GetSavedMessage(ctx context.Context, userID, messageID uint) (*model.SavedMessage, error) {
+	if userID == 0 || messageID == 0 {
+		return nil, errors.New("userID and messageID cannot be zero")
+	}
+
+	var saved model.SavedMessage
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND message_id = ?", userID, messageID).
+		First(&saved).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+
+	return &saved, err
+}
+
+// GetSavedMessages возвращает закладки пользователя от новых к старым.
+// beforeID — курсор (ID последней закладки предыдущей страницы), tag — фильтр по тегу.
+func (r *chatRepository) GetSavedMessages(ctx context.Context, userID, beforeID uint, tag string, limit int) ([]model.SavedMessage, error) {
+	if userID == 0 {
+		return nil, errors.New("userID cannot be zero")
+	}
+
+	query := r.db.WithContext(ctx).Where("user_id = ?", userID)
+	if beforeID != 0 {
+		query = query.Where("id < ?", beforeID)
+	}
+	if tag != "" {
+		filter, err := json.Marshal([]string{tag})
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where("tags @> ?::jsonb", string(filter))
+	}
+
+	var saved []model.SavedMessage
+	err := query.
+		Order("id DESC").
+		Limit(limit).
+		Find(&saved).Error
+
+	return saved, err
+}
+
+// DeleteSavedMessage убирает сообщение из закладок. Возвращает false, если закладки не было.
+func (r *chatRepository) DeleteSavedMessage(ctx context.Context, userID, messageID uint) (bool, error) {
+	if userID == 0 || messageID == 0 {
+		return false, errors.New("userID and messageID cannot be zero")
+	}
+
+	result := r.db.WithContext(ctx).
+		Where("user_id = ? AND message_id = ?", userID, messageID).
+		Delete(&model.SavedMessage{})
+
+	return result.RowsAffected > 0, result.Error
+}
+
+// tombstoneSavedMessages помечает закладки на удаляемые сообщения; messageIDs — срез ID или подзапрос
+func tombstoneSavedMessages(tx *gorm.DB, messageIDs any) error {
+	return tx.Model(&model.SavedMessage{}).
+		Where("message_id IN (?) AND original_deleted_at IS NULL", messageIDs).
+		Update("original_deleted_at", time.Now()).Error
+}
@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+	"tush00nka/bbbab_messenger/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CreateScheduledMessage сохраняет отложенное сообщение
+func (r *chatRepository) CreateScheduledMessage(ctx context.Context, message *model.ScheduledMessage) error {
+	if message == nil {
+		return errors.New("scheduled message cannot be nil")
+	}
+	if message.ChatID == 0 || message.SenderID == 0 {
+		return errors.New("chatID and senderID cannot be zero")
+	}
+
+	return r.db.WithContext(ctx).Create(message).Error
+}
+
+// GetScheduledMessage возвращает отложенное сообщение по ID
+func (r *chatRepository) GetScheduledMessage(ctx context.Context, id uint) (*model.ScheduledMessage, error) {
+	if id == 0 {
+		return nil, errors.New("scheduled message id cannot be zero")
+	}
+
+	var message model.ScheduledMessage
+	err := r.db.WithContext(ctx).First(&message, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+
+	return &message, err
+}
+
+// GetScheduledMessages возвращает ожидающие отправки сообщения пользователя в чате, ближайшие первыми
+func (r *chatRepository) GetScheduledMessages(ctx context.Context, chatID, senderID uint) ([]model.ScheduledMessage, error) {
+	if chatID == 0 || senderID == 0 {
+		return nil, errors.New("chatID and senderID cannot be zero")
+	}
+
+	var messages []model.ScheduledMessage
+	err := r.db.WithContext(ctx).
+		Where("chat_id = ? AND sender_id = ? AND status IN ?", chatID, senderID,
+			[]string{model.ScheduledPending, model.ScheduledProcessing}).
+		Order("send_at ASC, id ASC").
+		Find(&messages).Error
+
+	return messages, err
+}
+
+// UpdatePendingScheduledMessage обновляет поля сообщения, пока оно еще не взято в отправку.
+// Возвращает false, если сообщение уже отправляется, отправлено или отменено.
+func (r *chatRepository) UpdatePendingScheduledMessage(ctx context.Context, id uint, updates map[string]any) (bool, error) {
+	if id == 0 {
+		return false, errors.New("scheduled message id cannot be zero")
+	}
+
+	result := r.db.WithContext(ctx).Model(&model.ScheduledMessage{}).
+		Where("id = ? AND status = ?", id, model.ScheduledPending).
+		Updates(updates)
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+// ClaimDueScheduledMessages забирает в отправку сообщения, время которых наступило.
+// Строки блокируются через FOR UPDATE SKIP LOCKED, поэтому несколько экземпляров
+// сервиса не получат одно и то же сообщение. Сообщения, зависшие в обработке
+// дольше staleBefore (например, после падения экземпляра), забираются повторно.
+func (r *chatRepository) ClaimDueScheduledMessages(ctx context.Context, now, staleBefore time.Time, limit int) ([]model.ScheduledMessage, error) {
+	if limit <= 0 {
+		return []model.ScheduledMessage{}, nil
+	}
+
+	var messages []model.ScheduledMessage
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("(status = ? AND send_at <= ?) OR (status = ? AND updated_at < ?)",
+				model.ScheduledPending, now, model.ScheduledProcessing, staleBefore).
+			Order("send_at ASC, id ASC").
+			Limit(limit).
+			Find(&messages).Error
+		if err != nil || len(messages) == 0 {
+			return err
+		}
+
+		ids := make([]uint, len(messages))
+		for i := range messages {
+			ids[i] = messages[i].ID
+			messages[i].Status = model.ScheduledProcessing
+			messages[i].Attempts++
+		}
+
+		return tx.Model(&model.ScheduledMessage{}).
+			Where("id IN ?", ids).
+			Updates(map[string]any{
+				"status":     model.ScheduledProcessing,
+				"attempts":   gorm.Expr("attempts + 1"),
+				"updated_at": now,
+			}).Error
+	})
+
+	return messages, err
+}
+
+// CompleteScheduledMessage фиксирует результат отправки
+func (r *chatRepository) CompleteScheduledMessage(ctx context.Context, id uint, status string, sentMessageID *uint, lastError string) error {
+	if id == 0 {
+		return errors.New("scheduled message id cannot be zero")
+	}
+
+	return r.db.WithContext(ctx).Model(&model.ScheduledMessage{}).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"status":          status,
+			"sent_message_id": sentMessageID,
+			"last_error":      lastError,
+		}).Error
+}
+
+// RescheduleScheduledMessage возвращает сообщение в очередь с новым временем отправки
+func (r *chatRepository) RescheduleScheduledMessage(ctx context.Context, id uint, sendAt time.Time, lastError string) error {
+	if id == 0 {
+		return errors.New("scheduled message id cannot be zero")
+	}
+
+	return r.db.WithContext(ctx).Model(&model.ScheduledMessage{}).
+		Where("id = ? AND status = ?", id, model.ScheduledProcessing).
+		Updates(map[string]any{
+			"status":     model.ScheduledPending,
+			"send_at":    sendAt,
+			"last_error": lastError,
+		}).Error
+}
@@ -0,0 +1,322 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"tush00nka/bbbab_messenger/internal/model"
+	"unicode"
+	"unicode/utf16"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Конфигурации полнотекстового поиска Postgres
+const (
+	SearchConfigRussian = "russian"
+	SearchConfigEnglish = "english"
+	SearchConfigSimple  = "simple"
+
+	DefaultSearchConfig = SearchConfigRussian
+)
+
+// maxSearchTerms ограничивает число слов в поисковом запросе
+const maxSearchTerms = 16
+
+// Маркеры совпадений в ts_headline: символы из области частного использования,
+// в обычном тексте не встречаются и после разбора удаляются
+const (
+	headlineStartSel = '\ue000'
+	headlineStopSel  = '\ue001'
+)
+
+// MessageSearchFilter параметры поиска сообщений. Нужен ChatID или ViewerID:
+// без ChatID поиск идет по всем чатам, где состоит ViewerID.
+type MessageSearchFilter struct {
+	ChatID   uint
+	ViewerID uint // ищутся только чаты, где он состоит, и не скрытые им сообщения
+	Query    string
+
+	// Дополнительные фильтры, нулевые значения не применяются
+	SenderID uint
+	Type     string
+	From     *time.Time // включительно
+	To       *time.Time // не включительно
+
+	// After курсор: последний результат предыдущей страницы
+	After *SearchCursor
+	Limit int
+}
+
+// SearchCursor позиция в выдаче, упорядоченной по убыванию релевантности и ID
+type SearchCursor struct {
+	Rank float32
+	ID   uint
+}
+
+// IsValidSearchConfig проверяет, поддерживается ли конфигурация поиска
+func IsValidSearchConfig(name string) bool {
+	switch name {
+	case SearchConfigRussian, SearchConfigEnglish, SearchConfigSimple:
+		return true
+	}
+	return false
+}
+
+// MigrateMessageSearch создает в messages генерируемую колонку search_vector с GIN-индексом.
+// При смене конфигурации колонка пересоздается, что перестраивает таблицу.
+func MigrateMessageSearch(db *gorm.DB, searchConfig string) error {
+	if !IsValidSearchConfig(searchConfig) {
+		return fmt.Errorf("unsupported text search config %q", searchConfig)
+	}
+
+	var expression string
+	err := db.Raw(`
+		SELECT COALESCE(generation_expression, '')
+		FROM information_schema.columns
+		WHERE table_schema = current_schema() AND table_name = 'messages' AND column_name = 'search_vector'
+	`).Scan(&expression).Error
+	if err != nil {
+		return err
+	}
+
+	if !strings.Contains(expression, "'"+searchConfig+"'::regconfig") {
+		// Конфигурация подставляется в DDL только после проверки по списку допустимых
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Exec(`ALTER TABLE messages DROP COLUMN IF EXISTS search_vector`).Error; err != nil {
+				return err
+			}
+
+			return tx.Exec(fmt.Sprintf(`
+				ALTER TABLE messages ADD COLUMN search_vector tsvector
+				GENERATED ALWAYS AS (to_tsvector('%s'::regconfig, message)) STORED
+			`, searchConfig)).Error
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return db.Exec(`CREATE INDEX IF NOT EXISTS idx_messages_search_vector ON messages USING GIN (search_vector)`).Error
+}
+
+// SearchMessages ищет сообщения по словам с учетом словоформ. Результаты упорядочены
+// по релевантности, у каждого есть фрагмент текста с отмеченными совпадениями.
+func (r *chatRepository) SearchMessages(ctx context.Context, filter MessageSearchFilter) ([]model.MessageSearchResult, error) {
+	if filter.ChatID == 0 && filter.ViewerID == 0 {
+		return nil, errors.New("chatID or viewerID must be set")
+	}
+	if filter.Limit <= 0 {
+		return []model.MessageSearchResult{}, nil
+	}
+
+	tsQuery := buildTSQuery(filter.Query)
+	if tsQuery == "" {
+		return []model.MessageSearchResult{}, nil
+	}
+
+	rankExpr := "ts_rank_cd(messages.search_vector, to_tsquery(?::regconfig, ?))"
+
+	inner := r.db.WithContext(ctx).
+		Model(&model.Message{}).
+		Scopes(visibleTo(filter.ViewerID)).
+		Select("messages.id, messages.message, "+rankExpr+" AS rank", r.searchConfig, tsQuery).
+		Where("messages.search_vector @@ to_tsquery(?::regconfig, ?)", r.searchConfig, tsQuery)
+	if filter.ChatID != 0 {
+		inner = inner.Where("messages.chat_id = ?", filter.ChatID)
+	}
+	if filter.ViewerID != 0 {
+		inner = inner.Where("messages.chat_id IN (SELECT chat_id FROM chat_users WHERE user_id = ?)", filter.ViewerID)
+	}
+	if filter.SenderID != 0 {
+		inner = inner.Where("messages.sender_id = ?", filter.SenderID)
+	}
+	if filter.Type != "" {
+		inner = inner.Where("messages.type = ?", filter.Type)
+	}
+	if filter.From != nil {
+		inner = inner.Where("messages.created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		inner = inner.Where("messages.created_at < ?", *filter.To)
+	}
+	if filter.After != nil {
+		inner = inner.Where("("+rankExpr+", messages.id) < (?, ?)",
+			r.searchConfig, tsQuery, filter.After.Rank, filter.After.ID)
+	}
+	inner = inner.Order("rank DESC, messages.id DESC").Limit(filter.Limit)
+
+	// Фрагменты строятся только для строк страницы
+	var rows []struct {
+		ID      uint
+		Rank    float32
+		Snippet string
+	}
+	err := r.db.WithContext(ctx).
+		Table("(?) AS found", inner).
+		Select("found.id, found.rank, ts_headline(?::regconfig, found.message, to_tsquery(?::regconfig, ?), ?) AS snippet",
+			r.searchConfig, r.searchConfig, tsQuery,
+			fmt.Sprintf(`StartSel="%c", StopSel="%c", MaxWords=35, MinWords=15`, headlineStartSel, headlineStopSel)).
+		Order("found.rank DESC, found.id DESC").
+		Scan(&rows).Error
+	if err != nil || len(rows) == 0 {
+		return []model.MessageSearchResult{}, err
+	}
+
+	ids := make([]uint, len(rows))
+	for i := range rows {
+		ids[i] = rows[i].ID
+	}
+
+	messages, err := r.GetMessagesByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[uint]model.Message, len(messages))
+	for _, m := range messages {
+		byID[m.ID] = m
+	}
+
+	results := make([]model.MessageSearchResult, 0, len(rows))
+	for _, row := range rows {
+		message, ok := byID[row.ID]
+		if !ok {
+			// Удалено между запросами
+			continue
+		}
+
+		snippet, highlights := parseHeadline(row.Snippet)
+		results = append(results, model.MessageSearchResult{
+			Message:    message,
+			Rank:       row.Rank,
+			Snippet:    snippet,
+			Highlights: highlights,
+		})
+	}
+
+	return results, nil
+}
+
+// SearchChats ищет чаты пользователя по названию
+func (r *chatRepository) SearchChats(ctx context.Context, userID uint, query string, limit int) ([]model.Chat, error) {
+	if userID == 0 {
+		return nil, errors.New("userID cannot be zero")
+	}
+
+	query = strings.TrimSpace(query)
+	if query == "" || limit <= 0 {
+		return []model.Chat{}, nil
+	}
+
+	var chats []model.Chat
+	err := r.db.WithContext(ctx).
+		Where("id IN (SELECT chat_id FROM chat_users WHERE user_id = ?)", userID).
+		Where("name ILIKE ? ESCAPE '\\'", "%"+escapeLike(query)+"%").
+		// Сначала названия, начинающиеся с запроса
+		Order(clause.OrderBy{Expression: clause.Expr{
+			SQL:                "CASE WHEN name ILIKE ? ESCAPE '\\' THEN 0 ELSE 1 END, name ASC, id ASC",
+			Vars:               []any{escapeLike(query) + "%"},
+			WithoutParentheses: true,
+		}}).
+		Limit(limit).
+		Find(&chats).Error
+
+	return chats, err
+}
+
+// escapeLike экранирует спецсимволы шаблона LIKE
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// buildTSQuery переводит пользовательский запрос в синтаксис to_tsquery.
+// Слова объединяются через И, текст в кавычках ищется как фраза, "слово*" — по префиксу.
+// В результат попадают только буквы и цифры, поэтому операторы tsquery из ввода не проходят.
+func buildTSQuery(query string) string {
+	var (
+		terms    []string
+		phrase   []string
+		inPhrase bool
+		word     strings.Builder
+		count    int
+	)
+
+	flushWord := func(prefix bool) {
+		if word.Len() == 0 {
+			return
+		}
+		term := word.String()
+		word.Reset()
+		if count >= maxSearchTerms {
+			return
+		}
+		count++
+		if prefix {
+			term += ":*"
+		}
+		if inPhrase {
+			phrase = append(phrase, term)
+		} else {
+			terms = append(terms, term)
+		}
+	}
+	flushPhrase := func() {
+		if len(phrase) > 0 {
+			terms = append(terms, "("+strings.Join(phrase, " <-> ")+")")
+			phrase = nil
+		}
+	}
+
+	for _, r := range query {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			word.WriteRune(r)
+		case r == '*':
+			flushWord(true)
+		case r == '"':
+			flushWord(false)
+			if inPhrase {
+				flushPhrase()
+			}
+			inPhrase = !inPhrase
+		default:
+			flushWord(false)
+		}
+	}
+	flushWord(false)
+	// Незакрытая кавычка — фраза до конца запроса
+	flushPhrase()
+
+	return strings.Join(terms, " & ")
+}
+
+// parseHeadline убирает маркеры совпадений из результата ts_headline и возвращает их позиции
+func parseHeadline(headline string) (string, []model.TextRange) {
+	var (
+		text       strings.Builder
+		highlights []model.TextRange
+		offset     int
+		start      = -1
+	)
+
+	for _, r := range headline {
+		switch r {
+		case headlineStartSel:
+			start = offset
+		case headlineStopSel:
+			if start >= 0 && offset > start {
+				highlights = append(highlights, model.TextRange{Offset: start, Length: offset - start})
+			}
+			start = -1
+		default:
+			text.WriteRune(r)
+			offset += utf16.RuneLen(r)
+		}
+	}
+
+	return text.String(), highlights
+}
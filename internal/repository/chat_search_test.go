@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"tush00nka/bbbab_messenger/internal/model"
+)
+
+func TestBuildTSQuery(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{"empty", "", ""},
+		{"only punctuation", `!@#$%^&()`, ""},
+		{"single word", "привет", "привет"},
+		{"words are joined with and", "hello  world", "hello & world"},
+		{"prefix", "прив*", "прив:*"},
+		{"phrase", `"hello world" again`, "(hello <-> world) & again"},
+		{"phrase with prefix", `"good morn*"`, "(good <-> morn:*)"},
+		{"unclosed phrase", `find "rest of query`, "find & (rest <-> of <-> query)"},
+		{"empty phrase", `"" word`, "word"},
+		{"digits", "v2 release 2024", "v2 & release & 2024"},
+		{"tsquery operators are dropped", "a & b | !c <-> d:* (e)", "a & b & c & d & e"},
+		{"quotes and backslashes are dropped", `it's \x`, "it & s & x"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := buildTSQuery(tt.query); got != tt.want {
+				t.Errorf("buildTSQuery(%q) = %q, want %q", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildTSQueryLimitsTerms(t *testing.T) {
+	query := strings.Repeat("word ", maxSearchTerms+10)
+
+	got := buildTSQuery(query)
+	if n := len(strings.Split(got, " & ")); n != maxSearchTerms {
+		t.Errorf("buildTSQuery() has %d terms, want %d", n, maxSearchTerms)
+	}
+}
+
+func TestParseHeadline(t *testing.T) {
+	start, stop := string(headlineStartSel), string(headlineStopSel)
+
+	tests := []struct {
+		name       string
+		headline   string
+		wantText   string
+		wantRanges []model.TextRange
+	}{
+		{"no highlights", "plain text", "plain text", nil},
+		{
+			name:       "single highlight",
+			headline:   "say " + start + "hello" + stop + " world",
+			wantText:   "say hello world",
+			wantRanges: []model.TextRange{{Offset: 4, Length: 5}},
+		},
+		{
+			name:     "several highlights",
+			headline: start + "a" + stop + " b " + start + "cd" + stop,
+			wantText: "a b cd",
+			wantRanges: []model.TextRange{
+				{Offset: 0, Length: 1},
+				{Offset: 4, Length: 2},
+			},
+		},
+		{
+			name:       "cyrillic",
+			headline:   "текст " + start + "поиска" + stop,
+			wantText:   "текст поиска",
+			wantRanges: []model.TextRange{{Offset: 6, Length: 6}},
+		},
+		{
+			// Смещения считаются в UTF-16, как в разметке сообщений
+			name:       "surrogate pairs",
+			headline:   "😀 " + start + "🎉x" + stop,
+			wantText:   "😀 🎉x",
+			wantRanges: []model.TextRange{{Offset: 3, Length: 3}},
+		},
+		{"empty highlight is skipped", "a" + start + stop + "b", "ab", nil},
+		{"unpaired stop is skipped", "a" + stop + "b", "ab", nil},
+		{"unclosed start is skipped", "a" + start + "b", "ab", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text, ranges := parseHeadline(tt.headline)
+			if text != tt.wantText {
+				t.Errorf("parseHeadline() text = %q, want %q", text, tt.wantText)
+			}
+			if !reflect.DeepEqual(ranges, tt.wantRanges) {
+				t.Errorf("parseHeadline() ranges = %+v, want %+v", ranges, tt.wantRanges)
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// AcquireSendSlot резервирует право участника отправить сообщение в чат.
+// Если интервал с прошлой отправки еще не истек, возвращает оставшееся время ожидания.
+// Состояние хранится в Redis, поэтому лимит общий для всех инстансов и переживает переподключения.
+func (r *chatCacheRepository) AcquireSendSlot(ctx context.Context, chatID, userID uint, interval time.Duration) (time.Duration, error) {
+	if chatID == 0 || userID == 0 {
+		return 0, fmt.Errorf("chatID and userID cannot be zero")
+	}
+	if interval <= 0 {
+		return 0, nil
+	}
+
+	key := r.getSendSlotKey(chatID, userID)
+
+	// Ключ может истечь между SETNX и PTTL, поэтому пробуем дважды
+	for range 2 {
+		acquired, err := r.rdb.SetNX(ctx, key, time.Now().UnixMilli(), interval).Result()
+		if err != nil {
+			return 0, fmt.Errorf("failed to acquire send slot: %w", err)
+		}
+		if acquired {
+			return 0, nil
+		}
+
+		ttl, err := r.rdb.PTTL(ctx, key).Result()
+		if err != nil {
+			return 0, fmt.Errorf("failed to get send slot ttl: %w", err)
+		}
+		if ttl > 0 {
+			return ttl, nil
+		}
+	}
+
+	return 0, nil
+}
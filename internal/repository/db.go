@@ -19,15 +19,7 @@ func NewDB(dsn string) (*gorm.DB, error) {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
-	if err := db.AutoMigrate(&model.User{}); err != nil {
-		return nil, err
-	}
-
-	if err := db.AutoMigrate(&model.Chat{}); err != nil {
-		return nil, err
-	}
-
-	if err := db.AutoMigrate(&model.Message{}); err != nil {
+	if err := Migrate(db); err != nil {
 		return nil, err
 	}
 
@@ -44,3 +36,153 @@ func NewDB(dsn string) (*gorm.DB, error) {
 
 	return db, nil
 }
+
+// Migrate приводит схему базы к текущим моделям и выполняет разовые исправления данных.
+// Порядок важен: таблицы создаются раньше, чем на них ссылаются backfill-запросы.
+func Migrate(db *gorm.DB) error {
+	if err := db.AutoMigrate(&model.User{}); err != nil {
+		return err
+	}
+
+	// Индекс для поиска по username без учета регистра (упоминания)
+	if err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_users_username_lower ON users (LOWER(username))`).Error; err != nil {
+		return err
+	}
+
+	if err := db.AutoMigrate(&model.Chat{}); err != nil {
+		return err
+	}
+
+	// Связь many2many у Chat создает chat_users только с ключами; остальные колонки
+	// (роль, настройки участника) добавляет миграция ChatUser. Она должна пройти
+	// до любых запросов к этим колонкам, в том числе до backfillGroupOwners.
+	if err := db.Set("gorm:table_options", "CREATE TABLE chat_users (chat_id bigint, user_id bigint, PRIMARY KEY(chat_id, user_id))").
+		AutoMigrate(&model.ChatUser{}); err != nil {
+		return err
+	}
+
+	if err := migrateMessages(db); err != nil {
+		return fmt.Errorf("failed to migrate messages: %w", err)
+	}
+
+	if err := db.AutoMigrate(&model.ChatInvite{}); err != nil {
+		return err
+	}
+
+	if err := db.AutoMigrate(&model.ChatJoinRequest{}); err != nil {
+		return err
+	}
+
+	if err := db.AutoMigrate(&model.ChatBan{}); err != nil {
+		return err
+	}
+
+	if err := db.AutoMigrate(&model.ChatAuditLog{}); err != nil {
+		return err
+	}
+
+	if err := db.AutoMigrate(&model.PinnedMessage{}); err != nil {
+		return err
+	}
+
+	if err := db.AutoMigrate(&model.Poll{}); err != nil {
+		return err
+	}
+
+	if err := db.AutoMigrate(&model.PollOption{}); err != nil {
+		return err
+	}
+
+	if err := db.AutoMigrate(&model.PollVote{}); err != nil {
+		return err
+	}
+
+	if err := db.AutoMigrate(&model.HiddenMessage{}); err != nil {
+		return err
+	}
+
+	if err := db.AutoMigrate(&model.MessageMention{}); err != nil {
+		return err
+	}
+
+	if err := db.AutoMigrate(&model.ChatDraft{}); err != nil {
+		return err
+	}
+
+	if err := db.AutoMigrate(&model.SavedMessage{}); err != nil {
+		return err
+	}
+
+	if err := db.AutoMigrate(&model.ChatFolder{}); err != nil {
+		return err
+	}
+
+	if err := db.AutoMigrate(&model.ScheduledMessage{}); err != nil {
+		return err
+	}
+
+	if err := db.AutoMigrate(&model.DeviceToken{}); err != nil {
+		return err
+	}
+
+	if err := backfillGroupOwners(db); err != nil {
+		return fmt.Errorf("failed to backfill group owners: %w", err)
+	}
+
+	return nil
+}
+
+// migrateMessages обновляет таблицу сообщений. До появления разметки (колонка entities)
+// текст сохранялся HTML-экранированным; такие строки приводятся к исходному тексту.
+// Отсутствие колонки служит признаком старых данных, поэтому исправление выполняется
+// в одной транзакции с ее добавлением и не повторяется при следующих запусках.
+func migrateMessages(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		legacyEscaped := tx.Migrator().HasTable(&model.Message{}) &&
+			!tx.Migrator().HasColumn(&model.Message{}, "Entities")
+
+		if err := tx.AutoMigrate(&model.Message{}); err != nil {
+			return err
+		}
+
+		if !legacyEscaped {
+			return nil
+		}
+
+		return unescapeLegacyMessages(tx)
+	})
+}
+
+// unescapeLegacyMessages отменяет html.EscapeString для сохраненных сообщений.
+// &amp; заменяется последним, чтобы введенный пользователем "&lt;" не превратился в "<".
+func unescapeLegacyMessages(tx *gorm.DB) error {
+	return tx.Exec(`
+		UPDATE messages
+		SET message = REPLACE(REPLACE(REPLACE(REPLACE(REPLACE(message,
+			'&lt;', '<'), '&gt;', '>'), '&#34;', '"'), '&#39;', ''''), '&amp;', '&')
+		WHERE message LIKE '%&%'
+	`).Error
+}
+
+// backfillGroupOwners назначает владельца группам, созданным без него: раньше роль
+// выставлялась отдельным запросом после создания и могла не записаться.
+// Создатель группы нигде не сохранен, поэтому владельцем становится участник,
+// вступивший раньше всех. Повторный запуск ничего не меняет.
+func backfillGroupOwners(db *gorm.DB) error {
+	return db.Exec(`
+		UPDATE chat_users cu
+		SET role = ?
+		FROM (
+			SELECT DISTINCT ON (m.chat_id) m.chat_id, m.user_id
+			FROM chat_users m
+			JOIN chats c ON c.id = m.chat_id AND c.is_group AND c.deleted_at IS NULL
+			WHERE m.deleted_at IS NULL
+				AND NOT EXISTS (
+					SELECT 1 FROM chat_users o
+					WHERE o.chat_id = m.chat_id AND o.role = ? AND o.deleted_at IS NULL
+				)
+			ORDER BY m.chat_id, m.created_at ASC, m.user_id ASC
+		) first_member
+		WHERE cu.chat_id = first_member.chat_id AND cu.user_id = first_member.user_id
+	`, model.ChatRoleOwner, model.ChatRoleOwner).Error
+}
@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+	"tush00nka/bbbab_messenger/internal/model"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// Проверки с базой данных запускаются на настоящем PostgreSQL:
+//
+//	TEST_DATABASE_DSN="host=localhost user=postgres password=postgres dbname=postgres sslmode=disable" \
+//		go test ./internal/repository
+//
+// Каждая проверка работает в отдельной временной схеме и удаляет ее после себя.
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN is not set")
+	}
+
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+
+	// Одно соединение, чтобы search_path действовал на все запросы теста
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+
+	schema := fmt.Sprintf("repository_test_%d", time.Now().UnixNano())
+	if err := db.Exec("CREATE SCHEMA " + schema).Error; err != nil {
+		t.Fatalf("failed to create schema: %v", err)
+	}
+	t.Cleanup(func() {
+		db.Exec("DROP SCHEMA " + schema + " CASCADE")
+		sqlDB.Close()
+	})
+	if err := db.Exec("SET search_path TO " + schema).Error; err != nil {
+		t.Fatalf("failed to set search_path: %v", err)
+	}
+
+	return db
+}
+
+func TestMigrateFreshDatabase(t *testing.T) {
+	db := openTestDB(t)
+
+	if err := Migrate(db); err != nil {
+		t.Fatalf("Migrate() on empty database error = %v", err)
+	}
+	// Повторный запуск при старте следующей версии не должен падать
+	if err := Migrate(db); err != nil {
+		t.Fatalf("second Migrate() error = %v", err)
+	}
+}
+
+func TestMigrateBaselineDatabase(t *testing.T) {
+	db := openTestDB(t)
+
+	// Схема до изменений: chat_users только с ключами и временными метками
+	baseline := []string{
+		`CREATE TABLE users (id bigserial PRIMARY KEY, created_at timestamptz, updated_at timestamptz,
+			deleted_at timestamptz, username text, password text, phone text, display_name text, profile_picture_key text)`,
+		`CREATE TABLE chats (id bigserial PRIMARY KEY, created_at timestamptz, updated_at timestamptz,
+			deleted_at timestamptz, name text, is_group boolean)`,
+		`CREATE TABLE chat_users (chat_id bigint, user_id bigint, created_at timestamptz, updated_at timestamptz,
+			deleted_at timestamptz, PRIMARY KEY (chat_id, user_id))`,
+		`CREATE TABLE messages (id bigserial PRIMARY KEY, created_at timestamptz, updated_at timestamptz,
+			deleted_at timestamptz, chat_id bigint NOT NULL, sender_id bigint NOT NULL, message text NOT NULL,
+			type varchar(20) DEFAULT 'text', status varchar(20) DEFAULT 'sent', timestamp timestamptz,
+			attachment_url text, reply_to_id bigint, is_edited boolean DEFAULT false)`,
+		`INSERT INTO users (id, username) VALUES (1, 'alice'), (2, 'bob'), (3, 'carol')`,
+		`INSERT INTO chats (id, name, is_group) VALUES (10, 'group', true), (11, '', false)`,
+		`INSERT INTO chat_users (chat_id, user_id, created_at) VALUES
+			(10, 2, '2024-01-01'), (10, 3, '2024-01-01'), (10, 1, '2024-02-01'),
+			(11, 1, '2024-01-01'), (11, 2, '2024-01-01')`,
+		// Текст сохранялся через html.EscapeString
+		`INSERT INTO messages (id, chat_id, sender_id, message) VALUES
+			(100, 10, 1, '&lt;b&gt;Tom &amp; Jerry&lt;/b&gt; &#39;hi&#39; &#34;q&#34;'),
+			(101, 10, 2, 'typed &amp;lt; literally'),
+			(102, 10, 3, 'plain text')`,
+	}
+	for _, stmt := range baseline {
+		if err := db.Exec(stmt).Error; err != nil {
+			t.Fatalf("failed to create baseline schema: %v", err)
+		}
+	}
+
+	if err := Migrate(db); err != nil {
+		t.Fatalf("Migrate() on baseline database error = %v", err)
+	}
+	// Повторный запуск не должен снимать экранирование второй раз
+	if err := Migrate(db); err != nil {
+		t.Fatalf("second Migrate() error = %v", err)
+	}
+
+	var members []model.ChatUser
+	if err := db.Order("chat_id, user_id").Find(&members).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	// Владельцем группы становится участник, вступивший раньше всех; в личном чате владельца нет
+	want := map[[2]uint]string{
+		{10, 1}: model.ChatRoleMember,
+		{10, 2}: model.ChatRoleOwner,
+		{10, 3}: model.ChatRoleMember,
+		{11, 1}: model.ChatRoleMember,
+		{11, 2}: model.ChatRoleMember,
+	}
+	if len(members) != len(want) {
+		t.Fatalf("got %d chat members, want %d", len(members), len(want))
+	}
+	for _, m := range members {
+		if role := want[[2]uint{m.ChatID, m.UserID}]; m.Role != role {
+			t.Errorf("chat %d user %d role = %q, want %q", m.ChatID, m.UserID, m.Role, role)
+		}
+		if m.NotifyMode != model.NotifyAll {
+			t.Errorf("chat %d user %d notify mode = %q, want default", m.ChatID, m.UserID, m.NotifyMode)
+		}
+	}
+
+	wantText := map[uint]string{
+		100: `<b>Tom & Jerry</b> 'hi' "q"`,
+		101: "typed &lt; literally",
+		102: "plain text",
+	}
+	var messages []model.Message
+	if err := db.Order("id").Find(&messages).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(messages) != len(wantText) {
+		t.Fatalf("got %d messages, want %d", len(messages), len(wantText))
+	}
+	for _, m := range messages {
+		if m.Message != wantText[m.ID] {
+			t.Errorf("message %d text = %q, want %q", m.ID, m.Message, wantText[m.ID])
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"tush00nka/bbbab_messenger/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DeviceTokenRepository хранит токены устройств для push-уведомлений
+type DeviceTokenRepository interface {
+	SaveDeviceToken(ctx context.Context, device *model.DeviceToken) error
+	DeleteDeviceToken(ctx context.Context, userID uint, token string) (bool, error)
+	DeleteDeviceTokens(ctx context.Context, tokens []string) error
+	GetDeviceTokens(ctx context.Context, userIDs []uint) ([]model.DeviceToken, error)
+	TrimDeviceTokens(ctx context.Context, userID uint, keep int) error
+}
+
+type deviceTokenRepository struct {
+	db *gorm.DB
+}
+
+// NewDeviceTokenRepository создает репозиторий токенов устройств
+func NewDeviceTokenRepository(db *gorm.DB) DeviceTokenRepository {
+	return &deviceTokenRepository{db: db}
+}
+
+// SaveDeviceToken сохраняет токен; уже известный токен переходит к device.UserID
+func (r *deviceTokenRepository) SaveDeviceToken(ctx context.Context, device *model.DeviceToken) error {
+	if device == nil || device.UserID == 0 || device.Token == "" {
+		return errors.New("device userID and token cannot be empty")
+	}
+
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "token"}},
+		DoUpdates: clause.AssignmentColumns([]string{"user_id", "platform", "updated_at"}),
+	}).Create(device).Error
+}
+
+// DeleteDeviceToken удаляет токен пользователя
+func (r *deviceTokenRepository) DeleteDeviceToken(ctx context.Context, userID uint, token string) (bool, error) {
+	if userID == 0 {
+		return false, errors.New("userID cannot be zero")
+	}
+
+	result := r.db.WithContext(ctx).
+		Where("user_id = ? AND token = ?", userID, token).
+		Delete(&model.DeviceToken{})
+
+	return result.RowsAffected > 0, result.Error
+}
+
+// DeleteDeviceTokens удаляет токены, отозванные сервисом доставки
+func (r *deviceTokenRepository) DeleteDeviceTokens(ctx context.Context, tokens []string) error {
+	if len(tokens) == 0 {
+		return nil
+	}
+
+	return r.db.WithContext(ctx).Where("token IN ?", tokens).Delete(&model.DeviceToken{}).Error
+}
+
+// GetDeviceTokens возвращает токены устройств пользователей
+func (r *deviceTokenRepository) GetDeviceTokens(ctx context.Context, userIDs []uint) ([]model.DeviceToken, error) {
+	if len(userIDs) == 0 {
+		return []model.DeviceToken{}, nil
+	}
+
+	var devices []model.DeviceToken
+	err := r.db.WithContext(ctx).
+		Where("user_id IN ?", userIDs).
+		Order("user_id, id").
+		Find(&devices).Error
+
+	return devices, err
+}
+
+// TrimDeviceTokens оставляет у пользователя keep недавно обновленных токенов
+func (r *deviceTokenRepository) TrimDeviceTokens(ctx context.Context, userID uint, keep int) error {
+	if userID == 0 {
+		return errors.New("userID cannot be zero")
+	}
+
+	return r.db.WithContext(ctx).Exec(`
+		DELETE FROM device_tokens
+		WHERE user_id = ? AND id NOT IN (
+			SELECT id FROM device_tokens WHERE user_id = ? ORDER BY updated_at DESC, id DESC LIMIT ?
+		)
+	`, userID, userID, keep).Error
+}
@@ -6,17 +6,20 @@ import (
 	"tush00nka/bbbab_messenger/internal/model"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type UserRepository interface {
 	Create(user *model.User) error
 	FindByID(id uint) (*model.User, error)
 	FindByUsername(username string) (*model.User, error)
+	FindByUsernameIgnoreCase(username string) (*model.User, error)
 	FindByPhone(phone string) (*model.User, error)
 	Update(user *model.User) error
 	UsernameExists(username string) (bool, error)
 	PhoneExists(phone string) (bool, error)
 	Search(prompt string) ([]*model.User, error)
+	UpdatePrivacy(id uint, hideForwardOrigin bool) error
 	// Delete(id uint) error
 	// FindAll() ([]model.User, error)
 }
@@ -49,6 +52,19 @@ func (r *userRepository) FindByUsername(username string) (*model.User, error) {
 	return &user, nil
 }
 
+// FindByUsernameIgnoreCase ищет пользователя по username без учета регистра.
+// Если имена различаются только регистром, предпочтение отдается точному совпадению.
+func (r *userRepository) FindByUsernameIgnoreCase(username string) (*model.User, error) {
+	var user model.User
+	err := r.db.Where("LOWER(username) = LOWER(?)", username).
+		Order(clause.OrderBy{Expression: clause.Expr{SQL: "username = ? DESC, id ASC", Vars: []any{username}}}).
+		First(&user).Error
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
 func (r *userRepository) FindByPhone(phone string) (*model.User, error) {
 	var user model.User
 	if err := r.db.Where("phone = ?", phone).First(&user).Error; err != nil {
@@ -87,3 +103,7 @@ func (r *userRepository) Search(prompt string) ([]*model.User, error) {
 	}
 	return users, nil
 }
+
+func (r *userRepository) UpdatePrivacy(id uint, hideForwardOrigin bool) error {
+	return r.db.Model(&model.User{}).Where("id = ?", id).Update("hide_forward_origin", hideForwardOrigin).Error
+}
@@ -8,8 +8,12 @@ import (
 	"time"
 	"tush00nka/bbbab_messenger/internal/model"
 	"tush00nka/bbbab_messenger/internal/repository"
+	"unicode/utf8"
 )
 
+// MaxChatDescriptionLength максимальная длина описания группы
+const MaxChatDescriptionLength = 1000
+
 // ChatStatistics статистика чата
 type ChatStatistics struct {
 	TotalMessages  int64     `json:"totalMessages"`
@@ -18,14 +22,33 @@ type ChatStatistics struct {
 	FirstMessageAt time.Time `json:"firstMessageAt"`
 }
 
+// ChatServiceOptions опции сервиса чатов
+type ChatServiceOptions struct {
+	// RateLimiter общий для инстансов ограничитель медленного режима; nil — медленный режим не применяется
+	RateLimiter SendRateLimiter
+	// Users поиск пользователей для разрешения упоминаний @username; nil — упоминания не разрешаются
+	Users UserService
+}
+
 // chatService реализация ChatService
 type chatService struct {
-	chatRepo repository.ChatRepository
+	chatRepo    repository.ChatRepository
+	rateLimiter SendRateLimiter
+	users       UserService
 }
 
 // NewChatService создает новый экземпляр ChatService
-func NewChatService(chatRepo repository.ChatRepository) ChatService {
-	return &chatService{chatRepo: chatRepo}
+func NewChatService(chatRepo repository.ChatRepository, options ...ChatServiceOptions) ChatService {
+	var opts ChatServiceOptions
+	if len(options) > 0 {
+		opts = options[0]
+	}
+
+	return &chatService{
+		chatRepo:    chatRepo,
+		rateLimiter: opts.RateLimiter,
+		users:       opts.Users,
+	}
 }
 
 // CreateChat создает новый чат
@@ -56,6 +79,15 @@ func (s *chatService) GetChatByID(ctx context.Context, chatID uint) (*model.Chat
 	return s.chatRepo.GetByID(ctx, chatID)
 }
 
+// GetChatMeta возвращает чат без участников и сообщений
+func (s *chatService) GetChatMeta(ctx context.Context, chatID uint) (*model.Chat, error) {
+	if chatID == 0 {
+		return nil, errors.New("chatID cannot be zero")
+	}
+
+	return s.chatRepo.GetMeta(ctx, chatID)
+}
+
 // DeleteChat удаляет чат
 func (s *chatService) DeleteChat(ctx context.Context, chatID uint) error {
 	if chatID == 0 {
@@ -97,6 +129,13 @@ func (s *chatService) AddUsersToChat(ctx context.Context, chatID uint, userIDs .
 		userMap[userID] = true
 	}
 
+	// Заблокированных пользователей добавить нельзя
+	for _, userID := range userIDs {
+		if err := s.ensureNotBanned(ctx, chatID, userID); err != nil {
+			return fmt.Errorf("failed to add user %d: %w", userID, err)
+		}
+	}
+
 	// Добавляем пользователей
 	for _, userID := range userIDs {
 		if err := s.chatRepo.AddUser(ctx, chatID, userID); err != nil {
@@ -136,6 +175,98 @@ func (s *chatService) IsUserInChat(ctx context.Context, chatID, userID uint) (bo
 	return s.chatRepo.IsUserInChat(ctx, chatID, userID)
 }
 
+// GetMemberRole возвращает роль пользователя в чате
+func (s *chatService) GetMemberRole(ctx context.Context, chatID, userID uint) (string, error) {
+	if chatID == 0 || userID == 0 {
+		return "", errors.New("chatID and userID cannot be zero")
+	}
+
+	return s.chatRepo.GetUserRole(ctx, chatID, userID)
+}
+
+// SetMemberRole меняет роль участника чата
+func (s *chatService) SetMemberRole(ctx context.Context, chatID, userID uint, role string) error {
+	if chatID == 0 || userID == 0 {
+		return errors.New("chatID and userID cannot be zero")
+	}
+
+	switch role {
+	case model.ChatRoleOwner, model.ChatRoleAdmin, model.ChatRoleMember:
+	default:
+		return fmt.Errorf("unknown role: %s", role)
+	}
+
+	return s.chatRepo.SetUserRole(ctx, chatID, userID, role)
+}
+
+// ChangeMemberRole меняет роль участника группы от имени actorID и возвращает прежнюю роль.
+// Роли назначает только владелец; назначение другого владельца передает ему права,
+// а прежний владелец становится администратором.
+func (s *chatService) ChangeMemberRole(ctx context.Context, chatID, actorID, userID uint, role string) (string, error) {
+	if chatID == 0 || actorID == 0 || userID == 0 {
+		return "", errors.New("chatID, actorID and userID cannot be zero")
+	}
+	if actorID == userID {
+		return "", errors.New("cannot change your own role")
+	}
+
+	switch role {
+	case model.ChatRoleOwner, model.ChatRoleAdmin, model.ChatRoleMember:
+	default:
+		return "", fmt.Errorf("unknown role: %s", role)
+	}
+
+	chat, err := s.chatRepo.GetMeta(ctx, chatID)
+	if err != nil {
+		return "", err
+	}
+	if chat == nil || !chat.IsGroup {
+		return "", errors.New("roles are only available for group chats")
+	}
+
+	actorRole, err := s.chatRepo.GetUserRole(ctx, chatID, actorID)
+	if err != nil {
+		return "", err
+	}
+	if actorRole != model.ChatRoleOwner {
+		return "", ErrCannotModerate
+	}
+
+	oldRole, err := s.chatRepo.GetUserRole(ctx, chatID, userID)
+	if err != nil {
+		return "", err
+	}
+	if oldRole == "" {
+		return "", errors.New("user is not a member of this chat")
+	}
+	if oldRole == role {
+		return oldRole, nil
+	}
+
+	if role == model.ChatRoleOwner {
+		if err := s.chatRepo.TransferOwnership(ctx, chatID, actorID, userID); err != nil {
+			return "", err
+		}
+		return oldRole, nil
+	}
+
+	if err := s.chatRepo.SetUserRole(ctx, chatID, userID, role); err != nil {
+		return "", err
+	}
+
+	return oldRole, nil
+}
+
+// IsChatAdmin проверяет, является ли пользователь владельцем или администратором чата
+func (s *chatService) IsChatAdmin(ctx context.Context, chatID, userID uint) (bool, error) {
+	role, err := s.GetMemberRole(ctx, chatID, userID)
+	if err != nil {
+		return false, err
+	}
+
+	return model.IsAdminRole(role), nil
+}
+
 // SendMessageToChat отправляет сообщение в чат
 func (s *chatService) SendMessageToChat(ctx context.Context, chat *model.Chat, message *model.Message) error {
 	if chat == nil || chat.ID == 0 {
@@ -150,10 +281,105 @@ func (s *chatService) SendMessageToChat(ctx context.Context, chat *model.Chat, m
 		return errors.New("senderID cannot be zero")
 	}
 
-	if strings.TrimSpace(message.Message) == "" {
+	message.ChatID = chat.ID
+	if err := validatePoll(message); err != nil {
+		return err
+	}
+
+	text, entities, err := normalizeMessageText(message.Message, message.Entities)
+	if err != nil {
+		return err
+	}
+	message.Message, message.Entities = text, entities
+
+	if message.Message == "" {
 		return errors.New("message cannot be empty")
 	}
 
+	meta, err := s.checkCanSend(ctx, chat.ID, message.SenderID)
+	if err != nil {
+		return err
+	}
+
+	if message.Poll != nil && !meta.IsGroup {
+		return ErrPollGroupOnly
+	}
+
+	if err := s.resolveMentions(ctx, meta, message); err != nil {
+		return err
+	}
+
+	return s.saveMessage(ctx, meta, message)
+}
+
+// SendSystemMessage сохраняет служебное сообщение о событии в чате от имени actorID.
+// Ограничения каналов и медленного режима к нему не применяются.
+func (s *chatService) SendSystemMessage(ctx context.Context, chatID, actorID uint, text string) (*model.Message, error) {
+	if chatID == 0 || actorID == 0 {
+		return nil, errors.New("chatID and actorID cannot be zero")
+	}
+
+	meta, err := s.chatRepo.GetMeta(ctx, chatID)
+	if err != nil {
+		return nil, err
+	}
+	if meta == nil {
+		return nil, errors.New("chat not found")
+	}
+
+	message := &model.Message{
+		ChatID:   chatID,
+		SenderID: actorID,
+		Message:  text,
+		Type:     model.MessageTypeSystem,
+	}
+	if err := s.saveMessage(ctx, meta, message); err != nil {
+		return nil, err
+	}
+
+	return message, nil
+}
+
+// checkCanSend проверяет ограничения чата на отправку: каналы доступны для записи
+// только администраторам, в медленном режиме резервируется слот отправки.
+// Возвращает актуальные настройки чата.
+func (s *chatService) checkCanSend(ctx context.Context, chatID, senderID uint) (*model.Chat, error) {
+	// Загружаем актуальные настройки чата: вызывающий код может передать заглушку только с ID
+	meta, err := s.chatRepo.GetMeta(ctx, chatID)
+	if err != nil {
+		return nil, err
+	}
+	if meta == nil {
+		return nil, errors.New("chat not found")
+	}
+
+	isAdmin := false
+	if meta.IsChannel || meta.SlowModeSeconds > 0 {
+		if isAdmin, err = s.IsChatAdmin(ctx, chatID, senderID); err != nil {
+			return nil, err
+		}
+	}
+
+	if meta.IsChannel && !isAdmin {
+		return nil, ErrChannelReadOnly
+	}
+
+	if err := s.checkSlowMode(ctx, chatID, senderID, meta.SlowModeSeconds, isAdmin); err != nil {
+		return nil, err
+	}
+
+	return meta, nil
+}
+
+// saveMessage проставляет временные метки и срок жизни по настройкам чата и сохраняет сообщение
+func (s *chatService) saveMessage(ctx context.Context, chat *model.Chat, message *model.Message) error {
+	prepareMessage(chat, message)
+
+	return s.chatRepo.SendMessage(ctx, chat, message)
+}
+
+// prepareMessage проставляет временные метки и срок жизни по настройкам чата
+func prepareMessage(chat *model.Chat, message *model.Message) {
 	now := time.Now()
 
 	// GORM всё равно проставит CreatedAt/UpdatedAt, но мы можем синхронизировать Timestamp
@@ -167,13 +393,17 @@ func (s *chatService) SendMessageToChat(ctx context.Context, chat *model.Chat, m
 		message.Timestamp = message.CreatedAt
 	}
 
-	return s.chatRepo.SendMessage(ctx, chat, message)
+	if message.ExpiresAt == nil && chat.MessageTTLSeconds > 0 {
+		expiresAt := message.CreatedAt.Add(time.Duration(chat.MessageTTLSeconds) * time.Second)
+		message.ExpiresAt = &expiresAt
+	}
 }
 
-// GetChatMessages возвращает сообщения чата с пагинацией
+// GetChatMessages возвращает сообщения чата с пагинацией.
+// Сообщения, скрытые пользователем viewerID, не возвращаются (0 — без фильтра).
 func (s *chatService) GetChatMessages(
 	ctx context.Context,
-	chatID uint,
+	chatID, viewerID uint,
 	cursor string,
 	limit int,
 	direction string,
@@ -193,11 +423,20 @@ func (s *chatService) GetChatMessages(
 		direction = "older"
 	}
 
-	return s.chatRepo.GetChatMessages(ctx, chatID, cursor, limit, direction)
+	messages, hasNext, hasPrevious, total, err := s.chatRepo.GetChatMessages(ctx, chatID, viewerID, cursor, limit, direction)
+	if err != nil {
+		return nil, false, false, nil, err
+	}
+
+	if err := s.AttachPolls(ctx, messages, viewerID); err != nil {
+		return nil, false, false, nil, err
+	}
+
+	return messages, hasNext, hasPrevious, total, nil
 }
 
-// GetRecentMessages возвращает последние сообщения чата
-func (s *chatService) GetRecentMessages(ctx context.Context, chatID uint, limit int) ([]model.Message, error) {
+// GetRecentMessages возвращает последние сообщения чата, кроме скрытых viewerID
+func (s *chatService) GetRecentMessages(ctx context.Context, chatID, viewerID uint, limit int) ([]model.Message, error) {
 	if chatID == 0 {
 		return nil, errors.New("chatID cannot be zero")
 	}
@@ -209,7 +448,12 @@ func (s *chatService) GetRecentMessages(ctx context.Context, chatID uint, limit
 		limit = 200
 	}
 
-	return s.chatRepo.GetRecentMessages(ctx, chatID, limit)
+	messages, err := s.chatRepo.GetRecentMessages(ctx, chatID, viewerID, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return messages, s.AttachPolls(ctx, messages, viewerID)
 }
 
 // GetMessageByID возвращает сообщение по ID
@@ -270,8 +514,8 @@ func (s *chatService) GetChatForUsers(ctx context.Context, user1ID, user2ID uint
 	return s.chatRepo.GetForUsers(ctx, user1ID, user2ID)
 }
 
-// CreateGroupChat создает групповой чат
-func (s *chatService) CreateGroupChat(ctx context.Context, name string, userIDs []uint) (*model.Chat, error) {
+// CreateGroupChat создает групповой чат, владельцем которого становится ownerID
+func (s *chatService) CreateGroupChat(ctx context.Context, name string, userIDs []uint, ownerID uint) (*model.Chat, error) {
 	name = strings.TrimSpace(name)
 	if name == "" {
 		return nil, errors.New("group chat name cannot be empty")
@@ -293,12 +537,16 @@ func (s *chatService) CreateGroupChat(ctx context.Context, name string, userIDs
 		userMap[userID] = true
 	}
 
+	if !userMap[ownerID] {
+		return nil, errors.New("group owner must be among group users")
+	}
+
 	chat := &model.Chat{
 		Name:    name,
 		IsGroup: true,
 	}
 
-	err := s.chatRepo.CreateGroup(ctx, chat, userIDs)
+	err := s.chatRepo.CreateGroup(ctx, chat, userIDs, ownerID)
 	if err != nil {
 		return nil, err
 	}
@@ -317,9 +565,23 @@ func (s *chatService) UpdateGroupInfo(ctx context.Context, chatID uint, name, de
 		return errors.New("group name cannot be empty")
 	}
 
+	description = strings.TrimSpace(description)
+	if utf8.RuneCountInString(description) > MaxChatDescriptionLength {
+		return fmt.Errorf("group description too long (max %d characters)", MaxChatDescriptionLength)
+	}
+
 	return s.chatRepo.UpdateGroupInfo(ctx, chatID, name, description)
 }
 
+// UpdateChatAvatar сохраняет ключ аватара группового чата
+func (s *chatService) UpdateChatAvatar(ctx context.Context, chatID uint, avatarKey string) error {
+	if chatID == 0 {
+		return errors.New("chatID cannot be zero")
+	}
+
+	return s.chatRepo.UpdateAvatar(ctx, chatID, avatarKey)
+}
+
 // GetChatStatistics возвращает статистику чата
 func (s *chatService) GetChatStatistics(ctx context.Context, chatID uint) (*ChatStatistics, error) {
 	if chatID == 0 {
@@ -339,27 +601,6 @@ func (s *chatService) GetChatStatistics(ctx context.Context, chatID uint) (*Chat
 	}, nil
 }
 
-// SearchMessages ищет сообщения в чате
-func (s *chatService) SearchMessages(ctx context.Context, chatID uint, query string, limit int) ([]model.Message, error) {
-	if chatID == 0 {
-		return nil, errors.New("chatID cannot be zero")
-	}
-
-	query = strings.TrimSpace(query)
-	if query == "" {
-		return nil, errors.New("search query cannot be empty")
-	}
-
-	if limit <= 0 {
-		limit = 20
-	}
-	if limit > 100 {
-		limit = 100
-	}
-
-	return s.chatRepo.SearchMessages(ctx, chatID, query, limit)
-}
-
 // GetUnreadCount возвращает количество непрочитанных сообщений
 func (s *chatService) GetUnreadCount(ctx context.Context, userID, chatID uint) (int64, error) {
 	if userID == 0 {
@@ -388,11 +629,11 @@ func (s *chatService) SendMessageToChatLegacy(chat *model.Chat, message model.Me
 
 func (s *chatService) GetChatMessagesLegacy(chatID uint, cursor string, limit int, direction string, ctx context.Context) (
 	[]model.Message, bool, bool, *int64, error) {
-	return s.GetChatMessages(ctx, chatID, cursor, limit, direction)
+	return s.GetChatMessages(ctx, chatID, 0, cursor, limit, direction)
 }
 
-func (s *chatService) CreateGroupChatLegacy(name string, userIDs []uint) (*model.Chat, error) {
-	return s.CreateGroupChat(context.Background(), name, userIDs)
+func (s *chatService) CreateGroupChatLegacy(name string, userIDs []uint, ownerID uint) (*model.Chat, error) {
+	return s.CreateGroupChat(context.Background(), name, userIDs, ownerID)
 }
 
 func (s *chatService) IsUserInChatLegacy(chatID, userID uint) (bool, error) {
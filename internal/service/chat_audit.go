@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"tush00nka/bbbab_messenger/internal/model"
+)
+
+const (
+	defaultAuditLogLimit = 50
+	maxAuditLogLimit     = 100
+)
+
+// RecordAudit сохраняет запись журнала модерации
+func (s *chatService) RecordAudit(ctx context.Context, entry *model.ChatAuditLog) error {
+	if entry == nil {
+		return errors.New("audit entry cannot be nil")
+	}
+	if entry.Action == "" {
+		return errors.New("audit action cannot be empty")
+	}
+
+	return s.chatRepo.CreateAuditLog(ctx, entry)
+}
+
+// GetAuditLog возвращает страницу журнала модерации, начиная с новых записей,
+// и признак наличия следующей страницы
+func (s *chatService) GetAuditLog(ctx context.Context, chatID, beforeID uint, limit int) ([]model.ChatAuditLog, bool, error) {
+	if chatID == 0 {
+		return nil, false, errors.New("chatID cannot be zero")
+	}
+
+	if limit <= 0 {
+		limit = defaultAuditLogLimit
+	}
+	if limit > maxAuditLogLimit {
+		limit = maxAuditLogLimit
+	}
+
+	// Запрашиваем на одну запись больше, чтобы узнать, есть ли следующая страница
+	entries, err := s.chatRepo.GetAuditLog(ctx, chatID, beforeID, limit+1)
+	if err != nil {
+		return nil, false, err
+	}
+
+	hasNext := len(entries) > limit
+	if hasNext {
+		entries = entries[:limit]
+	}
+
+	return entries, hasNext, nil
+}
@@ -0,0 +1,202 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"tush00nka/bbbab_messenger/internal/model"
+	"unicode/utf8"
+)
+
+// Ошибки модерации участников
+var (
+	ErrUserBanned       = errors.New("user is banned from this chat")
+	ErrBanNotFound      = errors.New("ban not found")
+	ErrCannotModerate   = errors.New("insufficient rights to moderate this member")
+	ErrCannotAddMembers = errors.New("only group admins can add members")
+)
+
+const maxBanReasonLength = 500
+
+// MaxBanDuration наибольший срок временной блокировки; дольше — только бессрочно
+const MaxBanDuration = 366 * 24 * time.Hour
+
+// BanUser блокирует пользователя в групповом чате на duration (0 — бессрочно)
+// и удаляет его из участников
+func (s *chatService) BanUser(
+	ctx context.Context,
+	chatID, actorID, userID uint,
+	reason string,
+	duration time.Duration,
+) (*model.ChatBan, error) {
+	if duration < 0 || duration > MaxBanDuration {
+		return nil, fmt.Errorf("ban duration must be between 0 and %d seconds", int64(MaxBanDuration/time.Second))
+	}
+
+	reason = strings.TrimSpace(reason)
+	if utf8.RuneCountInString(reason) > maxBanReasonLength {
+		return nil, fmt.Errorf("reason too long (max %d characters)", maxBanReasonLength)
+	}
+
+	if err := s.checkModerationRights(ctx, chatID, actorID, userID); err != nil {
+		return nil, err
+	}
+
+	ban := &model.ChatBan{
+		ChatID:     chatID,
+		UserID:     userID,
+		BannedByID: actorID,
+		Reason:     reason,
+	}
+	if duration > 0 {
+		expiresAt := time.Now().Add(duration)
+		ban.ExpiresAt = &expiresAt
+	}
+
+	if err := s.chatRepo.BanUser(ctx, ban); err != nil {
+		return nil, err
+	}
+
+	return ban, nil
+}
+
+// KickMember исключает участника из группового чата без блокировки
+func (s *chatService) KickMember(ctx context.Context, chatID, actorID, userID uint) error {
+	if err := s.checkModerationRights(ctx, chatID, actorID, userID); err != nil {
+		return err
+	}
+
+	isMember, err := s.chatRepo.IsUserInChat(ctx, chatID, userID)
+	if err != nil {
+		return err
+	}
+	if !isMember {
+		return errors.New("user is not a member of this chat")
+	}
+
+	return s.chatRepo.RemoveUser(ctx, chatID, userID)
+}
+
+// AddMember добавляет пользователя в групповой чат от имени администратора actorID.
+// Заблокированного в чате пользователя добавить нельзя.
+func (s *chatService) AddMember(ctx context.Context, chatID, actorID, userID uint) error {
+	if chatID == 0 || actorID == 0 || userID == 0 {
+		return errors.New("chatID, actorID and userID cannot be zero")
+	}
+
+	chat, err := s.chatRepo.GetMeta(ctx, chatID)
+	if err != nil {
+		return err
+	}
+	if chat == nil || !chat.IsGroup {
+		return errors.New("group chat not found")
+	}
+
+	actorRole, err := s.chatRepo.GetUserRole(ctx, chatID, actorID)
+	if err != nil {
+		return err
+	}
+	if !model.IsAdminRole(actorRole) {
+		return ErrCannotAddMembers
+	}
+
+	isMember, err := s.chatRepo.IsUserInChat(ctx, chatID, userID)
+	if err != nil {
+		return err
+	}
+	if isMember {
+		return ErrAlreadyMember
+	}
+
+	return s.AddUsersToChat(ctx, chatID, userID)
+}
+
+// UnbanUser снимает блокировку пользователя в чате
+func (s *chatService) UnbanUser(ctx context.Context, chatID, userID uint) error {
+	if chatID == 0 || userID == 0 {
+		return errors.New("chatID and userID cannot be zero")
+	}
+
+	ok, err := s.chatRepo.UnbanUser(ctx, chatID, userID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrBanNotFound
+	}
+
+	return nil
+}
+
+// GetChatBans возвращает действующие блокировки чата
+func (s *chatService) GetChatBans(ctx context.Context, chatID uint) ([]model.ChatBan, error) {
+	if chatID == 0 {
+		return nil, errors.New("chatID cannot be zero")
+	}
+
+	return s.chatRepo.GetChatBans(ctx, chatID)
+}
+
+// GetActiveBan возвращает действующую блокировку пользователя или nil
+func (s *chatService) GetActiveBan(ctx context.Context, chatID, userID uint) (*model.ChatBan, error) {
+	if chatID == 0 || userID == 0 {
+		return nil, errors.New("chatID and userID cannot be zero")
+	}
+
+	return s.chatRepo.GetActiveBan(ctx, chatID, userID)
+}
+
+// ensureNotBanned возвращает ErrUserBanned, если пользователь заблокирован в чате
+func (s *chatService) ensureNotBanned(ctx context.Context, chatID, userID uint) error {
+	ban, err := s.chatRepo.GetActiveBan(ctx, chatID, userID)
+	if err != nil {
+		return err
+	}
+	if ban != nil {
+		return ErrUserBanned
+	}
+
+	return nil
+}
+
+// checkModerationRights проверяет, может ли actorID исключать или блокировать userID.
+// Владельца исключить нельзя, администраторов может исключать только владелец.
+func (s *chatService) checkModerationRights(ctx context.Context, chatID, actorID, userID uint) error {
+	if chatID == 0 || actorID == 0 || userID == 0 {
+		return errors.New("chatID, actorID and userID cannot be zero")
+	}
+	if actorID == userID {
+		return errors.New("cannot moderate yourself")
+	}
+
+	chat, err := s.chatRepo.GetMeta(ctx, chatID)
+	if err != nil {
+		return err
+	}
+	if chat == nil || !chat.IsGroup {
+		return errors.New("group chat not found")
+	}
+
+	actorRole, err := s.chatRepo.GetUserRole(ctx, chatID, actorID)
+	if err != nil {
+		return err
+	}
+	if !model.IsAdminRole(actorRole) {
+		return ErrCannotModerate
+	}
+
+	targetRole, err := s.chatRepo.GetUserRole(ctx, chatID, userID)
+	if err != nil {
+		return err
+	}
+	if targetRole == model.ChatRoleOwner {
+		return ErrCannotModerate
+	}
+	if targetRole == model.ChatRoleAdmin && actorRole != model.ChatRoleOwner {
+		return ErrCannotModerate
+	}
+
+	return nil
+}
@@ -0,0 +1,211 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+	"tush00nka/bbbab_messenger/internal/model"
+)
+
+// newModerationFixture: группа 1 с владельцем 1, администраторами 2 и 3, участниками 4 и 5
+// и личный чат 2 пользователей 1 и 4
+func newModerationFixture() *memoryChatRepo {
+	repo := newMemoryChatRepo()
+	repo.addGroup(1, 1, 4, 5)
+	repo.addMember(1, 2, model.ChatRoleAdmin)
+	repo.addMember(1, 3, model.ChatRoleAdmin)
+	repo.addDirect(2, 1, 4)
+	return repo
+}
+
+func TestBanUserPermissions(t *testing.T) {
+	tests := []struct {
+		name    string
+		chatID  uint
+		actorID uint
+		userID  uint
+		wantErr error
+	}{
+		{"owner bans member", 1, 1, 4, nil},
+		{"owner bans admin", 1, 1, 2, nil},
+		{"admin bans member", 1, 2, 4, nil},
+		{"admin bans admin", 1, 2, 3, ErrCannotModerate},
+		{"admin bans owner", 1, 2, 1, ErrCannotModerate},
+		{"member bans member", 1, 4, 5, ErrCannotModerate},
+		{"non-member bans member", 1, 9, 4, ErrCannotModerate},
+		{"bans self", 1, 1, 1, errAny},
+		{"direct chat", 2, 1, 4, errAny},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newModerationFixture()
+			svc := newTestChatService(repo)
+
+			ban, err := svc.BanUser(context.Background(), tt.chatID, tt.actorID, tt.userID, "spam", 0)
+			if !matchErr(err, tt.wantErr) {
+				t.Fatalf("BanUser() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				if len(repo.bans) != 0 || ban != nil {
+					t.Errorf("ban stored on error: %+v", repo.bans)
+				}
+				return
+			}
+
+			// Заблокированный исключается из чата, блокировка бессрочная
+			if repo.member(tt.chatID, tt.userID) != nil {
+				t.Error("banned user is still a member")
+			}
+			if ban.ExpiresAt != nil || ban.BannedByID != tt.actorID || ban.Reason != "spam" {
+				t.Errorf("ban = %+v", ban)
+			}
+		})
+	}
+}
+
+func TestBanUserValidation(t *testing.T) {
+	tests := []struct {
+		name     string
+		reason   string
+		duration time.Duration
+		wantErr  bool
+	}{
+		{"temporary", "flood", time.Hour, false},
+		{"max duration", "", MaxBanDuration, false},
+		{"negative duration", "", -time.Second, true},
+		{"too long duration", "", MaxBanDuration + time.Second, true},
+		// Длина причины считается в символах
+		{"cyrillic reason at limit", strings.Repeat("я", maxBanReasonLength), 0, false},
+		{"reason too long", strings.Repeat("a", maxBanReasonLength+1), 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newModerationFixture()
+			svc := newTestChatService(repo)
+
+			ban, err := svc.BanUser(context.Background(), 1, 1, 4, tt.reason, tt.duration)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("BanUser() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if repo.member(1, 4) == nil {
+					t.Error("user removed despite the error")
+				}
+				return
+			}
+			if tt.duration > 0 {
+				if ban.ExpiresAt == nil || time.Until(*ban.ExpiresAt) > tt.duration || time.Until(*ban.ExpiresAt) < tt.duration-time.Minute {
+					t.Errorf("ExpiresAt = %v, want now+%v", ban.ExpiresAt, tt.duration)
+				}
+			}
+		})
+	}
+}
+
+func TestBannedUserCannotRejoin(t *testing.T) {
+	repo := newModerationFixture()
+	svc := newTestChatService(repo)
+	ctx := context.Background()
+
+	if _, err := svc.BanUser(ctx, 1, 2, 4, "", 0); err != nil {
+		t.Fatalf("BanUser() error = %v", err)
+	}
+
+	if err := svc.AddMember(ctx, 1, 1, 4); !errors.Is(err, ErrUserBanned) {
+		t.Fatalf("AddMember() of banned user error = %v, want %v", err, ErrUserBanned)
+	}
+	if repo.member(1, 4) != nil {
+		t.Fatal("banned user was added back")
+	}
+
+	if err := svc.UnbanUser(ctx, 1, 4); err != nil {
+		t.Fatalf("UnbanUser() error = %v", err)
+	}
+	if err := svc.UnbanUser(ctx, 1, 4); !errors.Is(err, ErrBanNotFound) {
+		t.Errorf("second UnbanUser() error = %v, want %v", err, ErrBanNotFound)
+	}
+	if err := svc.AddMember(ctx, 1, 1, 4); err != nil {
+		t.Fatalf("AddMember() after unban error = %v", err)
+	}
+	if repo.member(1, 4) == nil {
+		t.Error("user was not added after unban")
+	}
+}
+
+func TestExpiredBanAllowsRejoin(t *testing.T) {
+	repo := newModerationFixture()
+	svc := newTestChatService(repo)
+	ctx := context.Background()
+
+	past := time.Now().Add(-time.Minute)
+	repo.bans = append(repo.bans, model.ChatBan{ChatID: 1, UserID: 9, ExpiresAt: &past})
+
+	if err := svc.AddMember(ctx, 1, 2, 9); err != nil {
+		t.Fatalf("AddMember() after expired ban error = %v", err)
+	}
+}
+
+func TestAddMember(t *testing.T) {
+	tests := []struct {
+		name    string
+		actorID uint
+		userID  uint
+		wantErr error
+	}{
+		{"admin adds user", 2, 9, nil},
+		{"member adds user", 4, 9, ErrCannotAddMembers},
+		{"already a member", 1, 5, ErrAlreadyMember},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newModerationFixture()
+			svc := newTestChatService(repo)
+
+			err := svc.AddMember(context.Background(), 1, tt.actorID, tt.userID)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("AddMember() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && repo.member(1, tt.userID) == nil {
+				t.Error("user was not added")
+			}
+		})
+	}
+}
+
+func TestKickMember(t *testing.T) {
+	tests := []struct {
+		name    string
+		actorID uint
+		userID  uint
+		wantErr error
+	}{
+		{"admin kicks member", 2, 4, nil},
+		{"member kicks member", 5, 4, ErrCannotModerate},
+		{"admin kicks admin", 3, 2, ErrCannotModerate},
+		{"not a member", 1, 9, errAny},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newModerationFixture()
+			svc := newTestChatService(repo)
+
+			err := svc.KickMember(context.Background(), 1, tt.actorID, tt.userID)
+			if !matchErr(err, tt.wantErr) {
+				t.Fatalf("KickMember() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && repo.member(1, tt.userID) != nil {
+				t.Error("kicked user is still a member")
+			}
+			// Исключение не блокирует: пользователя можно вернуть
+			if len(repo.bans) != 0 {
+				t.Errorf("kick created bans: %+v", repo.bans)
+			}
+		})
+	}
+}
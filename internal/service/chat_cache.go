@@ -192,6 +192,15 @@ func (s *ChatCacheService) DeleteMessage(ctx context.Context, chatID, messageID
 	return nil
 }
 
+// UpdateMessage заменяет закешированную версию сообщения
+func (s *ChatCacheService) UpdateMessage(ctx context.Context, msg model.Message) error {
+	if msg.ChatID == 0 || msg.ID == 0 {
+		return nil
+	}
+
+	return s.cacheRepo.UpdateMessage(ctx, msg.ChatID, msg)
+}
+
 // Legacy методы для обратной совместимости
 func (s *ChatCacheService) SendMessageLegacy(chat *model.Chat, msg model.Message) error {
 	return s.SendMessage(context.Background(), chat, msg)
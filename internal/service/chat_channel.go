@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"tush00nka/bbbab_messenger/internal/model"
+)
+
+// Ошибки каналов
+var (
+	ErrChannelReadOnly = errors.New("only channel admins can post messages")
+	ErrNotAChannel     = errors.New("chat is not a channel")
+)
+
+// CreateChannel создает канал, владельцем и первым участником которого становится ownerID
+func (s *chatService) CreateChannel(ctx context.Context, ownerID uint, name, description string) (*model.Chat, error) {
+	if ownerID == 0 {
+		return nil, errors.New("ownerID cannot be zero")
+	}
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, errors.New("channel name cannot be empty")
+	}
+
+	description = strings.TrimSpace(description)
+	if len(description) > MaxChatDescriptionLength {
+		return nil, fmt.Errorf("channel description too long (max %d characters)", MaxChatDescriptionLength)
+	}
+
+	now := time.Now()
+	chat := &model.Chat{
+		Name:        name,
+		Description: description,
+	}
+	chat.CreatedAt = now
+	chat.UpdatedAt = now
+
+	if err := s.chatRepo.CreateChannel(ctx, chat, ownerID); err != nil {
+		return nil, fmt.Errorf("failed to create channel: %w", err)
+	}
+
+	chat.SubscriberCount = 1
+
+	return chat, nil
+}
+
+// SubscribeToChannel подписывает пользователя на канал
+func (s *chatService) SubscribeToChannel(ctx context.Context, chatID, userID uint) error {
+	if err := s.ensureChannel(ctx, chatID); err != nil {
+		return err
+	}
+
+	isMember, err := s.chatRepo.IsUserInChat(ctx, chatID, userID)
+	if err != nil {
+		return err
+	}
+	if isMember {
+		return ErrAlreadyMember
+	}
+
+	return s.AddUsersToChat(ctx, chatID, userID)
+}
+
+// UnsubscribeFromChannel отписывает пользователя от канала
+func (s *chatService) UnsubscribeFromChannel(ctx context.Context, chatID, userID uint) error {
+	if err := s.ensureChannel(ctx, chatID); err != nil {
+		return err
+	}
+
+	role, err := s.chatRepo.GetUserRole(ctx, chatID, userID)
+	if err != nil {
+		return err
+	}
+	if role == model.ChatRoleOwner {
+		return errors.New("channel owner cannot unsubscribe")
+	}
+
+	return s.RemoveUserFromChat(ctx, chatID, userID)
+}
+
+// ensureChannel проверяет, что чат существует и является каналом
+func (s *chatService) ensureChannel(ctx context.Context, chatID uint) error {
+	if chatID == 0 {
+		return errors.New("chatID cannot be zero")
+	}
+
+	chat, err := s.chatRepo.GetMeta(ctx, chatID)
+	if err != nil {
+		return err
+	}
+	if chat == nil || !chat.IsChannel {
+		return ErrNotAChannel
+	}
+
+	return nil
+}
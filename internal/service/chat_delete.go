@@ -0,0 +1,130 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+	"tush00nka/bbbab_messenger/internal/model"
+)
+
+// Ограничения удаления сообщений
+const (
+	// DeleteForEveryoneWindow срок, в течение которого отправитель может удалить сообщение у всех
+	DeleteForEveryoneWindow = 48 * time.Hour
+	MaxBulkDelete           = 100
+)
+
+// Ошибки удаления сообщений
+var (
+	ErrNothingToDelete     = errors.New("no messages to delete")
+	ErrTooManyToDelete     = errors.New("too many messages to delete")
+	ErrDeleteNotAllowed    = errors.New("cannot delete messages of other users")
+	ErrDeleteWindowExpired = errors.New("message is too old to be deleted for everyone")
+	ErrNotChatMember       = errors.New("user is not a member of this chat")
+)
+
+// DeleteMessages удаляет сообщения чата от имени userID.
+// forEveryone — удалить у всех участников: отправитель может сделать это в течение
+// DeleteForEveryoneWindow, администраторы групп — с любым сообщением без ограничения срока.
+// Иначе сообщения скрываются только для самого пользователя.
+// Возвращает затронутые сообщения и ID тех из них, что были закреплены.
+func (s *chatService) DeleteMessages(
+	ctx context.Context,
+	userID, chatID uint,
+	messageIDs []uint,
+	forEveryone bool,
+) ([]model.Message, []uint, error) {
+	if userID == 0 || chatID == 0 {
+		return nil, nil, errors.New("userID and chatID cannot be zero")
+	}
+
+	messageIDs = uniqueIDs(messageIDs)
+	if len(messageIDs) == 0 {
+		return nil, nil, ErrNothingToDelete
+	}
+	if len(messageIDs) > MaxBulkDelete {
+		return nil, nil, ErrTooManyToDelete
+	}
+
+	inChat, err := s.chatRepo.IsUserInChat(ctx, chatID, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !inChat {
+		return nil, nil, ErrNotChatMember
+	}
+
+	messages, err := s.chatRepo.GetMessagesByIDs(ctx, messageIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(messages) != len(messageIDs) {
+		return nil, nil, ErrMessageNotInChat
+	}
+	for _, m := range messages {
+		if m.ChatID != chatID {
+			return nil, nil, ErrMessageNotInChat
+		}
+	}
+
+	if !forEveryone {
+		if err := s.chatRepo.HideMessages(ctx, userID, chatID, messageIDs); err != nil {
+			return nil, nil, err
+		}
+		return messages, nil, nil
+	}
+
+	if err := s.checkDeleteForEveryone(ctx, userID, chatID, messages); err != nil {
+		return nil, nil, err
+	}
+
+	pinnedIDs, err := s.chatRepo.DeleteMessages(ctx, messageIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return messages, pinnedIDs, nil
+}
+
+// GetHiddenMessageIDs возвращает ID сообщений чата, удаленных пользователем только у себя
+func (s *chatService) GetHiddenMessageIDs(ctx context.Context, userID, chatID uint) ([]uint, error) {
+	if userID == 0 || chatID == 0 {
+		return nil, errors.New("userID and chatID cannot be zero")
+	}
+
+	return s.chatRepo.GetHiddenMessageIDs(ctx, userID, chatID)
+}
+
+// checkDeleteForEveryone проверяет право удалить сообщения у всех участников
+func (s *chatService) checkDeleteForEveryone(ctx context.Context, userID, chatID uint, messages []model.Message) error {
+	meta, err := s.chatRepo.GetMeta(ctx, chatID)
+	if err != nil {
+		return err
+	}
+	if meta == nil {
+		return errors.New("chat not found")
+	}
+
+	// Администраторы модерируют только группы: в личных чатах ролей нет
+	isAdmin := false
+	if meta.IsGroup {
+		if isAdmin, err = s.IsChatAdmin(ctx, chatID, userID); err != nil {
+			return err
+		}
+	}
+	if isAdmin {
+		return nil
+	}
+
+	deadline := time.Now().Add(-DeleteForEveryoneWindow)
+	for _, m := range messages {
+		if m.SenderID != userID {
+			return ErrDeleteNotAllowed
+		}
+		if m.CreatedAt.Before(deadline) {
+			return ErrDeleteWindowExpired
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+	"tush00nka/bbbab_messenger/internal/model"
+)
+
+// newDeleteFixture: группа 1 (владелец 1, администратор 2, участники 3 и 4), личный чат 2 пользователей 1 и 3.
+// Сообщения: 10 и 11 от 3 в группе (11 старше окна удаления), 12 от 4 в группе, 20 от 1 в личном чате.
+func newDeleteFixture() *memoryChatRepo {
+	repo := newMemoryChatRepo()
+	repo.addGroup(1, 1, 3, 4)
+	repo.addMember(1, 2, model.ChatRoleAdmin)
+	repo.addDirect(2, 1, 3)
+
+	repo.addMessage(10, 1, 3, "fresh")
+	repo.addMessage(11, 1, 3, "old").CreatedAt = time.Now().Add(-DeleteForEveryoneWindow - time.Hour)
+	repo.addMessage(12, 1, 4, "other")
+	repo.addMessage(20, 2, 1, "direct")
+	return repo
+}
+
+func TestDeleteMessagesForEveryone(t *testing.T) {
+	tests := []struct {
+		name       string
+		userID     uint
+		chatID     uint
+		messageIDs []uint
+		wantErr    error
+	}{
+		{"own fresh message", 3, 1, []uint{10}, nil},
+		{"duplicate IDs", 3, 1, []uint{10, 10}, nil},
+		{"own old message", 3, 1, []uint{11}, ErrDeleteWindowExpired},
+		{"other's message", 3, 1, []uint{10, 12}, ErrDeleteNotAllowed},
+		{"admin deletes any message", 2, 1, []uint{11, 12}, nil},
+		{"owner deletes any message", 1, 1, []uint{10, 12}, nil},
+		// В личном чате ролей нет: чужое сообщение удалить у всех нельзя
+		{"other's message in direct chat", 3, 2, []uint{20}, ErrDeleteNotAllowed},
+		{"message from another chat", 3, 1, []uint{20}, ErrMessageNotInChat},
+		{"missing message", 3, 1, []uint{10, 99}, ErrMessageNotInChat},
+		{"non-member", 9, 1, []uint{10}, ErrNotChatMember},
+		{"nothing to delete", 3, 1, nil, ErrNothingToDelete},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newDeleteFixture()
+			svc := newTestChatService(repo)
+
+			messages, _, err := svc.DeleteMessages(context.Background(), tt.userID, tt.chatID, tt.messageIDs, true)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("DeleteMessages() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				if len(repo.messages) != 4 {
+					t.Errorf("messages deleted on error, %d left", len(repo.messages))
+				}
+				return
+			}
+			for _, id := range tt.messageIDs {
+				if _, ok := repo.messages[id]; ok {
+					t.Errorf("message %d was not deleted", id)
+				}
+			}
+			if want := len(slices.Compact(slices.Clone(tt.messageIDs))); len(messages) != want {
+				t.Errorf("returned %d messages, want %d", len(messages), want)
+			}
+		})
+	}
+}
+
+func TestDeleteMessagesTooMany(t *testing.T) {
+	repo := newDeleteFixture()
+	svc := newTestChatService(repo)
+
+	ids := make([]uint, MaxBulkDelete+1)
+	for i := range ids {
+		ids[i] = uint(i + 1)
+	}
+	if _, _, err := svc.DeleteMessages(context.Background(), 3, 1, ids, false); !errors.Is(err, ErrTooManyToDelete) {
+		t.Errorf("DeleteMessages() error = %v, want %v", err, ErrTooManyToDelete)
+	}
+}
+
+func TestDeleteMessagesForMe(t *testing.T) {
+	repo := newDeleteFixture()
+	svc := newTestChatService(repo)
+	ctx := context.Background()
+
+	// Скрыть у себя можно любое сообщение чата, даже чужое и старое
+	messages, pinnedIDs, err := svc.DeleteMessages(ctx, 3, 1, []uint{11, 12}, false)
+	if err != nil {
+		t.Fatalf("DeleteMessages() error = %v", err)
+	}
+	if len(messages) != 2 || pinnedIDs != nil {
+		t.Errorf("returned %d messages, pinned %v", len(messages), pinnedIDs)
+	}
+	if len(repo.messages) != 4 {
+		t.Errorf("messages deleted for everyone, %d left", len(repo.messages))
+	}
+
+	hidden, err := svc.GetHiddenMessageIDs(ctx, 3, 1)
+	if err != nil {
+		t.Fatalf("GetHiddenMessageIDs() error = %v", err)
+	}
+	slices.Sort(hidden)
+	if !slices.Equal(hidden, []uint{11, 12}) {
+		t.Errorf("hidden for user = %v, want [11 12]", hidden)
+	}
+	if others, _ := svc.GetHiddenMessageIDs(ctx, 4, 1); len(others) != 0 {
+		t.Errorf("hidden for another user = %v", others)
+	}
+}
+
+func TestDeleteMessagesUnpinsAndKeepsSavedCopies(t *testing.T) {
+	repo := newDeleteFixture()
+	svc := newTestChatService(repo)
+	ctx := context.Background()
+
+	if _, err := svc.PinMessage(ctx, 1, 10, 1); err != nil {
+		t.Fatalf("PinMessage() error = %v", err)
+	}
+	repo.saved = append(repo.saved, model.SavedMessage{UserID: 4, MessageID: 10})
+
+	_, pinnedIDs, err := svc.DeleteMessages(ctx, 3, 1, []uint{10}, true)
+	if err != nil {
+		t.Fatalf("DeleteMessages() error = %v", err)
+	}
+	if !slices.Equal(pinnedIDs, []uint{10}) || len(repo.pins) != 0 {
+		t.Errorf("pinnedIDs = %v, pins left %d", pinnedIDs, len(repo.pins))
+	}
+	if len(repo.saved) != 1 || repo.saved[0].OriginalDeletedAt == nil {
+		t.Errorf("saved copy = %+v, want it kept and marked deleted", repo.saved)
+	}
+}
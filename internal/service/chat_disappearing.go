@@ -0,0 +1,39 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"tush00nka/bbbab_messenger/internal/model"
+)
+
+// Допустимое время жизни исчезающих сообщений
+const (
+	MinMessageTTLSeconds = 5
+	MaxMessageTTLSeconds = 365 * 24 * 3600
+)
+
+// SetMessageTTL задает время жизни новых сообщений чата в секундах (0 — выключить).
+// Уже отправленные сообщения не меняются.
+func (s *chatService) SetMessageTTL(ctx context.Context, chatID uint, seconds int) error {
+	if chatID == 0 {
+		return errors.New("chatID cannot be zero")
+	}
+	if seconds != 0 && (seconds < MinMessageTTLSeconds || seconds > MaxMessageTTLSeconds) {
+		return fmt.Errorf("message timer must be 0 or between %d and %d seconds",
+			MinMessageTTLSeconds, MaxMessageTTLSeconds)
+	}
+
+	return s.chatRepo.UpdateMessageTTL(ctx, chatID, seconds)
+}
+
+// DeleteExpiredMessages удаляет до limit исчезающих сообщений с истекшим сроком и возвращает их
+func (s *chatService) DeleteExpiredMessages(ctx context.Context, limit int) ([]model.Message, error) {
+	return s.chatRepo.DeleteExpiredMessages(ctx, time.Now(), limit)
+}
+
+// IsAttachmentReferenced проверяет, используется ли вложение в оставшихся сообщениях
+func (s *chatService) IsAttachmentReferenced(ctx context.Context, attachmentURL string) (bool, error) {
+	return s.chatRepo.IsAttachmentReferenced(ctx, attachmentURL)
+}
@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+	"tush00nka/bbbab_messenger/internal/model"
+)
+
+func TestSetMessageTTL(t *testing.T) {
+	tests := []struct {
+		name    string
+		seconds int
+		wantErr bool
+	}{
+		{"disable", 0, false},
+		{"min", MinMessageTTLSeconds, false},
+		{"max", MaxMessageTTLSeconds, false},
+		{"too short", MinMessageTTLSeconds - 1, true},
+		{"too long", MaxMessageTTLSeconds + 1, true},
+		{"negative", -10, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newMemoryChatRepo()
+			repo.addDirect(1, 1, 2).MessageTTLSeconds = 60
+			svc := newTestChatService(repo)
+
+			err := svc.SetMessageTTL(context.Background(), 1, tt.seconds)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SetMessageTTL() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			want := 60
+			if !tt.wantErr {
+				want = tt.seconds
+			}
+			if got := repo.chats[1].MessageTTLSeconds; got != want {
+				t.Errorf("MessageTTLSeconds = %d, want %d", got, want)
+			}
+		})
+	}
+}
+
+func TestDisappearingMessagesExpire(t *testing.T) {
+	repo := newMemoryChatRepo()
+	repo.addDirect(1, 1, 2)
+	svc := newTestChatService(repo)
+	ctx := context.Background()
+
+	// Сообщение до включения таймера остается навсегда
+	if err := sendText(svc, 1, 1, "before"); err != nil {
+		t.Fatalf("send error = %v", err)
+	}
+	if err := svc.SetMessageTTL(ctx, 1, 60); err != nil {
+		t.Fatalf("SetMessageTTL() error = %v", err)
+	}
+
+	sent := &model.Message{SenderID: 2, Message: "secret"}
+	if err := svc.SendMessageToChat(ctx, repo.chats[1], sent); err != nil {
+		t.Fatalf("send error = %v", err)
+	}
+	if sent.ExpiresAt == nil || !sent.ExpiresAt.Equal(sent.CreatedAt.Add(time.Minute)) {
+		t.Fatalf("ExpiresAt = %v, want CreatedAt+1m", sent.ExpiresAt)
+	}
+	for _, m := range repo.messages {
+		if m.Message == "before" && m.ExpiresAt != nil {
+			t.Error("message sent before the timer got an expiry")
+		}
+	}
+
+	// До истечения срока ничего не удаляется
+	expired, err := svc.DeleteExpiredMessages(ctx, 100)
+	if err != nil || len(expired) != 0 {
+		t.Fatalf("DeleteExpiredMessages() = %d messages, error %v", len(expired), err)
+	}
+
+	past := time.Now().Add(-time.Second)
+	repo.messages[sent.ID].ExpiresAt = &past
+	expired, err = svc.DeleteExpiredMessages(ctx, 100)
+	if err != nil {
+		t.Fatalf("DeleteExpiredMessages() error = %v", err)
+	}
+	if len(expired) != 1 || expired[0].ID != sent.ID {
+		t.Fatalf("expired = %+v, want the secret message", expired)
+	}
+	if _, ok := repo.messages[sent.ID]; ok || len(repo.messages) != 1 {
+		t.Errorf("messages left = %d, want only the one sent before the timer", len(repo.messages))
+	}
+}
+
+func TestDisappearingMessagesLimit(t *testing.T) {
+	repo := newMemoryChatRepo()
+	repo.addDirect(1, 1, 2)
+	svc := newTestChatService(repo)
+
+	past := time.Now().Add(-time.Second)
+	for id := uint(1); id <= 5; id++ {
+		repo.addMessage(id, 1, 1, "old").ExpiresAt = &past
+	}
+
+	expired, err := svc.DeleteExpiredMessages(context.Background(), 3)
+	if err != nil {
+		t.Fatalf("DeleteExpiredMessages() error = %v", err)
+	}
+	if len(expired) != 3 || len(repo.messages) != 2 {
+		t.Errorf("deleted %d, left %d messages, want 3 and 2", len(expired), len(repo.messages))
+	}
+}
+
+func TestForwardIntoDisappearingChat(t *testing.T) {
+	repo := newMemoryChatRepo()
+	repo.addDirect(1, 1, 2)
+	repo.addDirect(2, 1, 3).MessageTTLSeconds = 30
+	repo.addMessage(10, 1, 2, "keep")
+	svc := newTestChatService(repo)
+
+	// Копия получает таймер чата, в который пересылается
+	created, err := svc.ForwardMessages(context.Background(), 1, []uint{10}, []uint{2})
+	if err != nil {
+		t.Fatalf("ForwardMessages() error = %v", err)
+	}
+	if len(created) != 1 || created[0].ExpiresAt == nil {
+		t.Fatalf("forwarded copy has no expiry: %+v", created)
+	}
+	if repo.messages[10].ExpiresAt != nil {
+		t.Error("original message got an expiry")
+	}
+}
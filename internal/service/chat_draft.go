@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+	"tush00nka/bbbab_messenger/internal/model"
+	"unicode/utf8"
+)
+
+// MaxDraftLength максимальная длина черновика в символах
+const MaxDraftLength = 5000
+
+// ErrDraftTooLong черновик превышает допустимую длину
+var ErrDraftTooLong = errors.New("draft is too long")
+
+// SaveDraft сохраняет черновик пользователя в чате. Черновик из одних пробелов удаляется,
+// в этом случае возвращается пустой черновик без даты изменения.
+func (s *chatService) SaveDraft(ctx context.Context, draft *model.ChatDraft) (*model.ChatDraft, error) {
+	if draft == nil || draft.UserID == 0 || draft.ChatID == 0 {
+		return nil, errors.New("userID and chatID cannot be zero")
+	}
+
+	inChat, err := s.chatRepo.IsUserInChat(ctx, draft.ChatID, draft.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if !inChat {
+		return nil, ErrNotChatMember
+	}
+
+	text, entities, err := normalizeMessageText(draft.Text, draft.Entities)
+	if err != nil {
+		return nil, err
+	}
+	if utf8.RuneCountInString(text) > MaxDraftLength {
+		return nil, ErrDraftTooLong
+	}
+
+	if text == "" && draft.ReplyToID == nil {
+		if _, err := s.chatRepo.DeleteDraft(ctx, draft.UserID, draft.ChatID); err != nil {
+			return nil, err
+		}
+		return &model.ChatDraft{UserID: draft.UserID, ChatID: draft.ChatID}, nil
+	}
+
+	draft.Text, draft.Entities = text, entities
+	draft.UpdatedAt = time.Now()
+	if err := s.chatRepo.SaveDraft(ctx, draft); err != nil {
+		return nil, err
+	}
+
+	return draft, nil
+}
+
+// GetDraft возвращает черновик пользователя в чате; без черновика — пустой
+func (s *chatService) GetDraft(ctx context.Context, userID, chatID uint) (*model.ChatDraft, error) {
+	if userID == 0 || chatID == 0 {
+		return nil, errors.New("userID and chatID cannot be zero")
+	}
+
+	draft, err := s.chatRepo.GetDraft(ctx, userID, chatID)
+	if err != nil {
+		return nil, err
+	}
+	if draft == nil {
+		return &model.ChatDraft{UserID: userID, ChatID: chatID}, nil
+	}
+
+	return draft, nil
+}
+
+// GetDraftsForUser возвращает черновики пользователя по ID чата
+func (s *chatService) GetDraftsForUser(ctx context.Context, userID uint) (map[uint]*model.ChatDraft, error) {
+	if userID == 0 {
+		return nil, errors.New("userID cannot be zero")
+	}
+
+	drafts, err := s.chatRepo.GetDraftsForUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	byChat := make(map[uint]*model.ChatDraft, len(drafts))
+	for i := range drafts {
+		byChat[drafts[i].ChatID] = &drafts[i]
+	}
+
+	return byChat, nil
+}
+
+// DeleteDraft удаляет черновик, например после отправки сообщения.
+// Возвращает false, если черновика не было.
+func (s *chatService) DeleteDraft(ctx context.Context, userID, chatID uint) (bool, error) {
+	if userID == 0 || chatID == 0 {
+		return false, errors.New("userID and chatID cannot be zero")
+	}
+
+	return s.chatRepo.DeleteDraft(ctx, userID, chatID)
+}
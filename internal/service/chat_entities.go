@@ -0,0 +1,175 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"tush00nka/bbbab_messenger/internal/model"
+	"unicode"
+	"unicode/utf16"
+)
+
+// Ограничения разметки
+const (
+	MaxMessageEntities = 100
+	MaxEntityURLLength = 2048
+)
+
+// ErrInvalidEntities ошибка разметки текста сообщения
+var ErrInvalidEntities = errors.New("invalid message entities")
+
+var entityTypes = map[string]bool{
+	model.EntityBold:          true,
+	model.EntityItalic:        true,
+	model.EntityUnderline:     true,
+	model.EntityStrikethrough: true,
+	model.EntityCode:          true,
+	model.EntityPre:           true,
+	model.EntityURL:           true,
+	model.EntityTextLink:      true,
+	model.EntityMention:       true,
+}
+
+// normalizeMessageText обрезает пробелы по краям текста, сдвигая разметку,
+// и проверяет разметку: известные типы, границы внутри текста, вложенность без пересечений.
+func normalizeMessageText(text string, entities model.MessageEntities) (string, model.MessageEntities, error) {
+	trimmed := strings.TrimLeftFunc(text, unicode.IsSpace)
+	shift := utf16Len(text[:len(text)-len(trimmed)])
+	trimmed = strings.TrimRightFunc(trimmed, unicode.IsSpace)
+
+	if len(entities) == 0 {
+		return trimmed, nil, nil
+	}
+	if len(entities) > MaxMessageEntities {
+		return "", nil, fmt.Errorf("%w: at most %d entities are allowed", ErrInvalidEntities, MaxMessageEntities)
+	}
+
+	units := utf16.Encode([]rune(trimmed))
+	result := make(model.MessageEntities, len(entities))
+	for i, e := range entities {
+		e.Type = strings.ToLower(strings.TrimSpace(e.Type))
+		e.Offset -= shift
+		// Получатель упоминания определяется только сервером
+		e.UserID = 0
+
+		if err := validateEntity(e, units); err != nil {
+			return "", nil, err
+		}
+		result[i] = e
+	}
+
+	sortEntities(result)
+
+	// Фрагменты могут быть вложены друг в друга, но не пересекаться частично.
+	// Внутри кода другая разметка не допускается.
+	var open []model.MessageEntity
+	for _, e := range result {
+		for len(open) > 0 && entityEnd(open[len(open)-1]) <= e.Offset {
+			open = open[:len(open)-1]
+		}
+		if len(open) > 0 {
+			parent := open[len(open)-1]
+			if entityEnd(e) > entityEnd(parent) {
+				return "", nil, fmt.Errorf("%w: entities must not overlap", ErrInvalidEntities)
+			}
+			if parent.Type == model.EntityCode || parent.Type == model.EntityPre {
+				return "", nil, fmt.Errorf("%w: code cannot contain other entities", ErrInvalidEntities)
+			}
+		}
+		open = append(open, e)
+	}
+
+	return trimmed, result, nil
+}
+
+// validateEntity проверяет отдельный фрагмент разметки
+func validateEntity(e model.MessageEntity, units []uint16) error {
+	if !entityTypes[e.Type] {
+		return fmt.Errorf("%w: unknown entity type %q", ErrInvalidEntities, e.Type)
+	}
+	if !entityInBounds(e, len(units)) {
+		return fmt.Errorf("%w: entity is out of text bounds", ErrInvalidEntities)
+	}
+	// Границы не должны разрезать суррогатную пару: половина символа при отображении ломается
+	if isLowSurrogate(units, e.Offset) || isLowSurrogate(units, e.Offset+e.Length) {
+		return fmt.Errorf("%w: entity must not split a character", ErrInvalidEntities)
+	}
+
+	fragment := string(utf16.Decode(units[e.Offset : e.Offset+e.Length]))
+
+	if e.Type == model.EntityTextLink {
+		if !isHTTPURL(e.URL) {
+			return fmt.Errorf("%w: text_link requires an http(s) url", ErrInvalidEntities)
+		}
+	} else if e.URL != "" {
+		return fmt.Errorf("%w: url is allowed only for text_link", ErrInvalidEntities)
+	}
+
+	if e.Language != "" && (e.Type != model.EntityPre || len(e.Language) > 32) {
+		return fmt.Errorf("%w: language is allowed only for pre", ErrInvalidEntities)
+	}
+
+	switch e.Type {
+	case model.EntityURL:
+		if strings.ContainsFunc(fragment, unicode.IsSpace) {
+			return fmt.Errorf("%w: url entity must not contain spaces", ErrInvalidEntities)
+		}
+	case model.EntityMention:
+		if len(fragment) < 2 || fragment[0] != '@' || strings.ContainsFunc(fragment, unicode.IsSpace) {
+			return fmt.Errorf("%w: mention must cover @username", ErrInvalidEntities)
+		}
+	}
+
+	return nil
+}
+
+// entityInBounds проверяет, что фрагмент непустой и лежит внутри текста из n кодовых единиц UTF-16.
+// Сумма Offset+Length не вычисляется: у присланных клиентом значений она может переполниться.
+func entityInBounds(e model.MessageEntity, n int) bool {
+	return e.Offset >= 0 && e.Length > 0 && e.Offset <= n && e.Length <= n-e.Offset
+}
+
+// isLowSurrogate сообщает, что на позиции i стоит вторая половина суррогатной пары
+func isLowSurrogate(units []uint16, i int) bool {
+	return i < len(units) && units[i] >= 0xDC00 && units[i] <= 0xDFFF
+}
+
+// isHTTPURL проверяет, что строка — абсолютная ссылка http или https
+func isHTTPURL(raw string) bool {
+	if raw == "" || len(raw) > MaxEntityURLLength {
+		return false
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return false
+	}
+
+	return (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// sortEntities упорядочивает разметку по началу фрагмента, внешние фрагменты раньше вложенных
+func sortEntities(entities model.MessageEntities) {
+	sort.SliceStable(entities, func(i, j int) bool {
+		if entities[i].Offset != entities[j].Offset {
+			return entities[i].Offset < entities[j].Offset
+		}
+		return entities[i].Length > entities[j].Length
+	})
+}
+
+// entityEnd возвращает позицию конца фрагмента
+func entityEnd(e model.MessageEntity) int {
+	return e.Offset + e.Length
+}
+
+// utf16Len возвращает длину строки в кодовых единицах UTF-16
+func utf16Len(s string) int {
+	n := 0
+	for _, r := range s {
+		n += utf16.RuneLen(r)
+	}
+	return n
+}
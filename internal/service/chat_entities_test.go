@@ -0,0 +1,158 @@
+package service
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"tush00nka/bbbab_messenger/internal/model"
+	"unicode/utf16"
+)
+
+func TestValidateEntity(t *testing.T) {
+	units := func(s string) []uint16 { return utf16.Encode([]rune(s)) }
+
+	tests := []struct {
+		name    string
+		text    string
+		entity  model.MessageEntity
+		wantErr bool
+	}{
+		{"whole text", "hello", model.MessageEntity{Type: model.EntityBold, Offset: 0, Length: 5}, false},
+		{"inside text", "hello world", model.MessageEntity{Type: model.EntityItalic, Offset: 6, Length: 5}, false},
+		{"unknown type", "hello", model.MessageEntity{Type: "blink", Offset: 0, Length: 5}, true},
+		{"negative offset", "hello", model.MessageEntity{Type: model.EntityBold, Offset: -1, Length: 2}, true},
+		{"zero length", "hello", model.MessageEntity{Type: model.EntityBold, Offset: 0, Length: 0}, true},
+		{"past end", "hello", model.MessageEntity{Type: model.EntityBold, Offset: 3, Length: 3}, true},
+		{"offset past end", "hello", model.MessageEntity{Type: model.EntityBold, Offset: 6, Length: 1}, true},
+		{"offset overflow", "hello", model.MessageEntity{Type: model.EntityBold, Offset: math.MaxInt, Length: 1}, true},
+		{"length overflow", "hello", model.MessageEntity{Type: model.EntityBold, Offset: 1, Length: math.MaxInt}, true},
+		{"emoji as surrogate pair", "a😀b", model.MessageEntity{Type: model.EntityBold, Offset: 1, Length: 2}, false},
+		{"starts inside surrogate pair", "a😀b", model.MessageEntity{Type: model.EntityBold, Offset: 2, Length: 2}, true},
+		{"ends inside surrogate pair", "a😀b", model.MessageEntity{Type: model.EntityBold, Offset: 0, Length: 2}, true},
+		{"text_link with https", "site", model.MessageEntity{Type: model.EntityTextLink, Offset: 0, Length: 4, URL: "https://example.com"}, false},
+		{"text_link with javascript", "site", model.MessageEntity{Type: model.EntityTextLink, Offset: 0, Length: 4, URL: "javascript:alert(1)"}, true},
+		{"url on bold", "site", model.MessageEntity{Type: model.EntityBold, Offset: 0, Length: 4, URL: "https://example.com"}, true},
+		{"url with space", "a b", model.MessageEntity{Type: model.EntityURL, Offset: 0, Length: 3}, true},
+		{"mention", "hi @bob", model.MessageEntity{Type: model.EntityMention, Offset: 3, Length: 4}, false},
+		{"mention without @", "hi @bob", model.MessageEntity{Type: model.EntityMention, Offset: 4, Length: 3}, true},
+		{"language on code", "x", model.MessageEntity{Type: model.EntityCode, Offset: 0, Length: 1, Language: "go"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateEntity(tt.entity, units(tt.text))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validateEntity() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidEntities) {
+				t.Errorf("validateEntity() error = %v, want ErrInvalidEntities", err)
+			}
+		})
+	}
+}
+
+func TestNormalizeMessageText(t *testing.T) {
+	tests := []struct {
+		name         string
+		text         string
+		entities     model.MessageEntities
+		wantText     string
+		wantEntities model.MessageEntities
+		wantErr      bool
+	}{
+		{
+			name:     "trims without entities",
+			text:     "  hello \n",
+			wantText: "hello",
+		},
+		{
+			name:         "shifts entities after trimming",
+			text:         "  hello world",
+			entities:     model.MessageEntities{{Type: "BOLD", Offset: 8, Length: 5}},
+			wantText:     "hello world",
+			wantEntities: model.MessageEntities{{Type: model.EntityBold, Offset: 6, Length: 5}},
+		},
+		{
+			name: "sorts outer before nested",
+			text: "hello world",
+			entities: model.MessageEntities{
+				{Type: model.EntityItalic, Offset: 0, Length: 5},
+				{Type: model.EntityBold, Offset: 0, Length: 11},
+			},
+			wantText: "hello world",
+			wantEntities: model.MessageEntities{
+				{Type: model.EntityBold, Offset: 0, Length: 11},
+				{Type: model.EntityItalic, Offset: 0, Length: 5},
+			},
+		},
+		{
+			name:         "clears mention user id",
+			text:         "@bob",
+			entities:     model.MessageEntities{{Type: model.EntityMention, Offset: 0, Length: 4, UserID: 7}},
+			wantText:     "@bob",
+			wantEntities: model.MessageEntities{{Type: model.EntityMention, Offset: 0, Length: 4}},
+		},
+		{
+			name: "rejects partial overlap",
+			text: "hello world",
+			entities: model.MessageEntities{
+				{Type: model.EntityBold, Offset: 0, Length: 7},
+				{Type: model.EntityItalic, Offset: 5, Length: 6},
+			},
+			wantErr: true,
+		},
+		{
+			name: "rejects entities inside code",
+			text: "hello world",
+			entities: model.MessageEntities{
+				{Type: model.EntityCode, Offset: 0, Length: 11},
+				{Type: model.EntityBold, Offset: 0, Length: 5},
+			},
+			wantErr: true,
+		},
+		{
+			name:     "rejects entity cut off by trimming",
+			text:     "hello   ",
+			entities: model.MessageEntities{{Type: model.EntityBold, Offset: 0, Length: 8}},
+			wantErr:  true,
+		},
+		{
+			name:     "rejects overflowing offset",
+			text:     "hello",
+			entities: model.MessageEntities{{Type: model.EntityBold, Offset: math.MaxInt, Length: 1}},
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text, entities, err := normalizeMessageText(tt.text, tt.entities)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("normalizeMessageText() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if text != tt.wantText {
+				t.Errorf("text = %q, want %q", text, tt.wantText)
+			}
+			if len(entities) != len(tt.wantEntities) {
+				t.Fatalf("entities = %+v, want %+v", entities, tt.wantEntities)
+			}
+			for i := range entities {
+				got, want := entities[i], tt.wantEntities[i]
+				if got.Type != want.Type || got.Offset != want.Offset || got.Length != want.Length || got.UserID != want.UserID {
+					t.Errorf("entities[%d] = %+v, want %+v", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestFirstURLIgnoresOutOfRangeEntities(t *testing.T) {
+	entities := model.MessageEntities{{Type: model.EntityURL, Offset: math.MaxInt, Length: 1}}
+
+	if got := firstURL("see https://example.com", entities); got != "https://example.com" {
+		t.Errorf("firstURL() = %q, want https://example.com", got)
+	}
+}
@@ -0,0 +1,213 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"tush00nka/bbbab_messenger/internal/model"
+)
+
+// Ограничения пересылки
+const (
+	MaxForwardMessages = 100
+	MaxForwardTargets  = 20
+)
+
+// Ошибки пересылки сообщений
+var (
+	ErrNothingToForward    = errors.New("no messages to forward")
+	ErrTooManyForwarded    = errors.New("too many messages to forward")
+	ErrTooManyTargets      = errors.New("too many target chats")
+	ErrForwardSourceDenied = errors.New("message not found or not accessible")
+	ErrForwardTargetDenied = errors.New("user is not a member of the target chat")
+)
+
+// ForwardMessages копирует сообщения в целевые чаты от имени userID.
+// Порядок сообщений сохраняется, для каждого целевого чата возвращаются созданные копии.
+// Копии сохраняются в одной транзакции: при ошибке не создается ни одной.
+func (s *chatService) ForwardMessages(ctx context.Context, userID uint, messageIDs, targetChatIDs []uint) ([]model.Message, error) {
+	if userID == 0 {
+		return nil, errors.New("userID cannot be zero")
+	}
+
+	messageIDs = uniqueIDs(messageIDs)
+	targetChatIDs = uniqueIDs(targetChatIDs)
+
+	if len(messageIDs) == 0 || len(targetChatIDs) == 0 {
+		return nil, ErrNothingToForward
+	}
+	if len(messageIDs) > MaxForwardMessages {
+		return nil, ErrTooManyForwarded
+	}
+	if len(targetChatIDs) > MaxForwardTargets {
+		return nil, ErrTooManyTargets
+	}
+
+	sources, err := s.chatRepo.GetMessagesByIDs(ctx, messageIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[uint]model.Message, len(sources))
+	for _, m := range sources {
+		byID[m.ID] = m
+	}
+
+	// Пользователь должен состоять во всех исходных чатах и видеть сообщения:
+	// удаленные «у себя» и скрытые очисткой истории переслать нельзя
+	ordered := make([]model.Message, 0, len(messageIDs))
+	visible := make(map[uint]func(uint) bool)
+	for _, id := range messageIDs {
+		m, ok := byID[id]
+		if !ok {
+			return nil, ErrForwardSourceDenied
+		}
+		isVisible, ok := visible[m.ChatID]
+		if !ok {
+			if isVisible, err = s.visibleMessagesFilter(ctx, m.ChatID, userID); err != nil {
+				return nil, err
+			}
+			visible[m.ChatID] = isVisible
+		}
+		if !isVisible(m.ID) {
+			return nil, ErrForwardSourceDenied
+		}
+		ordered = append(ordered, m)
+	}
+
+	for _, chatID := range targetChatIDs {
+		inChat, err := s.chatRepo.IsUserInChat(ctx, chatID, userID)
+		if err != nil {
+			return nil, err
+		}
+		if !inChat {
+			return nil, ErrForwardTargetDenied
+		}
+	}
+
+	created := make([]model.Message, 0, len(ordered)*len(targetChatIDs))
+	for _, chatID := range targetChatIDs {
+		// Пачка пересланных сообщений считается одной отправкой для медленного режима
+		chat, err := s.checkCanSend(ctx, chatID, userID)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, src := range ordered {
+			copied := newForwardedMessage(src, chatID, userID)
+			if copied.Poll != nil && !chat.IsGroup {
+				return nil, ErrPollGroupOnly
+			}
+			prepareMessage(chat, &copied)
+			created = append(created, copied)
+		}
+	}
+
+	if err := s.chatRepo.SendMessages(ctx, created); err != nil {
+		return nil, err
+	}
+
+	return created, nil
+}
+
+// visibleMessagesFilter возвращает проверку, видит ли участник сообщение чата.
+// Не участнику чата не видно ничего.
+func (s *chatService) visibleMessagesFilter(ctx context.Context, chatID, userID uint) (func(uint) bool, error) {
+	inChat, err := s.chatRepo.IsUserInChat(ctx, chatID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !inChat {
+		return func(uint) bool { return false }, nil
+	}
+
+	clearedUpTo, err := s.chatRepo.GetHistoryClearedUpTo(ctx, chatID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	hiddenIDs, err := s.chatRepo.GetHiddenMessageIDs(ctx, userID, chatID)
+	if err != nil {
+		return nil, err
+	}
+	hidden := make(map[uint]bool, len(hiddenIDs))
+	for _, id := range hiddenIDs {
+		hidden[id] = true
+	}
+
+	return func(messageID uint) bool {
+		return messageID > clearedUpTo && !hidden[messageID]
+	}, nil
+}
+
+// newForwardedMessage создает копию сообщения для целевого чата.
+// При повторной пересылке сохраняется исходный источник.
+func newForwardedMessage(src model.Message, chatID, senderID uint) model.Message {
+	copied := model.Message{
+		ChatID:        chatID,
+		SenderID:      senderID,
+		Message:       src.Message,
+		Entities:      src.Entities,
+		Type:          src.Type,
+		AttachmentURL: src.AttachmentURL,
+		LinkPreview:   src.LinkPreview,
+		IsForwarded:   true,
+	}
+
+	// Опрос пересылается без голосов и срока: в целевом чате голосование идет заново
+	if src.Poll != nil {
+		anonymous := src.Poll.IsAnonymous()
+		copied.Poll = &model.Poll{
+			ChatID:         chatID,
+			Question:       src.Poll.Question,
+			MultipleChoice: src.Poll.MultipleChoice,
+			Anonymous:      &anonymous,
+		}
+		for _, option := range src.Poll.Options {
+			copied.Poll.Options = append(copied.Poll.Options, model.PollOption{
+				Position: option.Position,
+				Text:     option.Text,
+			})
+		}
+	}
+
+	if src.IsForwarded {
+		copied.ForwardedFromUserID = src.ForwardedFromUserID
+		copied.ForwardedFromChatID = src.ForwardedFromChatID
+		copied.ForwardedFromMessageID = src.ForwardedFromMessageID
+		copied.ForwardedFromName = src.ForwardedFromName
+		return copied
+	}
+
+	name := src.Sender.DisplayName
+	if name == "" {
+		name = src.Sender.Username
+	}
+	copied.ForwardedFromName = name
+
+	// Автор скрыл источник: оставляем только имя без ссылок на профиль и чат
+	if src.Sender.HideForwardOrigin {
+		return copied
+	}
+
+	originUserID, originChatID, originMessageID := src.SenderID, src.ChatID, src.ID
+	copied.ForwardedFromUserID = &originUserID
+	copied.ForwardedFromChatID = &originChatID
+	copied.ForwardedFromMessageID = &originMessageID
+
+	return copied
+}
+
+// uniqueIDs убирает нулевые и повторяющиеся ID, сохраняя порядок
+func uniqueIDs(ids []uint) []uint {
+	seen := make(map[uint]bool, len(ids))
+	result := make([]uint, 0, len(ids))
+	for _, id := range ids {
+		if id == 0 || seen[id] {
+			continue
+		}
+		seen[id] = true
+		result = append(result, id)
+	}
+
+	return result
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"tush00nka/bbbab_messenger/internal/model"
+)
+
+func newForwardFixture() *memoryChatRepo {
+	repo := newMemoryChatRepo()
+	repo.addGroup(1, 1, 2)
+	repo.addGroup(2, 2)
+	repo.addGroup(3, 1)
+	repo.addGroup(4, 1)
+
+	repo.addMessage(10, 1, 2, "old")
+	repo.addMessage(11, 1, 2, "hidden")
+	repo.addMessage(12, 1, 2, "visible")
+	repo.addMessage(13, 1, 2, "second")
+	repo.addMessage(20, 2, 2, "foreign")
+
+	repo.hidden = append(repo.hidden, model.HiddenMessage{UserID: 1, ChatID: 1, MessageID: 11})
+	repo.member(1, 1).HistoryClearedUpToID = 10
+	return repo
+}
+
+func TestForwardMessages(t *testing.T) {
+	repo := newForwardFixture()
+	svc := NewChatService(repo)
+
+	created, err := svc.ForwardMessages(context.Background(), 1, []uint{13, 12}, []uint{3, 4})
+	if err != nil {
+		t.Fatalf("ForwardMessages() error = %v", err)
+	}
+
+	want := []struct {
+		chatID uint
+		text   string
+	}{{3, "second"}, {3, "visible"}, {4, "second"}, {4, "visible"}}
+	if len(created) != len(want) || len(repo.messages) != 5+len(want) {
+		t.Fatalf("created %d, stored %d messages, want %d new", len(created), len(repo.messages), len(want))
+	}
+	for i, w := range want {
+		m := created[i]
+		if m.ChatID != w.chatID || m.Message != w.text || !m.IsForwarded || m.SenderID != 1 || m.ID == 0 {
+			t.Errorf("created[%d] = chat %d %q forwarded=%v sender=%d id=%d, want chat %d %q",
+				i, m.ChatID, m.Message, m.IsForwarded, m.SenderID, m.ID, w.chatID, w.text)
+		}
+	}
+}
+
+func TestForwardMessagesDenied(t *testing.T) {
+	tests := []struct {
+		name       string
+		messageIDs []uint
+		targetIDs  []uint
+		wantErr    error
+	}{
+		{"deleted for me", []uint{12, 11}, []uint{3}, ErrForwardSourceDenied},
+		{"history cleared", []uint{10}, []uint{3}, ErrForwardSourceDenied},
+		{"not a member of source", []uint{20}, []uint{3}, ErrForwardSourceDenied},
+		{"missing message", []uint{99}, []uint{3}, ErrForwardSourceDenied},
+		{"not a member of target", []uint{12}, []uint{3, 2}, ErrForwardTargetDenied},
+		{"nothing to forward", nil, []uint{3}, ErrNothingToForward},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newForwardFixture()
+			svc := NewChatService(repo)
+
+			created, err := svc.ForwardMessages(context.Background(), 1, tt.messageIDs, tt.targetIDs)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ForwardMessages() error = %v, want %v", err, tt.wantErr)
+			}
+			if created != nil || len(repo.messages) != 5 {
+				t.Errorf("created %d, stored %d messages on error", len(created), len(repo.messages))
+			}
+		})
+	}
+}
+
+func TestForwardMessagesSaveFailure(t *testing.T) {
+	repo := newForwardFixture()
+	repo.sendErr = errors.New("db is down")
+	svc := NewChatService(repo)
+
+	created, err := svc.ForwardMessages(context.Background(), 1, []uint{12}, []uint{3, 4})
+	if !errors.Is(err, repo.sendErr) {
+		t.Fatalf("ForwardMessages() error = %v, want save error", err)
+	}
+	if created != nil {
+		t.Errorf("ForwardMessages() returned %d messages with an error", len(created))
+	}
+}
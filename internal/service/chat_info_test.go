@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestUpdateGroupInfo(t *testing.T) {
+	tests := []struct {
+		name            string
+		inName          string
+		inDescription   string
+		wantErr         bool
+		wantName        string
+		wantDescription string
+	}{
+		{"trimmed", "  Team  ", "  about us \n", false, "Team", "about us"},
+		{"empty description", "Team", "", false, "Team", ""},
+		{"blank name", "   ", "about", true, "chat", ""},
+		// Длина считается в символах, а не в байтах
+		{"cyrillic at limit", "Команда", strings.Repeat("я", MaxChatDescriptionLength), false, "Команда", strings.Repeat("я", MaxChatDescriptionLength)},
+		{"too long", "Team", strings.Repeat("a", MaxChatDescriptionLength+1), true, "chat", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newMemoryChatRepo()
+			repo.addGroup(1, 1)
+			svc := newTestChatService(repo)
+
+			err := svc.UpdateGroupInfo(context.Background(), 1, tt.inName, tt.inDescription)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("UpdateGroupInfo() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			chat := repo.chats[1]
+			if chat.Name != tt.wantName || chat.Description != tt.wantDescription {
+				t.Errorf("chat = %q / %q, want %q / %q", chat.Name, chat.Description, tt.wantName, tt.wantDescription)
+			}
+		})
+	}
+}
+
+func TestUpdateChatAvatar(t *testing.T) {
+	repo := newMemoryChatRepo()
+	repo.addGroup(1, 1)
+	svc := newTestChatService(repo)
+
+	if err := svc.UpdateChatAvatar(context.Background(), 1, "chat_avatars/1/a.png"); err != nil {
+		t.Fatalf("UpdateChatAvatar() error = %v", err)
+	}
+	if got := repo.chats[1].AvatarKey; got != "chat_avatars/1/a.png" {
+		t.Errorf("AvatarKey = %q", got)
+	}
+
+	// Пустой ключ снимает аватар
+	if err := svc.UpdateChatAvatar(context.Background(), 1, ""); err != nil {
+		t.Fatalf("UpdateChatAvatar(\"\") error = %v", err)
+	}
+	if got := repo.chats[1].AvatarKey; got != "" {
+		t.Errorf("AvatarKey after removal = %q", got)
+	}
+
+	if err := svc.UpdateChatAvatar(context.Background(), 0, "key"); err == nil {
+		t.Error("UpdateChatAvatar() with zero chatID succeeded")
+	}
+}
@@ -0,0 +1,437 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+	"tush00nka/bbbab_messenger/internal/model"
+	"unicode/utf8"
+)
+
+// Ошибки пригласительных ссылок
+var (
+	ErrInviteNotFound      = errors.New("invite not found")
+	ErrInviteInvalid       = errors.New("invite is expired, revoked or exhausted")
+	ErrAlreadyMember       = errors.New("user is already a member of this chat")
+	ErrJoinRequestNotFound = errors.New("join request not found")
+	ErrJoinRequestResolved = errors.New("join request has already been resolved")
+	// ErrGroupNotFound группа не существует или не принимает заявки без ссылки;
+	// причины не различаются, чтобы по ответу нельзя было перебирать закрытые группы
+	ErrGroupNotFound = errors.New("group chat not found")
+)
+
+const (
+	inviteTokenBytes            = 16
+	maxJoinRequestMessageLength = 500
+)
+
+// JoinRequestCooldown время после отклонения заявки, в течение которого новую подать нельзя
+const JoinRequestCooldown = 24 * time.Hour
+
+// JoinRequestNotifyInterval окно, в котором администраторы получают о новых заявках
+// не больше одного уведомления: остальные заявки видны в списке
+const JoinRequestNotifyInterval = 5 * time.Minute
+
+// JoinRequestCooldownError ошибка повторной заявки раньше, чем истек JoinRequestCooldown
+type JoinRequestCooldownError struct {
+	RetryAfter time.Duration
+}
+
+func (e *JoinRequestCooldownError) Error() string {
+	return fmt.Sprintf("join request was rejected recently, retry in %d seconds", e.RetryAfterSeconds())
+}
+
+// RetryAfterSeconds оставшееся время ожидания в секундах с округлением вверх
+func (e *JoinRequestCooldownError) RetryAfterSeconds() int {
+	return int(math.Ceil(e.RetryAfter.Seconds()))
+}
+
+// MaxInviteTTL наибольший срок действия пригласительной ссылки; дольше — только бессрочно
+const MaxInviteTTL = 366 * 24 * time.Hour
+
+// generateInviteToken генерирует случайный URL-безопасный токен
+func generateInviteToken() (string, error) {
+	buf := make([]byte, inviteTokenBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate invite token: %w", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// CreateInvite создает пригласительную ссылку в групповой чат
+func (s *chatService) CreateInvite(
+	ctx context.Context,
+	chatID, creatorID uint,
+	ttl time.Duration,
+	maxUses int,
+	requiresApproval bool,
+) (*model.ChatInvite, error) {
+	if chatID == 0 || creatorID == 0 {
+		return nil, errors.New("chatID and creatorID cannot be zero")
+	}
+	if ttl < 0 || ttl > MaxInviteTTL {
+		return nil, fmt.Errorf("ttl must be between 0 and %d seconds", int64(MaxInviteTTL/time.Second))
+	}
+	if maxUses < 0 {
+		return nil, errors.New("maxUses cannot be negative")
+	}
+
+	chat, err := s.chatRepo.GetByID(ctx, chatID)
+	if err != nil {
+		return nil, err
+	}
+	if chat == nil {
+		return nil, errors.New("chat not found")
+	}
+	if !chat.IsGroup {
+		return nil, errors.New("invites are only available for group chats")
+	}
+
+	token, err := generateInviteToken()
+	if err != nil {
+		return nil, err
+	}
+
+	invite := &model.ChatInvite{
+		ChatID:           chatID,
+		Token:            token,
+		CreatedByID:      creatorID,
+		MaxUses:          maxUses,
+		RequiresApproval: requiresApproval,
+	}
+	if ttl > 0 {
+		expiresAt := time.Now().Add(ttl)
+		invite.ExpiresAt = &expiresAt
+	}
+
+	if err := s.chatRepo.CreateInvite(ctx, invite); err != nil {
+		return nil, err
+	}
+
+	return invite, nil
+}
+
+// GetChatInvites возвращает пригласительные ссылки чата
+func (s *chatService) GetChatInvites(ctx context.Context, chatID uint) ([]model.ChatInvite, error) {
+	if chatID == 0 {
+		return nil, errors.New("chatID cannot be zero")
+	}
+
+	return s.chatRepo.GetChatInvites(ctx, chatID)
+}
+
+// RevokeInvite отзывает пригласительную ссылку чата
+func (s *chatService) RevokeInvite(ctx context.Context, chatID, inviteID uint) error {
+	if chatID == 0 || inviteID == 0 {
+		return errors.New("chatID and inviteID cannot be zero")
+	}
+
+	invite, err := s.chatRepo.GetInviteByID(ctx, inviteID)
+	if err != nil {
+		return err
+	}
+	if invite == nil || invite.ChatID != chatID {
+		return ErrInviteNotFound
+	}
+
+	return s.chatRepo.RevokeInvite(ctx, inviteID)
+}
+
+// JoinChatByInvite добавляет пользователя в чат по пригласительной ссылке.
+// Если ссылка требует одобрения, вместо вступления создается заявка и она возвращается вторым значением;
+// третье значение сообщает, нужно ли уведомить о ней администраторов (см. JoinRequestNotifyInterval).
+func (s *chatService) JoinChatByInvite(ctx context.Context, token string, userID uint) (
+	*model.ChatInvite, *model.ChatJoinRequest, bool, error) {
+	if userID == 0 {
+		return nil, nil, false, errors.New("userID cannot be zero")
+	}
+
+	invite, err := s.chatRepo.GetInviteByToken(ctx, token)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	if invite == nil {
+		return nil, nil, false, ErrInviteNotFound
+	}
+	if !invite.IsUsable(time.Now()) {
+		return nil, nil, false, ErrInviteInvalid
+	}
+
+	isMember, err := s.chatRepo.IsUserInChat(ctx, invite.ChatID, userID)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	if isMember {
+		return invite, nil, false, ErrAlreadyMember
+	}
+
+	if err := s.ensureNotBanned(ctx, invite.ChatID, userID); err != nil {
+		return nil, nil, false, err
+	}
+
+	if invite.RequiresApproval {
+		// Повторная заявка по той же ссылке не расходует лимит и не беспокоит администраторов
+		pending, err := s.chatRepo.GetPendingJoinRequest(ctx, invite.ChatID, userID)
+		if err != nil {
+			return nil, nil, false, err
+		}
+		if pending != nil {
+			return invite, pending, false, nil
+		}
+
+		if err := s.checkJoinRequestCooldown(ctx, invite.ChatID, userID); err != nil {
+			return nil, nil, false, err
+		}
+	}
+
+	// Использование ссылки расходуется в одной транзакции со вступлением или подачей заявки,
+	// поэтому неудачная попытка не съедает лимит.
+	// Для ссылок с одобрением использованием считается подача заявки.
+	if invite.RequiresApproval {
+		request := &model.ChatJoinRequest{
+			ChatID:   invite.ChatID,
+			UserID:   userID,
+			InviteID: &invite.ID,
+			Status:   model.JoinRequestPending,
+		}
+		ok, err := s.chatRepo.CreateJoinRequestByInvite(ctx, request)
+		if err != nil {
+			return nil, nil, false, err
+		}
+		if !ok {
+			return nil, nil, false, ErrInviteInvalid
+		}
+		return invite, request, s.isFirstRecentJoinRequest(ctx, invite.ChatID), nil
+	}
+
+	ok, err := s.chatRepo.JoinByInvite(ctx, invite.ID, userID)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	if !ok {
+		return nil, nil, false, ErrInviteInvalid
+	}
+
+	return invite, nil, false, nil
+}
+
+// RequestToJoin создает заявку на вступление в групповой чат без пригласительной ссылки.
+// Заявки принимают только группы с включенным JoinByRequest, остальные для пользователя
+// не существуют. Второе значение сообщает, нужно ли уведомить администраторов о заявке.
+func (s *chatService) RequestToJoin(ctx context.Context, chatID, userID uint, message string) (
+	*model.ChatJoinRequest, bool, error) {
+	if chatID == 0 || userID == 0 {
+		return nil, false, errors.New("chatID and userID cannot be zero")
+	}
+
+	message = strings.TrimSpace(message)
+	if utf8.RuneCountInString(message) > maxJoinRequestMessageLength {
+		return nil, false, fmt.Errorf("message too long (max %d characters)", maxJoinRequestMessageLength)
+	}
+
+	chat, err := s.chatRepo.GetMeta(ctx, chatID)
+	if err != nil {
+		return nil, false, err
+	}
+	if chat == nil || !chat.IsGroup {
+		return nil, false, ErrGroupNotFound
+	}
+
+	isMember, err := s.chatRepo.IsUserInChat(ctx, chatID, userID)
+	if err != nil {
+		return nil, false, err
+	}
+	if isMember {
+		return nil, false, ErrAlreadyMember
+	}
+
+	if !chat.JoinByRequest {
+		return nil, false, ErrGroupNotFound
+	}
+
+	if err := s.ensureNotBanned(ctx, chatID, userID); err != nil {
+		return nil, false, err
+	}
+
+	// Повторная заявка возвращает уже поданную и не беспокоит администраторов
+	pending, err := s.chatRepo.GetPendingJoinRequest(ctx, chatID, userID)
+	if err != nil {
+		return nil, false, err
+	}
+	if pending != nil {
+		return pending, false, nil
+	}
+
+	if err := s.checkJoinRequestCooldown(ctx, chatID, userID); err != nil {
+		return nil, false, err
+	}
+
+	request := &model.ChatJoinRequest{
+		ChatID:  chatID,
+		UserID:  userID,
+		Message: message,
+		Status:  model.JoinRequestPending,
+	}
+	if err := s.chatRepo.CreateJoinRequest(ctx, request); err != nil {
+		return nil, false, err
+	}
+
+	return request, s.isFirstRecentJoinRequest(ctx, chatID), nil
+}
+
+// SetJoinByRequest включает или выключает подачу заявок в группу без пригласительной ссылки
+func (s *chatService) SetJoinByRequest(ctx context.Context, chatID uint, enabled bool) error {
+	if chatID == 0 {
+		return errors.New("chatID cannot be zero")
+	}
+
+	chat, err := s.chatRepo.GetMeta(ctx, chatID)
+	if err != nil {
+		return err
+	}
+	if chat == nil || !chat.IsGroup {
+		return errors.New("join requests are only available for group chats")
+	}
+
+	return s.chatRepo.UpdateJoinByRequest(ctx, chatID, enabled)
+}
+
+// checkJoinRequestCooldown запрещает новую заявку, пока не истек JoinRequestCooldown после отклонения
+func (s *chatService) checkJoinRequestCooldown(ctx context.Context, chatID, userID uint) error {
+	rejected, err := s.chatRepo.GetLastRejectedJoinRequest(ctx, chatID, userID)
+	if err != nil {
+		return err
+	}
+	if rejected == nil || rejected.ReviewedAt == nil {
+		return nil
+	}
+
+	if wait := time.Until(rejected.ReviewedAt.Add(JoinRequestCooldown)); wait > 0 {
+		return &JoinRequestCooldownError{RetryAfter: wait}
+	}
+
+	return nil
+}
+
+// isFirstRecentJoinRequest проверяет, что только что поданная заявка — единственная
+// необработанная за JoinRequestNotifyInterval: тогда администраторов нужно уведомить.
+// При ошибке уведомление отправляется, чтобы заявка не осталась незамеченной.
+func (s *chatService) isFirstRecentJoinRequest(ctx context.Context, chatID uint) bool {
+	count, err := s.chatRepo.CountPendingJoinRequestsSince(ctx, chatID, time.Now().Add(-JoinRequestNotifyInterval))
+	if err != nil {
+		return true
+	}
+
+	return count <= 1
+}
+
+// GetJoinRequests возвращает заявки на вступление в чат
+func (s *chatService) GetJoinRequests(ctx context.Context, chatID uint, status string) ([]model.ChatJoinRequest, error) {
+	if chatID == 0 {
+		return nil, errors.New("chatID cannot be zero")
+	}
+
+	switch status {
+	case "", model.JoinRequestPending, model.JoinRequestApproved, model.JoinRequestRejected:
+	default:
+		return nil, fmt.Errorf("unknown join request status: %s", status)
+	}
+
+	return s.chatRepo.GetJoinRequests(ctx, chatID, status)
+}
+
+// ApproveJoinRequest одобряет заявку и добавляет пользователя в чат
+func (s *chatService) ApproveJoinRequest(ctx context.Context, chatID, requestID, reviewerID uint) (*model.ChatJoinRequest, error) {
+	request, err := s.getPendingJoinRequest(ctx, chatID, requestID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Заблокированного после подачи заявки пользователя добавить нельзя
+	if err := s.ensureNotBanned(ctx, chatID, request.UserID); err != nil {
+		return nil, err
+	}
+
+	ok, err := s.chatRepo.ApproveJoinRequest(ctx, request.ID, reviewerID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrJoinRequestResolved
+	}
+
+	return markJoinRequestResolved(request, reviewerID, model.JoinRequestApproved), nil
+}
+
+// RejectJoinRequest отклоняет заявку на вступление
+func (s *chatService) RejectJoinRequest(ctx context.Context, chatID, requestID, reviewerID uint) (*model.ChatJoinRequest, error) {
+	request, err := s.getPendingJoinRequest(ctx, chatID, requestID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.resolveJoinRequest(ctx, request, reviewerID, model.JoinRequestRejected)
+}
+
+// GetChatAdminIDs возвращает ID владельца и администраторов чата
+func (s *chatService) GetChatAdminIDs(ctx context.Context, chatID uint) ([]uint, error) {
+	if chatID == 0 {
+		return nil, errors.New("chatID cannot be zero")
+	}
+
+	return s.chatRepo.GetChatAdminIDs(ctx, chatID)
+}
+
+// getPendingJoinRequest загружает заявку и проверяет, что она относится к чату и еще не обработана
+func (s *chatService) getPendingJoinRequest(ctx context.Context, chatID, requestID uint) (*model.ChatJoinRequest, error) {
+	if chatID == 0 || requestID == 0 {
+		return nil, errors.New("chatID and requestID cannot be zero")
+	}
+
+	request, err := s.chatRepo.GetJoinRequestByID(ctx, requestID)
+	if err != nil {
+		return nil, err
+	}
+	if request == nil || request.ChatID != chatID {
+		return nil, ErrJoinRequestNotFound
+	}
+	if request.Status != model.JoinRequestPending {
+		return nil, ErrJoinRequestResolved
+	}
+
+	return request, nil
+}
+
+// resolveJoinRequest фиксирует решение по заявке
+func (s *chatService) resolveJoinRequest(
+	ctx context.Context,
+	request *model.ChatJoinRequest,
+	reviewerID uint,
+	status string,
+) (*model.ChatJoinRequest, error) {
+	ok, err := s.chatRepo.ResolveJoinRequest(ctx, request.ID, reviewerID, status)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrJoinRequestResolved
+	}
+
+	return markJoinRequestResolved(request, reviewerID, status), nil
+}
+
+// markJoinRequestResolved отражает в загруженной заявке сохраненное решение
+func markJoinRequestResolved(request *model.ChatJoinRequest, reviewerID uint, status string) *model.ChatJoinRequest {
+	now := time.Now()
+	request.Status = status
+	request.ReviewedByID = &reviewerID
+	request.ReviewedAt = &now
+
+	return request
+}
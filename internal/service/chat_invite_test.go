@@ -0,0 +1,162 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+	"tush00nka/bbbab_messenger/internal/model"
+)
+
+// newJoinRequestFixture: группа 1 принимает заявки (владелец 1, участник 2), группа 2 — нет
+func newJoinRequestFixture() (*memoryChatRepo, *chatService) {
+	repo := newMemoryChatRepo()
+	repo.addGroup(1, 1, 2).JoinByRequest = true
+	repo.addGroup(2, 1)
+	repo.addDirect(3, 1, 2)
+	return repo, newTestChatService(repo)
+}
+
+func TestRequestToJoin(t *testing.T) {
+	tests := []struct {
+		name    string
+		chatID  uint
+		userID  uint
+		message string
+		wantErr error
+	}{
+		{"new request", 1, 5, "  hi  ", nil},
+		{"cyrillic message at limit", 1, 5, strings.Repeat("я", maxJoinRequestMessageLength), nil},
+		{"message too long", 1, 5, strings.Repeat("a", maxJoinRequestMessageLength+1), errAny},
+		{"already a member", 1, 2, "", ErrAlreadyMember},
+		// Закрытая группа и личный чат неотличимы от несуществующей группы
+		{"requests disabled", 2, 5, "", ErrGroupNotFound},
+		{"direct chat", 3, 5, "", ErrGroupNotFound},
+		{"missing chat", 9, 5, "", ErrGroupNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, svc := newJoinRequestFixture()
+
+			request, notify, err := svc.RequestToJoin(context.Background(), tt.chatID, tt.userID, tt.message)
+			if !matchErr(err, tt.wantErr) {
+				t.Fatalf("RequestToJoin() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				if len(repo.joinRequests) != 0 {
+					t.Error("request stored on error")
+				}
+				return
+			}
+			if request.Status != model.JoinRequestPending || request.Message != strings.TrimSpace(tt.message) || !notify {
+				t.Errorf("request = %+v, notify = %v", request, notify)
+			}
+		})
+	}
+}
+
+func TestRequestToJoinRepeated(t *testing.T) {
+	repo, svc := newJoinRequestFixture()
+	ctx := context.Background()
+
+	first, notify, err := svc.RequestToJoin(ctx, 1, 5, "")
+	if err != nil || !notify {
+		t.Fatalf("RequestToJoin() = notify %v, error %v", notify, err)
+	}
+
+	// Повторная заявка возвращает уже поданную без нового уведомления
+	again, notify, err := svc.RequestToJoin(ctx, 1, 5, "please")
+	if err != nil || notify || again.ID != first.ID || len(repo.joinRequests) != 1 {
+		t.Fatalf("repeated request = id %d notify %v error %v, %d stored", again.ID, notify, err, len(repo.joinRequests))
+	}
+
+	// Вторая заявка за короткое время не уведомляет администраторов повторно
+	if _, notify, err := svc.RequestToJoin(ctx, 1, 6, ""); err != nil || notify {
+		t.Errorf("second user's request = notify %v, error %v", notify, err)
+	}
+}
+
+func TestRequestToJoinAfterRejection(t *testing.T) {
+	repo, svc := newJoinRequestFixture()
+	ctx := context.Background()
+
+	request, _, err := svc.RequestToJoin(ctx, 1, 5, "")
+	if err != nil {
+		t.Fatalf("RequestToJoin() error = %v", err)
+	}
+	if _, err := svc.RejectJoinRequest(ctx, 1, request.ID, 1); err != nil {
+		t.Fatalf("RejectJoinRequest() error = %v", err)
+	}
+
+	_, _, err = svc.RequestToJoin(ctx, 1, 5, "")
+	var cooldown *JoinRequestCooldownError
+	if !errors.As(err, &cooldown) || cooldown.RetryAfter > JoinRequestCooldown || cooldown.RetryAfter < JoinRequestCooldown-time.Minute {
+		t.Fatalf("RequestToJoin() after rejection error = %v, want cooldown", err)
+	}
+
+	// После окончания паузы заявку можно подать снова
+	reviewedAt := time.Now().Add(-JoinRequestCooldown - time.Second)
+	repo.joinRequests[request.ID].ReviewedAt = &reviewedAt
+	if _, _, err := svc.RequestToJoin(ctx, 1, 5, ""); err != nil {
+		t.Errorf("RequestToJoin() after cooldown error = %v", err)
+	}
+}
+
+func TestApproveJoinRequest(t *testing.T) {
+	repo, svc := newJoinRequestFixture()
+	ctx := context.Background()
+
+	request, _, err := svc.RequestToJoin(ctx, 1, 5, "")
+	if err != nil {
+		t.Fatalf("RequestToJoin() error = %v", err)
+	}
+
+	if _, err := svc.ApproveJoinRequest(ctx, 2, request.ID, 1); !errors.Is(err, ErrJoinRequestNotFound) {
+		t.Fatalf("ApproveJoinRequest() in another chat error = %v, want %v", err, ErrJoinRequestNotFound)
+	}
+
+	approved, err := svc.ApproveJoinRequest(ctx, 1, request.ID, 1)
+	if err != nil {
+		t.Fatalf("ApproveJoinRequest() error = %v", err)
+	}
+	if approved.Status != model.JoinRequestApproved || approved.ReviewedByID == nil || *approved.ReviewedByID != 1 {
+		t.Errorf("approved = %+v", approved)
+	}
+	if m := repo.member(1, 5); m == nil || m.Role != model.ChatRoleMember {
+		t.Fatal("approved user is not a member")
+	}
+
+	if _, err := svc.ApproveJoinRequest(ctx, 1, request.ID, 1); !errors.Is(err, ErrJoinRequestResolved) {
+		t.Errorf("second ApproveJoinRequest() error = %v, want %v", err, ErrJoinRequestResolved)
+	}
+	if _, err := svc.RejectJoinRequest(ctx, 1, request.ID, 1); !errors.Is(err, ErrJoinRequestResolved) {
+		t.Errorf("RejectJoinRequest() after approval error = %v, want %v", err, ErrJoinRequestResolved)
+	}
+}
+
+func TestApproveJoinRequestOfBannedUser(t *testing.T) {
+	repo, svc := newJoinRequestFixture()
+	ctx := context.Background()
+
+	request, _, err := svc.RequestToJoin(ctx, 1, 5, "")
+	if err != nil {
+		t.Fatalf("RequestToJoin() error = %v", err)
+	}
+
+	// Пользователя заблокировали после подачи заявки
+	repo.bans = append(repo.bans, model.ChatBan{ChatID: 1, UserID: 5})
+
+	if _, err := svc.ApproveJoinRequest(ctx, 1, request.ID, 1); !errors.Is(err, ErrUserBanned) {
+		t.Fatalf("ApproveJoinRequest() error = %v, want %v", err, ErrUserBanned)
+	}
+	if repo.member(1, 5) != nil || repo.joinRequests[request.ID].Status != model.JoinRequestPending {
+		t.Error("banned user was added or the request was resolved")
+	}
+
+	// Новая заявка заблокированного тоже не принимается
+	if _, _, err := svc.RequestToJoin(ctx, 1, 5, ""); !errors.Is(err, ErrUserBanned) {
+		t.Errorf("RequestToJoin() by banned user error = %v, want %v", err, ErrUserBanned)
+	}
+}
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"tush00nka/bbbab_messenger/internal/model"
+)
+
+// Ошибки выхода из чата и удаления чата
+var (
+	ErrCannotLeaveDirect = errors.New("direct chat cannot be left, delete it instead")
+	ErrNotDirectChat     = errors.New("only direct chats can be deleted for both members")
+)
+
+// LeaveChat удаляет пользователя из группы по его собственному желанию.
+// Если выходит владелец, права переходят самому давнему администратору или участнику;
+// его ID возвращается (0 — владелец не менялся). Группа без участников удаляется.
+func (s *chatService) LeaveChat(ctx context.Context, chatID, userID uint) (uint, error) {
+	if chatID == 0 || userID == 0 {
+		return 0, errors.New("chatID and userID cannot be zero")
+	}
+
+	meta, err := s.chatRepo.GetMeta(ctx, chatID)
+	if err != nil {
+		return 0, err
+	}
+	if meta == nil {
+		return 0, errors.New("chat not found")
+	}
+	if !meta.IsGroup {
+		return 0, ErrCannotLeaveDirect
+	}
+
+	role, err := s.chatRepo.GetUserRole(ctx, chatID, userID)
+	if err != nil {
+		return 0, err
+	}
+	if role == "" {
+		return 0, ErrNotChatMember
+	}
+
+	var newOwnerID uint
+	if role == model.ChatRoleOwner {
+		if newOwnerID, err = s.chatRepo.GetOwnershipSuccessor(ctx, chatID, userID); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := s.chatRepo.LeaveChat(ctx, chatID, userID, newOwnerID); err != nil {
+		return 0, err
+	}
+
+	// Владелец был последним участником
+	if role == model.ChatRoleOwner && newOwnerID == 0 {
+		if err := s.chatRepo.Delete(ctx, chatID); err != nil {
+			return 0, err
+		}
+	}
+
+	return newOwnerID, nil
+}
+
+// ClearHistory скрывает от пользователя все текущие сообщения чата.
+// Возвращает ID последнего скрытого сообщения.
+func (s *chatService) ClearHistory(ctx context.Context, chatID, userID uint) (uint, error) {
+	if chatID == 0 || userID == 0 {
+		return 0, errors.New("chatID and userID cannot be zero")
+	}
+
+	inChat, err := s.chatRepo.IsUserInChat(ctx, chatID, userID)
+	if err != nil {
+		return 0, err
+	}
+	if !inChat {
+		return 0, ErrNotChatMember
+	}
+
+	return s.chatRepo.ClearHistory(ctx, chatID, userID)
+}
+
+// GetHistoryClearedUpTo возвращает отметку очистки истории пользователя в чате
+func (s *chatService) GetHistoryClearedUpTo(ctx context.Context, chatID, userID uint) (uint, error) {
+	if chatID == 0 || userID == 0 {
+		return 0, errors.New("chatID and userID cannot be zero")
+	}
+
+	return s.chatRepo.GetHistoryClearedUpTo(ctx, chatID, userID)
+}
+
+// DeleteDirectChat безвозвратно удаляет личный чат у обоих участников.
+// Возвращает ID участников и ссылки на вложения удаленных сообщений.
+func (s *chatService) DeleteDirectChat(ctx context.Context, chatID, userID uint) ([]uint, []string, error) {
+	if chatID == 0 || userID == 0 {
+		return nil, nil, errors.New("chatID and userID cannot be zero")
+	}
+
+	meta, err := s.chatRepo.GetMeta(ctx, chatID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if meta == nil {
+		return nil, nil, errors.New("chat not found")
+	}
+	if meta.IsGroup {
+		return nil, nil, ErrNotDirectChat
+	}
+
+	users, err := s.chatRepo.GetChatUsers(ctx, chatID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	memberIDs := make([]uint, 0, len(users))
+	isMember := false
+	for _, u := range users {
+		memberIDs = append(memberIDs, u.ID)
+		if u.ID == userID {
+			isMember = true
+		}
+	}
+	if !isMember {
+		return nil, nil, ErrNotChatMember
+	}
+
+	attachments, err := s.chatRepo.PurgeChat(ctx, chatID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return memberIDs, attachments, nil
+}
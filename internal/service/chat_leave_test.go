@@ -0,0 +1,189 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"tush00nka/bbbab_messenger/internal/model"
+)
+
+func TestLeaveChat(t *testing.T) {
+	tests := []struct {
+		name         string
+		setup        func(repo *memoryChatRepo)
+		userID       uint
+		wantErr      error
+		wantNewOwner uint
+	}{
+		{
+			name:   "member leaves",
+			setup:  func(repo *memoryChatRepo) { repo.addGroup(1, 1, 2, 3) },
+			userID: 3,
+		},
+		{
+			// Администратор получает права раньше участников, вступивших до него
+			name: "owner leaves, admin succeeds",
+			setup: func(repo *memoryChatRepo) {
+				repo.addGroup(1, 1, 2)
+				repo.addMember(1, 3, model.ChatRoleAdmin)
+			},
+			userID:       1,
+			wantNewOwner: 3,
+		},
+		{
+			name:         "owner leaves, earliest member succeeds",
+			setup:        func(repo *memoryChatRepo) { repo.addGroup(1, 1, 3, 2) },
+			userID:       1,
+			wantNewOwner: 3,
+		},
+		{
+			name:    "non-member",
+			setup:   func(repo *memoryChatRepo) { repo.addGroup(1, 1, 2) },
+			userID:  9,
+			wantErr: ErrNotChatMember,
+		},
+		{
+			name:    "direct chat",
+			setup:   func(repo *memoryChatRepo) { repo.addDirect(1, 1, 2) },
+			userID:  1,
+			wantErr: ErrCannotLeaveDirect,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newMemoryChatRepo()
+			tt.setup(repo)
+			svc := newTestChatService(repo)
+			membersBefore := len(repo.members[1])
+
+			newOwnerID, err := svc.LeaveChat(context.Background(), 1, tt.userID)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("LeaveChat() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				if len(repo.members[1]) != membersBefore {
+					t.Error("members changed on error")
+				}
+				return
+			}
+
+			if newOwnerID != tt.wantNewOwner {
+				t.Errorf("new owner = %d, want %d", newOwnerID, tt.wantNewOwner)
+			}
+			if repo.member(1, tt.userID) != nil {
+				t.Error("user is still a member")
+			}
+			if tt.wantNewOwner != 0 {
+				if role := repo.member(1, tt.wantNewOwner).Role; role != model.ChatRoleOwner {
+					t.Errorf("successor role = %q, want owner", role)
+				}
+			}
+
+			// В группе всегда ровно один владелец
+			owners := 0
+			for _, m := range repo.members[1] {
+				if m.Role == model.ChatRoleOwner {
+					owners++
+				}
+			}
+			if owners != 1 {
+				t.Errorf("group has %d owners after leave", owners)
+			}
+		})
+	}
+}
+
+func TestLastOwnerLeavingDeletesGroup(t *testing.T) {
+	repo := newMemoryChatRepo()
+	repo.addGroup(1, 1)
+	svc := newTestChatService(repo)
+
+	newOwnerID, err := svc.LeaveChat(context.Background(), 1, 1)
+	if err != nil {
+		t.Fatalf("LeaveChat() error = %v", err)
+	}
+	if newOwnerID != 0 {
+		t.Errorf("new owner = %d, want none", newOwnerID)
+	}
+	if _, ok := repo.chats[1]; ok {
+		t.Error("empty group was not deleted")
+	}
+}
+
+func TestClearHistory(t *testing.T) {
+	repo := newMemoryChatRepo()
+	repo.addDirect(1, 1, 2)
+	repo.addMessage(10, 1, 1, "a")
+	repo.addMessage(11, 1, 2, "b")
+	svc := newTestChatService(repo)
+	ctx := context.Background()
+
+	if _, err := svc.ClearHistory(ctx, 1, 9); !errors.Is(err, ErrNotChatMember) {
+		t.Fatalf("ClearHistory() by non-member error = %v, want %v", err, ErrNotChatMember)
+	}
+
+	lastID, err := svc.ClearHistory(ctx, 1, 1)
+	if err != nil {
+		t.Fatalf("ClearHistory() error = %v", err)
+	}
+	if lastID != 11 {
+		t.Errorf("cleared up to %d, want 11", lastID)
+	}
+
+	// История очищается только у самого пользователя, сообщения остаются
+	if got, _ := svc.GetHistoryClearedUpTo(ctx, 1, 1); got != 11 {
+		t.Errorf("GetHistoryClearedUpTo(user) = %d, want 11", got)
+	}
+	if got, _ := svc.GetHistoryClearedUpTo(ctx, 1, 2); got != 0 {
+		t.Errorf("GetHistoryClearedUpTo(other) = %d, want 0", got)
+	}
+	if len(repo.messages) != 2 {
+		t.Errorf("messages left = %d, want 2", len(repo.messages))
+	}
+}
+
+func TestDeleteDirectChat(t *testing.T) {
+	tests := []struct {
+		name    string
+		chatID  uint
+		userID  uint
+		wantErr error
+	}{
+		{"member", 1, 2, nil},
+		{"non-member", 1, 3, ErrNotChatMember},
+		{"group", 2, 1, ErrNotDirectChat},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newMemoryChatRepo()
+			repo.addDirect(1, 1, 2)
+			repo.addGroup(2, 1, 2)
+			attachment := "https://cdn/photo.png"
+			repo.addMessage(10, 1, 1, "photo").AttachmentURL = &attachment
+			repo.addMessage(11, 1, 2, "text")
+			svc := newTestChatService(repo)
+
+			memberIDs, attachments, err := svc.DeleteDirectChat(context.Background(), tt.chatID, tt.userID)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("DeleteDirectChat() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				if _, ok := repo.chats[tt.chatID]; !ok || len(repo.messages) != 2 {
+					t.Error("chat deleted on error")
+				}
+				return
+			}
+
+			slices.Sort(memberIDs)
+			if !slices.Equal(memberIDs, []uint{1, 2}) || !slices.Equal(attachments, []string{attachment}) {
+				t.Errorf("members = %v, attachments = %v", memberIDs, attachments)
+			}
+			if _, ok := repo.chats[1]; ok || len(repo.messages) != 0 {
+				t.Errorf("chat kept or %d messages left", len(repo.messages))
+			}
+		})
+	}
+}
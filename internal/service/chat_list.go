@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+	"tush00nka/bbbab_messenger/internal/model"
+	"tush00nka/bbbab_messenger/internal/repository"
+)
+
+// Размер страницы списка чатов
+const (
+	DefaultChatListLimit = 50
+	MaxChatListLimit     = 100
+)
+
+// ErrInvalidChatListCursor курсор списка чатов поврежден
+var ErrInvalidChatListCursor = errors.New("invalid chat list cursor")
+
+// ChatListParams параметры страницы списка чатов
+type ChatListParams struct {
+	// Since только чаты, изменившиеся после этого момента
+	Since *time.Time
+	// Archived показать архив вместо основного списка
+	Archived bool
+	// FolderID показать чаты папки, включая архивные, если папка их не исключает
+	FolderID uint
+	Cursor   string
+	Limit    int
+}
+
+// ListChats возвращает страницу чатов пользователя и курсор следующей страницы (пустой на последней).
+// Сначала идут закрепленные чаты по порядку, затем остальные от недавно активных к давним.
+// С Since возвращаются только чаты, изменившиеся после этого момента: новые и удаленные
+// сообщения, прочтения, настройки чата и участия в нем, черновики.
+func (s *chatService) ListChats(ctx context.Context, userID uint, params ChatListParams) ([]model.ChatListItem, string, error) {
+	if userID == 0 {
+		return nil, "", errors.New("userID cannot be zero")
+	}
+
+	after, err := decodeChatListCursor(params.Cursor)
+	if err != nil {
+		return nil, "", err
+	}
+
+	limit := params.Limit
+	if limit <= 0 {
+		limit = DefaultChatListLimit
+	}
+	if limit > MaxChatListLimit {
+		limit = MaxChatListLimit
+	}
+
+	filter := repository.ChatListFilter{
+		UserID: userID,
+		Since:  params.Since,
+		After:  after,
+		Limit:  limit + 1,
+	}
+
+	if params.FolderID != 0 {
+		folder, err := s.chatRepo.GetChatFolder(ctx, userID, params.FolderID)
+		if err != nil {
+			return nil, "", err
+		}
+		if folder == nil {
+			return nil, "", ErrChatFolderNotFound
+		}
+		filter.Folder = folder
+	} else {
+		filter.Archived = &params.Archived
+	}
+
+	items, err := s.chatRepo.ListChats(ctx, filter)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var nextCursor string
+	if len(items) > limit {
+		items = items[:limit]
+		last := items[len(items)-1]
+		nextCursor = encodeChatListCursor(repository.ChatListCursor{
+			PinnedPosition: last.PinnedPosition,
+			LastActivityAt: last.LastActivityAt,
+			ChatID:         last.Chat.ID,
+		})
+	}
+
+	return items, nextCursor, nil
+}
+
+// encodeChatListCursor кодирует позицию как "<место закрепления>_<время в микросекундах>_<ID чата>";
+// микросекунды — точность timestamp в Postgres
+func encodeChatListCursor(c repository.ChatListCursor) string {
+	return strconv.Itoa(c.PinnedPosition) + "_" +
+		strconv.FormatInt(c.LastActivityAt.UnixMicro(), 10) + "_" +
+		strconv.FormatUint(uint64(c.ChatID), 10)
+}
+
+// decodeChatListCursor разбирает курсор; пустая строка — первая страница
+func decodeChatListCursor(cursor string) (*repository.ChatListCursor, error) {
+	if cursor == "" {
+		return nil, nil
+	}
+
+	parts := strings.Split(cursor, "_")
+	if len(parts) != 3 {
+		return nil, ErrInvalidChatListCursor
+	}
+	positionStr, microStr, idStr := parts[0], parts[1], parts[2]
+
+	position, err := strconv.Atoi(positionStr)
+	if err != nil || position < 0 {
+		return nil, ErrInvalidChatListCursor
+	}
+	micro, err := strconv.ParseInt(microStr, 10, 64)
+	if err != nil {
+		return nil, ErrInvalidChatListCursor
+	}
+	id, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil || id == 0 {
+		return nil, ErrInvalidChatListCursor
+	}
+
+	return &repository.ChatListCursor{PinnedPosition: position, LastActivityAt: time.UnixMicro(micro), ChatID: uint(id)}, nil
+}
@@ -0,0 +1,226 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"tush00nka/bbbab_messenger/internal/model"
+	"unicode/utf16"
+)
+
+// Ограничения упоминаний
+const (
+	MaxMentionsPerMessage = 50
+	DefaultMentionsLimit  = 20
+	MaxMentionsLimit      = 100
+)
+
+// mentionPattern находит @username, перед которым нет буквы, цифры или @
+var mentionPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_@])@([A-Za-z0-9_]{1,64})`)
+
+// resolveMentions находит упоминания @username в тексте группового сообщения,
+// добавляет для них разметку mention и проставляет в ней ID участников чата.
+// Для упомянутых участников чата, кроме отправителя, заполняется message.Mentions.
+func (s *chatService) resolveMentions(ctx context.Context, chat *model.Chat, message *model.Message) error {
+	if s.users == nil || !chat.IsGroup {
+		return nil
+	}
+
+	detected := detectMentions(message.Message, message.Entities)
+	detectedAt := make(map[int]bool, len(detected))
+	for _, e := range detected {
+		detectedAt[e.Offset] = true
+	}
+	message.Entities = append(message.Entities, detected...)
+	sortEntities(message.Entities)
+
+	units := utf16.Encode([]rune(message.Message))
+	resolved := make(map[string]uint)
+	var mentions []model.MessageMention
+	seen := make(map[uint]bool)
+	kept := message.Entities[:0]
+
+	for _, e := range message.Entities {
+		if e.Type != model.EntityMention {
+			kept = append(kept, e)
+			continue
+		}
+		// Разметка уже проверена, но срез по присланным границам без проверки недопустим
+		if !entityInBounds(e, len(units)) || e.Length < 2 {
+			continue
+		}
+
+		// Упоминания не зависят от регистра: @Alice и @alice — один пользователь
+		username := string(utf16.Decode(units[e.Offset+1 : e.Offset+e.Length]))
+		key := strings.ToLower(username)
+		userID, ok := resolved[key]
+		if !ok && len(resolved) < MaxMentionsPerMessage {
+			var err error
+			if userID, err = s.resolveMentionedMember(ctx, chat.ID, username); err != nil {
+				return err
+			}
+			resolved[key] = userID
+		}
+
+		if userID == 0 {
+			// Неразрешенные упоминания, найденные сервером, не сохраняем
+			if detectedAt[e.Offset] {
+				continue
+			}
+		} else if userID != message.SenderID && !seen[userID] {
+			seen[userID] = true
+			mentions = append(mentions, model.MessageMention{ChatID: chat.ID, UserID: userID})
+		}
+
+		e.UserID = userID
+		kept = append(kept, e)
+	}
+
+	message.Entities = kept
+	if len(message.Entities) == 0 {
+		message.Entities = nil
+	}
+	message.Mentions = mentions
+
+	return nil
+}
+
+// resolveMentionedMember возвращает ID участника чата с указанным username или 0.
+// Пользователи не из чата не раскрываются, чтобы упоминание не выдавало их ID.
+func (s *chatService) resolveMentionedMember(ctx context.Context, chatID uint, username string) (uint, error) {
+	// Ошибку поиска считаем отсутствием пользователя: упоминание остается текстом
+	user, err := s.users.GetUserByUsernameIgnoreCase(username)
+	if err != nil || user == nil {
+		return 0, nil
+	}
+
+	inChat, err := s.chatRepo.IsUserInChat(ctx, chatID, user.ID)
+	if err != nil || !inChat {
+		return 0, err
+	}
+
+	return user.ID, nil
+}
+
+// detectMentions возвращает разметку для @username, которые клиент не отметил сам.
+// Упоминания внутри кода и ссылок, а также пересекающие другую разметку, пропускаются.
+func detectMentions(text string, entities model.MessageEntities) model.MessageEntities {
+	var detected model.MessageEntities
+	for _, idx := range mentionPattern.FindAllStringSubmatchIndex(text, -1) {
+		at, end := idx[2]-1, idx[3]
+		mention := model.MessageEntity{
+			Type:   model.EntityMention,
+			Offset: utf16Len(text[:at]),
+			Length: utf16Len(text[at:end]),
+		}
+		if canAddEntity(mention, entities) {
+			detected = append(detected, mention)
+		}
+	}
+
+	return detected
+}
+
+// canAddEntity проверяет, что фрагмент можно добавить к разметке без частичных пересечений
+func canAddEntity(added model.MessageEntity, entities model.MessageEntities) bool {
+	for _, e := range entities {
+		if entityEnd(e) <= added.Offset || entityEnd(added) <= e.Offset {
+			continue
+		}
+
+		switch e.Type {
+		case model.EntityCode, model.EntityPre, model.EntityURL, model.EntityTextLink, model.EntityMention:
+			return false
+		}
+
+		inside := e.Offset <= added.Offset && entityEnd(added) <= entityEnd(e)
+		contains := added.Offset <= e.Offset && entityEnd(e) <= entityEnd(added)
+		if !inside && !contains {
+			return false
+		}
+	}
+
+	return true
+}
+
+// GetMentions возвращает ленту упоминаний пользователя вместе с сообщениями.
+// hasMore сообщает, есть ли упоминания старше последнего возвращенного.
+func (s *chatService) GetMentions(
+	ctx context.Context,
+	userID, chatID, beforeID uint,
+	limit int,
+	unreadOnly bool,
+) ([]model.MessageMention, bool, error) {
+	if userID == 0 {
+		return nil, false, errors.New("userID cannot be zero")
+	}
+
+	if limit <= 0 {
+		limit = DefaultMentionsLimit
+	}
+	if limit > MaxMentionsLimit {
+		limit = MaxMentionsLimit
+	}
+
+	mentions, err := s.chatRepo.GetMentions(ctx, userID, chatID, beforeID, limit+1, unreadOnly)
+	if err != nil {
+		return nil, false, err
+	}
+
+	hasMore := len(mentions) > limit
+	if hasMore {
+		mentions = mentions[:limit]
+	}
+
+	messageIDs := make([]uint, len(mentions))
+	for i := range mentions {
+		messageIDs[i] = mentions[i].MessageID
+	}
+
+	messages, err := s.chatRepo.GetMessagesByIDs(ctx, messageIDs)
+	if err != nil {
+		return nil, false, err
+	}
+	if err := s.AttachPolls(ctx, messages, userID); err != nil {
+		return nil, false, err
+	}
+
+	byID := make(map[uint]*model.Message, len(messages))
+	for i := range messages {
+		byID[messages[i].ID] = &messages[i]
+	}
+	for i := range mentions {
+		mentions[i].Message = byID[mentions[i].MessageID]
+	}
+
+	return mentions, hasMore, nil
+}
+
+// GetUnreadMentionCounts возвращает число непрочитанных упоминаний пользователя по чатам
+func (s *chatService) GetUnreadMentionCounts(ctx context.Context, userID uint) (map[uint]int64, error) {
+	if userID == 0 {
+		return nil, errors.New("userID cannot be zero")
+	}
+
+	return s.chatRepo.GetUnreadMentionCounts(ctx, userID)
+}
+
+// GetUnreadMentionCount возвращает число непрочитанных упоминаний пользователя в чате
+func (s *chatService) GetUnreadMentionCount(ctx context.Context, userID, chatID uint) (int64, error) {
+	counts, err := s.GetUnreadMentionCounts(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	return counts[chatID], nil
+}
+
+// MarkMentionsRead отмечает прочитанными упоминания в чате до сообщения upToMessageID включительно (0 — все)
+func (s *chatService) MarkMentionsRead(ctx context.Context, userID, chatID, upToMessageID uint) error {
+	if userID == 0 || chatID == 0 {
+		return errors.New("userID and chatID cannot be zero")
+	}
+
+	return s.chatRepo.MarkMentionsRead(ctx, userID, chatID, upToMessageID)
+}
@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"tush00nka/bbbab_messenger/internal/model"
+)
+
+func TestDetectMentions(t *testing.T) {
+	mention := func(offset, length int) model.MessageEntity {
+		return model.MessageEntity{Type: model.EntityMention, Offset: offset, Length: length}
+	}
+
+	tests := []struct {
+		name     string
+		text     string
+		entities model.MessageEntities
+		want     model.MessageEntities
+	}{
+		{"no mentions", "hello world", nil, nil},
+		{"single", "hi @bob", nil, model.MessageEntities{mention(3, 4)}},
+		{"at start", "@bob hi", nil, model.MessageEntities{mention(0, 4)}},
+		{"several", "@alice, @bob_2!", nil, model.MessageEntities{mention(0, 6), mention(8, 6)}},
+		{"email is not a mention", "write to a@bob.com", nil, nil},
+		{"after cyrillic letter", "привет@bob", nil, nil},
+		{"after digit", "1@bob", nil, nil},
+		{"double at", "@@bob", nil, nil},
+		{"lone at", "@ bob", nil, nil},
+		// Смещения в UTF-16: эмодзи занимает две единицы
+		{"after emoji", "😀 @bob", nil, model.MessageEntities{mention(3, 4)}},
+		{"cyrillic before", "привет @bob", nil, model.MessageEntities{mention(7, 4)}},
+		{
+			name:     "inside code",
+			text:     "run @bob now",
+			entities: model.MessageEntities{{Type: model.EntityCode, Offset: 4, Length: 4}},
+			want:     nil,
+		},
+		{
+			name:     "inside link",
+			text:     "see @bob",
+			entities: model.MessageEntities{{Type: model.EntityTextLink, Offset: 0, Length: 8, URL: "https://example.com"}},
+			want:     nil,
+		},
+		{
+			name:     "already marked",
+			text:     "hi @bob",
+			entities: model.MessageEntities{mention(3, 4)},
+			want:     nil,
+		},
+		{
+			name:     "inside bold",
+			text:     "hi @bob",
+			entities: model.MessageEntities{{Type: model.EntityBold, Offset: 0, Length: 7}},
+			want:     model.MessageEntities{mention(3, 4)},
+		},
+		{
+			name:     "crosses bold",
+			text:     "hi @bob",
+			entities: model.MessageEntities{{Type: model.EntityBold, Offset: 0, Length: 5}},
+			want:     nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := detectMentions(tt.text, tt.entities)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("detectMentions(%q) = %+v, want %+v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+// mentionUsers находит пользователей по username без учета регистра
+type mentionUsers struct {
+	UserService
+	byName map[string]uint
+}
+
+func (u *mentionUsers) GetUserByUsernameIgnoreCase(username string) (*model.User, error) {
+	id, ok := u.byName[strings.ToLower(username)]
+	if !ok {
+		return nil, errors.New("record not found")
+	}
+	user := &model.User{Username: username}
+	user.ID = id
+	return user, nil
+}
+
+func TestResolveMentions(t *testing.T) {
+	repo := newMemoryChatRepo()
+	chat := repo.addGroup(10, 1, 2)
+	svc := newTestChatService(repo,
+		ChatServiceOptions{Users: &mentionUsers{byName: map[string]uint{"sender": 1, "alice": 2, "outsider": 3}}})
+
+	message := &model.Message{
+		SenderID: 1,
+		Message:  "@Alice @ALICE @sender @outsider @nobody",
+	}
+
+	if err := svc.resolveMentions(context.Background(), chat, message); err != nil {
+		t.Fatalf("resolveMentions() error = %v", err)
+	}
+
+	// Регистр не важен; неизвестный пользователь и пользователь не из чата остаются текстом
+	wantUserIDs := []uint{2, 2, 1}
+	if len(message.Entities) != len(wantUserIDs) {
+		t.Fatalf("got %d mention entities, want %d: %+v", len(message.Entities), len(wantUserIDs), message.Entities)
+	}
+	for i, e := range message.Entities {
+		if e.UserID != wantUserIDs[i] {
+			t.Errorf("entity %d UserID = %d, want %d", i, e.UserID, wantUserIDs[i])
+		}
+	}
+
+	// Уведомляется только участник чата, один раз и не автор сообщения
+	wantMentions := []model.MessageMention{{ChatID: 10, UserID: 2}}
+	if !reflect.DeepEqual(message.Mentions, wantMentions) {
+		t.Errorf("Mentions = %+v, want %+v", message.Mentions, wantMentions)
+	}
+}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+	"tush00nka/bbbab_messenger/internal/model"
+)
+
+// MaxMuteDuration наибольший срок отключения уведомлений; дольше — только бессрочно
+const MaxMuteDuration = 366 * 24 * time.Hour
+
+// Ошибки настроек уведомлений
+var (
+	ErrInvalidNotifyMode     = errors.New("invalid notification mode")
+	ErrInvalidMuteDuration   = errors.New("invalid mute duration")
+	ErrMentionsOnlyGroupOnly = errors.New("mentions only mode is available in groups only")
+)
+
+// SetChatNotifications меняет режим уведомлений участника чата.
+// duration — срок действия режима, 0 — бессрочно; для режима all срок не задается.
+func (s *chatService) SetChatNotifications(
+	ctx context.Context,
+	userID, chatID uint,
+	mode string,
+	duration time.Duration,
+) (*model.ChatNotificationSettings, error) {
+	if userID == 0 || chatID == 0 {
+		return nil, errors.New("userID and chatID cannot be zero")
+	}
+
+	settings := model.ChatNotificationSettings{Mode: mode}
+	switch mode {
+	case model.NotifyAll:
+		duration = 0
+	case model.NotifyMentions, model.NotifyNone:
+	default:
+		return nil, ErrInvalidNotifyMode
+	}
+	if duration < 0 || duration > MaxMuteDuration {
+		return nil, ErrInvalidMuteDuration
+	}
+
+	if mode == model.NotifyMentions {
+		chat, err := s.chatRepo.GetMeta(ctx, chatID)
+		if err != nil {
+			return nil, err
+		}
+		if chat == nil {
+			return nil, ErrNotChatMember
+		}
+		if !chat.IsGroup {
+			return nil, ErrMentionsOnlyGroupOnly
+		}
+	}
+
+	if duration > 0 {
+		until := time.Now().Add(duration)
+		settings.MutedUntil = &until
+	}
+
+	found, err := s.chatRepo.SetChatNotifications(ctx, userID, chatID, settings)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, ErrNotChatMember
+	}
+
+	return &settings, nil
+}
+
+// GetUnreadSummary возвращает общий счетчик непрочитанного для значка приложения
+func (s *chatService) GetUnreadSummary(ctx context.Context, userID uint) (*model.UnreadSummary, error) {
+	if userID == 0 {
+		return nil, errors.New("userID cannot be zero")
+	}
+
+	return s.chatRepo.GetUnreadSummary(ctx, userID)
+}
+
+// FilterNotificationRecipients оставляет из userIDs участников, которых нужно уведомить
+// о сообщении с учетом их настроек. Отправитель не уведомляется.
+func (s *chatService) FilterNotificationRecipients(ctx context.Context, msg model.Message, userIDs []uint) ([]uint, error) {
+	userIDs = uniqueIDs(userIDs)
+	if msg.ChatID == 0 || len(userIDs) == 0 {
+		return []uint{}, nil
+	}
+
+	settings, err := s.chatRepo.GetChatNotificationSettings(ctx, msg.ChatID, userIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	mentioned := make(map[uint]bool, len(msg.Mentions)+1)
+	for _, mention := range msg.Mentions {
+		mentioned[mention.UserID] = true
+	}
+	if msg.ReplyToID != nil {
+		replyTo := msg.ReplyTo
+		if replyTo == nil {
+			if replyTo, err = s.chatRepo.GetMessageByID(ctx, *msg.ReplyToID); err != nil {
+				return nil, err
+			}
+		}
+		if replyTo != nil {
+			mentioned[replyTo.SenderID] = true
+		}
+	}
+
+	now := time.Now()
+	recipients := make([]uint, 0, len(userIDs))
+	for _, userID := range userIDs {
+		setting, ok := settings[userID]
+		if !ok || userID == msg.SenderID || !setting.Allows(mentioned[userID], now) {
+			continue
+		}
+		recipients = append(recipients, userID)
+	}
+
+	return recipients, nil
+}
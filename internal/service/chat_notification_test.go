@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+	"tush00nka/bbbab_messenger/internal/model"
+)
+
+func TestSetChatNotifications(t *testing.T) {
+	tests := []struct {
+		name      string
+		chatID    uint
+		mode      string
+		duration  time.Duration
+		wantErr   error
+		wantMuted bool
+	}{
+		{"mute forever", 1, model.NotifyNone, 0, nil, false},
+		{"mute for an hour", 1, model.NotifyNone, time.Hour, nil, true},
+		{"mentions in group", 1, model.NotifyMentions, 0, nil, false},
+		// Для режима all срок не задается
+		{"all ignores duration", 1, model.NotifyAll, time.Hour, nil, false},
+		{"mentions in direct chat", 2, model.NotifyMentions, 0, ErrMentionsOnlyGroupOnly, false},
+		{"unknown mode", 1, "loud", 0, ErrInvalidNotifyMode, false},
+		{"negative duration", 1, model.NotifyNone, -time.Hour, ErrInvalidMuteDuration, false},
+		{"too long", 1, model.NotifyNone, MaxMuteDuration + time.Hour, ErrInvalidMuteDuration, false},
+		{"non-member", 3, model.NotifyNone, 0, ErrNotChatMember, false},
+		{"missing chat", 9, model.NotifyMentions, 0, ErrNotChatMember, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newMemoryChatRepo()
+			repo.addGroup(1, 1, 2)
+			repo.addDirect(2, 1, 2)
+			repo.addGroup(3, 2)
+			svc := newTestChatService(repo)
+
+			settings, err := svc.SetChatNotifications(context.Background(), 1, tt.chatID, tt.mode, tt.duration)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("SetChatNotifications() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				if m := repo.member(tt.chatID, 1); m != nil && m.NotifyMode != model.NotifyAll {
+					t.Errorf("mode changed on error to %q", m.NotifyMode)
+				}
+				return
+			}
+
+			member := repo.member(tt.chatID, 1)
+			if settings.Mode != tt.mode || member.NotifyMode != tt.mode {
+				t.Errorf("mode = %q, stored %q, want %q", settings.Mode, member.NotifyMode, tt.mode)
+			}
+			if (member.MutedUntil != nil) != tt.wantMuted {
+				t.Errorf("MutedUntil = %v, want set %v", member.MutedUntil, tt.wantMuted)
+			}
+			// Настройка меняется только у самого пользователя
+			if repo.member(tt.chatID, 2).NotifyMode != model.NotifyAll {
+				t.Error("other member's settings changed")
+			}
+		})
+	}
+}
+
+func TestFilterNotificationRecipients(t *testing.T) {
+	future := time.Now().Add(time.Hour)
+	past := time.Now().Add(-time.Hour)
+
+	repo := newMemoryChatRepo()
+	repo.addGroup(1, 1, 2, 3, 4, 5, 6)
+	repo.member(1, 2).NotifyMode = model.NotifyNone
+	repo.member(1, 3).NotifyMode = model.NotifyMentions
+	repo.member(1, 4).NotifyMode = model.NotifyMentions
+	repo.member(1, 5).NotifyMode, repo.member(1, 5).MutedUntil = model.NotifyNone, &future
+	repo.member(1, 6).NotifyMode, repo.member(1, 6).MutedUntil = model.NotifyNone, &past
+	repo.addMessage(50, 1, 4, "question")
+	svc := newTestChatService(repo)
+
+	replyTo := uint(50)
+	tests := []struct {
+		name string
+		msg  model.Message
+		want []uint
+	}{
+		// Отправитель не уведомляется, у 6 отключение истекло, 9 не в чате
+		{"plain", model.Message{ChatID: 1, SenderID: 1}, []uint{6}},
+		{"mention", model.Message{ChatID: 1, SenderID: 1, Mentions: []model.MessageMention{{ChatID: 1, UserID: 3}, {ChatID: 1, UserID: 2}}}, []uint{3, 6}},
+		{"reply", model.Message{ChatID: 1, SenderID: 1, ReplyToID: &replyTo}, []uint{4, 6}},
+		{"sender with own setting", model.Message{ChatID: 1, SenderID: 6}, []uint{1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := svc.FilterNotificationRecipients(context.Background(), tt.msg, []uint{1, 2, 3, 4, 5, 6, 6, 9})
+			if err != nil {
+				t.Fatalf("FilterNotificationRecipients() error = %v", err)
+			}
+			slices.Sort(got)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("recipients = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"tush00nka/bbbab_messenger/internal/model"
+	"unicode/utf8"
+)
+
+// Ограничения организации списка чатов
+const (
+	MaxPinnedChats          = 10
+	MaxChatFolders          = 10
+	MaxChatFolderNameLength = 64
+	MaxChatFolderChatIDs    = 100
+)
+
+// Ошибки организации списка чатов
+var (
+	ErrTooManyPinnedChats = errors.New("too many pinned chats")
+	ErrTooManyChatFolders = errors.New("too many chat folders")
+	ErrChatFolderNotFound = errors.New("chat folder not found")
+	ErrInvalidChatFolder  = errors.New("invalid chat folder")
+)
+
+// GetChatOrganization возвращает закрепленные и архивированные чаты пользователя и его папки
+func (s *chatService) GetChatOrganization(ctx context.Context, userID uint) (*model.ChatOrganization, error) {
+	if userID == 0 {
+		return nil, errors.New("userID cannot be zero")
+	}
+
+	return s.chatRepo.GetChatOrganization(ctx, userID)
+}
+
+// SetPinnedChats заменяет закрепленные чаты пользователя, порядок chatIDs сохраняется.
+// Пустой список открепляет все чаты.
+func (s *chatService) SetPinnedChats(ctx context.Context, userID uint, chatIDs []uint) error {
+	if userID == 0 {
+		return errors.New("userID cannot be zero")
+	}
+
+	chatIDs = uniqueIDs(chatIDs)
+	if len(chatIDs) > MaxPinnedChats {
+		return ErrTooManyPinnedChats
+	}
+
+	for _, chatID := range chatIDs {
+		inChat, err := s.chatRepo.IsUserInChat(ctx, chatID, userID)
+		if err != nil {
+			return err
+		}
+		if !inChat {
+			return ErrNotChatMember
+		}
+	}
+
+	return s.chatRepo.SetPinnedChats(ctx, userID, chatIDs)
+}
+
+// SetChatArchived архивирует чат у пользователя или возвращает его из архива
+func (s *chatService) SetChatArchived(ctx context.Context, userID, chatID uint, archived bool) error {
+	if userID == 0 || chatID == 0 {
+		return errors.New("userID and chatID cannot be zero")
+	}
+
+	found, err := s.chatRepo.SetChatArchived(ctx, userID, chatID, archived)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return ErrNotChatMember
+	}
+
+	return nil
+}
+
+// UnarchiveForNewMessage возвращает чат из архива у получателей нового сообщения
+// и возвращает ID пользователей, у которых чат вернулся в основной список
+func (s *chatService) UnarchiveForNewMessage(ctx context.Context, chatID, senderID uint) ([]uint, error) {
+	if chatID == 0 {
+		return nil, errors.New("chatID cannot be zero")
+	}
+
+	return s.chatRepo.UnarchiveForNewMessage(ctx, chatID, senderID)
+}
+
+// CreateChatFolder создает папку; новая папка добавляется в конец
+func (s *chatService) CreateChatFolder(ctx context.Context, folder *model.ChatFolder) (*model.ChatFolder, error) {
+	if folder == nil || folder.UserID == 0 {
+		return nil, errors.New("folder userID cannot be zero")
+	}
+
+	if err := normalizeChatFolder(folder); err != nil {
+		return nil, err
+	}
+
+	folders, err := s.chatRepo.GetChatFolders(ctx, folder.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if len(folders) >= MaxChatFolders {
+		return nil, ErrTooManyChatFolders
+	}
+
+	folder.ID = 0
+	folder.Position = len(folders)
+	for _, f := range folders {
+		if f.Position >= folder.Position {
+			folder.Position = f.Position + 1
+		}
+	}
+
+	if err := s.chatRepo.CreateChatFolder(ctx, folder); err != nil {
+		return nil, err
+	}
+
+	return folder, nil
+}
+
+// UpdateChatFolder заменяет название, позицию и правила папки пользователя
+func (s *chatService) UpdateChatFolder(ctx context.Context, folder *model.ChatFolder) (*model.ChatFolder, error) {
+	if folder == nil || folder.ID == 0 || folder.UserID == 0 {
+		return nil, errors.New("folder ID and userID cannot be zero")
+	}
+
+	existing, err := s.chatRepo.GetChatFolder(ctx, folder.UserID, folder.ID)
+	if err != nil {
+		return nil, err
+	}
+	if existing == nil {
+		return nil, ErrChatFolderNotFound
+	}
+
+	if err := normalizeChatFolder(folder); err != nil {
+		return nil, err
+	}
+
+	folder.CreatedAt = existing.CreatedAt
+	if err := s.chatRepo.UpdateChatFolder(ctx, folder); err != nil {
+		return nil, err
+	}
+
+	return s.chatRepo.GetChatFolder(ctx, folder.UserID, folder.ID)
+}
+
+// DeleteChatFolder удаляет папку; чаты из нее остаются в списке
+func (s *chatService) DeleteChatFolder(ctx context.Context, userID, folderID uint) error {
+	if userID == 0 || folderID == 0 {
+		return errors.New("userID and folderID cannot be zero")
+	}
+
+	deleted, err := s.chatRepo.DeleteChatFolder(ctx, userID, folderID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrChatFolderNotFound
+	}
+
+	return nil
+}
+
+// normalizeChatFolder проверяет название и правила папки и убирает повторы в списках чатов
+func normalizeChatFolder(folder *model.ChatFolder) error {
+	folder.Name = strings.TrimSpace(folder.Name)
+	if folder.Name == "" {
+		return fmt.Errorf("%w: name cannot be empty", ErrInvalidChatFolder)
+	}
+	if utf8.RuneCountInString(folder.Name) > MaxChatFolderNameLength {
+		return fmt.Errorf("%w: name is too long", ErrInvalidChatFolder)
+	}
+	if folder.Position < 0 {
+		return fmt.Errorf("%w: position cannot be negative", ErrInvalidChatFolder)
+	}
+
+	folder.IncludeChatIDs = uniqueIDs(folder.IncludeChatIDs)
+	folder.ExcludeChatIDs = uniqueIDs(folder.ExcludeChatIDs)
+	if len(folder.IncludeChatIDs) > MaxChatFolderChatIDs || len(folder.ExcludeChatIDs) > MaxChatFolderChatIDs {
+		return fmt.Errorf("%w: too many chats", ErrInvalidChatFolder)
+	}
+
+	if !folder.IncludeDirect && !folder.IncludeGroups && !folder.IncludeChannels && len(folder.IncludeChatIDs) == 0 {
+		return fmt.Errorf("%w: at least one include rule is required", ErrInvalidChatFolder)
+	}
+
+	return nil
+}
@@ -0,0 +1,204 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"tush00nka/bbbab_messenger/internal/model"
+)
+
+// newOrganizationFixture: пользователь 1 состоит в чатах 1–12, в чате 20 — нет
+func newOrganizationFixture() (*memoryChatRepo, *chatService) {
+	repo := newMemoryChatRepo()
+	for id := uint(1); id <= 12; id++ {
+		repo.addDirect(id, 1, 2)
+	}
+	repo.addGroup(20, 2)
+	return repo, newTestChatService(repo)
+}
+
+func TestSetPinnedChats(t *testing.T) {
+	tests := []struct {
+		name    string
+		chatIDs []uint
+		wantErr error
+		// Позиции закрепления чатов 1–3 после вызова
+		wantPositions []int
+	}{
+		{"order kept", []uint{3, 1}, nil, []int{2, 0, 1}},
+		{"duplicates removed", []uint{2, 2, 1}, nil, []int{2, 1, 0}},
+		{"unpin all", nil, nil, []int{0, 0, 0}},
+		{"max", []uint{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, nil, []int{1, 2, 3}},
+		{"too many", []uint{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11}, ErrTooManyPinnedChats, []int{1, 0, 0}},
+		{"foreign chat", []uint{2, 20}, ErrNotChatMember, []int{1, 0, 0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, svc := newOrganizationFixture()
+			// До вызова закреплен чат 1
+			repo.member(1, 1).PinnedPosition = 1
+
+			err := svc.SetPinnedChats(context.Background(), 1, tt.chatIDs)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("SetPinnedChats() error = %v, want %v", err, tt.wantErr)
+			}
+			for i, want := range tt.wantPositions {
+				if got := repo.member(uint(i+1), 1).PinnedPosition; got != want {
+					t.Errorf("chat %d position = %d, want %d", i+1, got, want)
+				}
+			}
+			// У собеседника закрепления не меняются
+			if repo.member(1, 2).PinnedPosition != 0 {
+				t.Error("other member's pins changed")
+			}
+		})
+	}
+}
+
+func TestSetChatArchived(t *testing.T) {
+	repo, svc := newOrganizationFixture()
+	ctx := context.Background()
+
+	if err := svc.SetChatArchived(ctx, 1, 20, true); !errors.Is(err, ErrNotChatMember) {
+		t.Fatalf("SetChatArchived() in foreign chat error = %v, want %v", err, ErrNotChatMember)
+	}
+
+	if err := svc.SetChatArchived(ctx, 1, 1, true); err != nil {
+		t.Fatalf("SetChatArchived(true) error = %v", err)
+	}
+	if repo.member(1, 1).ArchivedAt == nil || repo.member(1, 2).ArchivedAt != nil {
+		t.Error("chat is not archived for the user only")
+	}
+
+	if err := svc.SetChatArchived(ctx, 1, 1, false); err != nil {
+		t.Fatalf("SetChatArchived(false) error = %v", err)
+	}
+	if repo.member(1, 1).ArchivedAt != nil {
+		t.Error("chat is still archived")
+	}
+}
+
+func TestCreateChatFolder(t *testing.T) {
+	tests := []struct {
+		name    string
+		folder  model.ChatFolder
+		wantErr error
+	}{
+		{"groups", model.ChatFolder{Name: " Work ", IncludeGroups: true}, nil},
+		{"explicit chats", model.ChatFolder{Name: "Family", IncludeChatIDs: []uint{3, 3, 4}}, nil},
+		{"cyrillic name at limit", model.ChatFolder{Name: strings.Repeat("я", MaxChatFolderNameLength), IncludeDirect: true}, nil},
+		{"no include rule", model.ChatFolder{Name: "Empty", ExcludeRead: true}, ErrInvalidChatFolder},
+		{"blank name", model.ChatFolder{Name: "  ", IncludeGroups: true}, ErrInvalidChatFolder},
+		{"name too long", model.ChatFolder{Name: strings.Repeat("a", MaxChatFolderNameLength+1), IncludeGroups: true}, ErrInvalidChatFolder},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, svc := newOrganizationFixture()
+			folder := tt.folder
+			folder.UserID = 1
+
+			created, err := svc.CreateChatFolder(context.Background(), &folder)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("CreateChatFolder() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				if len(repo.folders) != 0 {
+					t.Error("folder stored on error")
+				}
+				return
+			}
+			stored := repo.folders[created.ID]
+			if stored == nil || stored.Name != strings.TrimSpace(tt.folder.Name) {
+				t.Fatalf("stored = %+v", stored)
+			}
+			if len(tt.folder.IncludeChatIDs) > 0 && len(stored.IncludeChatIDs) != 2 {
+				t.Errorf("IncludeChatIDs = %v, want duplicates removed", stored.IncludeChatIDs)
+			}
+		})
+	}
+}
+
+func TestChatFolderPositionsAndLimit(t *testing.T) {
+	repo, svc := newOrganizationFixture()
+	ctx := context.Background()
+
+	var ids []uint
+	for i := 0; i < MaxChatFolders; i++ {
+		// Позиция из запроса не учитывается: новая папка добавляется в конец
+		folder, err := svc.CreateChatFolder(ctx, &model.ChatFolder{UserID: 1, Name: "f", Position: 0, IncludeGroups: true})
+		if err != nil {
+			t.Fatalf("folder %d error = %v", i, err)
+		}
+		if folder.Position != i {
+			t.Errorf("folder %d position = %d", i, folder.Position)
+		}
+		ids = append(ids, folder.ID)
+	}
+
+	if _, err := svc.CreateChatFolder(ctx, &model.ChatFolder{UserID: 1, Name: "f", IncludeGroups: true}); !errors.Is(err, ErrTooManyChatFolders) {
+		t.Fatalf("folder over limit error = %v, want %v", err, ErrTooManyChatFolders)
+	}
+	// Лимит считается для каждого пользователя отдельно
+	if _, err := svc.CreateChatFolder(ctx, &model.ChatFolder{UserID: 2, Name: "f", IncludeGroups: true}); err != nil {
+		t.Fatalf("other user's folder error = %v", err)
+	}
+
+	// После удаления из середины новая папка встает после последней
+	if err := svc.DeleteChatFolder(ctx, 1, ids[3]); err != nil {
+		t.Fatalf("DeleteChatFolder() error = %v", err)
+	}
+	folder, err := svc.CreateChatFolder(ctx, &model.ChatFolder{UserID: 1, Name: "f", IncludeGroups: true})
+	if err != nil {
+		t.Fatalf("folder after delete error = %v", err)
+	}
+	if folder.Position != MaxChatFolders {
+		t.Errorf("position after delete = %d, want %d", folder.Position, MaxChatFolders)
+	}
+	if len(repo.folders) != MaxChatFolders+1 {
+		t.Errorf("stored %d folders", len(repo.folders))
+	}
+}
+
+func TestUpdateAndDeleteChatFolder(t *testing.T) {
+	repo, svc := newOrganizationFixture()
+	ctx := context.Background()
+
+	folder, err := svc.CreateChatFolder(ctx, &model.ChatFolder{UserID: 1, Name: "Work", IncludeGroups: true})
+	if err != nil {
+		t.Fatalf("CreateChatFolder() error = %v", err)
+	}
+	createdAt := folder.CreatedAt
+
+	// Чужую папку нельзя ни изменить, ни удалить
+	if _, err := svc.UpdateChatFolder(ctx, &model.ChatFolder{ID: folder.ID, UserID: 2, Name: "Mine", IncludeDirect: true}); !errors.Is(err, ErrChatFolderNotFound) {
+		t.Fatalf("UpdateChatFolder() by another user error = %v, want %v", err, ErrChatFolderNotFound)
+	}
+	if err := svc.DeleteChatFolder(ctx, 2, folder.ID); !errors.Is(err, ErrChatFolderNotFound) {
+		t.Fatalf("DeleteChatFolder() by another user error = %v, want %v", err, ErrChatFolderNotFound)
+	}
+
+	if _, err := svc.UpdateChatFolder(ctx, &model.ChatFolder{ID: folder.ID, UserID: 1, Name: "Work"}); !errors.Is(err, ErrInvalidChatFolder) {
+		t.Fatalf("UpdateChatFolder() without include rule error = %v, want %v", err, ErrInvalidChatFolder)
+	}
+
+	updated, err := svc.UpdateChatFolder(ctx, &model.ChatFolder{ID: folder.ID, UserID: 1, Name: " Direct ", Position: 3, IncludeDirect: true})
+	if err != nil {
+		t.Fatalf("UpdateChatFolder() error = %v", err)
+	}
+	if updated.Name != "Direct" || updated.Position != 3 || !updated.IncludeDirect || updated.IncludeGroups {
+		t.Errorf("updated = %+v", updated)
+	}
+	if !repo.folders[folder.ID].CreatedAt.Equal(createdAt) {
+		t.Error("CreatedAt changed on update")
+	}
+
+	if err := svc.DeleteChatFolder(ctx, 1, folder.ID); err != nil {
+		t.Fatalf("DeleteChatFolder() error = %v", err)
+	}
+	if err := svc.DeleteChatFolder(ctx, 1, folder.ID); !errors.Is(err, ErrChatFolderNotFound) {
+		t.Errorf("second DeleteChatFolder() error = %v, want %v", err, ErrChatFolderNotFound)
+	}
+}
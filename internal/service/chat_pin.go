@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"tush00nka/bbbab_messenger/internal/model"
+)
+
+// Ошибки закрепления сообщений
+var (
+	ErrPinNotAllowed        = errors.New("only chat admins can pin messages")
+	ErrMessageNotInChat     = errors.New("message not found in this chat")
+	ErrMessageAlreadyPinned = errors.New("message is already pinned")
+	ErrMessageNotPinned     = errors.New("message is not pinned")
+)
+
+// PinMessage закрепляет сообщение чата от имени userID
+func (s *chatService) PinMessage(ctx context.Context, chatID, messageID, userID uint) (*model.PinnedMessage, error) {
+	message, err := s.checkPinRights(ctx, chatID, messageID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	pin := &model.PinnedMessage{
+		ChatID:     chatID,
+		MessageID:  messageID,
+		PinnedByID: userID,
+	}
+	if err := s.chatRepo.PinMessage(ctx, pin); err != nil {
+		return nil, err
+	}
+	// При конфликте уникального индекса запись не создается
+	if pin.ID == 0 {
+		return nil, ErrMessageAlreadyPinned
+	}
+
+	pin.Message = *message
+	return pin, nil
+}
+
+// UnpinMessage открепляет сообщение чата от имени userID
+func (s *chatService) UnpinMessage(ctx context.Context, chatID, messageID, userID uint) error {
+	if _, err := s.checkPinRights(ctx, chatID, messageID, userID); err != nil {
+		return err
+	}
+
+	ok, err := s.chatRepo.UnpinMessage(ctx, chatID, messageID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrMessageNotPinned
+	}
+
+	return nil
+}
+
+// IsMessagePinned проверяет, закреплено ли сообщение в чате
+func (s *chatService) IsMessagePinned(ctx context.Context, chatID, messageID uint) (bool, error) {
+	if chatID == 0 || messageID == 0 {
+		return false, errors.New("chatID and messageID cannot be zero")
+	}
+
+	return s.chatRepo.IsMessagePinned(ctx, chatID, messageID)
+}
+
+// GetPinnedMessages возвращает закрепленные сообщения чата
+func (s *chatService) GetPinnedMessages(ctx context.Context, chatID uint) ([]model.PinnedMessage, error) {
+	if chatID == 0 {
+		return nil, errors.New("chatID cannot be zero")
+	}
+
+	return s.chatRepo.GetPinnedMessages(ctx, chatID)
+}
+
+// GetLatestPin возвращает последнее закрепленное сообщение чата или nil
+func (s *chatService) GetLatestPin(ctx context.Context, chatID uint) (*model.PinnedMessage, error) {
+	if chatID == 0 {
+		return nil, errors.New("chatID cannot be zero")
+	}
+
+	return s.chatRepo.GetLatestPin(ctx, chatID)
+}
+
+// checkPinRights проверяет, что сообщение принадлежит чату и userID может его закреплять:
+// в группах и каналах — администраторы, в личных чатах — оба участника
+func (s *chatService) checkPinRights(ctx context.Context, chatID, messageID, userID uint) (*model.Message, error) {
+	if chatID == 0 || messageID == 0 || userID == 0 {
+		return nil, errors.New("chatID, messageID and userID cannot be zero")
+	}
+
+	chat, err := s.chatRepo.GetMeta(ctx, chatID)
+	if err != nil {
+		return nil, err
+	}
+	if chat == nil {
+		return nil, errors.New("chat not found")
+	}
+
+	role, err := s.chatRepo.GetUserRole(ctx, chatID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if role == "" {
+		return nil, errors.New("user is not a member of this chat")
+	}
+	if chat.IsGroup && !model.IsAdminRole(role) {
+		return nil, ErrPinNotAllowed
+	}
+
+	message, err := s.chatRepo.GetMessageByID(ctx, messageID)
+	if err != nil {
+		return nil, err
+	}
+	if message == nil || message.ChatID != chatID {
+		return nil, ErrMessageNotInChat
+	}
+
+	return message, nil
+}
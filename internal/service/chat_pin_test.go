@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"tush00nka/bbbab_messenger/internal/model"
+)
+
+// newPinFixture: группа 1 (владелец 1, администратор 2, участник 3) и личный чат 2 пользователей 1 и 3
+func newPinFixture() *memoryChatRepo {
+	repo := newMemoryChatRepo()
+	repo.addGroup(1, 1, 3)
+	repo.addMember(1, 2, model.ChatRoleAdmin)
+	repo.addDirect(2, 1, 3)
+
+	repo.addMessage(10, 1, 3, "group")
+	repo.addMessage(20, 2, 3, "direct")
+	return repo
+}
+
+func TestPinMessagePermissions(t *testing.T) {
+	tests := []struct {
+		name      string
+		chatID    uint
+		messageID uint
+		userID    uint
+		wantErr   error
+	}{
+		{"group owner", 1, 10, 1, nil},
+		{"group admin", 1, 10, 2, nil},
+		{"group member", 1, 10, 3, ErrPinNotAllowed},
+		{"non-member", 1, 10, 9, errAny},
+		{"direct chat member", 2, 20, 3, nil},
+		{"message from another chat", 1, 20, 1, ErrMessageNotInChat},
+		{"missing message", 1, 99, 1, ErrMessageNotInChat},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newPinFixture()
+			svc := newTestChatService(repo)
+
+			pin, err := svc.PinMessage(context.Background(), tt.chatID, tt.messageID, tt.userID)
+			if !matchErr(err, tt.wantErr) {
+				t.Fatalf("PinMessage() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				if len(repo.pins) != 0 {
+					t.Errorf("pinned on error: %+v", repo.pins)
+				}
+				return
+			}
+			if pin.PinnedByID != tt.userID || pin.Message.ID != tt.messageID || len(repo.pins) != 1 {
+				t.Errorf("pin = %+v, stored %d pins", pin, len(repo.pins))
+			}
+		})
+	}
+}
+
+func TestPinMessageTwice(t *testing.T) {
+	repo := newPinFixture()
+	svc := newTestChatService(repo)
+	ctx := context.Background()
+
+	if _, err := svc.PinMessage(ctx, 1, 10, 1); err != nil {
+		t.Fatalf("PinMessage() error = %v", err)
+	}
+	if _, err := svc.PinMessage(ctx, 1, 10, 2); !errors.Is(err, ErrMessageAlreadyPinned) {
+		t.Errorf("second PinMessage() error = %v, want %v", err, ErrMessageAlreadyPinned)
+	}
+	if len(repo.pins) != 1 || repo.pins[0].PinnedByID != 1 {
+		t.Errorf("pins = %+v, want the first pin kept", repo.pins)
+	}
+}
+
+func TestUnpinMessage(t *testing.T) {
+	repo := newPinFixture()
+	svc := newTestChatService(repo)
+	ctx := context.Background()
+
+	if _, err := svc.PinMessage(ctx, 1, 10, 1); err != nil {
+		t.Fatalf("PinMessage() error = %v", err)
+	}
+
+	if err := svc.UnpinMessage(ctx, 1, 10, 3); !errors.Is(err, ErrPinNotAllowed) {
+		t.Fatalf("UnpinMessage() by member error = %v, want %v", err, ErrPinNotAllowed)
+	}
+	if len(repo.pins) != 1 {
+		t.Fatal("member unpinned the message")
+	}
+
+	if err := svc.UnpinMessage(ctx, 1, 10, 2); err != nil {
+		t.Fatalf("UnpinMessage() by admin error = %v", err)
+	}
+	if len(repo.pins) != 0 {
+		t.Errorf("pins after unpin = %+v", repo.pins)
+	}
+	if err := svc.UnpinMessage(ctx, 1, 10, 2); !errors.Is(err, ErrMessageNotPinned) {
+		t.Errorf("second UnpinMessage() error = %v, want %v", err, ErrMessageNotPinned)
+	}
+}
@@ -0,0 +1,251 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"tush00nka/bbbab_messenger/internal/model"
+	"unicode/utf8"
+)
+
+// Ограничения опросов
+const (
+	MaxPollQuestionLength = 300
+	MaxPollOptionLength   = 100
+	MinPollOptions        = 2
+	MaxPollOptions        = 10
+)
+
+// Ошибки опросов
+var (
+	ErrInvalidPoll         = errors.New("invalid poll")
+	ErrPollGroupOnly       = errors.New("polls are available only in group chats")
+	ErrPollNotFound        = errors.New("poll not found")
+	ErrPollClosed          = errors.New("poll is closed")
+	ErrPollSingleChoice    = errors.New("poll allows only one option")
+	ErrPollInvalidOption   = errors.New("option does not belong to this poll")
+	ErrPollCloseNotAllowed = errors.New("only the poll author or chat admins can close the poll")
+)
+
+// validatePoll проверяет опрос в сообщении и приводит его к виду для сохранения.
+// Для сообщений других типов опрос недопустим.
+func validatePoll(message *model.Message) error {
+	if message.Type != model.MessageTypePoll {
+		if message.Poll != nil {
+			return fmt.Errorf("%w: poll is allowed only in poll messages", ErrInvalidPoll)
+		}
+		return nil
+	}
+
+	poll := message.Poll
+	if poll == nil {
+		return fmt.Errorf("%w: poll is required", ErrInvalidPoll)
+	}
+
+	poll.Question = strings.TrimSpace(poll.Question)
+	if poll.Question == "" || utf8.RuneCountInString(poll.Question) > MaxPollQuestionLength {
+		return fmt.Errorf("%w: question must be 1-%d characters", ErrInvalidPoll, MaxPollQuestionLength)
+	}
+
+	if len(poll.Options) < MinPollOptions || len(poll.Options) > MaxPollOptions {
+		return fmt.Errorf("%w: poll must have %d-%d options", ErrInvalidPoll, MinPollOptions, MaxPollOptions)
+	}
+
+	seen := make(map[string]bool, len(poll.Options))
+	options := make([]model.PollOption, len(poll.Options))
+	for i, option := range poll.Options {
+		text := strings.TrimSpace(option.Text)
+		if text == "" || utf8.RuneCountInString(text) > MaxPollOptionLength {
+			return fmt.Errorf("%w: option must be 1-%d characters", ErrInvalidPoll, MaxPollOptionLength)
+		}
+
+		key := strings.ToLower(text)
+		if seen[key] {
+			return fmt.Errorf("%w: options must be unique", ErrInvalidPoll)
+		}
+		seen[key] = true
+
+		// Клиент задает только текст, порядок определяется позицией в списке
+		options[i] = model.PollOption{Position: i, Text: text}
+	}
+
+	if poll.ClosesAt != nil && !poll.ClosesAt.After(time.Now()) {
+		return fmt.Errorf("%w: closes_at must be in the future", ErrInvalidPoll)
+	}
+
+	// Флаг сохраняется явно, чтобы false не подменялся значением по умолчанию в БД
+	anonymous := poll.IsAnonymous()
+	message.Poll = &model.Poll{
+		ChatID:         message.ChatID,
+		Question:       poll.Question,
+		MultipleChoice: poll.MultipleChoice,
+		Anonymous:      &anonymous,
+		ClosesAt:       poll.ClosesAt,
+		Options:        options,
+	}
+
+	if strings.TrimSpace(message.Message) == "" {
+		message.Message = poll.Question
+	}
+
+	return nil
+}
+
+// VotePoll заменяет голос пользователя в опросе сообщения messageID.
+// Пустой optionIDs отзывает голос. Возвращает опрос с обновленными результатами.
+func (s *chatService) VotePoll(ctx context.Context, messageID, userID uint, optionIDs []uint) (*model.Poll, error) {
+	poll, err := s.getPollForMember(ctx, messageID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if poll.IsClosed(time.Now()) {
+		return nil, ErrPollClosed
+	}
+
+	optionIDs = uniqueIDs(optionIDs)
+	if !poll.MultipleChoice && len(optionIDs) > 1 {
+		return nil, ErrPollSingleChoice
+	}
+
+	valid := make(map[uint]bool, len(poll.Options))
+	for _, option := range poll.Options {
+		valid[option.ID] = true
+	}
+	for _, id := range optionIDs {
+		if !valid[id] {
+			return nil, ErrPollInvalidOption
+		}
+	}
+
+	if err := s.chatRepo.ReplacePollVotes(ctx, poll.ID, userID, optionIDs); err != nil {
+		return nil, err
+	}
+
+	return poll, s.loadPollResults(ctx, poll, userID)
+}
+
+// ClosePoll досрочно завершает опрос. Доступно автору опроса и администраторам чата.
+func (s *chatService) ClosePoll(ctx context.Context, messageID, userID uint) (*model.Poll, error) {
+	poll, err := s.getPollForMember(ctx, messageID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	message, err := s.chatRepo.GetMessageByID(ctx, messageID)
+	if err != nil {
+		return nil, err
+	}
+	if message == nil {
+		return nil, ErrPollNotFound
+	}
+
+	if message.SenderID != userID {
+		isAdmin, err := s.IsChatAdmin(ctx, poll.ChatID, userID)
+		if err != nil {
+			return nil, err
+		}
+		if !isAdmin {
+			return nil, ErrPollCloseNotAllowed
+		}
+	}
+
+	now := time.Now()
+	if poll.IsClosed(now) {
+		return nil, ErrPollClosed
+	}
+
+	closed, err := s.chatRepo.ClosePoll(ctx, poll.ID, now)
+	if err != nil {
+		return nil, err
+	}
+	if !closed {
+		return nil, ErrPollClosed
+	}
+	poll.ClosedAt = &now
+
+	return poll, s.loadPollResults(ctx, poll, userID)
+}
+
+// GetPoll возвращает опрос сообщения с результатами для участника чата viewerID
+func (s *chatService) GetPoll(ctx context.Context, messageID, viewerID uint) (*model.Poll, error) {
+	poll, err := s.getPollForMember(ctx, messageID, viewerID)
+	if err != nil {
+		return nil, err
+	}
+
+	return poll, s.loadPollResults(ctx, poll, viewerID)
+}
+
+// AttachPolls подгружает опросы с результатами к сообщениям типа MessageTypePoll.
+// viewerID определяет отмеченные пользователем варианты (0 — без них).
+func (s *chatService) AttachPolls(ctx context.Context, messages []model.Message, viewerID uint) error {
+	var messageIDs []uint
+	for _, m := range messages {
+		if m.Type == model.MessageTypePoll {
+			messageIDs = append(messageIDs, m.ID)
+		}
+	}
+	if len(messageIDs) == 0 {
+		return nil
+	}
+
+	polls, err := s.chatRepo.GetPollsByMessageIDs(ctx, messageIDs)
+	if err != nil {
+		return err
+	}
+	if err := s.chatRepo.LoadPollResults(ctx, polls, viewerID); err != nil {
+		return err
+	}
+
+	byMessage := make(map[uint]*model.Poll, len(polls))
+	for i := range polls {
+		byMessage[polls[i].MessageID] = &polls[i]
+	}
+	for i := range messages {
+		if poll, ok := byMessage[messages[i].ID]; ok {
+			messages[i].Poll = poll
+		}
+	}
+
+	return nil
+}
+
+// getPollForMember возвращает опрос сообщения, если userID состоит в его чате
+func (s *chatService) getPollForMember(ctx context.Context, messageID, userID uint) (*model.Poll, error) {
+	if messageID == 0 || userID == 0 {
+		return nil, errors.New("messageID and userID cannot be zero")
+	}
+
+	poll, err := s.chatRepo.GetPollByMessageID(ctx, messageID)
+	if err != nil {
+		return nil, err
+	}
+	if poll == nil {
+		return nil, ErrPollNotFound
+	}
+
+	inChat, err := s.chatRepo.IsUserInChat(ctx, poll.ChatID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !inChat {
+		// Не раскрываем существование опроса посторонним
+		return nil, ErrPollNotFound
+	}
+
+	return poll, nil
+}
+
+// loadPollResults заполняет результаты одного опроса
+func (s *chatService) loadPollResults(ctx context.Context, poll *model.Poll, viewerID uint) error {
+	polls := []model.Poll{*poll}
+	if err := s.chatRepo.LoadPollResults(ctx, polls, viewerID); err != nil {
+		return err
+	}
+	*poll = polls[0]
+
+	return nil
+}
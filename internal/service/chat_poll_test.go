@@ -0,0 +1,36 @@
+package service
+
+import (
+	"encoding/json"
+	"testing"
+	"tush00nka/bbbab_messenger/internal/model"
+)
+
+func TestValidatePollAnonymous(t *testing.T) {
+	tests := []struct {
+		name string
+		poll string
+		want bool
+	}{
+		{"default is anonymous", `{"question":"q","options":[{"text":"a"},{"text":"b"}]}`, true},
+		{"explicit anonymous", `{"question":"q","anonymous":true,"options":[{"text":"a"},{"text":"b"}]}`, true},
+		{"public", `{"question":"q","anonymous":false,"options":[{"text":"a"},{"text":"b"}]}`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			message := &model.Message{ChatID: 1, Type: model.MessageTypePoll}
+			if err := json.Unmarshal([]byte(tt.poll), &message.Poll); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := validatePoll(message); err != nil {
+				t.Fatalf("validatePoll() error = %v", err)
+			}
+			// Флаг должен быть задан явно, иначе false заменится значением по умолчанию при вставке
+			if message.Poll.Anonymous == nil || *message.Poll.Anonymous != tt.want {
+				t.Errorf("Anonymous = %v, want %v", message.Poll.Anonymous, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"tush00nka/bbbab_messenger/internal/model"
+	"unicode/utf8"
+)
+
+// Ограничения закладок
+const (
+	MaxSavedNoteLength = 1000
+	MaxSavedTags       = 10
+	MaxSavedTagLength  = 32
+	DefaultSavedLimit  = 20
+	MaxSavedLimit      = 100
+)
+
+// Ошибки закладок
+var (
+	ErrSavedSourceDenied = errors.New("message not found or not accessible")
+	ErrSavedNoteTooLong  = errors.New("note is too long")
+	ErrInvalidSavedTags  = errors.New("invalid tags")
+)
+
+// SaveMessage добавляет сообщение в закладки пользователя. Сохранить можно сообщение
+// из любого чата, в котором пользователь состоит; повторный вызов заменяет заметку и теги.
+func (s *chatService) SaveMessage(ctx context.Context, userID, messageID uint, note string, tags []string) (*model.SavedMessage, error) {
+	if userID == 0 || messageID == 0 {
+		return nil, errors.New("userID and messageID cannot be zero")
+	}
+
+	note = strings.TrimSpace(note)
+	if utf8.RuneCountInString(note) > MaxSavedNoteLength {
+		return nil, ErrSavedNoteTooLong
+	}
+
+	tags, err := normalizeSavedTags(tags)
+	if err != nil {
+		return nil, err
+	}
+
+	message, err := s.chatRepo.GetMessageByID(ctx, messageID)
+	if err != nil {
+		return nil, err
+	}
+	if message == nil {
+		return nil, ErrSavedSourceDenied
+	}
+
+	inChat, err := s.chatRepo.IsUserInChat(ctx, message.ChatID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !inChat {
+		return nil, ErrSavedSourceDenied
+	}
+
+	saved := &model.SavedMessage{
+		UserID:    userID,
+		MessageID: messageID,
+		ChatID:    message.ChatID,
+		Note:      note,
+		Tags:      tags,
+	}
+	if err := s.chatRepo.SaveMessage(ctx, saved); err != nil {
+		return nil, err
+	}
+
+	// При обновлении существующей закладки ID и дата создания остаются прежними
+	return s.chatRepo.GetSavedMessage(ctx, userID, messageID)
+}
+
+// GetSavedMessages возвращает страницу закладок пользователя вместе с оригиналами и чатами.
+// У закладок на удаленные сообщения оригинала нет, выставлен OriginalDeletedAt.
+func (s *chatService) GetSavedMessages(
+	ctx context.Context,
+	userID, beforeID uint,
+	tag string,
+	limit int,
+) ([]model.SavedMessage, bool, error) {
+	if userID == 0 {
+		return nil, false, errors.New("userID cannot be zero")
+	}
+
+	if limit <= 0 {
+		limit = DefaultSavedLimit
+	}
+	if limit > MaxSavedLimit {
+		limit = MaxSavedLimit
+	}
+
+	saved, err := s.chatRepo.GetSavedMessages(ctx, userID, beforeID, normalizeSavedTag(tag), limit+1)
+	if err != nil {
+		return nil, false, err
+	}
+
+	hasMore := len(saved) > limit
+	if hasMore {
+		saved = saved[:limit]
+	}
+
+	messageIDs := make([]uint, 0, len(saved))
+	for i := range saved {
+		if saved[i].OriginalDeletedAt == nil {
+			messageIDs = append(messageIDs, saved[i].MessageID)
+		}
+	}
+
+	messages, err := s.chatRepo.GetMessagesByIDs(ctx, messageIDs)
+	if err != nil {
+		return nil, false, err
+	}
+	if err := s.AttachPolls(ctx, messages, userID); err != nil {
+		return nil, false, err
+	}
+
+	byID := make(map[uint]*model.Message, len(messages))
+	for i := range messages {
+		byID[messages[i].ID] = &messages[i]
+	}
+
+	chats := make(map[uint]*model.Chat)
+	for i := range saved {
+		saved[i].Message = byID[saved[i].MessageID]
+
+		chat, ok := chats[saved[i].ChatID]
+		if !ok {
+			if chat, err = s.chatRepo.GetMeta(ctx, saved[i].ChatID); err != nil {
+				return nil, false, err
+			}
+			chats[saved[i].ChatID] = chat
+		}
+		saved[i].Chat = chat
+	}
+
+	return saved, hasMore, nil
+}
+
+// DeleteSavedMessage убирает сообщение из закладок. Возвращает false, если закладки не было.
+func (s *chatService) DeleteSavedMessage(ctx context.Context, userID, messageID uint) (bool, error) {
+	if userID == 0 || messageID == 0 {
+		return false, errors.New("userID and messageID cannot be zero")
+	}
+
+	return s.chatRepo.DeleteSavedMessage(ctx, userID, messageID)
+}
+
+// normalizeSavedTags приводит теги к нижнему регистру без ведущего '#' и убирает повторы
+func normalizeSavedTags(tags []string) ([]string, error) {
+	normalized := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = normalizeSavedTag(tag)
+		if tag == "" || utf8.RuneCountInString(tag) > MaxSavedTagLength || strings.ContainsAny(tag, " \t\n") {
+			return nil, ErrInvalidSavedTags
+		}
+		if seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+
+	if len(normalized) > MaxSavedTags {
+		return nil, ErrInvalidSavedTags
+	}
+
+	return normalized, nil
+}
+
+func normalizeSavedTag(tag string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(tag), "#"))
+}
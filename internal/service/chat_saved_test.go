@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// newSavedFixture: личный чат 1 пользователей 1 и 2, группа 2 без пользователя 1
+func newSavedFixture() (*memoryChatRepo, *chatService) {
+	repo := newMemoryChatRepo()
+	repo.addDirect(1, 1, 2)
+	repo.addGroup(2, 3)
+	repo.addMessage(10, 1, 2, "recipe")
+	repo.addMessage(11, 1, 1, "address")
+	repo.addMessage(20, 2, 3, "private")
+	return repo, newTestChatService(repo)
+}
+
+func TestSaveMessage(t *testing.T) {
+	tests := []struct {
+		name      string
+		messageID uint
+		note      string
+		tags      []string
+		wantErr   error
+		wantTags  []string
+	}{
+		{"plain", 10, "", nil, nil, []string{}},
+		{"normalized tags", 10, "  for later  ", []string{"#Food", "food", " Recipes "}, nil, []string{"food", "recipes"}},
+		{"cyrillic note at limit", 10, strings.Repeat("я", MaxSavedNoteLength), nil, nil, []string{}},
+		{"note too long", 10, strings.Repeat("a", MaxSavedNoteLength+1), nil, ErrSavedNoteTooLong, nil},
+		{"tag with space", 10, "", []string{"two words"}, ErrInvalidSavedTags, nil},
+		{"empty tag", 10, "", []string{"#"}, ErrInvalidSavedTags, nil},
+		{"tag too long", 10, "", []string{strings.Repeat("t", MaxSavedTagLength+1)}, ErrInvalidSavedTags, nil},
+		{"too many tags", 10, "", []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k"}, ErrInvalidSavedTags, nil},
+		{"chat without user", 20, "", nil, ErrSavedSourceDenied, nil},
+		{"missing message", 99, "", nil, ErrSavedSourceDenied, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, svc := newSavedFixture()
+
+			saved, err := svc.SaveMessage(context.Background(), 1, tt.messageID, tt.note, tt.tags)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("SaveMessage() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				if len(repo.saved) != 0 {
+					t.Errorf("saved on error: %+v", repo.saved)
+				}
+				return
+			}
+			if saved.ChatID != 1 || saved.Note != strings.TrimSpace(tt.note) || !slices.Equal(saved.Tags, tt.wantTags) {
+				t.Errorf("saved = chat %d note %q tags %v, want tags %v", saved.ChatID, saved.Note, saved.Tags, tt.wantTags)
+			}
+		})
+	}
+}
+
+func TestSaveMessageTwiceUpdates(t *testing.T) {
+	repo, svc := newSavedFixture()
+	ctx := context.Background()
+
+	first, err := svc.SaveMessage(ctx, 1, 10, "first", []string{"a"})
+	if err != nil {
+		t.Fatalf("SaveMessage() error = %v", err)
+	}
+	second, err := svc.SaveMessage(ctx, 1, 10, "second", []string{"b"})
+	if err != nil {
+		t.Fatalf("second SaveMessage() error = %v", err)
+	}
+
+	if len(repo.saved) != 1 || second.ID != first.ID || !second.CreatedAt.Equal(first.CreatedAt) {
+		t.Fatalf("got %d bookmarks, ids %d/%d", len(repo.saved), first.ID, second.ID)
+	}
+	if second.Note != "second" || !slices.Equal(second.Tags, []string{"b"}) {
+		t.Errorf("updated = %q %v", second.Note, second.Tags)
+	}
+}
+
+func TestGetSavedMessages(t *testing.T) {
+	_, svc := newSavedFixture()
+	ctx := context.Background()
+
+	for _, id := range []uint{10, 11} {
+		if _, err := svc.SaveMessage(ctx, 1, id, "", []string{"work"}); err != nil {
+			t.Fatalf("SaveMessage(%d) error = %v", id, err)
+		}
+	}
+	if _, err := svc.SaveMessage(ctx, 2, 10, "", nil); err != nil {
+		t.Fatalf("SaveMessage() by another user error = %v", err)
+	}
+
+	// Сначала новые; оригинал и чат подставляются
+	page, hasMore, err := svc.GetSavedMessages(ctx, 1, 0, "", 1)
+	if err != nil {
+		t.Fatalf("GetSavedMessages() error = %v", err)
+	}
+	if len(page) != 1 || !hasMore || page[0].MessageID != 11 {
+		t.Fatalf("first page = %d items, hasMore %v", len(page), hasMore)
+	}
+	if page[0].Message == nil || page[0].Message.Message != "address" || page[0].Chat == nil || page[0].Chat.ID != 1 {
+		t.Errorf("first item message = %+v, chat = %+v", page[0].Message, page[0].Chat)
+	}
+
+	page, hasMore, err = svc.GetSavedMessages(ctx, 1, page[0].ID, "", 1)
+	if err != nil || len(page) != 1 || hasMore || page[0].MessageID != 10 {
+		t.Fatalf("second page = %d items, hasMore %v, error %v", len(page), hasMore, err)
+	}
+
+	// Фильтр по тегу нормализуется так же, как теги при сохранении
+	if tagged, _, _ := svc.GetSavedMessages(ctx, 1, 0, "#WORK", 10); len(tagged) != 2 {
+		t.Errorf("tag filter returned %d items, want 2", len(tagged))
+	}
+	if tagged, _, _ := svc.GetSavedMessages(ctx, 1, 0, "home", 10); len(tagged) != 0 {
+		t.Errorf("unknown tag returned %d items", len(tagged))
+	}
+
+	// Удаленный оригинал: закладка остается без сообщения
+	if _, _, err := svc.DeleteMessages(ctx, 2, 1, []uint{10}, true); err != nil {
+		t.Fatalf("DeleteMessages() error = %v", err)
+	}
+	all, _, err := svc.GetSavedMessages(ctx, 1, 0, "", 10)
+	if err != nil || len(all) != 2 {
+		t.Fatalf("after delete = %d items, error %v", len(all), err)
+	}
+	if all[1].Message != nil || all[1].OriginalDeletedAt == nil {
+		t.Errorf("deleted original = %+v", all[1])
+	}
+}
+
+func TestDeleteSavedMessage(t *testing.T) {
+	repo, svc := newSavedFixture()
+	ctx := context.Background()
+
+	if _, err := svc.SaveMessage(ctx, 1, 10, "", nil); err != nil {
+		t.Fatalf("SaveMessage() error = %v", err)
+	}
+
+	if ok, err := svc.DeleteSavedMessage(ctx, 2, 10); err != nil || ok {
+		t.Errorf("DeleteSavedMessage() by another user = %v, %v", ok, err)
+	}
+	if ok, err := svc.DeleteSavedMessage(ctx, 1, 10); err != nil || !ok {
+		t.Errorf("DeleteSavedMessage() = %v, %v", ok, err)
+	}
+	if ok, _ := svc.DeleteSavedMessage(ctx, 1, 10); ok || len(repo.saved) != 0 {
+		t.Errorf("second DeleteSavedMessage() = %v, %d left", ok, len(repo.saved))
+	}
+}
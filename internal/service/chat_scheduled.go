@@ -0,0 +1,243 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"tush00nka/bbbab_messenger/internal/model"
+	"unicode/utf8"
+)
+
+// Ограничения отложенных сообщений
+const (
+	MaxScheduleAhead      = 365 * 24 * time.Hour
+	MaxScheduledPerChat   = 100
+	maxScheduledErrorSize = 500
+)
+
+// Ошибки отложенных сообщений
+var (
+	ErrScheduledNotFound     = errors.New("scheduled message not found")
+	ErrScheduledNotEditable  = errors.New("scheduled message is already sent or canceled")
+	ErrScheduledInPast       = errors.New("send time must be in the future")
+	ErrScheduledTooFar       = errors.New("send time is too far in the future")
+	ErrTooManyScheduled      = errors.New("too many scheduled messages in this chat")
+	ErrScheduledEmptyMessage = errors.New("message cannot be empty")
+)
+
+// ScheduleMessage сохраняет сообщение для отправки в момент message.SendAt
+func (s *chatService) ScheduleMessage(ctx context.Context, message *model.ScheduledMessage) error {
+	if message == nil {
+		return errors.New("scheduled message cannot be nil")
+	}
+	if message.ChatID == 0 || message.SenderID == 0 {
+		return errors.New("chatID and senderID cannot be zero")
+	}
+	text, entities, err := normalizeMessageText(message.Message, message.Entities)
+	if err != nil {
+		return err
+	}
+	if text == "" {
+		return ErrScheduledEmptyMessage
+	}
+	message.Message, message.Entities = text, entities
+
+	if err := validateSendAt(message.SendAt); err != nil {
+		return err
+	}
+
+	inChat, err := s.chatRepo.IsUserInChat(ctx, message.ChatID, message.SenderID)
+	if err != nil {
+		return err
+	}
+	if !inChat {
+		return errors.New("user is not a member of this chat")
+	}
+
+	meta, err := s.chatRepo.GetMeta(ctx, message.ChatID)
+	if err != nil {
+		return err
+	}
+	if meta == nil {
+		return errors.New("chat not found")
+	}
+	if meta.IsChannel {
+		isAdmin, err := s.IsChatAdmin(ctx, message.ChatID, message.SenderID)
+		if err != nil {
+			return err
+		}
+		if !isAdmin {
+			return ErrChannelReadOnly
+		}
+	}
+
+	pending, err := s.chatRepo.GetScheduledMessages(ctx, message.ChatID, message.SenderID)
+	if err != nil {
+		return err
+	}
+	if len(pending) >= MaxScheduledPerChat {
+		return ErrTooManyScheduled
+	}
+
+	if message.Type == "" {
+		message.Type = "text"
+	}
+	// Опрос хранится отдельной записью, отложенное сообщение его не содержит
+	if message.Type == model.MessageTypePoll {
+		return fmt.Errorf("%w: polls cannot be scheduled", ErrInvalidPoll)
+	}
+	message.Status = model.ScheduledPending
+	message.SendAt = message.SendAt.UTC()
+
+	return s.chatRepo.CreateScheduledMessage(ctx, message)
+}
+
+// GetScheduledMessages возвращает ожидающие отправки сообщения пользователя в чате
+func (s *chatService) GetScheduledMessages(ctx context.Context, chatID, userID uint) ([]model.ScheduledMessage, error) {
+	if chatID == 0 || userID == 0 {
+		return nil, errors.New("chatID and userID cannot be zero")
+	}
+
+	return s.chatRepo.GetScheduledMessages(ctx, chatID, userID)
+}
+
+// UpdateScheduledMessage меняет текст и/или время отправки; nil-поля не изменяются
+func (s *chatService) UpdateScheduledMessage(
+	ctx context.Context,
+	userID, id uint,
+	text *string,
+	entities model.MessageEntities,
+	sendAt *time.Time,
+) (*model.ScheduledMessage, error) {
+	message, err := s.getOwnScheduledMessage(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := map[string]any{}
+	if text != nil {
+		// Разметка относится к тексту, поэтому заменяется вместе с ним
+		normalized, normalizedEntities, err := normalizeMessageText(*text, entities)
+		if err != nil {
+			return nil, err
+		}
+		if normalized == "" {
+			return nil, ErrScheduledEmptyMessage
+		}
+		updates["message"] = normalized
+		updates["entities"] = normalizedEntities
+		message.Message, message.Entities = normalized, normalizedEntities
+	}
+	if sendAt != nil {
+		if err := validateSendAt(*sendAt); err != nil {
+			return nil, err
+		}
+		updates["send_at"] = sendAt.UTC()
+		message.SendAt = sendAt.UTC()
+	}
+	if len(updates) == 0 {
+		return message, nil
+	}
+
+	updated, err := s.chatRepo.UpdatePendingScheduledMessage(ctx, id, updates)
+	if err != nil {
+		return nil, err
+	}
+	if !updated {
+		return nil, ErrScheduledNotEditable
+	}
+
+	return message, nil
+}
+
+// CancelScheduledMessage отменяет отправку, если сообщение еще не отправлено
+func (s *chatService) CancelScheduledMessage(ctx context.Context, userID, id uint) (*model.ScheduledMessage, error) {
+	message, err := s.getOwnScheduledMessage(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := s.chatRepo.UpdatePendingScheduledMessage(ctx, id, map[string]any{
+		"status": model.ScheduledCanceled,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !updated {
+		return nil, ErrScheduledNotEditable
+	}
+
+	message.Status = model.ScheduledCanceled
+	return message, nil
+}
+
+// ClaimDueScheduledMessages забирает в отправку сообщения, время которых наступило.
+// staleAfter — через сколько незавершенная отправка считается прерванной.
+func (s *chatService) ClaimDueScheduledMessages(ctx context.Context, limit int, staleAfter time.Duration) ([]model.ScheduledMessage, error) {
+	now := time.Now().UTC()
+	return s.chatRepo.ClaimDueScheduledMessages(ctx, now, now.Add(-staleAfter), limit)
+}
+
+// CompleteScheduledMessage фиксирует результат отправки: sendErr == nil — отправлено
+func (s *chatService) CompleteScheduledMessage(ctx context.Context, id uint, sentMessageID *uint, sendErr error) error {
+	if sendErr == nil {
+		return s.chatRepo.CompleteScheduledMessage(ctx, id, model.ScheduledSent, sentMessageID, "")
+	}
+
+	return s.chatRepo.CompleteScheduledMessage(ctx, id, model.ScheduledFailed, nil, truncateError(sendErr))
+}
+
+// RescheduleScheduledMessage откладывает отправку, например пока действует медленный режим
+func (s *chatService) RescheduleScheduledMessage(ctx context.Context, id uint, sendAt time.Time, reason error) error {
+	return s.chatRepo.RescheduleScheduledMessage(ctx, id, sendAt.UTC(), truncateError(reason))
+}
+
+// getOwnScheduledMessage загружает отложенное сообщение и проверяет, что оно принадлежит пользователю
+func (s *chatService) getOwnScheduledMessage(ctx context.Context, userID, id uint) (*model.ScheduledMessage, error) {
+	if userID == 0 || id == 0 {
+		return nil, errors.New("userID and id cannot be zero")
+	}
+
+	message, err := s.chatRepo.GetScheduledMessage(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	// Чужие сообщения не раскрываем
+	if message == nil || message.SenderID != userID {
+		return nil, ErrScheduledNotFound
+	}
+	if !message.IsEditable() {
+		return nil, ErrScheduledNotEditable
+	}
+
+	return message, nil
+}
+
+// validateSendAt проверяет, что время отправки в будущем и не дальше MaxScheduleAhead
+func validateSendAt(sendAt time.Time) error {
+	now := time.Now()
+	if !sendAt.After(now) {
+		return ErrScheduledInPast
+	}
+	if sendAt.After(now.Add(MaxScheduleAhead)) {
+		return ErrScheduledTooFar
+	}
+
+	return nil
+}
+
+// truncateError приводит текст ошибки к размеру колонки last_error
+func truncateError(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	// Колонка ограничена символами; обрезка по байтам могла бы разорвать UTF-8
+	msg := err.Error()
+	if utf8.RuneCountInString(msg) > maxScheduledErrorSize {
+		msg = string([]rune(msg)[:maxScheduledErrorSize])
+	}
+
+	return msg
+}
@@ -0,0 +1,256 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+	"tush00nka/bbbab_messenger/internal/model"
+	"unicode/utf8"
+)
+
+// newScheduledFixture: группа 1 (владелец 1, участник 2) и канал 2 (владелец 1, подписчик 2)
+func newScheduledFixture() (*memoryChatRepo, *chatService) {
+	repo := newMemoryChatRepo()
+	repo.addGroup(1, 1, 2)
+	repo.addGroup(2, 1, 2).IsChannel = true
+	return repo, newTestChatService(repo)
+}
+
+func newScheduled(chatID, senderID uint, text string, sendAt time.Time) *model.ScheduledMessage {
+	return &model.ScheduledMessage{ChatID: chatID, SenderID: senderID, Message: text, SendAt: sendAt}
+}
+
+func TestScheduleMessage(t *testing.T) {
+	inHour := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name    string
+		message *model.ScheduledMessage
+		wantErr error
+	}{
+		{"member", newScheduled(1, 2, "  later  ", inHour), nil},
+		{"channel admin", newScheduled(2, 1, "news", inHour), nil},
+		{"channel subscriber", newScheduled(2, 2, "hi", inHour), ErrChannelReadOnly},
+		{"non-member", newScheduled(1, 9, "hi", inHour), errAny},
+		{"empty text", newScheduled(1, 2, "   ", inHour), ErrScheduledEmptyMessage},
+		{"in the past", newScheduled(1, 2, "hi", time.Now().Add(-time.Minute)), ErrScheduledInPast},
+		{"too far", newScheduled(1, 2, "hi", time.Now().Add(MaxScheduleAhead+time.Hour)), ErrScheduledTooFar},
+		{"poll", &model.ScheduledMessage{ChatID: 1, SenderID: 2, Message: "?", Type: model.MessageTypePoll, SendAt: inHour}, ErrInvalidPoll},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, svc := newScheduledFixture()
+
+			err := svc.ScheduleMessage(context.Background(), tt.message)
+			if !matchErr(err, tt.wantErr) {
+				t.Fatalf("ScheduleMessage() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				if len(repo.scheduled) != 0 {
+					t.Errorf("stored %d scheduled messages on error", len(repo.scheduled))
+				}
+				return
+			}
+
+			stored := repo.scheduled[tt.message.ID]
+			if stored == nil {
+				t.Fatal("scheduled message was not stored")
+			}
+			if stored.Status != model.ScheduledPending || stored.Type != "text" || stored.SendAt.Location() != time.UTC {
+				t.Errorf("stored = status %q type %q send_at %v", stored.Status, stored.Type, stored.SendAt)
+			}
+			if stored.Message != strings.TrimSpace(tt.message.Message) {
+				t.Errorf("stored text = %q", stored.Message)
+			}
+		})
+	}
+}
+
+func TestScheduleMessageLimit(t *testing.T) {
+	repo, svc := newScheduledFixture()
+	ctx := context.Background()
+	sendAt := time.Now().Add(time.Hour)
+
+	for i := 0; i < MaxScheduledPerChat; i++ {
+		if err := svc.ScheduleMessage(ctx, newScheduled(1, 2, "hi", sendAt)); err != nil {
+			t.Fatalf("message %d error = %v", i, err)
+		}
+	}
+	if err := svc.ScheduleMessage(ctx, newScheduled(1, 2, "one more", sendAt)); !errors.Is(err, ErrTooManyScheduled) {
+		t.Fatalf("message over limit error = %v, want %v", err, ErrTooManyScheduled)
+	}
+
+	// Лимит считается для каждого отправителя отдельно
+	if err := svc.ScheduleMessage(ctx, newScheduled(1, 1, "owner", sendAt)); err != nil {
+		t.Errorf("other sender error = %v", err)
+	}
+
+	// Отмененные сообщения в лимит не входят
+	for _, m := range repo.scheduled {
+		if m.SenderID == 2 {
+			if _, err := svc.CancelScheduledMessage(ctx, 2, m.ID); err != nil {
+				t.Fatalf("CancelScheduledMessage() error = %v", err)
+			}
+			break
+		}
+	}
+	if err := svc.ScheduleMessage(ctx, newScheduled(1, 2, "after cancel", sendAt)); err != nil {
+		t.Errorf("message after cancel error = %v", err)
+	}
+}
+
+func TestUpdateAndCancelScheduledMessage(t *testing.T) {
+	repo, svc := newScheduledFixture()
+	ctx := context.Background()
+
+	message := newScheduled(1, 2, "draft", time.Now().Add(time.Hour))
+	if err := svc.ScheduleMessage(ctx, message); err != nil {
+		t.Fatalf("ScheduleMessage() error = %v", err)
+	}
+
+	text := "final"
+	sendAt := time.Now().Add(2 * time.Hour)
+	if _, err := svc.UpdateScheduledMessage(ctx, 1, message.ID, &text, nil, &sendAt); !errors.Is(err, ErrScheduledNotFound) {
+		t.Fatalf("UpdateScheduledMessage() by another user error = %v, want %v", err, ErrScheduledNotFound)
+	}
+	if _, err := svc.CancelScheduledMessage(ctx, 1, message.ID); !errors.Is(err, ErrScheduledNotFound) {
+		t.Fatalf("CancelScheduledMessage() by another user error = %v, want %v", err, ErrScheduledNotFound)
+	}
+
+	updated, err := svc.UpdateScheduledMessage(ctx, 2, message.ID, &text, nil, &sendAt)
+	if err != nil {
+		t.Fatalf("UpdateScheduledMessage() error = %v", err)
+	}
+	stored := repo.scheduled[message.ID]
+	if updated.Message != "final" || stored.Message != "final" || !stored.SendAt.Equal(sendAt) {
+		t.Errorf("after update stored = %q at %v", stored.Message, stored.SendAt)
+	}
+
+	past := time.Now().Add(-time.Hour)
+	if _, err := svc.UpdateScheduledMessage(ctx, 2, message.ID, nil, nil, &past); !errors.Is(err, ErrScheduledInPast) {
+		t.Errorf("UpdateScheduledMessage() to the past error = %v, want %v", err, ErrScheduledInPast)
+	}
+
+	if _, err := svc.CancelScheduledMessage(ctx, 2, message.ID); err != nil {
+		t.Fatalf("CancelScheduledMessage() error = %v", err)
+	}
+	if stored.Status != model.ScheduledCanceled {
+		t.Errorf("status after cancel = %q", stored.Status)
+	}
+
+	// Отмененное сообщение больше не меняется
+	if _, err := svc.UpdateScheduledMessage(ctx, 2, message.ID, &text, nil, nil); !errors.Is(err, ErrScheduledNotEditable) {
+		t.Errorf("UpdateScheduledMessage() after cancel error = %v, want %v", err, ErrScheduledNotEditable)
+	}
+	if _, err := svc.CancelScheduledMessage(ctx, 2, message.ID); !errors.Is(err, ErrScheduledNotEditable) {
+		t.Errorf("second CancelScheduledMessage() error = %v, want %v", err, ErrScheduledNotEditable)
+	}
+}
+
+// TestScheduledDispatch проходит цикл диспетчера: забрать наступившие сообщения,
+// отправить их в чат и зафиксировать результат
+func TestScheduledDispatch(t *testing.T) {
+	repo, svc := newScheduledFixture()
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	add := func(senderID uint, text string, status string, sendAt, updatedAt time.Time) *model.ScheduledMessage {
+		m := &model.ScheduledMessage{ChatID: 1, SenderID: senderID, Message: text, Type: "text", Status: status, SendAt: sendAt}
+		m.UpdatedAt = updatedAt
+		repo.CreateScheduledMessage(ctx, m)
+		return repo.scheduled[m.ID]
+	}
+	due := add(2, "due", model.ScheduledPending, now.Add(-time.Second), now)
+	future := add(2, "future", model.ScheduledPending, now.Add(time.Hour), now)
+	canceled := add(2, "canceled", model.ScheduledCanceled, now.Add(-time.Second), now)
+	// Отправка прервалась на другом экземпляре
+	stale := add(1, "stale", model.ScheduledProcessing, now.Add(-time.Hour), now.Add(-10*time.Minute))
+	inFlight := add(1, "in flight", model.ScheduledProcessing, now.Add(-time.Minute), now)
+
+	claimed, err := svc.ClaimDueScheduledMessages(ctx, 10, 5*time.Minute)
+	if err != nil {
+		t.Fatalf("ClaimDueScheduledMessages() error = %v", err)
+	}
+	claimedIDs := map[uint]bool{}
+	for _, m := range claimed {
+		claimedIDs[m.ID] = true
+	}
+	if len(claimed) != 2 || !claimedIDs[due.ID] || !claimedIDs[stale.ID] {
+		t.Fatalf("claimed %v, want due and stale messages", claimedIDs)
+	}
+	if future.Status != model.ScheduledPending || canceled.Status != model.ScheduledCanceled || inFlight.Attempts != 0 {
+		t.Errorf("unclaimed messages changed: %q %q attempts=%d", future.Status, canceled.Status, inFlight.Attempts)
+	}
+
+	// Повторный запрос не забирает то, что уже в отправке
+	again, err := svc.ClaimDueScheduledMessages(ctx, 10, 5*time.Minute)
+	if err != nil || len(again) != 0 {
+		t.Fatalf("second claim = %d messages, error %v", len(again), err)
+	}
+
+	// Отправитель stale вышел из чата — отправка завершается ошибкой
+	repo.RemoveUser(ctx, 1, 1)
+	for _, scheduled := range claimed {
+		var sentID *uint
+		var sendErr error
+		if isMember, _ := svc.IsUserInChat(ctx, scheduled.ChatID, scheduled.SenderID); !isMember {
+			sendErr = errors.New("sender is no longer a member of this chat")
+		} else {
+			chat := &model.Chat{}
+			chat.ID = scheduled.ChatID
+			msg := &model.Message{SenderID: scheduled.SenderID, Message: scheduled.Message}
+			if sendErr = svc.SendMessageToChat(ctx, chat, msg); sendErr == nil {
+				sentID = &msg.ID
+			}
+		}
+		if err := svc.CompleteScheduledMessage(ctx, scheduled.ID, sentID, sendErr); err != nil {
+			t.Fatalf("CompleteScheduledMessage() error = %v", err)
+		}
+	}
+
+	if due.Status != model.ScheduledSent || due.SentMessageID == nil || repo.messages[*due.SentMessageID].Message != "due" {
+		t.Errorf("due = status %q sent %v", due.Status, due.SentMessageID)
+	}
+	if stale.Status != model.ScheduledFailed || stale.SentMessageID != nil || stale.LastError == "" {
+		t.Errorf("stale = status %q sent %v error %q", stale.Status, stale.SentMessageID, stale.LastError)
+	}
+	if due.Attempts != 1 || stale.Attempts != 1 {
+		t.Errorf("attempts = %d, %d, want 1", due.Attempts, stale.Attempts)
+	}
+}
+
+func TestRescheduleScheduledMessage(t *testing.T) {
+	repo, svc := newScheduledFixture()
+	ctx := context.Background()
+
+	message := &model.ScheduledMessage{ChatID: 1, SenderID: 2, Message: "hi", Status: model.ScheduledProcessing}
+	repo.CreateScheduledMessage(ctx, message)
+
+	// Медленный режим: сообщение возвращается в очередь на время освобождения слота
+	retryAt := time.Now().Add(20 * time.Second)
+	if err := svc.RescheduleScheduledMessage(ctx, message.ID, retryAt, &SlowModeError{RetryAfter: 20 * time.Second}); err != nil {
+		t.Fatalf("RescheduleScheduledMessage() error = %v", err)
+	}
+	stored := repo.scheduled[message.ID]
+	if stored.Status != model.ScheduledPending || !stored.SendAt.Equal(retryAt) || stored.SendAt.Location() != time.UTC {
+		t.Errorf("stored = status %q send_at %v", stored.Status, stored.SendAt)
+	}
+	if !strings.Contains(stored.LastError, "slow mode") {
+		t.Errorf("LastError = %q", stored.LastError)
+	}
+}
+
+func TestTruncateError(t *testing.T) {
+	if got := truncateError(nil); got != "" {
+		t.Errorf("truncateError(nil) = %q", got)
+	}
+
+	// Обрезка по символам не разрывает многобайтовые символы
+	got := truncateError(errors.New(strings.Repeat("ошибка ", 200)))
+	if n := utf8.RuneCountInString(got); n != maxScheduledErrorSize || !utf8.ValidString(got) {
+		t.Errorf("truncated to %d runes, valid UTF-8 = %v", n, utf8.ValidString(got))
+	}
+}
@@ -0,0 +1,256 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"tush00nka/bbbab_messenger/internal/model"
+	"tush00nka/bbbab_messenger/internal/repository"
+	"unicode/utf8"
+)
+
+// Ограничения поиска
+const (
+	DefaultSearchLimit   = 20
+	MaxSearchLimit       = 100
+	MaxSearchQueryLength = 256
+	// Размер разделов чатов и людей в глобальном поиске
+	GlobalSearchChatsLimit  = 10
+	GlobalSearchPeopleLimit = 10
+)
+
+// Ошибки поиска
+var (
+	ErrEmptySearchQuery    = errors.New("search query cannot be empty")
+	ErrSearchQueryTooLong  = errors.New("search query is too long")
+	ErrInvalidSearchCursor = errors.New("invalid search cursor")
+	ErrInvalidSearchFilter = errors.New("invalid search filter")
+)
+
+// GlobalSearchParams параметры поиска по всем чатам пользователя.
+// Фильтры относятся к сообщениям, нулевые значения не применяются.
+type GlobalSearchParams struct {
+	Query    string
+	ChatID   uint
+	SenderID uint
+	Type     string
+	From     *time.Time
+	To       *time.Time
+	Cursor   string
+	Limit    int
+}
+
+// GlobalSearchResult результаты глобального поиска, сгруппированные по разделам.
+// Чаты и люди возвращаются только на первой странице и без фильтров сообщений.
+type GlobalSearchResult struct {
+	Chats      []model.Chat
+	People     []*model.User
+	Messages   []model.MessageSearchResult
+	NextCursor string
+}
+
+// GlobalSearch ищет по всем чатам пользователя: чаты по названию, людей по имени пользователя
+// и сообщения по тексту
+func (s *chatService) GlobalSearch(ctx context.Context, userID uint, params GlobalSearchParams) (*GlobalSearchResult, error) {
+	if userID == 0 {
+		return nil, errors.New("userID cannot be zero")
+	}
+
+	query := strings.TrimSpace(params.Query)
+	if query == "" {
+		return nil, ErrEmptySearchQuery
+	}
+	if utf8.RuneCountInString(query) > MaxSearchQueryLength {
+		return nil, ErrSearchQueryTooLong
+	}
+
+	if params.Type != "" && !isSearchableMessageType(params.Type) {
+		return nil, fmt.Errorf("%w: unknown message type %q", ErrInvalidSearchFilter, params.Type)
+	}
+	if params.From != nil && params.To != nil && !params.From.Before(*params.To) {
+		return nil, fmt.Errorf("%w: empty date range", ErrInvalidSearchFilter)
+	}
+
+	result := &GlobalSearchResult{
+		Chats:  []model.Chat{},
+		People: []*model.User{},
+	}
+
+	var err error
+	result.Messages, result.NextCursor, err = s.searchMessages(ctx, repository.MessageSearchFilter{
+		ChatID:   params.ChatID,
+		ViewerID: userID,
+		Query:    query,
+		SenderID: params.SenderID,
+		Type:     params.Type,
+		From:     params.From,
+		To:       params.To,
+	}, params.Cursor, params.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	filtered := params.ChatID != 0 || params.SenderID != 0 || params.Type != "" ||
+		params.From != nil || params.To != nil
+	if params.Cursor != "" || filtered {
+		return result, nil
+	}
+
+	if result.Chats, err = s.chatRepo.SearchChats(ctx, userID, query, GlobalSearchChatsLimit); err != nil {
+		return nil, err
+	}
+
+	if s.users != nil {
+		people, err := s.users.SearchUsers(query)
+		if err != nil {
+			return nil, err
+		}
+		if len(people) > GlobalSearchPeopleLimit {
+			people = people[:GlobalSearchPeopleLimit]
+		}
+		for _, user := range people {
+			user.SanitizePassword()
+			// Номера телефонов в выдаче по всем пользователям не раскрываем
+			user.Phone = ""
+		}
+		result.People = people
+	}
+
+	return result, nil
+}
+
+// isSearchableMessageType проверяет тип сообщения в фильтре поиска
+func isSearchableMessageType(messageType string) bool {
+	switch messageType {
+	case model.MessageTypeText, model.MessageTypeImage, model.MessageTypeFile,
+		model.MessageTypeSystem, model.MessageTypePoll:
+		return true
+	}
+	return false
+}
+
+// SearchMessages ищет сообщения в чате по словам с учетом словоформ, кроме скрытых viewerID.
+// Поддерживаются фразы в кавычках и поиск по префиксу ("прив*"). Результаты упорядочены
+// по релевантности; возвращается курсор следующей страницы или пустая строка.
+func (s *chatService) SearchMessages(
+	ctx context.Context,
+	chatID, viewerID uint,
+	query, cursor string,
+	limit int,
+) ([]model.MessageSearchResult, string, error) {
+	if chatID == 0 || viewerID == 0 {
+		return nil, "", errors.New("chatID and viewerID cannot be zero")
+	}
+
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, "", ErrEmptySearchQuery
+	}
+	if utf8.RuneCountInString(query) > MaxSearchQueryLength {
+		return nil, "", ErrSearchQueryTooLong
+	}
+
+	inChat, err := s.chatRepo.IsUserInChat(ctx, chatID, viewerID)
+	if err != nil {
+		return nil, "", err
+	}
+	if !inChat {
+		return nil, "", ErrNotChatMember
+	}
+
+	return s.searchMessages(ctx, repository.MessageSearchFilter{
+		ChatID:   chatID,
+		ViewerID: viewerID,
+		Query:    query,
+	}, cursor, limit)
+}
+
+// searchMessages выполняет поиск с пагинацией по курсору и подгружает опросы
+func (s *chatService) searchMessages(
+	ctx context.Context,
+	filter repository.MessageSearchFilter,
+	cursor string,
+	limit int,
+) ([]model.MessageSearchResult, string, error) {
+	after, err := decodeSearchCursor(cursor)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if limit <= 0 {
+		limit = DefaultSearchLimit
+	}
+	if limit > MaxSearchLimit {
+		limit = MaxSearchLimit
+	}
+
+	filter.After = after
+	filter.Limit = limit + 1
+
+	results, err := s.chatRepo.SearchMessages(ctx, filter)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var nextCursor string
+	if len(results) > limit {
+		results = results[:limit]
+		last := results[len(results)-1]
+		nextCursor = encodeSearchCursor(repository.SearchCursor{Rank: last.Rank, ID: last.Message.ID})
+	}
+
+	if err := s.attachSearchPolls(ctx, results, filter.ViewerID); err != nil {
+		return nil, "", err
+	}
+
+	return results, nextCursor, nil
+}
+
+// attachSearchPolls подгружает результаты опросов среди найденных сообщений
+func (s *chatService) attachSearchPolls(ctx context.Context, results []model.MessageSearchResult, viewerID uint) error {
+	messages := make([]model.Message, len(results))
+	for i := range results {
+		messages[i] = results[i].Message
+	}
+
+	if err := s.AttachPolls(ctx, messages, viewerID); err != nil {
+		return err
+	}
+
+	for i := range results {
+		results[i].Message.Poll = messages[i].Poll
+	}
+
+	return nil
+}
+
+// encodeSearchCursor кодирует позицию выдачи как "<релевантность>_<ID>"
+func encodeSearchCursor(c repository.SearchCursor) string {
+	return strconv.FormatFloat(float64(c.Rank), 'g', -1, 32) + "_" + strconv.FormatUint(uint64(c.ID), 10)
+}
+
+// decodeSearchCursor разбирает курсор; пустая строка — первая страница
+func decodeSearchCursor(cursor string) (*repository.SearchCursor, error) {
+	if cursor == "" {
+		return nil, nil
+	}
+
+	rankStr, idStr, ok := strings.Cut(cursor, "_")
+	if !ok {
+		return nil, ErrInvalidSearchCursor
+	}
+
+	rank, err := strconv.ParseFloat(rankStr, 32)
+	if err != nil {
+		return nil, ErrInvalidSearchCursor
+	}
+	id, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil || id == 0 {
+		return nil, ErrInvalidSearchCursor
+	}
+
+	return &repository.SearchCursor{Rank: float32(rank), ID: uint(id)}, nil
+}
@@ -0,0 +1,186 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+	"tush00nka/bbbab_messenger/internal/model"
+	"tush00nka/bbbab_messenger/internal/repository"
+)
+
+// searchUsers возвращает одних и тех же найденных пользователей на любой запрос
+type searchUsers struct {
+	UserService
+	found int
+}
+
+func (u *searchUsers) SearchUsers(string) ([]*model.User, error) {
+	users := make([]*model.User, u.found)
+	for i := range users {
+		users[i] = &model.User{Username: fmt.Sprintf("user%d", i), Password: "hash", Phone: "+70000000000"}
+		users[i].ID = uint(100 + i)
+	}
+	return users, nil
+}
+
+// newSearchFixture: пользователь 1 состоит в чатах 1 и 2, поиск сообщений находит count результатов
+func newSearchFixture(count int) (*memoryChatRepo, *chatService) {
+	repo := newMemoryChatRepo()
+	repo.addDirect(1, 1, 2)
+	repo.addGroup(2, 1)
+	repo.addGroup(3, 2)
+	for i := 0; i < count; i++ {
+		m := model.Message{ChatID: 1, Message: "hello", Type: model.MessageTypeText}
+		m.ID = uint(10 + i)
+		repo.searchResults = append(repo.searchResults, model.MessageSearchResult{Message: m, Rank: 0.5})
+	}
+	return repo, newTestChatService(repo, ChatServiceOptions{Users: &searchUsers{found: GlobalSearchPeopleLimit + 5}})
+}
+
+func TestGlobalSearchSections(t *testing.T) {
+	repo, svc := newSearchFixture(3)
+
+	result, err := svc.GlobalSearch(context.Background(), 1, GlobalSearchParams{Query: "  hello  "})
+	if err != nil {
+		t.Fatalf("GlobalSearch() error = %v", err)
+	}
+
+	if len(result.Messages) != 3 || result.NextCursor != "" {
+		t.Errorf("messages = %d, next cursor %q", len(result.Messages), result.NextCursor)
+	}
+	// Только чаты пользователя
+	if len(result.Chats) != 2 {
+		t.Errorf("chats = %d, want 2", len(result.Chats))
+	}
+	if len(result.People) != GlobalSearchPeopleLimit {
+		t.Errorf("people = %d, want %d", len(result.People), GlobalSearchPeopleLimit)
+	}
+	for _, user := range result.People {
+		if user.Password != "" || user.Phone != "" {
+			t.Errorf("user %d exposes password or phone", user.ID)
+		}
+	}
+
+	filter := repo.searches[0]
+	if filter.ViewerID != 1 || filter.Query != "hello" || filter.ChatID != 0 {
+		t.Errorf("message filter = %+v", filter)
+	}
+}
+
+func TestGlobalSearchFilteredSkipsChatsAndPeople(t *testing.T) {
+	from := time.Now().Add(-time.Hour)
+	to := time.Now()
+
+	tests := []struct {
+		name   string
+		params GlobalSearchParams
+	}{
+		{"chat", GlobalSearchParams{Query: "hello", ChatID: 1}},
+		{"sender", GlobalSearchParams{Query: "hello", SenderID: 2}},
+		{"type", GlobalSearchParams{Query: "hello", Type: model.MessageTypeImage}},
+		{"dates", GlobalSearchParams{Query: "hello", From: &from, To: &to}},
+		{"next page", GlobalSearchParams{Query: "hello", Cursor: "0.5_10"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, svc := newSearchFixture(1)
+
+			result, err := svc.GlobalSearch(context.Background(), 1, tt.params)
+			if err != nil {
+				t.Fatalf("GlobalSearch() error = %v", err)
+			}
+			if len(result.Chats) != 0 || len(result.People) != 0 || len(result.Messages) != 1 {
+				t.Errorf("chats %d, people %d, messages %d", len(result.Chats), len(result.People), len(result.Messages))
+			}
+
+			filter := repo.searches[0]
+			if filter.ChatID != tt.params.ChatID || filter.SenderID != tt.params.SenderID || filter.Type != tt.params.Type ||
+				filter.From != tt.params.From || filter.To != tt.params.To {
+				t.Errorf("filter = %+v, want params %+v", filter, tt.params)
+			}
+			if tt.params.Cursor != "" && (filter.After == nil || filter.After.ID != 10) {
+				t.Errorf("filter.After = %+v", filter.After)
+			}
+		})
+	}
+}
+
+func TestGlobalSearchValidation(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name    string
+		params  GlobalSearchParams
+		wantErr error
+	}{
+		{"empty query", GlobalSearchParams{Query: "   "}, ErrEmptySearchQuery},
+		{"query too long", GlobalSearchParams{Query: strings.Repeat("я", MaxSearchQueryLength+1)}, ErrSearchQueryTooLong},
+		{"unknown type", GlobalSearchParams{Query: "hi", Type: "sticker"}, ErrInvalidSearchFilter},
+		{"empty date range", GlobalSearchParams{Query: "hi", From: &now, To: &now}, ErrInvalidSearchFilter},
+		{"bad cursor", GlobalSearchParams{Query: "hi", Cursor: "abc"}, ErrInvalidSearchCursor},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, svc := newSearchFixture(1)
+
+			if _, err := svc.GlobalSearch(context.Background(), 1, tt.params); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("GlobalSearch() error = %v, want %v", err, tt.wantErr)
+			}
+			if len(repo.searches) != 0 {
+				t.Error("search ran despite invalid params")
+			}
+		})
+	}
+}
+
+func TestSearchMessagesInChat(t *testing.T) {
+	repo, svc := newSearchFixture(5)
+	ctx := context.Background()
+
+	if _, _, err := svc.SearchMessages(ctx, 3, 1, "hello", "", 2); !errors.Is(err, ErrNotChatMember) {
+		t.Fatalf("SearchMessages() in foreign chat error = %v, want %v", err, ErrNotChatMember)
+	}
+
+	results, cursor, err := svc.SearchMessages(ctx, 1, 1, "hello", "", 2)
+	if err != nil {
+		t.Fatalf("SearchMessages() error = %v", err)
+	}
+	if len(results) != 2 || cursor != "0.5_11" {
+		t.Errorf("got %d results, cursor %q, want 2 and 0.5_11", len(results), cursor)
+	}
+
+	// Запрашивается на один результат больше, чтобы понять, есть ли следующая страница
+	want := repository.MessageSearchFilter{ChatID: 1, ViewerID: 1, Query: "hello", Limit: 3}
+	if got := repo.searches[0]; got.ChatID != want.ChatID || got.ViewerID != want.ViewerID ||
+		got.Query != want.Query || got.Limit != want.Limit || got.After != nil {
+		t.Errorf("filter = %+v, want %+v", got, want)
+	}
+
+	// Курсор следующей страницы передается в репозиторий
+	if _, _, err := svc.SearchMessages(ctx, 1, 1, "hello", cursor, 2); err != nil {
+		t.Fatalf("SearchMessages() next page error = %v", err)
+	}
+	if after := repo.searches[1].After; after == nil || after.ID != 11 || after.Rank != 0.5 {
+		t.Errorf("After = %+v, want rank 0.5 id 11", after)
+	}
+}
+
+func TestSearchCursorRoundTrip(t *testing.T) {
+	for _, c := range []repository.SearchCursor{{Rank: 0.0607927, ID: 1}, {Rank: 1e-20, ID: 42}, {Rank: 0, ID: 7}} {
+		decoded, err := decodeSearchCursor(encodeSearchCursor(c))
+		if err != nil || *decoded != c {
+			t.Errorf("round trip %+v = %+v, %v", c, decoded, err)
+		}
+	}
+
+	for _, cursor := range []string{"0.5", "x_1", "0.5_x", "0.5_0"} {
+		if _, err := decodeSearchCursor(cursor); !errors.Is(err, ErrInvalidSearchCursor) {
+			t.Errorf("decodeSearchCursor(%q) error = %v", cursor, err)
+		}
+	}
+}
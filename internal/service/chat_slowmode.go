@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// MaxSlowModeSeconds максимальный интервал медленного режима
+const MaxSlowModeSeconds = 3600
+
+// SendRateLimiter ограничивает частоту отправки сообщений участником чата.
+// AcquireSendSlot возвращает оставшееся время ожидания, если отправлять еще рано.
+type SendRateLimiter interface {
+	AcquireSendSlot(ctx context.Context, chatID, userID uint, interval time.Duration) (time.Duration, error)
+}
+
+// SlowModeError ошибка отправки сообщения раньше, чем истек интервал медленного режима
+type SlowModeError struct {
+	RetryAfter time.Duration
+}
+
+func (e *SlowModeError) Error() string {
+	return fmt.Sprintf("slow mode is enabled, retry in %d seconds", e.RetryAfterSeconds())
+}
+
+// RetryAfterSeconds оставшееся время ожидания в секундах с округлением вверх
+func (e *SlowModeError) RetryAfterSeconds() int {
+	return int(math.Ceil(e.RetryAfter.Seconds()))
+}
+
+// SetSlowMode задает интервал медленного режима группы в секундах (0 — выключить)
+func (s *chatService) SetSlowMode(ctx context.Context, chatID uint, seconds int) error {
+	if chatID == 0 {
+		return errors.New("chatID cannot be zero")
+	}
+	if seconds < 0 || seconds > MaxSlowModeSeconds {
+		return fmt.Errorf("slow mode interval must be between 0 and %d seconds", MaxSlowModeSeconds)
+	}
+
+	chat, err := s.chatRepo.GetMeta(ctx, chatID)
+	if err != nil {
+		return err
+	}
+	if chat == nil || !chat.IsGroup {
+		return errors.New("slow mode is only available for group chats")
+	}
+
+	return s.chatRepo.UpdateSlowMode(ctx, chatID, seconds)
+}
+
+// checkSlowMode резервирует слот отправки для участника; администраторы не ограничены
+func (s *chatService) checkSlowMode(ctx context.Context, chatID, userID uint, seconds int, isAdmin bool) error {
+	if seconds <= 0 || isAdmin || s.rateLimiter == nil {
+		return nil
+	}
+
+	wait, err := s.rateLimiter.AcquireSendSlot(ctx, chatID, userID, time.Duration(seconds)*time.Second)
+	if err != nil {
+		// Недоступность Redis не должна блокировать отправку сообщений
+		return nil
+	}
+	if wait > 0 {
+		return &SlowModeError{RetryAfter: wait}
+	}
+
+	return nil
+}
@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"tush00nka/bbbab_messenger/internal/model"
+)
+
+// memoryRateLimiter выдает слот отправки не чаще раза в interval по часам now
+type memoryRateLimiter struct {
+	now      time.Time
+	lastSent map[[2]uint]time.Time
+	err      error
+}
+
+func (l *memoryRateLimiter) AcquireSendSlot(_ context.Context, chatID, userID uint, interval time.Duration) (time.Duration, error) {
+	if l.err != nil {
+		return 0, l.err
+	}
+	key := [2]uint{chatID, userID}
+	if last, ok := l.lastSent[key]; ok {
+		if wait := last.Add(interval).Sub(l.now); wait > 0 {
+			return wait, nil
+		}
+	}
+	l.lastSent[key] = l.now
+	return 0, nil
+}
+
+func newSlowModeFixture(seconds int) (*memoryChatRepo, *memoryRateLimiter, *chatService) {
+	repo := newMemoryChatRepo()
+	repo.addGroup(1, 1, 3).SlowModeSeconds = seconds
+	repo.addMember(1, 2, model.ChatRoleAdmin)
+
+	limiter := &memoryRateLimiter{now: time.Now(), lastSent: make(map[[2]uint]time.Time)}
+	return repo, limiter, newTestChatService(repo, ChatServiceOptions{RateLimiter: limiter})
+}
+
+func sendText(svc *chatService, chatID, senderID uint, text string) error {
+	chat := &model.Chat{}
+	chat.ID = chatID
+	return svc.SendMessageToChat(context.Background(), chat, &model.Message{SenderID: senderID, Message: text})
+}
+
+func TestSlowModeLimitsMembers(t *testing.T) {
+	_, limiter, svc := newSlowModeFixture(30)
+
+	if err := sendText(svc, 1, 3, "first"); err != nil {
+		t.Fatalf("first message error = %v", err)
+	}
+
+	limiter.now = limiter.now.Add(10*time.Second + 500*time.Millisecond)
+	err := sendText(svc, 1, 3, "too soon")
+	var slowMode *SlowModeError
+	if !errors.As(err, &slowMode) {
+		t.Fatalf("second message error = %v, want SlowModeError", err)
+	}
+	// Ожидание округляется вверх до целых секунд
+	if got := slowMode.RetryAfterSeconds(); got != 20 {
+		t.Errorf("RetryAfterSeconds() = %d, want 20", got)
+	}
+
+	// Интервал считается для каждого участника отдельно
+	if err := sendText(svc, 1, 1, "owner"); err != nil {
+		t.Errorf("owner message error = %v", err)
+	}
+
+	limiter.now = limiter.now.Add(20 * time.Second)
+	if err := sendText(svc, 1, 3, "after interval"); err != nil {
+		t.Errorf("message after interval error = %v", err)
+	}
+}
+
+func TestSlowModeBypass(t *testing.T) {
+	tests := []struct {
+		name       string
+		seconds    int
+		senderID   uint
+		limiterErr error
+	}{
+		{"admin", 30, 2, nil},
+		{"owner", 30, 1, nil},
+		{"disabled", 0, 3, nil},
+		// Недоступный ограничитель не блокирует отправку
+		{"limiter unavailable", 30, 3, errors.New("redis is down")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, limiter, svc := newSlowModeFixture(tt.seconds)
+			limiter.err = tt.limiterErr
+
+			for i := 0; i < 3; i++ {
+				if err := sendText(svc, 1, tt.senderID, "hi"); err != nil {
+					t.Fatalf("message %d error = %v", i, err)
+				}
+			}
+		})
+	}
+}
+
+func TestSlowModeDoesNotSaveRejectedMessage(t *testing.T) {
+	repo, _, svc := newSlowModeFixture(60)
+
+	sendText(svc, 1, 3, "first")
+	if err := sendText(svc, 1, 3, "second"); err == nil {
+		t.Fatal("second message was accepted")
+	}
+	if len(repo.messages) != 1 {
+		t.Errorf("stored %d messages, want 1", len(repo.messages))
+	}
+}
+
+func TestSetSlowMode(t *testing.T) {
+	tests := []struct {
+		name    string
+		chatID  uint
+		seconds int
+		wantErr bool
+	}{
+		{"enable", 1, 30, false},
+		{"max", 1, MaxSlowModeSeconds, false},
+		{"disable", 1, 0, false},
+		{"negative", 1, -1, true},
+		{"too long", 1, MaxSlowModeSeconds + 1, true},
+		{"direct chat", 2, 30, true},
+		{"missing chat", 9, 30, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, _, svc := newSlowModeFixture(10)
+			repo.addDirect(2, 1, 3)
+
+			err := svc.SetSlowMode(context.Background(), tt.chatID, tt.seconds)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SetSlowMode() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			want := 10
+			if !tt.wantErr {
+				want = tt.seconds
+			}
+			if got := repo.chats[1].SlowModeSeconds; got != want {
+				t.Errorf("SlowModeSeconds = %d, want %d", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,778 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+	"tush00nka/bbbab_messenger/internal/model"
+	"tush00nka/bbbab_messenger/internal/repository"
+)
+
+// memoryChatRepo хранит чаты, участников, сообщения и связанные с ними записи в памяти.
+// Он повторяет поведение репозитория в том, что проверяют тесты сервиса; методы,
+// которые ни одному тесту не нужны, остаются у встроенного интерфейса и паникуют при вызове.
+type memoryChatRepo struct {
+	repository.ChatRepository
+
+	nextID       uint
+	users        map[uint]model.User
+	chats        map[uint]*model.Chat
+	members      map[uint][]*model.ChatUser // в порядке вступления
+	messages     map[uint]*model.Message
+	hidden       []model.HiddenMessage
+	bans         []model.ChatBan
+	pins         []model.PinnedMessage
+	joinRequests map[uint]*model.ChatJoinRequest
+	scheduled    map[uint]*model.ScheduledMessage
+	folders      map[uint]*model.ChatFolder
+	saved        []model.SavedMessage
+
+	// searchResults возвращаются из SearchMessages, фильтры запросов сохраняются в searches
+	searchResults []model.MessageSearchResult
+	searches      []repository.MessageSearchFilter
+
+	// sendErr возвращается из SendMessage и SendMessages
+	sendErr error
+}
+
+func newMemoryChatRepo() *memoryChatRepo {
+	return &memoryChatRepo{
+		nextID:       1000,
+		users:        make(map[uint]model.User),
+		chats:        make(map[uint]*model.Chat),
+		members:      make(map[uint][]*model.ChatUser),
+		messages:     make(map[uint]*model.Message),
+		joinRequests: make(map[uint]*model.ChatJoinRequest),
+		scheduled:    make(map[uint]*model.ScheduledMessage),
+		folders:      make(map[uint]*model.ChatFolder),
+	}
+}
+
+// errAny в таблицах тестов означает любую ошибку, когда ее значение не проверяется
+var errAny = errors.New("any error")
+
+// matchErr сравнивает ошибку с ожидаемой с учетом errAny
+func matchErr(err, want error) bool {
+	if want == errAny {
+		return err != nil
+	}
+	return errors.Is(err, want)
+}
+
+// newTestChatService создает сервис чатов поверх repo
+func newTestChatService(repo repository.ChatRepository, options ...ChatServiceOptions) *chatService {
+	return NewChatService(repo, options...).(*chatService)
+}
+
+// addGroup добавляет группу, в которой ownerID — владелец, а остальные — участники
+func (r *memoryChatRepo) addGroup(id, ownerID uint, memberIDs ...uint) *model.Chat {
+	chat := r.addChat(id, true)
+	r.addMember(id, ownerID, model.ChatRoleOwner)
+	for _, userID := range memberIDs {
+		r.addMember(id, userID, model.ChatRoleMember)
+	}
+	return chat
+}
+
+// addDirect добавляет личный чат двух пользователей
+func (r *memoryChatRepo) addDirect(id, user1ID, user2ID uint) *model.Chat {
+	chat := r.addChat(id, false)
+	r.addMember(id, user1ID, model.ChatRoleMember)
+	r.addMember(id, user2ID, model.ChatRoleMember)
+	return chat
+}
+
+// addUser добавляет профиль, который вернет GetChatUsers
+func (r *memoryChatRepo) addUser(id uint, username string) {
+	user := model.User{Username: username, DisplayName: username}
+	user.ID = id
+	r.users[id] = user
+}
+
+func (r *memoryChatRepo) addChat(id uint, isGroup bool) *model.Chat {
+	chat := &model.Chat{Name: "chat", IsGroup: isGroup}
+	chat.ID = id
+	r.chats[id] = chat
+	return chat
+}
+
+func (r *memoryChatRepo) addMember(chatID, userID uint, role string) *model.ChatUser {
+	member := &model.ChatUser{ChatID: chatID, UserID: userID, Role: role, NotifyMode: model.NotifyAll}
+	r.members[chatID] = append(r.members[chatID], member)
+	return member
+}
+
+// addMessage добавляет сообщение, отправленное только что
+func (r *memoryChatRepo) addMessage(id, chatID, senderID uint, text string) *model.Message {
+	message := &model.Message{ChatID: chatID, SenderID: senderID, Message: text, Type: model.MessageTypeText}
+	message.ID = id
+	message.CreatedAt = time.Now()
+	r.messages[id] = message
+	return message
+}
+
+func (r *memoryChatRepo) member(chatID, userID uint) *model.ChatUser {
+	for _, m := range r.members[chatID] {
+		if m.UserID == userID {
+			return m
+		}
+	}
+	return nil
+}
+
+func (r *memoryChatRepo) newID() uint {
+	r.nextID++
+	return r.nextID
+}
+
+// Чаты
+
+func (r *memoryChatRepo) GetMeta(_ context.Context, chatID uint) (*model.Chat, error) {
+	chat, ok := r.chats[chatID]
+	if !ok {
+		return nil, nil
+	}
+	copied := *chat
+	return &copied, nil
+}
+
+func (r *memoryChatRepo) Delete(_ context.Context, chatID uint) error {
+	delete(r.chats, chatID)
+	delete(r.members, chatID)
+	return nil
+}
+
+func (r *memoryChatRepo) UpdateGroupInfo(_ context.Context, chatID uint, name, description string) error {
+	chat, ok := r.chats[chatID]
+	if !ok {
+		return errors.New("chat not found")
+	}
+	chat.Name, chat.Description = name, description
+	return nil
+}
+
+func (r *memoryChatRepo) UpdateAvatar(_ context.Context, chatID uint, avatarKey string) error {
+	chat, ok := r.chats[chatID]
+	if !ok {
+		return errors.New("chat not found")
+	}
+	chat.AvatarKey = avatarKey
+	return nil
+}
+
+func (r *memoryChatRepo) UpdateSlowMode(_ context.Context, chatID uint, seconds int) error {
+	chat, ok := r.chats[chatID]
+	if !ok {
+		return errors.New("chat not found")
+	}
+	chat.SlowModeSeconds = seconds
+	return nil
+}
+
+func (r *memoryChatRepo) UpdateMessageTTL(_ context.Context, chatID uint, seconds int) error {
+	chat, ok := r.chats[chatID]
+	if !ok {
+		return errors.New("chat not found")
+	}
+	chat.MessageTTLSeconds = seconds
+	return nil
+}
+
+func (r *memoryChatRepo) UpdateJoinByRequest(_ context.Context, chatID uint, enabled bool) error {
+	chat, ok := r.chats[chatID]
+	if !ok {
+		return errors.New("chat not found")
+	}
+	chat.JoinByRequest = enabled
+	return nil
+}
+
+// Участники
+
+func (r *memoryChatRepo) AddUser(_ context.Context, chatID, userID uint) error {
+	if r.member(chatID, userID) != nil {
+		return errors.New("duplicate key value violates unique constraint")
+	}
+	r.addMember(chatID, userID, model.ChatRoleMember)
+	return nil
+}
+
+func (r *memoryChatRepo) RemoveUser(_ context.Context, chatID, userID uint) error {
+	r.members[chatID] = slices.DeleteFunc(r.members[chatID], func(m *model.ChatUser) bool { return m.UserID == userID })
+	return nil
+}
+
+func (r *memoryChatRepo) GetChatUsers(_ context.Context, chatID uint) ([]model.User, error) {
+	var users []model.User
+	for _, m := range r.members[chatID] {
+		user, ok := r.users[m.UserID]
+		if !ok {
+			user.ID = m.UserID
+		}
+		users = append(users, user)
+	}
+	return users, nil
+}
+
+func (r *memoryChatRepo) IsUserInChat(_ context.Context, chatID, userID uint) (bool, error) {
+	return r.member(chatID, userID) != nil, nil
+}
+
+func (r *memoryChatRepo) GetUserRole(_ context.Context, chatID, userID uint) (string, error) {
+	if m := r.member(chatID, userID); m != nil {
+		return m.Role, nil
+	}
+	return "", nil
+}
+
+func (r *memoryChatRepo) SetUserRole(_ context.Context, chatID, userID uint, role string) error {
+	m := r.member(chatID, userID)
+	if m == nil {
+		return errors.New("user is not a member of this chat")
+	}
+	m.Role = role
+	return nil
+}
+
+func (r *memoryChatRepo) TransferOwnership(_ context.Context, chatID, fromUserID, toUserID uint) error {
+	from, to := r.member(chatID, fromUserID), r.member(chatID, toUserID)
+	if from == nil || from.Role != model.ChatRoleOwner {
+		return errors.New("user is not the chat owner")
+	}
+	if to == nil {
+		return errors.New("user is not a member of this chat")
+	}
+	from.Role, to.Role = model.ChatRoleAdmin, model.ChatRoleOwner
+	return nil
+}
+
+func (r *memoryChatRepo) GetChatAdminIDs(_ context.Context, chatID uint) ([]uint, error) {
+	var ids []uint
+	for _, m := range r.members[chatID] {
+		if model.IsAdminRole(m.Role) {
+			ids = append(ids, m.UserID)
+		}
+	}
+	return ids, nil
+}
+
+// Сообщения
+
+func (r *memoryChatRepo) SendMessage(_ context.Context, chat *model.Chat, message *model.Message) error {
+	if r.sendErr != nil {
+		return r.sendErr
+	}
+	message.ChatID = chat.ID
+	message.ID = r.newID()
+	stored := *message
+	r.messages[message.ID] = &stored
+	return nil
+}
+
+func (r *memoryChatRepo) SendMessages(_ context.Context, messages []model.Message) error {
+	if r.sendErr != nil {
+		return r.sendErr
+	}
+	for i := range messages {
+		messages[i].ID = r.newID()
+		stored := messages[i]
+		r.messages[stored.ID] = &stored
+	}
+	return nil
+}
+
+func (r *memoryChatRepo) GetMessageByID(_ context.Context, messageID uint) (*model.Message, error) {
+	message, ok := r.messages[messageID]
+	if !ok {
+		return nil, nil
+	}
+	copied := *message
+	return &copied, nil
+}
+
+func (r *memoryChatRepo) GetMessagesByIDs(_ context.Context, messageIDs []uint) ([]model.Message, error) {
+	var messages []model.Message
+	for _, id := range messageIDs {
+		if message, ok := r.messages[id]; ok {
+			messages = append(messages, *message)
+		}
+	}
+	return messages, nil
+}
+
+func (r *memoryChatRepo) DeleteMessages(_ context.Context, messageIDs []uint) ([]uint, error) {
+	var pinnedIDs []uint
+	r.pins = slices.DeleteFunc(r.pins, func(p model.PinnedMessage) bool {
+		if slices.Contains(messageIDs, p.MessageID) {
+			pinnedIDs = append(pinnedIDs, p.MessageID)
+			return true
+		}
+		return false
+	})
+	now := time.Now()
+	for i := range r.saved {
+		if slices.Contains(messageIDs, r.saved[i].MessageID) {
+			r.saved[i].OriginalDeletedAt = &now
+		}
+	}
+	for _, id := range messageIDs {
+		delete(r.messages, id)
+	}
+	return pinnedIDs, nil
+}
+
+func (r *memoryChatRepo) HideMessages(_ context.Context, userID, chatID uint, messageIDs []uint) error {
+	for _, id := range messageIDs {
+		r.hidden = append(r.hidden, model.HiddenMessage{UserID: userID, ChatID: chatID, MessageID: id})
+	}
+	return nil
+}
+
+func (r *memoryChatRepo) GetHiddenMessageIDs(_ context.Context, userID, chatID uint) ([]uint, error) {
+	var ids []uint
+	for _, h := range r.hidden {
+		if h.UserID == userID && h.ChatID == chatID {
+			ids = append(ids, h.MessageID)
+		}
+	}
+	return ids, nil
+}
+
+func (r *memoryChatRepo) DeleteExpiredMessages(_ context.Context, now time.Time, limit int) ([]model.Message, error) {
+	var expired []model.Message
+	for id, message := range r.messages {
+		if len(expired) == limit {
+			break
+		}
+		if message.ExpiresAt != nil && !message.ExpiresAt.After(now) {
+			expired = append(expired, *message)
+			delete(r.messages, id)
+		}
+	}
+	return expired, nil
+}
+
+// Блокировки
+
+func (r *memoryChatRepo) BanUser(ctx context.Context, ban *model.ChatBan) error {
+	r.bans = slices.DeleteFunc(r.bans, func(b model.ChatBan) bool { return b.ChatID == ban.ChatID && b.UserID == ban.UserID })
+	ban.ID = r.newID()
+	r.bans = append(r.bans, *ban)
+	return r.RemoveUser(ctx, ban.ChatID, ban.UserID)
+}
+
+func (r *memoryChatRepo) GetActiveBan(_ context.Context, chatID, userID uint) (*model.ChatBan, error) {
+	for _, b := range r.bans {
+		if b.ChatID == chatID && b.UserID == userID && b.IsActive(time.Now()) {
+			return &b, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memoryChatRepo) UnbanUser(_ context.Context, chatID, userID uint) (bool, error) {
+	n := len(r.bans)
+	r.bans = slices.DeleteFunc(r.bans, func(b model.ChatBan) bool { return b.ChatID == chatID && b.UserID == userID })
+	return len(r.bans) < n, nil
+}
+
+// Закрепленные сообщения
+
+func (r *memoryChatRepo) PinMessage(_ context.Context, pin *model.PinnedMessage) error {
+	for _, p := range r.pins {
+		if p.ChatID == pin.ChatID && p.MessageID == pin.MessageID {
+			return nil
+		}
+	}
+	pin.ID = r.newID()
+	r.pins = append(r.pins, *pin)
+	return nil
+}
+
+func (r *memoryChatRepo) UnpinMessage(_ context.Context, chatID, messageID uint) (bool, error) {
+	n := len(r.pins)
+	r.pins = slices.DeleteFunc(r.pins, func(p model.PinnedMessage) bool { return p.ChatID == chatID && p.MessageID == messageID })
+	return len(r.pins) < n, nil
+}
+
+// Заявки на вступление
+
+func (r *memoryChatRepo) CreateJoinRequest(_ context.Context, request *model.ChatJoinRequest) error {
+	request.ID = r.newID()
+	request.CreatedAt = time.Now()
+	stored := *request
+	r.joinRequests[request.ID] = &stored
+	return nil
+}
+
+func (r *memoryChatRepo) GetJoinRequestByID(_ context.Context, requestID uint) (*model.ChatJoinRequest, error) {
+	request, ok := r.joinRequests[requestID]
+	if !ok {
+		return nil, nil
+	}
+	copied := *request
+	return &copied, nil
+}
+
+func (r *memoryChatRepo) GetPendingJoinRequest(_ context.Context, chatID, userID uint) (*model.ChatJoinRequest, error) {
+	for _, request := range r.joinRequests {
+		if request.ChatID == chatID && request.UserID == userID && request.Status == model.JoinRequestPending {
+			copied := *request
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memoryChatRepo) ResolveJoinRequest(_ context.Context, requestID, reviewerID uint, status string) (bool, error) {
+	request, ok := r.joinRequests[requestID]
+	if !ok || request.Status != model.JoinRequestPending {
+		return false, nil
+	}
+	now := time.Now()
+	request.Status, request.ReviewedByID, request.ReviewedAt = status, &reviewerID, &now
+	return true, nil
+}
+
+func (r *memoryChatRepo) ApproveJoinRequest(ctx context.Context, requestID, reviewerID uint) (bool, error) {
+	ok, err := r.ResolveJoinRequest(ctx, requestID, reviewerID, model.JoinRequestApproved)
+	if err != nil || !ok {
+		return ok, err
+	}
+	request := r.joinRequests[requestID]
+	if r.member(request.ChatID, request.UserID) == nil {
+		r.addMember(request.ChatID, request.UserID, model.ChatRoleMember)
+	}
+	return true, nil
+}
+
+func (r *memoryChatRepo) GetLastRejectedJoinRequest(_ context.Context, chatID, userID uint) (*model.ChatJoinRequest, error) {
+	var last *model.ChatJoinRequest
+	for _, request := range r.joinRequests {
+		if request.ChatID != chatID || request.UserID != userID || request.Status != model.JoinRequestRejected {
+			continue
+		}
+		if last == nil || request.ReviewedAt.After(*last.ReviewedAt) {
+			last = request
+		}
+	}
+	return last, nil
+}
+
+func (r *memoryChatRepo) CountPendingJoinRequestsSince(_ context.Context, chatID uint, since time.Time) (int64, error) {
+	var count int64
+	for _, request := range r.joinRequests {
+		if request.ChatID == chatID && request.Status == model.JoinRequestPending && !request.CreatedAt.Before(since) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// Выход из чата и очистка истории
+
+func (r *memoryChatRepo) GetOwnershipSuccessor(_ context.Context, chatID, excludeUserID uint) (uint, error) {
+	var successor uint
+	for _, m := range r.members[chatID] {
+		if m.UserID == excludeUserID {
+			continue
+		}
+		if m.Role == model.ChatRoleAdmin {
+			return m.UserID, nil
+		}
+		if successor == 0 {
+			successor = m.UserID
+		}
+	}
+	return successor, nil
+}
+
+func (r *memoryChatRepo) LeaveChat(ctx context.Context, chatID, userID, newOwnerID uint) error {
+	if newOwnerID != 0 {
+		if err := r.SetUserRole(ctx, chatID, newOwnerID, model.ChatRoleOwner); err != nil {
+			return err
+		}
+	}
+	return r.RemoveUser(ctx, chatID, userID)
+}
+
+func (r *memoryChatRepo) ClearHistory(_ context.Context, chatID, userID uint) (uint, error) {
+	var lastID uint
+	for _, message := range r.messages {
+		if message.ChatID == chatID && message.ID > lastID {
+			lastID = message.ID
+		}
+	}
+	if m := r.member(chatID, userID); m != nil {
+		m.HistoryClearedUpToID = lastID
+	}
+	return lastID, nil
+}
+
+func (r *memoryChatRepo) GetHistoryClearedUpTo(_ context.Context, chatID, userID uint) (uint, error) {
+	if m := r.member(chatID, userID); m != nil {
+		return m.HistoryClearedUpToID, nil
+	}
+	return 0, nil
+}
+
+func (r *memoryChatRepo) PurgeChat(ctx context.Context, chatID uint) ([]string, error) {
+	var attachments []string
+	for id, message := range r.messages {
+		if message.ChatID != chatID {
+			continue
+		}
+		if message.AttachmentURL != nil {
+			attachments = append(attachments, *message.AttachmentURL)
+		}
+		delete(r.messages, id)
+	}
+	return attachments, r.Delete(ctx, chatID)
+}
+
+// Организация списка чатов
+
+func (r *memoryChatRepo) SetPinnedChats(_ context.Context, userID uint, chatIDs []uint) error {
+	for chatID := range r.members {
+		if m := r.member(chatID, userID); m != nil {
+			m.PinnedPosition = slices.Index(chatIDs, chatID) + 1
+		}
+	}
+	return nil
+}
+
+func (r *memoryChatRepo) SetChatArchived(_ context.Context, userID, chatID uint, archived bool) (bool, error) {
+	m := r.member(chatID, userID)
+	if m == nil {
+		return false, nil
+	}
+	m.ArchivedAt = nil
+	if archived {
+		now := time.Now()
+		m.ArchivedAt = &now
+	}
+	return true, nil
+}
+
+func (r *memoryChatRepo) GetChatFolders(_ context.Context, userID uint) ([]model.ChatFolder, error) {
+	var folders []model.ChatFolder
+	for _, folder := range r.folders {
+		if folder.UserID == userID {
+			folders = append(folders, *folder)
+		}
+	}
+	slices.SortFunc(folders, func(a, b model.ChatFolder) int { return a.Position - b.Position })
+	return folders, nil
+}
+
+func (r *memoryChatRepo) GetChatFolder(_ context.Context, userID, folderID uint) (*model.ChatFolder, error) {
+	folder, ok := r.folders[folderID]
+	if !ok || folder.UserID != userID {
+		return nil, nil
+	}
+	copied := *folder
+	return &copied, nil
+}
+
+func (r *memoryChatRepo) CreateChatFolder(_ context.Context, folder *model.ChatFolder) error {
+	folder.ID = r.newID()
+	folder.CreatedAt = time.Now()
+	stored := *folder
+	r.folders[folder.ID] = &stored
+	return nil
+}
+
+func (r *memoryChatRepo) UpdateChatFolder(_ context.Context, folder *model.ChatFolder) error {
+	stored := *folder
+	r.folders[folder.ID] = &stored
+	return nil
+}
+
+func (r *memoryChatRepo) DeleteChatFolder(_ context.Context, userID, folderID uint) (bool, error) {
+	folder, ok := r.folders[folderID]
+	if !ok || folder.UserID != userID {
+		return false, nil
+	}
+	delete(r.folders, folderID)
+	return true, nil
+}
+
+// Уведомления
+
+func (r *memoryChatRepo) SetChatNotifications(_ context.Context, userID, chatID uint, settings model.ChatNotificationSettings) (bool, error) {
+	m := r.member(chatID, userID)
+	if m == nil {
+		return false, nil
+	}
+	m.NotifyMode, m.MutedUntil = settings.Mode, settings.MutedUntil
+	return true, nil
+}
+
+func (r *memoryChatRepo) GetChatNotificationSettings(_ context.Context, chatID uint, userIDs []uint) (map[uint]model.ChatNotificationSettings, error) {
+	settings := make(map[uint]model.ChatNotificationSettings)
+	for _, userID := range userIDs {
+		if m := r.member(chatID, userID); m != nil {
+			settings[userID] = model.ChatNotificationSettings{Mode: m.NotifyMode, MutedUntil: m.MutedUntil}.Effective(time.Now())
+		}
+	}
+	return settings, nil
+}
+
+// GetUnreadSummary отдает userID*10 непрочитанных, чтобы по бейджу было видно, чей он
+func (r *memoryChatRepo) GetUnreadSummary(_ context.Context, userID uint) (*model.UnreadSummary, error) {
+	return &model.UnreadSummary{Messages: int64(userID) * 10, Chats: 1}, nil
+}
+
+// Закладки
+
+func (r *memoryChatRepo) SaveMessage(_ context.Context, saved *model.SavedMessage) error {
+	for i := range r.saved {
+		if r.saved[i].UserID == saved.UserID && r.saved[i].MessageID == saved.MessageID {
+			r.saved[i].Note, r.saved[i].Tags = saved.Note, saved.Tags
+			r.saved[i].UpdatedAt = time.Now()
+			return nil
+		}
+	}
+	saved.ID = r.newID()
+	saved.CreatedAt = time.Now()
+	r.saved = append(r.saved, *saved)
+	return nil
+}
+
+func (r *memoryChatRepo) GetSavedMessage(_ context.Context, userID, messageID uint) (*model.SavedMessage, error) {
+	for _, saved := range r.saved {
+		if saved.UserID == userID && saved.MessageID == messageID {
+			return &saved, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memoryChatRepo) GetSavedMessages(_ context.Context, userID, beforeID uint, tag string, limit int) ([]model.SavedMessage, error) {
+	var result []model.SavedMessage
+	for i := len(r.saved) - 1; i >= 0 && len(result) < limit; i-- {
+		saved := r.saved[i]
+		if saved.UserID != userID || (beforeID != 0 && saved.ID >= beforeID) {
+			continue
+		}
+		if tag != "" && !slices.Contains(saved.Tags, tag) {
+			continue
+		}
+		result = append(result, saved)
+	}
+	return result, nil
+}
+
+func (r *memoryChatRepo) DeleteSavedMessage(_ context.Context, userID, messageID uint) (bool, error) {
+	n := len(r.saved)
+	r.saved = slices.DeleteFunc(r.saved, func(s model.SavedMessage) bool { return s.UserID == userID && s.MessageID == messageID })
+	return len(r.saved) < n, nil
+}
+
+// Поиск
+
+func (r *memoryChatRepo) SearchMessages(_ context.Context, filter repository.MessageSearchFilter) ([]model.MessageSearchResult, error) {
+	r.searches = append(r.searches, filter)
+	results := r.searchResults
+	if len(results) > filter.Limit {
+		results = results[:filter.Limit]
+	}
+	return slices.Clone(results), nil
+}
+
+func (r *memoryChatRepo) SearchChats(_ context.Context, userID uint, _ string, limit int) ([]model.Chat, error) {
+	var chats []model.Chat
+	for chatID, chat := range r.chats {
+		if r.member(chatID, userID) != nil && len(chats) < limit {
+			chats = append(chats, *chat)
+		}
+	}
+	return chats, nil
+}
+
+// Отложенные сообщения
+
+func (r *memoryChatRepo) CreateScheduledMessage(_ context.Context, message *model.ScheduledMessage) error {
+	message.ID = r.newID()
+	stored := *message
+	r.scheduled[message.ID] = &stored
+	return nil
+}
+
+func (r *memoryChatRepo) GetScheduledMessage(_ context.Context, id uint) (*model.ScheduledMessage, error) {
+	message, ok := r.scheduled[id]
+	if !ok {
+		return nil, nil
+	}
+	copied := *message
+	return &copied, nil
+}
+
+func (r *memoryChatRepo) GetScheduledMessages(_ context.Context, chatID, senderID uint) ([]model.ScheduledMessage, error) {
+	var messages []model.ScheduledMessage
+	for _, message := range r.scheduled {
+		if message.ChatID == chatID && message.SenderID == senderID && message.Status == model.ScheduledPending {
+			messages = append(messages, *message)
+		}
+	}
+	return messages, nil
+}
+
+func (r *memoryChatRepo) UpdatePendingScheduledMessage(_ context.Context, id uint, updates map[string]any) (bool, error) {
+	message, ok := r.scheduled[id]
+	if !ok || message.Status != model.ScheduledPending {
+		return false, nil
+	}
+	for column, value := range updates {
+		switch column {
+		case "message":
+			message.Message = value.(string)
+		case "entities":
+			message.Entities = value.(model.MessageEntities)
+		case "send_at":
+			message.SendAt = value.(time.Time)
+		case "status":
+			message.Status = value.(string)
+		default:
+			return false, errors.New("unexpected column " + column)
+		}
+	}
+	return true, nil
+}
+
+func (r *memoryChatRepo) ClaimDueScheduledMessages(_ context.Context, now, staleBefore time.Time, limit int) ([]model.ScheduledMessage, error) {
+	var due []model.ScheduledMessage
+	for _, message := range r.scheduled {
+		if len(due) == limit {
+			break
+		}
+		pending := message.Status == model.ScheduledPending && !message.SendAt.After(now)
+		stale := message.Status == model.ScheduledProcessing && message.UpdatedAt.Before(staleBefore)
+		if pending || stale {
+			message.Status = model.ScheduledProcessing
+			message.Attempts++
+			message.UpdatedAt = now
+			due = append(due, *message)
+		}
+	}
+	return due, nil
+}
+
+func (r *memoryChatRepo) CompleteScheduledMessage(_ context.Context, id uint, status string, sentMessageID *uint, lastError string) error {
+	message, ok := r.scheduled[id]
+	if !ok {
+		return errors.New("scheduled message not found")
+	}
+	message.Status, message.SentMessageID, message.LastError = status, sentMessageID, lastError
+	return nil
+}
+
+func (r *memoryChatRepo) RescheduleScheduledMessage(_ context.Context, id uint, sendAt time.Time, lastError string) error {
+	message, ok := r.scheduled[id]
+	if !ok {
+		return errors.New("scheduled message not found")
+	}
+	message.Status, message.SendAt, message.LastError = model.ScheduledPending, sendAt, lastError
+	return nil
+}
@@ -11,11 +11,13 @@ type UserService interface {
 	CreateUser(user *model.User) error
 	GetUserByID(id uint) (*model.User, error)
 	GetUserByUsername(username string) (*model.User, error)
+	GetUserByUsernameIgnoreCase(username string) (*model.User, error)
 	GetUserByPhone(phone string) (*model.User, error)
 	UpdateUser(user *model.User) error
 	UsernameExists(username string) (bool, error)
 	PhoneExists(phone string) (bool, error)
 	SearchUsers(prompt string) ([]*model.User, error)
+	UpdatePrivacy(userID uint, hideForwardOrigin bool) error
 	// DeleteUser(id uint) error
 	// ListUsers() ([]model.User, error)
 }
@@ -28,6 +30,7 @@ type ChatService interface {
 	// Основные операции с чатами
 	CreateChat(ctx context.Context, chat *model.Chat) error
 	GetChatByID(ctx context.Context, chatID uint) (*model.Chat, error)
+	GetChatMeta(ctx context.Context, chatID uint) (*model.Chat, error)
 	DeleteChat(ctx context.Context, chatID uint) error
 	UpdateChat(ctx context.Context, chat *model.Chat) error
 
@@ -36,29 +39,136 @@ type ChatService interface {
 	RemoveUserFromChat(ctx context.Context, chatID, userID uint) error
 	GetChatUsers(ctx context.Context, chatID uint) ([]model.User, error)
 	IsUserInChat(ctx context.Context, chatID, userID uint) (bool, error)
+	GetMemberRole(ctx context.Context, chatID, userID uint) (string, error)
+	SetMemberRole(ctx context.Context, chatID, userID uint, role string) error
+	ChangeMemberRole(ctx context.Context, chatID, actorID, userID uint, role string) (string, error)
+	IsChatAdmin(ctx context.Context, chatID, userID uint) (bool, error)
 
 	// Операции с сообщениями
 	SendMessageToChat(ctx context.Context, chat *model.Chat, message *model.Message) error
-	GetChatMessages(ctx context.Context, chatID uint, cursor string, limit int, direction string) (
+	GetChatMessages(ctx context.Context, chatID, viewerID uint, cursor string, limit int, direction string) (
 		[]model.Message, bool, bool, *int64, error)
-	GetRecentMessages(ctx context.Context, chatID uint, limit int) ([]model.Message, error)
+	GetRecentMessages(ctx context.Context, chatID, viewerID uint, limit int) ([]model.Message, error)
 	GetMessageByID(ctx context.Context, messageID uint) (*model.Message, error)
 	MarkMessageAsRead(ctx context.Context, messageID, userID uint) error
 	DeleteMessage(ctx context.Context, messageID uint) error
+	DeleteMessages(ctx context.Context, userID, chatID uint, messageIDs []uint, forEveryone bool) ([]model.Message, []uint, error)
+	GetHiddenMessageIDs(ctx context.Context, userID, chatID uint) ([]uint, error)
+	SendSystemMessage(ctx context.Context, chatID, actorID uint, text string) (*model.Message, error)
+	ForwardMessages(ctx context.Context, userID uint, messageIDs, targetChatIDs []uint) ([]model.Message, error)
 
 	// Операции с пользовательскими чатами
 	GetChatsForUser(ctx context.Context, userID uint) (*[]model.Chat, error)
+	ListChats(ctx context.Context, userID uint, params ChatListParams) ([]model.ChatListItem, string, error)
 	GetDirectChatsForUser(ctx context.Context, userID uint) ([]model.Chat, error)
 	GetChatForUsers(ctx context.Context, user1ID, user2ID uint) (*model.Chat, error)
 
 	// Групповые чаты
-	CreateGroupChat(ctx context.Context, name string, userIDs []uint) (*model.Chat, error)
+	CreateGroupChat(ctx context.Context, name string, userIDs []uint, ownerID uint) (*model.Chat, error)
 	UpdateGroupInfo(ctx context.Context, chatID uint, name, description string) error
+	UpdateChatAvatar(ctx context.Context, chatID uint, avatarKey string) error
+	SetSlowMode(ctx context.Context, chatID uint, seconds int) error
+
+	// Каналы
+	CreateChannel(ctx context.Context, ownerID uint, name, description string) (*model.Chat, error)
+	SubscribeToChannel(ctx context.Context, chatID, userID uint) error
+	UnsubscribeFromChannel(ctx context.Context, chatID, userID uint) error
 
 	// Статистика и утилиты
 	GetChatStatistics(ctx context.Context, chatID uint) (*ChatStatistics, error)
-	SearchMessages(ctx context.Context, chatID uint, query string, limit int) ([]model.Message, error)
+	SearchMessages(ctx context.Context, chatID, viewerID uint, query, cursor string, limit int) ([]model.MessageSearchResult, string, error)
+	GlobalSearch(ctx context.Context, userID uint, params GlobalSearchParams) (*GlobalSearchResult, error)
 	GetUnreadCount(ctx context.Context, userID, chatID uint) (int64, error)
+
+	// Пригласительные ссылки
+	CreateInvite(ctx context.Context, chatID, creatorID uint, ttl time.Duration, maxUses int, requiresApproval bool) (*model.ChatInvite, error)
+	GetChatInvites(ctx context.Context, chatID uint) ([]model.ChatInvite, error)
+	RevokeInvite(ctx context.Context, chatID, inviteID uint) error
+	JoinChatByInvite(ctx context.Context, token string, userID uint) (*model.ChatInvite, *model.ChatJoinRequest, bool, error)
+
+	// Заявки на вступление
+	RequestToJoin(ctx context.Context, chatID, userID uint, message string) (*model.ChatJoinRequest, bool, error)
+	SetJoinByRequest(ctx context.Context, chatID uint, enabled bool) error
+	GetJoinRequests(ctx context.Context, chatID uint, status string) ([]model.ChatJoinRequest, error)
+	ApproveJoinRequest(ctx context.Context, chatID, requestID, reviewerID uint) (*model.ChatJoinRequest, error)
+	RejectJoinRequest(ctx context.Context, chatID, requestID, reviewerID uint) (*model.ChatJoinRequest, error)
+	GetChatAdminIDs(ctx context.Context, chatID uint) ([]uint, error)
+
+	// Модерация участников
+	BanUser(ctx context.Context, chatID, actorID, userID uint, reason string, duration time.Duration) (*model.ChatBan, error)
+	KickMember(ctx context.Context, chatID, actorID, userID uint) error
+	AddMember(ctx context.Context, chatID, actorID, userID uint) error
+	UnbanUser(ctx context.Context, chatID, userID uint) error
+	GetChatBans(ctx context.Context, chatID uint) ([]model.ChatBan, error)
+	GetActiveBan(ctx context.Context, chatID, userID uint) (*model.ChatBan, error)
+
+	// Закрепленные сообщения
+	PinMessage(ctx context.Context, chatID, messageID, userID uint) (*model.PinnedMessage, error)
+	UnpinMessage(ctx context.Context, chatID, messageID, userID uint) error
+	IsMessagePinned(ctx context.Context, chatID, messageID uint) (bool, error)
+	GetPinnedMessages(ctx context.Context, chatID uint) ([]model.PinnedMessage, error)
+	GetLatestPin(ctx context.Context, chatID uint) (*model.PinnedMessage, error)
+
+	// Журнал модерации
+	RecordAudit(ctx context.Context, entry *model.ChatAuditLog) error
+	GetAuditLog(ctx context.Context, chatID, beforeID uint, limit int) ([]model.ChatAuditLog, bool, error)
+
+	// Выход из чата и очистка истории
+	LeaveChat(ctx context.Context, chatID, userID uint) (uint, error)
+	ClearHistory(ctx context.Context, chatID, userID uint) (uint, error)
+	GetHistoryClearedUpTo(ctx context.Context, chatID, userID uint) (uint, error)
+	DeleteDirectChat(ctx context.Context, chatID, userID uint) ([]uint, []string, error)
+
+	// Организация списка чатов
+	GetChatOrganization(ctx context.Context, userID uint) (*model.ChatOrganization, error)
+	SetPinnedChats(ctx context.Context, userID uint, chatIDs []uint) error
+	SetChatArchived(ctx context.Context, userID, chatID uint, archived bool) error
+	UnarchiveForNewMessage(ctx context.Context, chatID, senderID uint) ([]uint, error)
+	CreateChatFolder(ctx context.Context, folder *model.ChatFolder) (*model.ChatFolder, error)
+	UpdateChatFolder(ctx context.Context, folder *model.ChatFolder) (*model.ChatFolder, error)
+	DeleteChatFolder(ctx context.Context, userID, folderID uint) error
+
+	// Уведомления
+	SetChatNotifications(ctx context.Context, userID, chatID uint, mode string, duration time.Duration) (*model.ChatNotificationSettings, error)
+	GetUnreadSummary(ctx context.Context, userID uint) (*model.UnreadSummary, error)
+	FilterNotificationRecipients(ctx context.Context, msg model.Message, userIDs []uint) ([]uint, error)
+
+	// Закладки
+	SaveMessage(ctx context.Context, userID, messageID uint, note string, tags []string) (*model.SavedMessage, error)
+	GetSavedMessages(ctx context.Context, userID, beforeID uint, tag string, limit int) ([]model.SavedMessage, bool, error)
+	DeleteSavedMessage(ctx context.Context, userID, messageID uint) (bool, error)
+
+	// Черновики
+	SaveDraft(ctx context.Context, draft *model.ChatDraft) (*model.ChatDraft, error)
+	GetDraft(ctx context.Context, userID, chatID uint) (*model.ChatDraft, error)
+	GetDraftsForUser(ctx context.Context, userID uint) (map[uint]*model.ChatDraft, error)
+	DeleteDraft(ctx context.Context, userID, chatID uint) (bool, error)
+
+	// Упоминания
+	GetMentions(ctx context.Context, userID, chatID, beforeID uint, limit int, unreadOnly bool) ([]model.MessageMention, bool, error)
+	GetUnreadMentionCounts(ctx context.Context, userID uint) (map[uint]int64, error)
+	GetUnreadMentionCount(ctx context.Context, userID, chatID uint) (int64, error)
+	MarkMentionsRead(ctx context.Context, userID, chatID, upToMessageID uint) error
+
+	// Опросы
+	VotePoll(ctx context.Context, messageID, userID uint, optionIDs []uint) (*model.Poll, error)
+	ClosePoll(ctx context.Context, messageID, userID uint) (*model.Poll, error)
+	GetPoll(ctx context.Context, messageID, viewerID uint) (*model.Poll, error)
+	AttachPolls(ctx context.Context, messages []model.Message, viewerID uint) error
+
+	// Исчезающие сообщения
+	SetMessageTTL(ctx context.Context, chatID uint, seconds int) error
+	DeleteExpiredMessages(ctx context.Context, limit int) ([]model.Message, error)
+	IsAttachmentReferenced(ctx context.Context, attachmentURL string) (bool, error)
+
+	// Отложенные сообщения
+	ScheduleMessage(ctx context.Context, message *model.ScheduledMessage) error
+	GetScheduledMessages(ctx context.Context, chatID, userID uint) ([]model.ScheduledMessage, error)
+	UpdateScheduledMessage(ctx context.Context, userID, id uint, text *string, entities model.MessageEntities, sendAt *time.Time) (*model.ScheduledMessage, error)
+	CancelScheduledMessage(ctx context.Context, userID, id uint) (*model.ScheduledMessage, error)
+	ClaimDueScheduledMessages(ctx context.Context, limit int, staleAfter time.Duration) ([]model.ScheduledMessage, error)
+	CompleteScheduledMessage(ctx context.Context, id uint, sentMessageID *uint, sendErr error) error
+	RescheduleScheduledMessage(ctx context.Context, id uint, sendAt time.Time, reason error) error
 }
 
 type IS3Service interface {
@@ -0,0 +1,163 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"time"
+	"tush00nka/bbbab_messenger/internal/model"
+	"tush00nka/bbbab_messenger/internal/pkg/linkpreview"
+	"tush00nka/bbbab_messenger/internal/repository"
+	"unicode/utf16"
+)
+
+// Параметры превью ссылок
+const (
+	LinkPreviewCacheTTL = 24 * time.Hour
+	// Неудачные попытки кешируются ненадолго, чтобы не загружать страницу на каждое сообщение
+	LinkPreviewFailureTTL = time.Hour
+	// Одновременно загружаемых страниц на инстанс
+	MaxConcurrentLinkPreviews = 8
+)
+
+// urlPattern находит ссылку в тексте, если клиент не передал разметку url
+var urlPattern = regexp.MustCompile(`https?://[^\s<>"'` + "`" + `]+`)
+
+// LinkPreviewService собирает превью ссылок в сообщениях
+type LinkPreviewService struct {
+	fetcher   linkpreview.Fetcher
+	cacheRepo repository.ChatCacheRepository
+	chatRepo  repository.ChatRepository
+	slots     chan struct{}
+}
+
+// NewLinkPreviewService создает новый экземпляр LinkPreviewService.
+// fetcher можно подменить, например, для загрузки с локального тестового сервера.
+func NewLinkPreviewService(
+	fetcher linkpreview.Fetcher,
+	cacheRepo repository.ChatCacheRepository,
+	chatRepo repository.ChatRepository,
+) *LinkPreviewService {
+	return &LinkPreviewService{
+		fetcher:   fetcher,
+		cacheRepo: cacheRepo,
+		chatRepo:  chatRepo,
+		slots:     make(chan struct{}, MaxConcurrentLinkPreviews),
+	}
+}
+
+// GeneratePreview собирает превью первой ссылки сообщения, сохраняет его в сообщении
+// и возвращает. nil без ошибки — в сообщении нет ссылки или у страницы нет превью.
+func (s *LinkPreviewService) GeneratePreview(ctx context.Context, msg *model.Message) (*model.LinkPreview, error) {
+	if msg == nil || msg.ID == 0 || msg.Type == model.MessageTypeSystem {
+		return nil, nil
+	}
+
+	url := firstURL(msg.Message, msg.Entities)
+	if url == "" {
+		return nil, nil
+	}
+
+	preview, err := s.getPreview(ctx, url)
+	if err != nil || preview == nil {
+		return nil, err
+	}
+
+	if err := s.chatRepo.UpdateMessageLinkPreview(ctx, msg.ID, preview); err != nil {
+		return nil, err
+	}
+	msg.LinkPreview = preview
+
+	return preview, nil
+}
+
+// getPreview возвращает превью ссылки из кеша или загружает страницу
+func (s *LinkPreviewService) getPreview(ctx context.Context, url string) (*model.LinkPreview, error) {
+	if s.cacheRepo != nil {
+		cached, found, err := s.cacheRepo.GetLinkPreview(ctx, url)
+		if err == nil && found {
+			return cached, nil
+		}
+	}
+
+	select {
+	case s.slots <- struct{}{}:
+		defer func() { <-s.slots }()
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	fetched, err := s.fetcher.Fetch(ctx, url)
+	if err != nil {
+		// Истечение контекста — не свойство страницы, такую неудачу не кешируем
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return nil, err
+		}
+		s.savePreview(ctx, url, nil, LinkPreviewFailureTTL)
+		return nil, nil
+	}
+
+	preview := &model.LinkPreview{
+		URL:         fetched.URL,
+		Title:       fetched.Title,
+		Description: fetched.Description,
+		ImageURL:    fetched.ImageURL,
+		SiteName:    fetched.SiteName,
+		FetchedAt:   time.Now().UTC(),
+	}
+	s.savePreview(ctx, url, preview, LinkPreviewCacheTTL)
+
+	return preview, nil
+}
+
+// savePreview кеширует превью; ошибка кеша не мешает прикрепить превью к сообщению
+func (s *LinkPreviewService) savePreview(ctx context.Context, url string, preview *model.LinkPreview, ttl time.Duration) {
+	if s.cacheRepo == nil {
+		return
+	}
+	_ = s.cacheRepo.SaveLinkPreview(ctx, url, preview, ttl)
+}
+
+// firstURL возвращает первую ссылку сообщения: из разметки url или text_link,
+// а без разметки — найденную в тексте
+func firstURL(text string, entities model.MessageEntities) string {
+	units := utf16.Encode([]rune(text))
+	for _, e := range entities {
+		switch e.Type {
+		case model.EntityTextLink:
+			return e.URL
+		case model.EntityURL:
+			if entityInBounds(e, len(units)) {
+				if url := string(utf16.Decode(units[e.Offset : e.Offset+e.Length])); isHTTPURL(url) {
+					return url
+				}
+			}
+		}
+	}
+
+	for _, idx := range urlPattern.FindAllStringIndex(text, -1) {
+		match := model.MessageEntity{Offset: utf16Len(text[:idx[0]]), Length: utf16Len(text[idx[0]:idx[1]])}
+		if insideCode(match, entities) {
+			continue
+		}
+		if url := text[idx[0]:idx[1]]; isHTTPURL(url) {
+			return url
+		}
+	}
+
+	return ""
+}
+
+// insideCode проверяет, попадает ли фрагмент в code или pre
+func insideCode(fragment model.MessageEntity, entities model.MessageEntities) bool {
+	for _, e := range entities {
+		if e.Type != model.EntityCode && e.Type != model.EntityPre {
+			continue
+		}
+		if e.Offset < entityEnd(fragment) && fragment.Offset < entityEnd(e) {
+			return true
+		}
+	}
+
+	return false
+}
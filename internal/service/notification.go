@@ -0,0 +1,347 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"tush00nka/bbbab_messenger/internal/model"
+	"tush00nka/bbbab_messenger/internal/pkg/push"
+	"tush00nka/bbbab_messenger/internal/repository"
+	"unicode/utf8"
+)
+
+// Параметры push-уведомлений
+const (
+	MaxDeviceTokenLength = 4096
+	// MaxDevicesPerUser при превышении забываются давно не обновлявшиеся устройства
+	MaxDevicesPerUser = 20
+	// MaxPushBodyLength длина текста сообщения в уведомлении, в символах
+	MaxPushBodyLength = 200
+	// PushTTL сколько сервис доставки хранит уведомление для выключенного устройства
+	PushTTL = 24 * time.Hour
+	// Одновременных отправок на инстанс
+	MaxConcurrentPushes = 16
+
+	DefaultPushAttempts   = 4
+	DefaultPushBackoff    = 500 * time.Millisecond
+	DefaultMaxPushBackoff = 30 * time.Second
+)
+
+// Ошибки регистрации устройств
+var (
+	ErrUnsupportedPushPlatform = errors.New("unsupported push platform")
+	ErrInvalidDeviceToken      = errors.New("invalid device token")
+)
+
+// PresenceChecker сообщает, кто из участников сейчас открыл чат; реализуется ChatCacheService
+type PresenceChecker interface {
+	GetActiveUsers(ctx context.Context, chatID uint) ([]uint, error)
+}
+
+// NotificationServiceOptions настройки NotificationService
+type NotificationServiceOptions struct {
+	// Providers провайдеры доставки по платформам model.PushPlatform*
+	Providers map[string]push.Provider
+	// Presence активные участники чата; без него уведомляются все участники
+	Presence PresenceChecker
+	// MaxAttempts попыток отправки на устройство, включая первую
+	MaxAttempts int
+	// Backoff пауза перед первым повтором, дальше удваивается до MaxBackoff
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+}
+
+// NotificationService доставляет push-уведомления о новых сообщениях участникам,
+// которые не держат чат открытым
+type NotificationService struct {
+	devices     repository.DeviceTokenRepository
+	chats       ChatService
+	providers   map[string]push.Provider
+	presence    PresenceChecker
+	maxAttempts int
+	backoff     time.Duration
+	maxBackoff  time.Duration
+	slots       chan struct{}
+}
+
+// NewNotificationService создает новый экземпляр NotificationService
+func NewNotificationService(
+	devices repository.DeviceTokenRepository,
+	chats ChatService,
+	options ...NotificationServiceOptions,
+) *NotificationService {
+	s := &NotificationService{
+		devices:     devices,
+		chats:       chats,
+		providers:   map[string]push.Provider{},
+		maxAttempts: DefaultPushAttempts,
+		backoff:     DefaultPushBackoff,
+		maxBackoff:  DefaultMaxPushBackoff,
+		slots:       make(chan struct{}, MaxConcurrentPushes),
+	}
+
+	for _, opt := range options {
+		for platform, provider := range opt.Providers {
+			if provider != nil {
+				s.providers[platform] = provider
+			}
+		}
+		if opt.Presence != nil {
+			s.presence = opt.Presence
+		}
+		if opt.MaxAttempts > 0 {
+			s.maxAttempts = opt.MaxAttempts
+		}
+		if opt.Backoff > 0 {
+			s.backoff = opt.Backoff
+		}
+		if opt.MaxBackoff > 0 {
+			s.maxBackoff = opt.MaxBackoff
+		}
+	}
+
+	return s
+}
+
+// RegisterDevice сохраняет токен устройства пользователя для платформы
+func (s *NotificationService) RegisterDevice(ctx context.Context, userID uint, platform, token string) (*model.DeviceToken, error) {
+	if userID == 0 {
+		return nil, errors.New("userID cannot be zero")
+	}
+
+	provider, ok := s.providers[platform]
+	if !ok {
+		return nil, ErrUnsupportedPushPlatform
+	}
+
+	token = strings.TrimSpace(token)
+	if token == "" || len(token) > MaxDeviceTokenLength {
+		return nil, ErrInvalidDeviceToken
+	}
+	if validator, ok := provider.(push.TokenValidator); ok {
+		if err := validator.ValidateToken(token); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidDeviceToken, err)
+		}
+	}
+
+	device := &model.DeviceToken{UserID: userID, Platform: platform, Token: token}
+	if err := s.devices.SaveDeviceToken(ctx, device); err != nil {
+		return nil, err
+	}
+	if err := s.devices.TrimDeviceTokens(ctx, userID, MaxDevicesPerUser); err != nil {
+		return nil, err
+	}
+
+	return device, nil
+}
+
+// UnregisterDevice удаляет токен устройства пользователя, например, при выходе из аккаунта
+func (s *NotificationService) UnregisterDevice(ctx context.Context, userID uint, token string) (bool, error) {
+	if userID == 0 {
+		return false, errors.New("userID cannot be zero")
+	}
+
+	return s.devices.DeleteDeviceToken(ctx, userID, strings.TrimSpace(token))
+}
+
+// NotifyNewMessage отправляет уведомление о сообщении участникам, у которых чат не открыт,
+// с учетом их настроек уведомлений. Возвращается, когда все отправки завершены.
+func (s *NotificationService) NotifyNewMessage(ctx context.Context, msg model.Message) error {
+	if len(s.providers) == 0 || msg.ID == 0 || msg.Type == model.MessageTypeSystem {
+		return nil
+	}
+
+	chat, err := s.chats.GetChatMeta(ctx, msg.ChatID)
+	if err != nil || chat == nil {
+		return err
+	}
+
+	members, err := s.chats.GetChatUsers(ctx, msg.ChatID)
+	if err != nil {
+		return err
+	}
+
+	active := make(map[uint]bool)
+	if s.presence != nil {
+		activeIDs, err := s.presence.GetActiveUsers(ctx, msg.ChatID)
+		if err != nil {
+			// Без данных о присутствии лучше прислать лишнее уведомление, чем пропустить сообщение
+			log.Printf("failed to get active chat users: %v", err)
+		}
+		for _, id := range activeIDs {
+			active[id] = true
+		}
+	}
+
+	var sender *model.User
+	offline := make([]uint, 0, len(members))
+	for i := range members {
+		if members[i].ID == msg.SenderID {
+			sender = &members[i]
+			continue
+		}
+		if !active[members[i].ID] {
+			offline = append(offline, members[i].ID)
+		}
+	}
+
+	recipients, err := s.chats.FilterNotificationRecipients(ctx, msg, offline)
+	if err != nil || len(recipients) == 0 {
+		return err
+	}
+
+	devices, err := s.devices.GetDeviceTokens(ctx, recipients)
+	if err != nil || len(devices) == 0 {
+		return err
+	}
+
+	notification := newMessageNotification(chat, sender, msg)
+	badges := make(map[uint]*int64)
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		invalid []string
+	)
+	for _, device := range devices {
+		provider, ok := s.providers[device.Platform]
+		if !ok {
+			continue
+		}
+
+		badge, ok := badges[device.UserID]
+		if !ok {
+			if summary, err := s.chats.GetUnreadSummary(ctx, device.UserID); err == nil {
+				badge = &summary.Messages
+			}
+			badges[device.UserID] = badge
+		}
+		n := notification
+		n.Badge = badge
+
+		select {
+		case s.slots <- struct{}{}:
+		case <-ctx.Done():
+			wg.Wait()
+			return ctx.Err()
+		}
+
+		wg.Add(1)
+		go func(device model.DeviceToken) {
+			defer wg.Done()
+			defer func() { <-s.slots }()
+
+			err := s.send(ctx, provider, device.Token, n)
+			switch {
+			case err == nil:
+			case errors.Is(err, push.ErrInvalidToken):
+				mu.Lock()
+				invalid = append(invalid, device.Token)
+				mu.Unlock()
+			default:
+				log.Printf("failed to send push notification to %s device of user %d: %v", device.Platform, device.UserID, err)
+			}
+		}(device)
+	}
+	wg.Wait()
+
+	if len(invalid) > 0 {
+		// Контекст мог истечь за время повторов, а отозванные токены стоит удалить в любом случае
+		cleanupCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+		defer cancel()
+		return s.devices.DeleteDeviceTokens(cleanupCtx, invalid)
+	}
+
+	return nil
+}
+
+// send отправляет уведомление с повторами при временных ошибках. Пауза растет экспоненциально
+// со случайной добавкой, чтобы повторы разных устройств не совпадали; Retry-After сервиса учитывается.
+func (s *NotificationService) send(ctx context.Context, provider push.Provider, token string, n push.Notification) error {
+	backoff := s.backoff
+	for attempt := 1; ; attempt++ {
+		err := provider.Send(ctx, token, n)
+		if err == nil || errors.Is(err, push.ErrInvalidToken) || errors.Is(err, push.ErrRejected) ||
+			attempt >= s.maxAttempts || ctx.Err() != nil {
+			return err
+		}
+
+		wait := backoff + rand.N(backoff/2+1)
+		var temporary *push.TemporaryError
+		if errors.As(err, &temporary) && temporary.RetryAfter > wait {
+			wait = temporary.RetryAfter
+		}
+		wait = min(wait, s.maxBackoff)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		}
+
+		backoff = min(backoff*2, s.maxBackoff)
+	}
+}
+
+// newMessageNotification собирает уведомление о сообщении: в личном чате заголовок — имя
+// отправителя, в группе — название чата и имя отправителя перед текстом
+func newMessageNotification(chat *model.Chat, sender *model.User, msg model.Message) push.Notification {
+	senderName := ""
+	if sender != nil {
+		senderName = sender.DisplayName
+		if senderName == "" {
+			senderName = sender.Username
+		}
+	}
+
+	body := messagePreview(msg)
+	title := chat.Name
+	switch {
+	case !chat.IsGroup:
+		title = senderName
+	case !chat.IsChannel && senderName != "":
+		body = senderName + ": " + body
+	}
+
+	return push.Notification{
+		Title:    title,
+		Body:     body,
+		ThreadID: "chat-" + strconv.FormatUint(uint64(chat.ID), 10),
+		Data: map[string]string{
+			"type":       "message",
+			"chat_id":    strconv.FormatUint(uint64(msg.ChatID), 10),
+			"message_id": strconv.FormatUint(uint64(msg.ID), 10),
+		},
+		TTL: PushTTL,
+	}
+}
+
+// messagePreview возвращает текст сообщения для уведомления, обрезанный до MaxPushBodyLength
+func messagePreview(msg model.Message) string {
+	text := strings.TrimSpace(msg.Message)
+	if text == "" {
+		switch msg.Type {
+		case model.MessageTypeImage:
+			text = "Фото"
+		case model.MessageTypeFile:
+			text = "Файл"
+		default:
+			text = "Новое сообщение"
+		}
+	}
+
+	if utf8.RuneCountInString(text) > MaxPushBodyLength {
+		runes := []rune(text)
+		text = string(runes[:MaxPushBodyLength-1]) + "…"
+	}
+
+	return text
+}
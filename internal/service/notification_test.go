@@ -0,0 +1,255 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+	"tush00nka/bbbab_messenger/internal/model"
+	"tush00nka/bbbab_messenger/internal/pkg/push"
+)
+
+// memoryDeviceTokens хранит токены устройств в памяти
+type memoryDeviceTokens struct {
+	devices []model.DeviceToken
+}
+
+func (r *memoryDeviceTokens) SaveDeviceToken(_ context.Context, device *model.DeviceToken) error {
+	r.devices = slices.DeleteFunc(r.devices, func(d model.DeviceToken) bool { return d.Token == device.Token })
+	r.devices = append(r.devices, *device)
+	return nil
+}
+
+func (r *memoryDeviceTokens) DeleteDeviceToken(_ context.Context, userID uint, token string) (bool, error) {
+	n := len(r.devices)
+	r.devices = slices.DeleteFunc(r.devices, func(d model.DeviceToken) bool { return d.UserID == userID && d.Token == token })
+	return len(r.devices) < n, nil
+}
+
+func (r *memoryDeviceTokens) DeleteDeviceTokens(_ context.Context, tokens []string) error {
+	r.devices = slices.DeleteFunc(r.devices, func(d model.DeviceToken) bool { return slices.Contains(tokens, d.Token) })
+	return nil
+}
+
+func (r *memoryDeviceTokens) GetDeviceTokens(_ context.Context, userIDs []uint) ([]model.DeviceToken, error) {
+	var devices []model.DeviceToken
+	for _, d := range r.devices {
+		if slices.Contains(userIDs, d.UserID) {
+			devices = append(devices, d)
+		}
+	}
+	return devices, nil
+}
+
+func (r *memoryDeviceTokens) TrimDeviceTokens(context.Context, uint, int) error {
+	return nil
+}
+
+func (r *memoryDeviceTokens) tokens() []string {
+	var tokens []string
+	for _, d := range r.devices {
+		tokens = append(tokens, d.Token)
+	}
+	slices.Sort(tokens)
+	return tokens
+}
+
+// staticPresence считает чат открытым у перечисленных пользователей
+type staticPresence []uint
+
+func (p staticPresence) GetActiveUsers(context.Context, uint) ([]uint, error) {
+	return p, nil
+}
+
+func newNotificationFixture(t *testing.T, presence PresenceChecker) (*memoryChatRepo, *memoryDeviceTokens, *push.FakeProvider, *NotificationService) {
+	t.Helper()
+
+	chats := newMemoryChatRepo()
+	chats.addGroup(7, 1, 2, 3, 4, 5).Name = "Team"
+	for id, name := range []string{"alice", "bob", "carol", "dave", "erin"} {
+		chats.addUser(uint(id+1), name)
+	}
+	devices := &memoryDeviceTokens{}
+	provider := push.NewFakeProvider()
+
+	options := NotificationServiceOptions{
+		Providers:   map[string]push.Provider{model.PushPlatformFCM: provider, model.PushPlatformAPNs: provider},
+		Presence:    presence,
+		MaxAttempts: 3,
+		Backoff:     time.Millisecond,
+		MaxBackoff:  5 * time.Millisecond,
+	}
+	notifications := NewNotificationService(devices, NewChatService(chats), options)
+
+	return chats, devices, provider, notifications
+}
+
+func newTestMessage(id, senderID uint, text string) model.Message {
+	msg := model.Message{ChatID: 7, SenderID: senderID, Message: text, Type: model.MessageTypeText}
+	msg.ID = id
+	return msg
+}
+
+func sentTokens(provider *push.FakeProvider) []string {
+	var tokens []string
+	for _, delivery := range provider.Sent() {
+		tokens = append(tokens, delivery.Token)
+	}
+	slices.Sort(tokens)
+	return tokens
+}
+
+func TestNotifyNewMessageFanOut(t *testing.T) {
+	_, devices, provider, notifications := newNotificationFixture(t, staticPresence{3})
+	ctx := context.Background()
+
+	for _, d := range []model.DeviceToken{
+		{UserID: 1, Platform: model.PushPlatformFCM, Token: "alice-phone"},
+		{UserID: 2, Platform: model.PushPlatformFCM, Token: "bob-phone"},
+		{UserID: 3, Platform: model.PushPlatformFCM, Token: "carol-phone"},
+		{UserID: 4, Platform: model.PushPlatformFCM, Token: "dave-phone"},
+		{UserID: 4, Platform: model.PushPlatformAPNs, Token: "dave-tablet"},
+		{UserID: 4, Platform: model.PushPlatformWebPush, Token: "dave-browser"},
+		// Не участник чата
+		{UserID: 9, Platform: model.PushPlatformFCM, Token: "stranger-phone"},
+	} {
+		devices.SaveDeviceToken(ctx, &d)
+	}
+
+	if err := notifications.NotifyNewMessage(ctx, newTestMessage(100, 1, "hello")); err != nil {
+		t.Fatalf("NotifyNewMessage() error = %v", err)
+	}
+
+	// Отправитель и участник с открытым чатом не уведомляются,
+	// у webpush нет провайдера, у erin нет устройств
+	want := []string{"bob-phone", "dave-phone", "dave-tablet"}
+	if got := sentTokens(provider); !slices.Equal(got, want) {
+		t.Fatalf("sent to %v, want %v", got, want)
+	}
+
+	for _, delivery := range provider.Sent() {
+		n := delivery.Notification
+		if n.Title != "Team" || n.Body != "alice: hello" {
+			t.Errorf("notification = %q / %q, want group title and sender prefix", n.Title, n.Body)
+		}
+		if n.Data["chat_id"] != "7" || n.Data["message_id"] != "100" {
+			t.Errorf("notification data = %v", n.Data)
+		}
+		if n.Badge == nil {
+			t.Errorf("notification for %s has no badge", delivery.Token)
+		} else if wantBadge := map[string]int64{"bob-phone": 20, "dave-phone": 40, "dave-tablet": 40}[delivery.Token]; *n.Badge != wantBadge {
+			t.Errorf("badge for %s = %d, want %d", delivery.Token, *n.Badge, wantBadge)
+		}
+	}
+
+	// Служебные сообщения не рассылаются
+	provider.Reset()
+	system := newTestMessage(101, 1, "bob joined")
+	system.Type = model.MessageTypeSystem
+	if err := notifications.NotifyNewMessage(ctx, system); err != nil {
+		t.Fatalf("NotifyNewMessage(system) error = %v", err)
+	}
+	if sent := provider.Sent(); len(sent) != 0 {
+		t.Errorf("system message sent %d notifications", len(sent))
+	}
+}
+
+func TestNotifyNewMessageRespectsSettings(t *testing.T) {
+	future := time.Now().Add(time.Hour)
+	past := time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		name     string
+		setting  model.ChatNotificationSettings
+		mentions bool
+		reply    bool
+		want     bool
+	}{
+		{"all", model.ChatNotificationSettings{Mode: model.NotifyAll}, false, false, true},
+		{"muted forever", model.ChatNotificationSettings{Mode: model.NotifyNone}, true, false, false},
+		{"muted for a while", model.ChatNotificationSettings{Mode: model.NotifyNone, MutedUntil: &future}, false, false, false},
+		{"mute expired", model.ChatNotificationSettings{Mode: model.NotifyNone, MutedUntil: &past}, false, false, true},
+		{"mentions only without mention", model.ChatNotificationSettings{Mode: model.NotifyMentions}, false, false, false},
+		{"mentions only with mention", model.ChatNotificationSettings{Mode: model.NotifyMentions}, true, false, true},
+		{"mentions only with reply", model.ChatNotificationSettings{Mode: model.NotifyMentions}, false, true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chats, devices, provider, notifications := newNotificationFixture(t, nil)
+			ctx := context.Background()
+			devices.SaveDeviceToken(ctx, &model.DeviceToken{UserID: 2, Platform: model.PushPlatformFCM, Token: "bob-phone"})
+			bob := chats.member(7, 2)
+			bob.NotifyMode, bob.MutedUntil = tt.setting.Mode, tt.setting.MutedUntil
+
+			msg := newTestMessage(200, 1, "@bob look")
+			if tt.mentions {
+				msg.Mentions = []model.MessageMention{{ChatID: 7, UserID: 2}}
+			}
+			if tt.reply {
+				original := chats.addMessage(150, 7, 2, "question")
+				msg.ReplyToID = &original.ID
+			}
+
+			if err := notifications.NotifyNewMessage(ctx, msg); err != nil {
+				t.Fatalf("NotifyNewMessage() error = %v", err)
+			}
+			if got := len(provider.Sent()) == 1; got != tt.want {
+				t.Errorf("notified = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNotifyNewMessagePrunesInvalidTokens(t *testing.T) {
+	_, devices, provider, notifications := newNotificationFixture(t, nil)
+	ctx := context.Background()
+
+	for _, d := range []model.DeviceToken{
+		{UserID: 2, Platform: model.PushPlatformFCM, Token: "bob-old"},
+		{UserID: 2, Platform: model.PushPlatformFCM, Token: "bob-new"},
+		{UserID: 3, Platform: model.PushPlatformAPNs, Token: "carol-flaky"},
+		{UserID: 4, Platform: model.PushPlatformFCM, Token: "dave-rejected"},
+		{UserID: 5, Platform: model.PushPlatformFCM, Token: "erin-down"},
+	} {
+		devices.SaveDeviceToken(ctx, &d)
+	}
+
+	provider.FailToken("bob-old", push.ErrInvalidToken, 0)
+	// Временная ошибка повторяется, после нее доставка проходит
+	provider.FailToken("carol-flaky", &push.TemporaryError{Err: errors.New("unavailable")}, 2)
+	provider.FailToken("dave-rejected", push.ErrRejected, 0)
+	// Попытки исчерпаны, но токен остается: сервис мог быть временно недоступен
+	provider.FailToken("erin-down", &push.TemporaryError{Err: errors.New("unavailable")}, 0)
+
+	if err := notifications.NotifyNewMessage(ctx, newTestMessage(300, 1, "ping")); err != nil {
+		t.Fatalf("NotifyNewMessage() error = %v", err)
+	}
+
+	if got, want := sentTokens(provider), []string{"bob-new", "carol-flaky"}; !slices.Equal(got, want) {
+		t.Errorf("sent to %v, want %v", got, want)
+	}
+	if got, want := devices.tokens(), []string{"bob-new", "carol-flaky", "dave-rejected", "erin-down"}; !slices.Equal(got, want) {
+		t.Errorf("tokens after send = %v, want %v", got, want)
+	}
+}
+
+func TestRegisterDevice(t *testing.T) {
+	_, devices, _, notifications := newNotificationFixture(t, nil)
+	ctx := context.Background()
+
+	if _, err := notifications.RegisterDevice(ctx, 2, model.PushPlatformFCM, "  token  "); err != nil {
+		t.Fatalf("RegisterDevice() error = %v", err)
+	}
+	if got := devices.tokens(); !slices.Equal(got, []string{"token"}) {
+		t.Errorf("tokens = %v, want trimmed token", got)
+	}
+
+	if _, err := notifications.RegisterDevice(ctx, 2, "pager", "token"); !errors.Is(err, ErrUnsupportedPushPlatform) {
+		t.Errorf("RegisterDevice(unknown platform) error = %v, want ErrUnsupportedPushPlatform", err)
+	}
+	if _, err := notifications.RegisterDevice(ctx, 2, model.PushPlatformFCM, " "); !errors.Is(err, ErrInvalidDeviceToken) {
+		t.Errorf("RegisterDevice(empty token) error = %v, want ErrInvalidDeviceToken", err)
+	}
+}
@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"net/url"
 	"path"
+	"strings"
 	"time"
 	"tush00nka/bbbab_messenger/internal/config"
 	"tush00nka/bbbab_messenger/internal/model"
@@ -167,3 +169,106 @@ func (s *S3Service) DeleteProfilePicture(ctx context.Context, s3Key string) erro
 
 	return nil
 }
+
+func (s *S3Service) UploadChatAvatar(ctx context.Context, file io.Reader, filename, contentType string, chatID uint) (*model.FileMetadata, error) {
+	fileID := uuid.New().String()
+
+	ext := path.Ext(filename)
+	s3Key := path.Join("chat_avatars", fmt.Sprint(chatID), fileID+ext)
+
+	result, err := s.uploader.Upload(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(s.Config.S3BucketName),
+		Key:         aws.String(s3Key),
+		Body:        file,
+		ContentType: aws.String(contentType),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload chat avatar: %w", err)
+	}
+
+	log.Printf("[S3] Chat avatar uploaded successfully: %s", result.Location)
+
+	return &model.FileMetadata{
+		ID:          fileID,
+		Filename:    filename,
+		ContentType: contentType,
+		S3Key:       s3Key,
+		S3Bucket:    s.Config.S3BucketName,
+		ChatID:      chatID,
+		CreatedAt:   time.Now(),
+	}, nil
+}
+
+func (s *S3Service) DeleteChatAvatar(ctx context.Context, s3Key string) error {
+	if s3Key == "" {
+		return nil
+	}
+
+	_, err := s.s3Client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(s.Config.S3BucketName),
+		Key:    aws.String(s3Key),
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to delete chat avatar: %w", err)
+	}
+
+	return nil
+}
+
+// messageAttachmentsPrefix каталог бакета с вложениями сообщений. Объекты вне него
+// (аватары пользователей и чатов) по ссылке из сообщения никогда не удаляются.
+const messageAttachmentsPrefix = "attachments/"
+
+// DeleteMessageAttachment удаляет из хранилища вложение сообщения по его ссылке.
+// Внешние ссылки и объекты вне каталога вложений пропускаются.
+func (s *S3Service) DeleteMessageAttachment(ctx context.Context, attachmentURL string) error {
+	s3Key := s.attachmentKey(attachmentURL)
+	if s3Key == "" {
+		return nil
+	}
+
+	_, err := s.s3Client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(s.Config.S3BucketName),
+		Key:    aws.String(s3Key),
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to delete message attachment: %w", err)
+	}
+
+	return nil
+}
+
+// attachmentKey извлекает ключ объекта из ссылки на вложение: поддерживаются
+// голый ключ и ссылки вида {endpoint}/{bucket}/{key} или {bucket-host}/{key}
+func (s *S3Service) attachmentKey(attachmentURL string) string {
+	attachmentURL = strings.TrimSpace(attachmentURL)
+	if attachmentURL == "" {
+		return ""
+	}
+
+	key := attachmentURL
+	if u, err := url.Parse(attachmentURL); err == nil && u.Scheme != "" {
+		// Ссылки на чужие хосты не принадлежат нашему бакету
+		if s.Config.S3Endpoint != "" {
+			endpoint, err := url.Parse(s.Config.S3Endpoint)
+			if err != nil || endpoint.Host != u.Host {
+				return ""
+			}
+		} else if !strings.HasPrefix(u.Host, s.Config.S3BucketName+".") {
+			return ""
+		}
+		key = u.Path
+	}
+
+	key = strings.TrimPrefix(key, "/")
+	key = strings.TrimPrefix(key, s.Config.S3BucketName+"/")
+	key = path.Clean(key)
+
+	if !strings.HasPrefix(key, messageAttachmentsPrefix) {
+		return ""
+	}
+
+	return key
+}
@@ -66,6 +66,22 @@ func (s *userService) GetUserByUsername(username string) (*model.User, error) {
 	return user, nil
 }
 
+// GetUserByUsernameIgnoreCase ищет пользователя по username без учета регистра
+func (s *userService) GetUserByUsernameIgnoreCase(username string) (*model.User, error) {
+	if username == "" {
+		return nil, errors.New("invalid username")
+	}
+
+	user, err := s.userRepo.FindByUsernameIgnoreCase(username)
+	if err != nil {
+		return nil, err
+	}
+
+	user.EnsureDisplayName()
+
+	return user, nil
+}
+
 func (s *userService) GetUserByPhone(phone string) (*model.User, error) {
 	if phone == "" {
 		return nil, errors.New("invalid phone")
@@ -119,3 +135,11 @@ func (s *userService) PhoneExists(phone string) (bool, error) {
 func (s *userService) SearchUsers(prompt string) ([]*model.User, error) {
 	return s.userRepo.Search(prompt)
 }
+
+func (s *userService) UpdatePrivacy(userID uint, hideForwardOrigin bool) error {
+	if userID == 0 {
+		return errors.New("invalid user ID")
+	}
+
+	return s.userRepo.UpdatePrivacy(userID, hideForwardOrigin)
+}
@@ -6,6 +6,7 @@ import (
 	"log"
 	"sync"
 	"time"
+	"tush00nka/bbbab_messenger/internal/model"
 
 	"github.com/gorilla/websocket"
 	"go.uber.org/atomic"
@@ -19,6 +20,8 @@ const (
 	maxMessageSize     = 64 * 1024 // 64KB
 	maxSendChannelSize = 256
 	defaultRoomSize    = 100
+	// Каналы рассчитаны на гораздо большее число одновременных читателей
+	defaultChannelRoomSize = 10000
 )
 
 // Типы событий
@@ -34,6 +37,27 @@ const (
 	EventTypePresence       = "presence"
 	EventTypeRoomInfo       = "room_info"
 	EventTypeMessageDeleted = "message_deleted"
+	EventTypeMessageUpdated = "message_updated"
+	EventTypeMessagesHidden = "messages_hidden"
+	EventTypeHistoryCleared = "history_cleared"
+	EventTypeChatDeleted    = "chat_deleted"
+	EventTypeChatUpdated    = "chat_updated"
+	EventTypeUserBanned     = "user_banned"
+	EventTypeUserKicked     = "user_kicked"
+	EventTypeRoleChanged    = "role_changed"
+
+	EventTypeMessagePinned   = "message_pinned"
+	EventTypeMessageUnpinned = "message_unpinned"
+
+	EventTypePollUpdated = "poll_updated"
+	EventTypeMention     = "mention"
+	EventTypeDraft       = "draft"
+
+	EventTypeChatOrganization  = "chat_organization"
+	EventTypeChatNotifications = "chat_notifications"
+
+	EventTypeJoinRequest         = "join_request"
+	EventTypeJoinRequestResolved = "join_request_resolved"
 )
 
 // OutEvent исходящее событие
@@ -50,14 +74,16 @@ type OutEvent struct {
 
 // InEvent входящее событие
 type InEvent struct {
-	Type      string `json:"type"`
-	Message   string `json:"message,omitempty"`
-	Timestamp int64  `json:"timestamp,omitempty"`
+	Type      string                `json:"type"`
+	Message   string                `json:"message,omitempty"`
+	Entities  model.MessageEntities `json:"entities,omitempty"`
+	Timestamp int64                 `json:"timestamp,omitempty"`
 }
 
 // HubOptions опции хаба
 type HubOptions struct {
 	MaxRoomSize           int
+	MaxChannelRoomSize    int
 	MaxConnectionsPerUser int
 	EnableMetrics         bool
 	CleanupInterval       time.Duration
@@ -93,6 +119,7 @@ type Metrics struct {
 func NewHub(options ...HubOptions) *Hub {
 	opts := HubOptions{
 		MaxRoomSize:           defaultRoomSize,
+		MaxChannelRoomSize:    defaultChannelRoomSize,
 		MaxConnectionsPerUser: 10,
 		EnableMetrics:         true,
 		CleanupInterval:       5 * time.Minute,
@@ -102,6 +129,10 @@ func NewHub(options ...HubOptions) *Hub {
 		opts = options[0]
 	}
 
+	if opts.MaxChannelRoomSize < opts.MaxRoomSize {
+		opts.MaxChannelRoomSize = max(opts.MaxRoomSize, defaultChannelRoomSize)
+	}
+
 	hub := &Hub{
 		rooms:     make(map[uint]*Room),
 		userRooms: make(map[uint]map[uint]bool),
@@ -121,6 +152,19 @@ func NewHub(options ...HubOptions) *Hub {
 
 // GetRoom возвращает комнату по ID чата
 func (h *Hub) GetRoom(chatID uint) *Room {
+	return h.getOrCreateRoom(chatID, h.options.MaxRoomSize)
+}
+
+// GetChannelRoom возвращает комнату канала с увеличенным лимитом подключений
+func (h *Hub) GetChannelRoom(chatID uint) *Room {
+	room := h.getOrCreateRoom(chatID, h.options.MaxChannelRoomSize)
+	// Комната могла быть создана раньше с обычным лимитом (например, при рассылке из REST)
+	room.SetMaxSize(h.options.MaxChannelRoomSize)
+	return room
+}
+
+// getOrCreateRoom возвращает существующую комнату или создает новую с заданным лимитом
+func (h *Hub) getOrCreateRoom(chatID uint, maxSize int) *Room {
 	h.mu.RLock()
 	room, exists := h.rooms[chatID]
 	h.mu.RUnlock()
@@ -137,7 +181,7 @@ func (h *Hub) GetRoom(chatID uint) *Room {
 		return room
 	}
 
-	room = NewRoom(chatID, h.options.MaxRoomSize)
+	room = NewRoom(chatID, maxSize)
 	h.rooms[chatID] = room
 	h.stats.TotalRooms++
 
@@ -263,6 +307,108 @@ func (h *Hub) BroadcastUserPresence(chatID, userID uint, isOnline bool) {
 	room.BroadcastToOthers(userID, data)
 }
 
+// BroadcastEvent отправляет произвольное событие всем клиентам комнаты чата
+func (h *Hub) BroadcastEvent(chatID uint, ev OutEvent) {
+	room, exists := h.GetRoomSafe(chatID)
+	if !exists {
+		return
+	}
+
+	ev.ChatID = chatID
+	if ev.Timestamp.IsZero() {
+		ev.Timestamp = time.Now()
+	}
+
+	data, err := json.Marshal(ev)
+	if err != nil {
+		log.Printf("hub: failed to marshal %s event: %v", ev.Type, err)
+		return
+	}
+
+	room.Broadcast(data)
+}
+
+// BroadcastUserJoined уведомляет участников о новом участнике чата
+func (h *Hub) BroadcastUserJoined(chatID, userID uint, meta any) {
+	room, exists := h.GetRoomSafe(chatID)
+	if !exists {
+		return
+	}
+
+	ev := OutEvent{
+		Type:      EventTypeUserJoined,
+		UserID:    userID,
+		ChatID:    chatID,
+		Message:   true,
+		Timestamp: time.Now(),
+		Meta:      meta,
+	}
+
+	data, _ := json.Marshal(ev)
+	room.Broadcast(data)
+}
+
+// SendToUser отправляет событие во все активные соединения пользователя,
+// независимо от того, к какому чату они подключены. Возвращает число соединений, получивших событие.
+func (h *Hub) SendToUser(userID uint, ev OutEvent) int {
+	if ev.Timestamp.IsZero() {
+		ev.Timestamp = time.Now()
+	}
+
+	data, err := json.Marshal(ev)
+	if err != nil {
+		log.Printf("hub: failed to marshal user event: %v", err)
+		return 0
+	}
+
+	h.mu.RLock()
+	rooms := make([]*Room, 0, len(h.rooms))
+	for _, room := range h.rooms {
+		rooms = append(rooms, room)
+	}
+	h.mu.RUnlock()
+
+	// Один и тот же клиент может быть зарегистрирован в нескольких комнатах,
+	// поэтому событие отправляется каждому соединению один раз
+	clients := make(map[*Client]bool)
+	for _, room := range rooms {
+		if client := room.GetClient(userID); client != nil {
+			clients[client] = true
+		}
+	}
+
+	delivered := 0
+	for client := range clients {
+		if client.SendRaw(data) {
+			delivered++
+		}
+	}
+
+	return delivered
+}
+
+// DisconnectUser отправляет пользователю событие и отключает его от комнаты чата.
+// Возвращает false, если пользователь не был подключен.
+func (h *Hub) DisconnectUser(chatID, userID uint, ev OutEvent) bool {
+	room, exists := h.GetRoomSafe(chatID)
+	if !exists {
+		return false
+	}
+
+	ev.ChatID = chatID
+	if ev.Timestamp.IsZero() {
+		ev.Timestamp = time.Now()
+	}
+
+	data, err := json.Marshal(ev)
+	if err != nil {
+		log.Printf("hub: failed to marshal %s event: %v", ev.Type, err)
+		return false
+	}
+
+	return room.DisconnectUser(userID, data)
+}
+
 // GetRoomInfo возвращает информацию о комнате
 func (h *Hub) GetRoomInfo(chatID uint) *RoomInfo {
 	room, exists := h.GetRoomSafe(chatID)
@@ -507,6 +653,43 @@ func (r *Room) BroadcastToOthers(excludeUserID uint, message []byte) {
 	r.lastActive.Store(time.Now())
 }
 
+// GetClient возвращает клиента пользователя в комнате или nil
+func (r *Room) GetClient(userID uint) *Client {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.clients[userID]
+}
+
+// DisconnectUser удаляет клиента пользователя из комнаты и закрывает соединение
+// после отправки ему последнего сообщения
+func (r *Room) DisconnectUser(userID uint, message []byte) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	client, exists := r.clients[userID]
+	if !exists {
+		return false
+	}
+
+	delete(r.clients, userID)
+	r.activeCount.Dec()
+	r.lastActive.Store(time.Now())
+
+	client.Disconnect(message)
+	return true
+}
+
+// SetMaxSize увеличивает лимит клиентов комнаты (уменьшение игнорируется)
+func (r *Room) SetMaxSize(maxSize int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if maxSize > r.maxSize {
+		r.maxSize = maxSize
+	}
+}
+
 // GetInfo возвращает информацию о комнате
 func (r *Room) GetInfo() *RoomInfo {
 	r.mu.RLock()
@@ -546,6 +729,9 @@ type Client struct {
 	mu        sync.RWMutex
 	isClosed  bool
 	rateLimit *RateLimiter
+
+	// ReadOnly клиент может только читать (подписчик канала)
+	ReadOnly bool
 }
 
 // RateLimiter ограничитель частоты сообщений
@@ -726,8 +912,9 @@ func (c *Client) SendRaw(data []byte) bool {
 	}
 }
 
-// Close закрывает соединение
-func (c *Client) Close() {
+// Disconnect ставит в очередь последнее сообщение и закрывает канал отправки.
+// WritePump доставит оставшиеся сообщения, отправит close frame и закроет соединение.
+func (c *Client) Disconnect(message []byte) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
@@ -735,9 +922,29 @@ func (c *Client) Close() {
 		return
 	}
 
+	if message != nil {
+		select {
+		case c.send <- message:
+		default:
+		}
+	}
+
 	c.isClosed = true
-	c.cancel()
 	close(c.send)
+}
+
+// Close закрывает соединение
+func (c *Client) Close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.isClosed {
+		c.isClosed = true
+		close(c.send)
+	}
+
+	// Соединение могло быть переведено в режим закрытия через Disconnect
+	c.cancel()
 	c.conn.Close()
 }
 